@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/viper"
+)
+
+// expandAlias expands a command alias of the config, like "notes" for
+// "--all --width 100 ~/notes", given as the first argument. Aliases can't
+// replace glow's commands, and aren't expanded in their own expansion.
+func expandAlias(args []string) ([]string, error) {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		return args, nil
+	}
+	name := args[1]
+	if cmd, _, err := rootCmd.Find([]string{name}); err == nil && cmd != rootCmd {
+		return args, nil
+	}
+	expansion, ok := viper.GetStringMapString("commandAliases")[strings.ToLower(name)]
+	if !ok {
+		return args, nil
+	}
+	expanded, err := splitArgs(expansion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias %s: %w", name, err)
+	}
+	for i, arg := range expanded {
+		if strings.HasPrefix(arg, "~") {
+			expanded[i] = utils.ExpandPath(arg)
+		}
+	}
+	out := append([]string{args[0]}, expanded...)
+	return append(out, args[2:]...), nil
+}
+
+// splitArgs splits a command line into its arguments, like a shell does:
+// at spaces, except within single or double quotes or after a backslash.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		b       strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args, nil
+}
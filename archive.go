@@ -0,0 +1,235 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/ui"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// maxArchiveFileSize limits the size of the files read from archives, so
+// they can't be inflated without bounds.
+const maxArchiveFileSize = 10 << 20
+
+// archiveSuffixes are the extensions of the archives markdown files are read
+// from.
+var archiveSuffixes = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2"}
+
+// archiveFile is a markdown file of an archive.
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+// isArchiveArg returns whether a source argument is an archive, local or
+// remote, by its extension.
+func isArchiveArg(arg string) bool {
+	name := strings.ToLower(archiveName(arg))
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveName returns the file name of an archive, without the query of its
+// URL.
+func archiveName(arg string) string {
+	if u, err := url.ParseRequestURI(arg); err == nil && strings.Contains(arg, "://") {
+		return u.Path
+	}
+	return arg
+}
+
+// archiveSource reads the markdown of an archive: its README, the one
+// closest to its root, or else all its markdown files, one after another
+// under headings with their paths.
+func archiveSource(arg string) (*source, error) {
+	files, err := archiveFiles(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	readme := -1
+	for i, f := range files {
+		base := path.Base(f.name)
+		if !slices.ContainsFunc(readmeNames, func(n string) bool { return strings.EqualFold(n, base) }) {
+			continue
+		}
+		if readme < 0 || strings.Count(f.name, "/") < strings.Count(files[readme].name, "/") {
+			readme = i
+		}
+	}
+	if readme >= 0 {
+		f := files[readme]
+		return &source{reader: io.NopCloser(bytes.NewReader(f.data)), URL: archiveFileURL(arg, f.name)}, nil
+	}
+	if len(files) == 1 {
+		return &source{reader: io.NopCloser(bytes.NewReader(files[0].data)), URL: archiveFileURL(arg, files[0].name)}, nil
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "## %s\n\n", utils.EscapeMarkdown(f.name))
+		b.WriteString(strings.TrimRight(string(f.data), "\n") + "\n\n")
+	}
+	return &source{reader: io.NopCloser(strings.NewReader(b.String())), URL: arg}, nil
+}
+
+// runArchiveTUI lists the markdown files of an archive in the TUI.
+func runArchiveTUI(arg string) error {
+	files, err := archiveFiles(arg)
+	if err != nil {
+		return err
+	}
+	remoteFiles := make([]ui.RemoteFile, len(files))
+	for i, f := range files {
+		remoteFiles[i] = ui.RemoteFile{
+			Path: f.name,
+			Fetch: func() (io.ReadCloser, string, error) {
+				return io.NopCloser(bytes.NewReader(f.data)), archiveFileURL(arg, f.name), nil
+			},
+		}
+	}
+	return runRemoteFilesTUI(remoteFiles)
+}
+
+// archiveFileURL returns the URL of a file of an archive, for resolving its
+// links and converting it by its extension.
+func archiveFileURL(arg, name string) string {
+	if isURL(arg) {
+		return strings.TrimRight(arg, "/") + "/" + name
+	}
+	if abs, err := filepath.Abs(arg); err == nil {
+		arg = abs
+	}
+	return filepath.Join(arg, filepath.FromSlash(name))
+}
+
+// archiveFiles returns the markdown files of a local or remote archive,
+// sorted by their paths.
+func archiveFiles(arg string) ([]archiveFile, error) {
+	var r io.ReadCloser
+	if isURL(arg) {
+		res, err := httpGet(arg) //nolint:bodyclose
+		if err != nil {
+			return nil, fmt.Errorf("unable to get url: %w", err)
+		}
+		if res.StatusCode != http.StatusOK {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("unable to get %s: HTTP status %d", arg, res.StatusCode)
+		}
+		r = res.Body
+	} else {
+		f, err := os.Open(arg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open file: %w", err)
+		}
+		r = f
+	}
+	defer r.Close() //nolint:errcheck
+
+	files, err := readArchive(r, archiveName(arg))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s has no markdown files", arg)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+// readArchive reads the markdown files of an archive, of the format of its
+// extension.
+func readArchive(r io.Reader, name string) ([]archiveFile, error) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read archive: %w", err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read archive: %w", err)
+		}
+		var files []archiveFile
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !isDocumentPath(f.Name) {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %s: %w", f.Name, err)
+			}
+			data, err := readArchiveFile(rc, f.Name)
+			_ = rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, archiveFile{name: f.Name, data: data})
+		}
+		return files, nil
+	case strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz"):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read archive: %w", err)
+		}
+		defer zr.Close() //nolint:errcheck
+		return readTar(zr)
+	case strings.HasSuffix(name, ".bz2") || strings.HasSuffix(name, ".tbz2"):
+		return readTar(bzip2.NewReader(r))
+	default:
+		return readTar(r)
+	}
+}
+
+func readTar(r io.Reader) ([]archiveFile, error) {
+	var files []archiveFile
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read archive: %w", err)
+		}
+		if !h.FileInfo().Mode().IsRegular() || !isDocumentPath(h.Name) {
+			continue
+		}
+		data, err := readArchiveFile(tr, h.Name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{name: strings.TrimPrefix(h.Name, "./"), data: data})
+	}
+}
+
+func readArchiveFile(r io.Reader, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", name, err)
+	}
+	if len(data) > maxArchiveFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxArchiveFileSize)
+	}
+	return data, nil
+}
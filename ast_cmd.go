@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	astCompact bool
+
+	astCmd = &cobra.Command{
+		Use:   "ast [SOURCE]",
+		Short: "Print the syntax tree of a markdown document as JSON",
+		Long: paragraph(fmt.Sprintf("\n%s the syntax tree glow renders a document from, with node types, positions and raw text, as JSON.",
+			keyword("Print"))),
+		Example: paragraph("glow ast README.md\nglow ast --compact README.md | jq '.children[].type'"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			content, err := readSourceArg(args)
+			if err != nil {
+				return err
+			}
+			return writeAST(os.Stdout, content)
+		},
+	}
+)
+
+// readSourceArg reads the markdown source given as argument, or piped to
+// stdin.
+func readSourceArg(args []string) ([]byte, error) {
+	arg := "-"
+	if len(args) > 0 {
+		arg = args[0]
+	} else if yes, err := stdinIsPipe(); err != nil {
+		return nil, err
+	} else if !yes {
+		return nil, errors.New("missing markdown source")
+	}
+
+	src, err := sourceFromArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	defer src.reader.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(src.reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read from reader: %w", err)
+	}
+	return utils.ConvertToMarkdown(b, src.URL) //nolint:wrapcheck
+}
+
+// writeAST writes the syntax tree of a markdown document as JSON.
+func writeAST(w io.Writer, content []byte) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	if !astCompact {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(utils.MarkdownAST(content)); err != nil {
+		return fmt.Errorf("unable to encode syntax tree: %w", err)
+	}
+	return writeFiltered(w, b.Bytes(), "json")
+}
+
+func init() {
+	astCmd.Flags().BoolVar(&astCompact, "compact", false, "print the JSON on a single line")
+}
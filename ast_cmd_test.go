@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestWriteAST(t *testing.T) {
+	md := "---\ntitle: x\n---\n# Hi\n\nSee [docs](https://example.com).\n"
+	var b bytes.Buffer
+	if err := writeAST(&b, []byte(md)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var doc utils.ASTNode
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if doc.Type != "Document" || len(doc.Children) != 2 {
+		t.Fatalf("expected a document with two blocks, got %+v", doc)
+	}
+
+	heading := doc.Children[0]
+	if heading.Type != "Heading" || heading.Line != 4 || heading.Attributes["level"] != float64(1) {
+		t.Errorf("expected a level 1 heading on line 4, got %+v", heading)
+	}
+	if text := heading.Children[0]; md[*text.Start:*text.Stop] != "Hi" || text.Text != "Hi" {
+		t.Errorf("expected positions relative to the file, got %+v", text)
+	}
+
+	link := doc.Children[1].Children[1]
+	if link.Type != "Link" || link.Attributes["destination"] != "https://example.com" {
+		t.Errorf("expected a link, got %+v", link)
+	}
+}
+
+func TestWriteASTRedacts(t *testing.T) {
+	redactSecrets(t)
+	var b bytes.Buffer
+	if err := writeAST(&b, []byte("The token is sk-abc123.\n")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out := b.String(); strings.Contains(out, "sk-abc123") || !strings.Contains(out, "***") {
+		t.Errorf("expected the secret to be masked, got %q", out)
+	}
+	if !json.Valid(b.Bytes()) {
+		t.Errorf("expected valid JSON, got %q", b.String())
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// hostTokens are access tokens by host, from the config.
+var hostTokens map[string]string
+
+// githubHosts are the hosts GitHub serves repositories and their files from.
+var githubHosts = []string{"github.com", "api.github.com", "raw.githubusercontent.com"}
+
+// hostToken returns the access token for a host, from the config or else the
+// environment, and the scheme of the Authorization header it's sent with.
+// Tokens from the environment are only sent over HTTPS.
+func hostToken(host, scheme string) (string, string) {
+	authScheme := "Bearer"
+	if isGiteaHost(host) {
+		authScheme = "token"
+	}
+	if token := hostTokens[host]; token != "" {
+		return authScheme, token
+	}
+	if scheme != "https" {
+		return "", ""
+	}
+
+	var envs []string
+	switch {
+	case slices.Contains(githubHosts, host):
+		envs = []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	case isGitLabHost(host):
+		envs = []string{"GITLAB_TOKEN"}
+	case isGiteaHost(host):
+		envs = []string{"GITEA_TOKEN"}
+	case host == bitbucketHost || host == "api."+bitbucketHost:
+		envs = []string{"BITBUCKET_TOKEN"}
+	}
+	for _, env := range envs {
+		if token := os.Getenv(env); token != "" {
+			return authScheme, token
+		}
+	}
+	// gh glow uses the token gh is logged in with.
+	if ghExtension && slices.Contains(githubHosts, host) {
+		if token := ghAuthToken(); token != "" {
+			return authScheme, token
+		}
+	}
+	return "", ""
+}
+
+// authorize adds the access token of the request's host to it, if there is
+// one, or else its login from .netrc.
+func authorize(req *http.Request) {
+	if authScheme, token := hostToken(req.URL.Hostname(), req.URL.Scheme); token != "" {
+		req.Header.Set("Authorization", authScheme+" "+token)
+		return
+	}
+	if req.URL.Scheme != "https" {
+		return
+	}
+	if login, ok := netrcCredentials(req.URL.Hostname()); ok {
+		req.SetBasicAuth(login.login, login.password)
+	}
+}
+
+// httpGet gets a URL, authenticated with the access token of its host, so
+// private repositories can be read. The token isn't sent along when the
+// request is redirected to another host. Servers asking for basic
+// authentication get the credentials of git's credential helpers.
+func httpGet(u string) (*http.Response, error) {
+	return httpGetAccepting(u, "")
+}
+
+// httpGetAccepting gets a URL like httpGet, asking for the given media types
+// in the Accept header.
+func httpGetAccepting(u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	if res, ok := cachedResponse(u, req); ok {
+		return res, nil
+	}
+	if offline {
+		return nil, fmt.Errorf("%s: %w", u, errNotCached)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	authorize(req)
+	conditional := revalidateCached(u, req)
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if conditional && res.StatusCode == http.StatusNotModified {
+		_ = res.Body.Close()
+		if cached, ok := notModifiedResponse(u, req); ok {
+			return cached, nil
+		}
+		// The cached response is gone, so it's fetched in full.
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		if res, err = httpClient.Do(req); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	}
+	if res, err = retryWithGitCredential(req, res); err != nil {
+		return nil, err
+	}
+	if err := limitDownload(res); err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	cacheResponse(u, res)
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const bitbucketHost = "bitbucket.org"
+
+// bitbucketAPI is the base URL of the Bitbucket Cloud API.
+var bitbucketAPI = "https://api.bitbucket.org/2.0"
+
+// bitbucketRepo returns the workspace and name of the repository a Bitbucket
+// URL points to.
+func bitbucketRepo(u *url.URL) (string, string, bool) {
+	workspace, repo, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if !ok || workspace == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return workspace, strings.TrimSuffix(repo, ".git"), true
+}
+
+// findBitbucketREADME tries to find the README of a repository's main branch
+// using the Bitbucket API.
+func findBitbucketREADME(u *url.URL) (*source, error) {
+	workspace, name, ok := bitbucketRepo(u)
+	if !ok {
+		return nil, fmt.Errorf("invalid url: %s", u.String())
+	}
+	apiURL := fmt.Sprintf("%s/repositories/%s/%s", bitbucketAPI, url.PathEscape(workspace), url.PathEscape(name))
+
+	var repo struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	if err := getJSON(apiURL, &repo); err != nil {
+		return nil, err
+	}
+	branch := repo.MainBranch.Name
+
+	// The listing of the repository's root directory, which is paginated.
+	var files []string
+	next := apiURL + "/src/" + url.PathEscape(branch) + "/?pagelen=100"
+	for next != "" {
+		var page struct {
+			Values []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := getJSON(next, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Values {
+			if v.Type == "commit_file" {
+				files = append(files, v.Path)
+			}
+		}
+		next = page.Next
+	}
+
+	if file := readmeFile(files); file != "" {
+		//nolint:bodyclose
+		// it is closed on the caller
+		resp, err := httpGet(apiURL + "/src/" + url.PathEscape(branch) + "/" + url.PathEscape(file))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get url: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			rawURL := strings.TrimSuffix(repo.Links.HTML.Href, "/") + "/raw/" + branch + "/" + file
+			return &source{reader: resp.Body, URL: rawURL}, nil
+		}
+		_ = resp.Body.Close()
+	}
+
+	return nil, errors.New("can't find README in Bitbucket repository")
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// emptySHA256 is the SHA-256 hash of an empty payload, which S3
+	// requests are signed with.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// azureStorageVersion is the version of the Azure Storage API requests
+	// are made with.
+	azureStorageVersion = "2021-08-06"
+)
+
+// blobSchemes are the schemes of object storage URLs, like
+// s3://bucket/key.md.
+var blobSchemes = []string{"s3", "gs", "az"}
+
+// awsCredentials are the credentials S3 requests are signed with.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// blobSource fetches an object from S3 (s3://bucket/key), Google Cloud
+// Storage (gs://bucket/object) or Azure Blob Storage
+// (az://account/container/blob). Without the providers' SDKs, only static
+// credentials given in the environment are used: profiles, SSO sessions,
+// instance and workload identities and the providers' CLIs aren't consulted.
+// Public objects are fetched without any credentials.
+func blobSource(u *url.URL) (*source, error) {
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, fmt.Errorf("%s doesn't name an object", u)
+	}
+
+	var (
+		endpoint string
+		sign     func(*http.Request) error
+		err      error
+	)
+	switch u.Scheme {
+	case "s3":
+		endpoint, sign = s3Request(u.Host, key)
+	case "gs":
+		endpoint, sign = gcsRequest(u.Host, key)
+	case "az":
+		endpoint, sign, err = azureRequest(u.Host, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	res, ok := cachedResponse(u.String(), req)
+	if !ok {
+		if offline {
+			return nil, fmt.Errorf("%s: %w", u, errNotCached)
+		}
+		if err := sign(req); err != nil {
+			return nil, err
+		}
+		res, err = httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s: %w", u, err)
+		}
+		if err := limitDownload(res); err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+		cacheResponse(u.String(), res)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("%s not found", u)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("access to %s denied, check your credentials", u)
+	default:
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unable to get %s: HTTP status %d", u, res.StatusCode)
+	}
+	if err := checkContentType(res); err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	return &source{reader: res.Body, URL: u.String()}, nil
+}
+
+// s3Request returns the URL of an S3 object, and signs requests for it with
+// the credentials of AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN. The endpoint can be changed with AWS_ENDPOINT_URL_S3,
+// like for S3 compatible storage.
+func s3Request(bucket, key string) (string, func(*http.Request) error) {
+	region := awsRegion()
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	var objectURL string
+	switch {
+	case endpoint != "":
+		objectURL = strings.TrimRight(endpoint, "/") + "/" + bucket + "/" + awsURIEscape(key)
+	case strings.Contains(bucket, "."):
+		// virtual-hosted URLs of buckets with dots don't match the
+		// certificate
+		objectURL = fmt.Sprintf("https://s3.%s.amazonaws.com/%s/%s", region, bucket, awsURIEscape(key))
+	default:
+		objectURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, awsURIEscape(key))
+	}
+
+	return objectURL, func(req *http.Request) error {
+		if creds, ok := awsEnvCredentials(); ok {
+			signS3(req, creds, region, time.Now())
+		}
+		return nil
+	}
+}
+
+// awsRegion returns the AWS region of the environment.
+func awsRegion() string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+	return "us-east-1"
+}
+
+// awsEnvCredentials returns the AWS credentials of the environment, if both
+// the key ID and the secret are set.
+func awsEnvCredentials() (awsCredentials, bool) {
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != ""
+}
+
+// signS3 signs a request to S3 with AWS Signature Version 4.
+func signS3(req *http.Request, creds awsCredentials, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "range" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(), signedHeaders, emptySHA256,
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	for _, s := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// awsURIEscape escapes an object key for its URL the way it's signed, with
+// everything but unreserved characters and slashes escaped.
+func awsURIEscape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		values := q[k]
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, awsURIEscape(k)+"="+strings.ReplaceAll(awsURIEscape(v), "/", "%2F"))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// gcsRequest returns the URL of a Google Cloud Storage object, and
+// authorizes requests for it with the token of GOOGLE_OAUTH_ACCESS_TOKEN. An
+// emulator is used instead with STORAGE_EMULATOR_HOST.
+func gcsRequest(bucket, object string) (string, func(*http.Request) error) {
+	base := "https://storage.googleapis.com"
+	emulator := os.Getenv("STORAGE_EMULATOR_HOST")
+	if emulator != "" {
+		base = emulator
+		if !strings.Contains(base, "://") {
+			base = "http://" + base
+		}
+	}
+	objectURL := strings.TrimRight(base, "/") + "/" + bucket + "/" + awsURIEscape(object)
+
+	return objectURL, func(req *http.Request) error {
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return nil
+	}
+}
+
+// azureAccount is a storage account, and the credentials of it that are
+// known.
+type azureAccount struct {
+	name     string
+	endpoint string
+	key      string
+	sas      string
+}
+
+// azureRequest returns the URL of a blob in Azure Blob Storage, and
+// authorizes requests for it with the account's connection string, key or
+// SAS token from the environment.
+func azureRequest(account, path string) (string, func(*http.Request) error, error) {
+	acct := azureStorageAccount(account)
+	if _, _, ok := strings.Cut(path, "/"); !ok {
+		return "", nil, fmt.Errorf("az://%s/%s doesn't name a blob, like az://account/container/blob", account, path)
+	}
+	blobURL := acct.endpoint + "/" + awsURIEscape(path)
+	if acct.sas != "" {
+		blobURL += "?" + strings.TrimPrefix(acct.sas, "?")
+	}
+
+	return blobURL, func(req *http.Request) error {
+		req.Header.Set("X-Ms-Version", azureStorageVersion)
+		req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+		if acct.sas == "" && acct.key != "" {
+			return signAzure(req, acct)
+		}
+		return nil
+	}, nil
+}
+
+// azureStorageAccount returns a storage account with the credentials of
+// AZURE_STORAGE_CONNECTION_STRING, or of AZURE_STORAGE_KEY and
+// AZURE_STORAGE_SAS_TOKEN for the account of AZURE_STORAGE_ACCOUNT.
+func azureStorageAccount(name string) azureAccount {
+	acct := azureAccount{name: name, endpoint: "https://" + name + ".blob.core.windows.net"}
+	if cs := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); cs != "" {
+		settings := map[string]string{}
+		for _, kv := range strings.Split(cs, ";") {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				settings[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+			}
+		}
+		if settings["accountname"] == name {
+			acct.key = settings["accountkey"]
+			acct.sas = settings["sharedaccesssignature"]
+			if endpoint := settings["blobendpoint"]; endpoint != "" {
+				acct.endpoint = strings.TrimRight(endpoint, "/")
+			}
+			return acct
+		}
+	}
+	if os.Getenv("AZURE_STORAGE_ACCOUNT") == name {
+		acct.key = os.Getenv("AZURE_STORAGE_KEY")
+		acct.sas = os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+	}
+	return acct
+}
+
+// signAzure signs a request to Azure Storage with the account's key.
+func signAzure(req *http.Request, acct azureAccount) error {
+	key, err := base64.StdEncoding.DecodeString(acct.key)
+	if err != nil {
+		return fmt.Errorf("invalid key of storage account %s: %w", acct.name, err)
+	}
+
+	var headers []string
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name+":"+strings.Join(values, ","))
+		}
+	}
+	sort.Strings(headers)
+	resource := "/" + acct.name + req.URL.EscapedPath()
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for k, v := range query {
+		params = append(params, strings.ToLower(k)+":"+strings.Join(v, ","))
+	}
+	sort.Strings(params)
+
+	// The standard headers of the string to sign, from Content-Encoding to
+	// Range, are empty for GET requests.
+	stringToSign := req.Method + strings.Repeat("\n", 12) +
+		strings.Join(headers, "\n") + "\n" + resource
+	for _, p := range params {
+		stringToSign += "\n" + p
+	}
+	signature := base64.StdEncoding.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "SharedKey "+acct.name+":"+signature)
+	return nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// offline serves remote documents from the cache only.
+	offline bool
+
+	// cacheTTL is how long cached remote documents are served without
+	// fetching them again. They're always fetched again if zero.
+	cacheTTL time.Duration
+)
+
+// errNotCached is returned for documents that aren't cached when offline.
+var errNotCached = errors.New("not cached, and glow is offline")
+
+// cacheEntry describes a cached response, which is stored next to it.
+type cacheEntry struct {
+	URL         string    `json:"url"`
+	ContentType string    `json:"contentType,omitempty"`
+	Fetched     time.Time `json:"fetched"`
+	// ETag and LastModified validate the cached response once it's stale,
+	// so it's reused if the server answers it's unchanged.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+
+	// Size is the size of the cached document, when listing the cache.
+	Size int64 `json:"-"`
+}
+
+// remoteCacheDir returns the directory remote documents are cached in.
+func remoteCacheDir() (string, error) {
+	dir, err := glowCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "remote"), nil
+}
+
+// fenceCacheDir returns the directory the output of fence handlers is cached
+// in.
+func fenceCacheDir() (string, error) {
+	dir, err := glowCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "fences"), nil
+}
+
+// remoteCachePath returns where the response of a URL is cached. Its entry
+// is stored next to it, with a .json extension.
+func remoteCachePath(u string) (string, error) {
+	dir, err := remoteCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])), nil
+}
+
+func readCacheEntry(path string) (cacheEntry, error) {
+	var entry cacheEntry
+	b, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return entry, err //nolint:wrapcheck
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return entry, fmt.Errorf("unable to parse cache entry: %w", err)
+	}
+	return entry, nil
+}
+
+// cachedResponse returns the cached response to a request for a URL, if it's
+// fresh or we're offline.
+func cachedResponse(u string, req *http.Request) (*http.Response, bool) {
+	path, err := remoteCachePath(u)
+	if err != nil {
+		return nil, false
+	}
+	entry, err := readCacheEntry(path)
+	if err != nil || (!offline && time.Since(entry.Fetched) >= cacheTTL) {
+		return nil, false
+	}
+	return cacheFileResponse(path, entry, req)
+}
+
+// revalidateCached adds the validators of the cached response to a request
+// for a URL, asking the server to answer if it's unchanged. It returns
+// whether there are any.
+func revalidateCached(u string, req *http.Request) bool {
+	path, err := remoteCachePath(u)
+	if err != nil {
+		return false
+	}
+	entry, err := readCacheEntry(path)
+	if err != nil || (entry.ETag == "" && entry.LastModified == "") {
+		return false
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return true
+}
+
+// notModifiedResponse returns the cached response to a request for a URL the
+// server answered is unchanged, which is fresh again.
+func notModifiedResponse(u string, req *http.Request) (*http.Response, bool) {
+	path, err := remoteCachePath(u)
+	if err != nil {
+		return nil, false
+	}
+	entry, err := readCacheEntry(path)
+	if err != nil {
+		return nil, false
+	}
+	entry.Fetched = time.Now()
+	if b, err := json.Marshal(entry); err == nil {
+		_ = os.WriteFile(path+".json", b, 0o600)
+	}
+	return cacheFileResponse(path, entry, req)
+}
+
+// cacheFileResponse returns a response reading a cached document.
+func cacheFileResponse(path string, entry cacheEntry, req *http.Request) (*http.Response, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	header := http.Header{}
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       f,
+		Request:    req,
+	}, true
+}
+
+// cacheResponse caches the body of a successful response to a URL as it's
+// read. It's only stored once read to the end.
+func cacheResponse(u string, res *http.Response) {
+	if res.StatusCode != http.StatusOK {
+		return
+	}
+	path, err := remoteCachePath(u)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".fetch-*")
+	if err != nil {
+		return
+	}
+	res.Body = &cachingReader{
+		body: res.Body,
+		file: f,
+		path: path,
+		entry: cacheEntry{
+			URL:          u,
+			ContentType:  res.Header.Get("Content-Type"),
+			Fetched:      time.Now(),
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+		},
+	}
+}
+
+// cachingReader writes what's read from a response body to the cache.
+type cachingReader struct {
+	body  io.ReadCloser
+	file  *os.File
+	path  string
+	entry cacheEntry
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if r.file != nil {
+		if _, werr := r.file.Write(p[:n]); werr != nil {
+			r.discard()
+		} else if errors.Is(err, io.EOF) {
+			r.commit()
+		}
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (r *cachingReader) Close() error {
+	r.discard()
+	return r.body.Close() //nolint:wrapcheck
+}
+
+// commit moves the complete response into the cache.
+func (r *cachingReader) commit() {
+	name := r.file.Name()
+	r.file.Close() //nolint:errcheck,gosec
+	r.file = nil
+	b, err := json.Marshal(r.entry)
+	if err == nil {
+		err = os.Rename(name, r.path)
+	}
+	if err == nil {
+		err = os.WriteFile(r.path+".json", b, 0o600)
+	}
+	if err != nil {
+		_ = os.Remove(name)
+	}
+}
+
+// discard drops a partly read response.
+func (r *cachingReader) discard() {
+	if r.file == nil {
+		return
+	}
+	r.file.Close() //nolint:errcheck,gosec
+	_ = os.Remove(r.file.Name())
+	r.file = nil
+}
+
+// cachedEntries returns the entries of the remote cache.
+func cachedEntries() ([]cacheEntry, error) {
+	dir, err := remoteCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cache: %w", err)
+	}
+	var entries []cacheEntry
+	for _, f := range files {
+		path, ok := strings.CutSuffix(filepath.Join(dir, f.Name()), ".json")
+		if !ok {
+			continue
+		}
+		entry, err := readCacheEntry(path)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		entry.Size = info.Size()
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
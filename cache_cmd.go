@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of remote documents",
+		Long: paragraph(fmt.Sprintf("\n%s fetched remote documents, which are read from the cache for the configured cacheTTL, or whenever --offline.",
+			keyword("Manage"))),
+		Example: paragraph("glow cache list\nglow cache clear"),
+		Args:    cobra.NoArgs,
+	}
+
+	cacheListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the cached remote documents",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return listCache(os.Stdout)
+		},
+	}
+
+	cacheClearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached remote documents",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			dir, err := remoteCacheDir()
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				return fmt.Errorf("unable to clear cache: %w", err)
+			}
+			return nil
+		},
+	}
+)
+
+// listCache writes the URLs of the cached documents, most recently fetched
+// first, with when they were fetched and their size.
+func listCache(w io.Writer) error {
+	entries, err := cachedEntries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Fetched.After(entries[j].Fetched) })
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", e.URL, e.Fetched.Local().Format(time.DateTime), e.Size)
+	}
+	return tw.Flush() //nolint:wrapcheck
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheListCmd, cacheClearCmd)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"golang.org/x/term"
+)
+
+// clipboardTimeout is how long the terminal is given to answer a query of
+// the clipboard.
+const clipboardTimeout = 2 * time.Second
+
+// readClipboard returns the contents of the system clipboard, read natively
+// or else by asking the terminal with OSC 52, which works over SSH too.
+func readClipboard() (string, error) {
+	if s, err := clipboard.ReadAll(); err == nil && s != "" {
+		return s, nil
+	}
+	s, err := queryClipboard()
+	if err != nil {
+		return "", fmt.Errorf("unable to read clipboard: %w", err)
+	}
+	if s == "" {
+		return "", errors.New("clipboard is empty")
+	}
+	return s, nil
+}
+
+// queryClipboard asks the terminal for the contents of the clipboard with
+// OSC 52. Not all terminals answer, or allow it.
+func queryClipboard() (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal to ask: %w", err)
+	}
+	defer tty.Close() //nolint:errcheck
+
+	state, err := term.MakeRaw(int(tty.Fd()))
+	if err != nil {
+		return "", fmt.Errorf("unable to set terminal to raw mode: %w", err)
+	}
+	defer term.Restore(int(tty.Fd()), state) //nolint:errcheck
+
+	seq := osc52.Query()
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	if _, err := seq.WriteTo(tty); err != nil {
+		return "", fmt.Errorf("unable to query terminal: %w", err)
+	}
+
+	// The terminal may never answer, so its answer is read in the
+	// background.
+	answer := make(chan []byte, 1)
+	go func() {
+		var b []byte
+		buf := make([]byte, 4096)
+		for {
+			n, err := tty.Read(buf)
+			b = append(b, buf[:n]...)
+			if err != nil || bytes.HasSuffix(b, []byte("\a")) || bytes.HasSuffix(b, []byte("\x1b\\")) {
+				answer <- b
+				return
+			}
+		}
+	}()
+	select {
+	case b := <-answer:
+		return parseOSC52Response(b)
+	case <-time.After(clipboardTimeout):
+		return "", errors.New("the terminal didn't answer the clipboard query")
+	}
+}
+
+// parseOSC52Response returns the clipboard contents of a terminal's answer
+// to an OSC 52 query, like ESC ] 52 ; c ; <base64> BEL.
+func parseOSC52Response(b []byte) (string, error) {
+	start := bytes.Index(b, []byte("\x1b]52;"))
+	if start < 0 {
+		return "", errors.New("unexpected answer to the clipboard query")
+	}
+	b = b[start+len("\x1b]52;"):]
+	i := bytes.IndexByte(b, ';')
+	if i < 0 {
+		return "", errors.New("unexpected answer to the clipboard query")
+	}
+	b = b[i+1:]
+	b = bytes.TrimSuffix(bytes.TrimSuffix(b, []byte("\a")), []byte("\x1b\\"))
+	data, err := base64.StdEncoding.DecodeString(string(b))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode clipboard: %w", err)
+	}
+	return string(data), nil
+}
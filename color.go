@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/muesli/termenv"
+)
+
+// Color modes.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+func validateColorMode(mode string) error {
+	switch mode {
+	case colorAuto, colorAlways, colorNever:
+		return nil
+	}
+	return fmt.Errorf("invalid color mode %q, use %s, %s or %s",
+		mode, colorAuto, colorAlways, colorNever)
+}
+
+// colorProfile returns the color profile to render with. In auto mode colors
+// are used when writing to a terminal, unless NO_COLOR is set, or anywhere if
+// CLICOLOR_FORCE is (see https://no-color.org and
+// https://bixense.com/clicolors).
+func colorProfile(mode string, isTerminal bool) termenv.Profile {
+	switch mode {
+	case colorNever:
+		return termenv.Ascii
+	case colorAuto:
+		if os.Getenv("NO_COLOR") != "" {
+			return termenv.Ascii
+		}
+		if force := os.Getenv("CLICOLOR_FORCE"); force == "" || force == "0" {
+			if !isTerminal {
+				return termenv.Ascii
+			}
+			return termenv.NewOutput(os.Stdout).ColorProfile()
+		}
+	}
+
+	// Forced colors: use what the terminal supports, as if stdout was one,
+	// and 256 colors if that's unknown.
+	p := termenv.NewOutput(os.Stdout, termenv.WithTTY(true)).ColorProfile()
+	if p == termenv.Ascii {
+		return termenv.ANSI256
+	}
+	return p
+}
+
+// colorDisabled returns whether colors were turned off, with --color=never
+// or NO_COLOR, for output that isn't for the terminal, like exports.
+func colorDisabled(mode string) bool {
+	return mode == colorNever || (mode == colorAuto && os.Getenv("NO_COLOR") != "")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// completeDocuments completes source arguments with the documents glow
+// renders, and directories.
+func completeDocuments(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	// Completion runs without the config being applied.
+	utils.Converters = viper.GetStringMapString("converters")
+	patterns := utils.DocumentPatterns()
+	exts := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		exts = append(exts, strings.TrimPrefix(p, "*."))
+	}
+	return exts, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeStyles completes --style with the built-in styles and the JSON
+// styles next to the config file, or with JSON files once a path is typed.
+func completeStyles(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.ContainsAny(toComplete, `/\.~`) {
+		return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	names := []string{styles.AutoStyle}
+	for name := range styles.DefaultStyles {
+		if name != styles.NoTTYStyle && name != styles.AutoStyle {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	user, _ := filepath.Glob(filepath.Join(filepath.Dir(configFilePath()), "*.json"))
+	return append(names, user...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCodeThemes completes --code-theme with the chroma themes.
+func completeCodeThemes(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return chromastyles.Names(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSections completes --section with the anchors of the headings of
+// the file given as argument, described by their titles.
+func completeSections(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	for _, arg := range args {
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			continue
+		}
+		var sections []string
+		for _, h := range utils.Headings(utils.RemoveFrontmatter(b)) {
+			sections = append(sections, h.Slug+"\t"+h.Text)
+		}
+		return sections, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerCompletions completes the arguments and flags of the commands.
+// It runs once all flags are defined.
+func registerCompletions() {
+	for _, cmd := range []*cobra.Command{astCmd, exportCmd, metaCmd, statsCmd, tocCmd, diffCmd} {
+		cmd.ValidArgsFunction = completeDocuments
+	}
+	for _, cmd := range []*cobra.Command{rootCmd, exportCmd, serveCmd} {
+		_ = cmd.RegisterFlagCompletionFunc("style", completeStyles)
+	}
+	_ = rootCmd.RegisterFlagCompletionFunc("code-theme", completeCodeThemes)
+	_ = rootCmd.RegisterFlagCompletionFunc("section", completeSections)
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	configCheckCmd = &cobra.Command{
+		Use:   "check [FILE]",
+		Short: "Check the config file for mistakes",
+		Long: paragraph(fmt.Sprintf("\n%s the config file, or another one like a project's .glow.yml, for unknown settings, invalid values and commands that can't be run, pointing to the lines they're on.",
+			keyword("Check"))),
+		Example: paragraph("glow config check\nglow config check .glow.yml"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := configFilePath()
+			if len(args) > 0 {
+				path = args[0]
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("unable to read config file: %w", err)
+			}
+			projectDir := ""
+			if filepath.Base(path) == projectConfigName {
+				projectDir = filepath.Dir(path)
+			}
+			problems := checkConfig(data, projectDir)
+			writeConfigProblems(os.Stdout, path, data, problems)
+			switch len(problems) {
+			case 0:
+				fmt.Printf("No problems found in %s.\n", path)
+				return nil
+			case 1:
+				return errors.New("found 1 problem in the config file")
+			default:
+				return fmt.Errorf("found %d problems in the config file", len(problems))
+			}
+		},
+	}
+
+	problemStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Bold(true)
+)
+
+// renamedSettings maps settings of older configs to their new names.
+var renamedSettings = map[string]string{
+	// alias was too easily confused with aliases.
+	"alias": "commandAliases",
+}
+
+// commandSettings are the settings of commands glow runs, split at spaces.
+var commandSettings = map[string]bool{
+	"preRenderHooks": true, "postRenderHooks": true, "converters": true,
+	"schemeHandlers": true, "linkHandlers": true, "fenceHandlers": true,
+	"outputFilters": true,
+}
+
+// configProblem is a mistake in the config file, at a line and column of it,
+// and as wide as the value it's about.
+type configProblem struct {
+	line, column, width int
+	msg                 string
+}
+
+func nodeProblem(n *yaml.Node, format string, a ...any) configProblem {
+	width := 1
+	if n.Kind == yaml.ScalarNode && !strings.Contains(n.Value, "\n") {
+		width = max(1, len(n.Value))
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			width += 2
+		}
+	}
+	return configProblem{line: n.Line, column: n.Column, width: width, msg: fmt.Sprintf(format, a...)}
+}
+
+// yamlErrorLine matches the line YAML syntax errors are on.
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// checkConfig returns the mistakes in a config file, in the order of their
+// lines. Project configs are given the directory they're in.
+func checkConfig(data []byte, projectDir string) []configProblem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		p := configProblem{msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			p.line, _ = strconv.Atoi(m[1])
+			p.column, p.width, p.msg = 1, 1, strings.TrimPrefix(err.Error(), m[0])
+		}
+		return []configProblem{p}
+	}
+	if doc.Kind == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []configProblem{nodeProblem(root, "the config file must be a mapping of settings, like style: dark")}
+	}
+
+	var problems []configProblem
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		setting, kind, ok := findSetting(key.Value)
+		if !ok {
+			msg := fmt.Sprintf("unknown setting %q", key.Value)
+			if s := suggestSetting(key.Value); s != "" {
+				msg += fmt.Sprintf(", did you mean %q?", s)
+			}
+			problems = append(problems, nodeProblem(key, "%s", msg))
+			continue
+		}
+		if projectDir != "" {
+			trusted := slices.Contains(trustedProjectConfigKeys, setting) &&
+				trustedProject(filepath.Join(projectDir, projectConfigName), viper.GetStringSlice("trustedProjects"))
+			if !slices.Contains(projectConfigKeys, setting) && !trusted {
+				msg := "%s can't be set in a project config, only in your own"
+				if slices.Contains(trustedProjectConfigKeys, setting) {
+					msg = "%s can only be set in the project configs of your trustedProjects"
+				}
+				problems = append(problems, nodeProblem(key, msg, setting))
+				continue
+			}
+			if s := value.Value; setting == "style" && strings.HasSuffix(s, ".json") && !filepath.IsAbs(s) && !strings.HasPrefix(s, "~") {
+				// Like when reading the project config.
+				v := *value
+				v.Value = filepath.Join(projectDir, s)
+				value = &v
+			}
+		}
+		problems = append(problems, checkSetting(setting, kind, value)...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].line != problems[j].line {
+			return problems[i].line < problems[j].line
+		}
+		return problems[i].column < problems[j].column
+	})
+	return problems
+}
+
+// findSetting returns the setting a key of the config file sets, matched
+// case-insensitively, like viper does.
+func findSetting(key string) (string, settingKind, bool) {
+	for setting, kind := range settings {
+		if strings.EqualFold(setting, key) {
+			return setting, kind, true
+		}
+	}
+	return "", 0, false
+}
+
+// checkSetting checks the value of a setting in the config file.
+func checkSetting(setting string, kind settingKind, value *yaml.Node) []configProblem {
+	if value.Tag == "!!null" {
+		// Unset, like "exclude:" without a value.
+		return nil
+	}
+	switch kind {
+	case settingList:
+		if value.Kind != yaml.SequenceNode {
+			return []configProblem{nodeProblem(value, "%s must be a list, with an item per line starting with \"- \"", setting)}
+		}
+		var problems []configProblem
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				problems = append(problems, nodeProblem(item, "the items of %s must be single values", setting))
+				continue
+			}
+			problems = append(problems, checkEntry(setting, item)...)
+		}
+		return problems
+	case settingMap:
+		if value.Kind != yaml.MappingNode {
+			return []configProblem{nodeProblem(value, "%s must be a mapping, with an entry per line like \"name: value\"", setting)}
+		}
+		var problems []configProblem
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			name, entry := value.Content[i], value.Content[i+1]
+			if entry.Kind != yaml.ScalarNode {
+				problems = append(problems, nodeProblem(entry, "%s.%s must be a single value", setting, name.Value))
+				continue
+			}
+			if setting == "commandAliases" {
+				problems = append(problems, checkCommandAlias(name, entry)...)
+			}
+			problems = append(problems, checkEntry(setting, entry)...)
+		}
+		return problems
+	case settingStyle:
+		if value.Kind != yaml.MappingNode {
+			return []configProblem{nodeProblem(value, "%s must be a mapping of style elements, like h1", setting)}
+		}
+		var problems []configProblem
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			element, override := value.Content[i], value.Content[i+1]
+			var v any
+			if err := override.Decode(&v); err != nil {
+				problems = append(problems, nodeProblem(override, "%s", err))
+				continue
+			}
+			err := utils.CheckStyleOverride(element.Value, v)
+			var overrideErr *utils.StyleOverrideError
+			switch {
+			case errors.As(err, &overrideErr):
+				n := styleOverrideNode(element, override, overrideErr)
+				problems = append(problems, nodeProblem(n, "%s", err))
+			case err != nil:
+				problems = append(problems, nodeProblem(element, "%s", err))
+			}
+		}
+		return problems
+	default:
+		if value.Kind != yaml.ScalarNode {
+			return []configProblem{nodeProblem(value, "%s must be a single value", setting)}
+		}
+		if _, err := parseSetting(setting, kind, []string{value.Value}); err != nil {
+			return []configProblem{nodeProblem(value, "%s", err)}
+		}
+		return nil
+	}
+}
+
+// checkEntry checks an item or entry of a list or map setting, the commands
+// of hooks and handlers in particular.
+func checkEntry(setting string, entry *yaml.Node) []configProblem {
+	if setting == "aliases" {
+		if err := setAlias(map[string]string{}, entry.Value); err != nil {
+			return []configProblem{nodeProblem(entry, "%s", err)}
+		}
+		return nil
+	}
+	if setting == "redact" {
+		if _, err := regexp.Compile(entry.Value); err != nil {
+			return []configProblem{nodeProblem(entry, "invalid pattern: %s", strings.TrimPrefix(err.Error(), "error parsing regexp: "))}
+		}
+		return nil
+	}
+	if !commandSettings[setting] {
+		return nil
+	}
+	args := strings.Fields(entry.Value)
+	switch {
+	case len(args) == 0:
+		return []configProblem{nodeProblem(entry, "empty command in %s", setting)}
+	case strings.ContainsAny(entry.Value, `"'`):
+		return []configProblem{nodeProblem(entry, "commands are split at spaces, without quotes: put this one in a script")}
+	case setting == "linkHandlers" && args[0] == utils.GlowHandler:
+		return nil
+	}
+	if _, err := exec.LookPath(utils.ExpandPath(args[0])); err != nil {
+		return []configProblem{nodeProblem(entry, "command %s not found, or not executable", args[0])}
+	}
+	return nil
+}
+
+// checkCommandAlias checks a command alias can be run.
+func checkCommandAlias(name, expansion *yaml.Node) []configProblem {
+	if cmd, _, err := rootCmd.Find([]string{name.Value}); err == nil && cmd != rootCmd {
+		return []configProblem{nodeProblem(name, "alias %s is ignored, as it's a glow command", name.Value)}
+	}
+	if _, err := splitArgs(expansion.Value); err != nil {
+		return []configProblem{nodeProblem(expansion, "invalid alias %s: %s", name.Value, err)}
+	}
+	return nil
+}
+
+// styleOverrideNode returns the node of the field a style override error is
+// about: its name if it's unknown, or else its value.
+func styleOverrideNode(element, override *yaml.Node, err *utils.StyleOverrideError) *yaml.Node {
+	if err.Unknown && err.Field == element.Value {
+		return element
+	}
+	path := strings.Split(err.Field, ".")
+	if !err.Unknown {
+		path = path[1:]
+	}
+	n := override
+	for _, name := range path {
+		next := styleField(n, name, err.Unknown)
+		if next == nil {
+			return element
+		}
+		n = next
+	}
+	return n
+}
+
+// styleField finds a field of a style override by name, returning its name
+// or its value. Unknown fields are looked for at any depth, as their path
+// isn't known.
+func styleField(n *yaml.Node, name string, key bool) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == name {
+			if key {
+				return n.Content[i]
+			}
+			return n.Content[i+1]
+		}
+	}
+	if key {
+		for i := 1; i < len(n.Content); i += 2 {
+			if found := styleField(n.Content[i], name, key); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// suggestSetting returns the setting a mistyped one is closest to, if any
+// is close enough.
+func suggestSetting(key string) string {
+	if name, ok := renamedSettings[strings.ToLower(key)]; ok {
+		return name
+	}
+	best, bestDist := "", 3
+	for _, name := range settingNames() {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance of two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// writeConfigProblems writes the problems of a config file, each with the
+// line it's on and a pointer to where on the line, like compilers do.
+func writeConfigProblems(w io.Writer, path string, data []byte, problems []configProblem) {
+	lines := strings.Split(string(data), "\n")
+	for _, p := range problems {
+		if p.line == 0 {
+			fmt.Fprintf(w, "%s: %s\n\n", path, problemStyle.Render(p.msg))
+			continue
+		}
+		fmt.Fprintf(w, "%s:%d:%d: %s\n", path, p.line, p.column, problemStyle.Render(p.msg))
+		if p.line > len(lines) {
+			fmt.Fprintln(w)
+			continue
+		}
+		number := strconv.Itoa(p.line)
+		gutter := strings.Repeat(" ", len(number))
+		line := strings.ReplaceAll(lines[p.line-1], "\t", " ")
+		fmt.Fprintf(w, " %s | %s\n", number, line)
+		fmt.Fprintf(w, " %s | %s%s\n\n", gutter, strings.Repeat(" ", max(0, p.column-1)), problemStyle.Render(strings.Repeat("^", p.width)))
+	}
+}
@@ -15,23 +15,50 @@ import (
 
 const defaultConfig = `# style name or JSON path (default "auto")
 style: "auto"
+# patch individual elements of the style, using the keys of glamour's style JSON
+# styleOverrides:
+#   h1:
+#     color: "212"
 # mouse support (TUI-mode only)
 mouse: false
 # use pager to display markdown
 pager: false
-# word-wrap at width
+# word-wrap at width: columns, a percentage like 80%, or auto
 width: 80
 # show all files, including hidden and ignored.
 all: false
+# files and directories to leave out when finding files, like "drafts"
+# exclude: []
+# high contrast, no animations or alternate screen
+accessible: false
+# margins, maximum width and padding of documents in the pager (TUI-mode only)
+marginLeft: 0
+marginRight: 0
+maxWidth: 0
+padding: 0
+# heading notes appended from the pager are placed under (TUI-mode only)
+noteHeading: "Notes"
+# file notes are appended to, instead of the open document (TUI-mode only)
+noteInbox: ""
 `
 
+var configListJSON bool
+
 var configCmd = &cobra.Command{
 	Use:     "config",
 	Hidden:  false,
 	Short:   "Edit the glow config file",
 	Long:    paragraph(fmt.Sprintf("\n%s the glow config file. We’ll use EDITOR to determine which editor to use. If the config file doesn't exist, it will be created.", keyword("Edit"))),
-	Example: paragraph("glow config\nglow config --config path/to/config.yml"),
+	Example: paragraph("glow config\nglow config --config path/to/config.yml\nglow config set style dracula\nglow config get width\nglow config list --json\nglow config check"),
 	Args:    cobra.NoArgs,
+	// The config can be edited and listed even when it's invalid, so it
+	// isn't validated like for the other commands.
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := applyFlagEnv(cmd); err != nil {
+			return err
+		}
+		return configureLog()
+	},
 	RunE: func(*cobra.Command, []string) error {
 		if err := ensureConfigFile(); err != nil {
 			return err
@@ -53,6 +80,63 @@ var configCmd = &cobra.Command{
 	},
 }
 
+var (
+	configGetCmd = &cobra.Command{
+		Use:               "get SETTING",
+		Short:             "Print the value of a setting",
+		Example:           paragraph("glow config get width\nglow config get tokens.gitlab.example.com"),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSettings,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return getSetting(os.Stdout, args[0])
+		},
+	}
+
+	configSetCmd = &cobra.Command{
+		Use:   "set SETTING VALUE...",
+		Short: "Set a setting in the config file",
+		Long: paragraph(fmt.Sprintf("\n%s a setting in the config file, checking its value first. Lists take any number of values, and entries of settings like converters are set as converters.EXTENSION.",
+			keyword("Set"))),
+		Example:           paragraph("glow config set style dracula\nglow config set width 100\nglow config set exclude drafts vendor\nglow config set converters.rst \"pandoc -f rst -t gfm\"\nglow config set styleOverrides.h1.color 212"),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeSettings,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return setSetting(args[0], args[1:])
+		},
+	}
+
+	configListCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List the settings in effect",
+		Example: paragraph("glow config list\nglow config list --json"),
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return listSettings(os.Stdout, configListJSON)
+		},
+	}
+)
+
+func completeSettings(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return settingNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	configListCmd.Flags().BoolVar(&configListJSON, "json", false, "list the settings as JSON")
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd, configCheckCmd)
+}
+
+// configFilePath returns the path of the config file in use, or the one that
+// would be created.
+func configFilePath() string {
+	if configFile != "" {
+		return configFile
+	}
+	return viper.GetViper().ConfigFileUsed()
+}
+
 func ensureConfigFile() error {
 	if configFile == "" {
 		configFile = viper.GetViper().ConfigFileUsed()
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSetSetting(t *testing.T) {
+	defer func(v string) { configFile = v }(configFile)
+	configFile = filepath.Join(t.TempDir(), "glow.yml")
+	config := "# style name or JSON path\nstyle: \"auto\"\n# word-wrap at width\nwidth: 80\nMouse: false\n"
+	if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"style", "dracula"},
+		{"width", "100"},
+		{"mouse", "1"},
+		{"exclude", "drafts", "vendor"},
+		{"tokens.gitlab.example.com", "glpat-x"},
+		{"styleOverrides.h1.color", "212"},
+		{"styleOverrides.h1.bold", "true"},
+	} {
+		if err := setSetting(args[0], args[1:]); err != nil {
+			t.Fatalf("expected no error setting %v, got %v", args, err)
+		}
+	}
+	b, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# style name or JSON path\nstyle: \"dracula\"\n# word-wrap at width\nwidth: \"100\"\nMouse: true\n" +
+		"exclude:\n  - drafts\n  - vendor\ntokens:\n  gitlab.example.com: glpat-x\n" +
+		"styleOverrides:\n  h1:\n    color: 212\n    bold: true\n"
+	if string(b) != want {
+		t.Errorf("expected the config\n%s\ngot\n%s", want, b)
+	}
+
+	for args, want := range map[string]string{
+		"style nope":         "specified style does not exist",
+		"width wide":         "invalid width",
+		"mouse maybe":        "mouse must be true or false",
+		"padding -1":         "padding must be a number",
+		"cacheTTL forever":   "cacheTTL must be a duration",
+		"links a b":          "links takes a single value",
+		"tokens x":           "set the entries of tokens",
+		"width.x 1":          "width has no entries",
+		"unknown 1":          "unknown setting",
+		"links footnotes":    "invalid link mode",
+		"maxDownloadSize 1X": "invalid size",
+	} {
+		fields := strings.Fields(args)
+		if err := setSetting(fields[0], fields[1:]); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected setting %s to fail with %q, got %v", args, want, err)
+		}
+	}
+}
+
+func TestGetSetting(t *testing.T) {
+	t.Setenv("GLOW_NOTEHEADING", "Todo")
+	t.Setenv("GLOW_HOOKTIMEOUT", "1m")
+
+	for key, want := range map[string]string{
+		"noteHeading": "Todo\n",
+		"noteheading": "Todo\n",
+		"hookTimeout": "1m0s\n",
+		"fenceCache":  "true\n",
+	} {
+		var b bytes.Buffer
+		if err := getSetting(&b, key); err != nil {
+			t.Fatalf("expected no error getting %s, got %v", key, err)
+		}
+		if b.String() != want {
+			t.Errorf("expected %s to be %q, got %q", key, want, b.String())
+		}
+	}
+	if err := getSetting(&bytes.Buffer{}, "converters.nope"); err == nil {
+		t.Error("expected an error for an entry that isn't set")
+	}
+
+	var b bytes.Buffer
+	if err := listSettings(&b, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(b.Bytes(), &values); err != nil {
+		t.Fatalf("expected JSON, got %v: %s", err, b.String())
+	}
+	if values["noteHeading"] != "Todo" || values["fenceCache"] != true || len(values) != len(settings) {
+		t.Errorf("expected all settings in effect, got %v", values)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	if problems := checkConfig([]byte(defaultConfig), ""); len(problems) != 0 {
+		t.Errorf("expected the default config to have no problems, got %v", problems)
+	}
+
+	config := "stlye: dark\nwidth: wide\nexclude: drafts\npreRenderHooks:\n  - \"sed 's/a b/c/'\"\n" +
+		"commandAliases:\n  config: --all\nstyleOverrides:\n  h1:\n    color: 212\n    colour: red\n  h2:\n    bold: \"yes\"\n"
+	wants := []struct {
+		line, column int
+		msg          string
+	}{
+		{1, 1, `unknown setting "stlye", did you mean "style"?`},
+		{2, 8, "invalid width"},
+		{3, 10, "exclude must be a list"},
+		{5, 5, "commands are split at spaces"},
+		{7, 3, "alias config is ignored"},
+		{11, 5, `h1 has no property "colour"`},
+		{13, 11, "h2.bold can't be a string"},
+	}
+	problems := checkConfig([]byte(config), "")
+	if len(problems) != len(wants) {
+		t.Fatalf("expected %d problems, got %v", len(wants), problems)
+	}
+	for i, want := range wants {
+		p := problems[i]
+		if p.line != want.line || p.column != want.column || !strings.Contains(p.msg, want.msg) {
+			t.Errorf("expected %q at %d:%d, got %q at %d:%d", want.msg, want.line, want.column, p.msg, p.line, p.column)
+		}
+	}
+
+	var b bytes.Buffer
+	writeConfigProblems(&b, "glow.yml", []byte(config), problems[1:2])
+	want := "glow.yml:2:8: invalid width \"wide\", use columns, a percentage like 80% or auto\n 2 | width: wide\n   |        ^^^^\n\n"
+	if b.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, b.String())
+	}
+
+	if problems := checkConfig([]byte("alias:\n  notes: --all\n"), ""); len(problems) != 1 || !strings.Contains(problems[0].msg, `did you mean "commandAliases"?`) {
+		t.Errorf("expected the renamed setting to be suggested, got %v", problems)
+	}
+
+	if problems := checkConfig([]byte("style: dark\n  width: 80\n"), ""); len(problems) != 1 || problems[0].line != 2 {
+		t.Errorf("expected a syntax error on line 2, got %v", problems)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docs.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	problems = checkConfig([]byte("style: docs.json\nconverters:\n  rst: pandoc\n"), dir)
+	if len(problems) != 1 || problems[0].line != 2 || !strings.Contains(problems[0].msg, "can't be set in a project config") {
+		t.Errorf("expected converters to be refused in a project config, got %v", problems)
+	}
+
+	defer viper.Set("trustedProjects", nil)
+	config = "linkHandlers:\n  zoommtg: zoom\n"
+	if problems := checkConfig([]byte(config), dir); len(problems) != 1 || !strings.Contains(problems[0].msg, "trustedProjects") {
+		t.Errorf("expected linkHandlers to be refused in an untrusted project, got %v", problems)
+	}
+	viper.Set("trustedProjects", []string{dir})
+	for _, p := range checkConfig([]byte(config), dir) {
+		if strings.Contains(p.msg, "project config") {
+			t.Errorf("expected linkHandlers in a trusted project, got %v", p)
+		}
+	}
+}
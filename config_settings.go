@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// settingKind is the type of the value of a config setting.
+type settingKind int
+
+const (
+	settingString settingKind = iota
+	settingBool
+	settingInt
+	settingDuration
+	settingList
+	// settingMap settings map names, like hosts or file extensions, to
+	// strings.
+	settingMap
+	// settingStyle is the styleOverrides setting, nested like glamour's
+	// style JSON.
+	settingStyle
+)
+
+// settings are the kinds of the settings of the config file.
+var settings = map[string]settingKind{
+	"style": settingString, "styleOverrides": settingStyle, "codeTheme": settingString,
+	"mouse": settingBool, "pager": settingBool, "paginate": settingBool, "tui": settingBool,
+	"color": settingString, "width": settingString, "all": settingBool, "exclude": settingList,
+	"accessible": settingBool, "showLineNumbers": settingBool, "preserveNewLines": settingBool,
+	"hyperlinks": settingString, "links": settingString, "images": settingString,
+	"frontmatter": settingString, "header": settingBool, "separator": settingString,
+	"detectLanguage": settingBool, "hardBreaks": settingBool, "aliases": settingList, "smartypants": settingBool,
+	"readability": settingBool, "codeLineNumbers": settingBool, "pretty": settingBool,
+	"marginLeft": settingInt, "marginRight": settingInt, "maxWidth": settingInt, "padding": settingInt,
+	"noteHeading": settingString, "noteInbox": settingString, "wikiLinks": settingBool,
+	"locale": settingString, "dateFormat": settingString, "spellLang": settingString, "dictionaries": settingString,
+	"converters": settingMap, "preRenderHooks": settingList, "postRenderHooks": settingList,
+	"hookTimeout": settingDuration, "schemeHandlers": settingMap, "linkHandlers": settingMap, "fenceHandlers": settingMap, "commandAliases": settingMap,
+	"fenceCache": settingBool, "fenceSandbox": settingBool, "fenceTimeout": settingDuration,
+	"trustedProjects": settingList, "gitlabHosts": settingList, "giteaHosts": settingList, "tokens": settingMap,
+	"httpProxy": settingString, "httpHeaders": settingMap, "userAgent": settingString,
+	"tlsCACert": settingString, "tlsClientCert": settingString, "tlsClientKey": settingString,
+	"tlsInsecureSkipVerify": settingBool, "httpTimeout": settingDuration, "maxRedirects": settingInt,
+	"maxDownloadSize": settingString, "acceptTypes": settingList, "cacheTTL": settingDuration,
+	"offline": settingBool, "redact": settingList, "redactReplacement": settingString, "outputFilters": settingList,
+}
+
+// settingValidators check the values of settings with a fixed set of values
+// or a format of their own.
+var settingValidators = map[string]func(string) error{
+	"style":       validateStyle,
+	"codeTheme":   validateCodeTheme,
+	"color":       validateColorMode,
+	"hyperlinks":  validateHyperlinksMode,
+	"links":       validateLinkMode,
+	"images":      validateImagesMode,
+	"frontmatter": validateFrontmatterMode,
+	"width": func(s string) error {
+		_, err := parseWidth(s)
+		return err
+	},
+	"maxDownloadSize": func(s string) error {
+		_, err := parseSize(s)
+		return err
+	},
+}
+
+// lookupSetting splits a setting like converters.rst into the name of the
+// setting and the path of the entry in it, and returns its kind.
+func lookupSetting(key string) (string, []string, settingKind, error) {
+	name, entry, hasEntry := strings.Cut(key, ".")
+	for setting, kind := range settings {
+		if !strings.EqualFold(setting, name) {
+			continue
+		}
+		switch {
+		case kind == settingMap && hasEntry && entry != "":
+			// Entries are hosts or extensions, which may contain dots.
+			return setting, []string{entry}, kind, nil
+		case kind == settingStyle && hasEntry && !slices.Contains(strings.Split(entry, "."), ""):
+			return setting, strings.Split(entry, "."), kind, nil
+		case hasEntry:
+			return "", nil, kind, fmt.Errorf("%s has no entries", setting)
+		}
+		return setting, nil, kind, nil
+	}
+	return "", nil, 0, fmt.Errorf("unknown setting %q, see \"glow config list\"", name)
+}
+
+// settingValue returns the value of a setting in effect, from the config
+// file, the environment or its default.
+func settingValue(setting string, kind settingKind) any {
+	switch kind {
+	case settingBool:
+		return viper.GetBool(setting)
+	case settingInt:
+		return viper.GetInt(setting)
+	case settingDuration:
+		return viper.GetDuration(setting).String()
+	case settingList:
+		if v := viper.GetStringSlice(setting); v != nil {
+			return v
+		}
+		return []string{}
+	case settingMap:
+		return viper.GetStringMapString(setting)
+	case settingStyle:
+		return viper.GetStringMap(setting)
+	default:
+		return viper.GetString(setting)
+	}
+}
+
+// getSetting writes the value of a setting, or of an entry of it.
+func getSetting(w io.Writer, key string) error {
+	setting, entry, kind, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+	v := settingValue(setting, kind)
+	for _, k := range entry {
+		var ok bool
+		switch m := v.(type) {
+		case map[string]string:
+			v, ok = m[strings.ToLower(k)]
+		case map[string]any:
+			v, ok = m[strings.ToLower(k)]
+		}
+		if !ok {
+			return fmt.Errorf("%s is not set", key)
+		}
+	}
+
+	switch v := v.(type) {
+	case []string:
+		for _, item := range v {
+			fmt.Fprintln(w, item)
+		}
+		return nil
+	case map[string]string, map[string]any:
+		return writeSettings(w, v, false)
+	default:
+		_, err := fmt.Fprintln(w, v)
+		return err //nolint:wrapcheck
+	}
+}
+
+// listSettings writes all settings in effect.
+func listSettings(w io.Writer, asJSON bool) error {
+	values := make(map[string]any, len(settings))
+	for setting, kind := range settings {
+		values[setting] = settingValue(setting, kind)
+	}
+	return writeSettings(w, values, asJSON)
+}
+
+func writeSettings(w io.Writer, v any, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("unable to encode settings: %w", err)
+		}
+		return nil
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("unable to encode settings: %w", err)
+	}
+	return enc.Close() //nolint:wrapcheck
+}
+
+// parseSetting parses the values given for a setting, checking they fit its
+// kind. Lists take any number of values, the others exactly one.
+func parseSetting(setting string, kind settingKind, values []string) (*yaml.Node, error) {
+	if kind == settingList {
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, v := range values {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v})
+		}
+		return node, nil
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("%s takes a single value", setting)
+	}
+	value := values[0]
+	tag := "!!str"
+	switch kind {
+	case settingBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", setting)
+		}
+		value, tag = strconv.FormatBool(b), "!!bool"
+	case settingInt:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s must be a number", setting)
+		}
+		value, tag = strconv.Itoa(n), "!!int"
+	case settingDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("%s must be a duration, like 30s or 1h", setting)
+		}
+	case settingStyle:
+		// Numbers and booleans are set as such, like with --style-set.
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			switch v.(type) {
+			case bool:
+				tag = "!!bool"
+			case float64:
+				tag = "!!int"
+				if strings.ContainsAny(value, ".eE") {
+					tag = "!!float"
+				}
+			}
+		}
+	}
+	if validate, ok := settingValidators[setting]; ok {
+		if err := validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}, nil
+}
+
+// setSetting sets a setting, or an entry of it, in the config file. The rest
+// of the file, including its comments, is kept as it is.
+func setSetting(key string, values []string) error {
+	setting, entry, kind, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+	if (kind == settingMap || kind == settingStyle) && len(entry) == 0 {
+		return fmt.Errorf("set the entries of %s, like %s.NAME", setting, setting)
+	}
+	if kind == settingMap {
+		kind = settingString
+	}
+	value, err := parseSetting(setting, kind, values)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureConfigFile(); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return fmt.Errorf("unable to read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("unable to parse config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return errors.New("unable to set config: the config file isn't a mapping of settings")
+	}
+
+	m := doc.Content[0]
+	path := append([]string{setting}, entry...)
+	for i, k := range path {
+		if i == len(path)-1 {
+			setMappingValue(m, k, value, i == 0)
+			break
+		}
+		next := mappingValue(m, k, i == 0)
+		if next == nil || next.Kind != yaml.MappingNode {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(m, k, next, i == 0)
+		}
+		m = next
+	}
+
+	f, err := os.Create(configFile)
+	if err != nil {
+		return fmt.Errorf("unable to write config file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	enc := yaml.NewEncoder(f)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("unable to write config file: %w", err)
+	}
+	return enc.Close() //nolint:wrapcheck
+}
+
+// mappingValue returns the value of a key of a YAML mapping. Settings are
+// matched case-insensitively, like viper does.
+func mappingValue(m *yaml.Node, key string, foldCase bool) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if k := m.Content[i].Value; k == key || foldCase && strings.EqualFold(k, key) {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets the value of a key of a YAML mapping, keeping the
+// comments and quoting of the value it replaces.
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node, foldCase bool) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if k := m.Content[i].Value; k == key || foldCase && strings.EqualFold(k, key) {
+			old := m.Content[i+1]
+			value.LineComment, value.HeadComment, value.FootComment = old.LineComment, old.HeadComment, old.FootComment
+			if old.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode {
+				value.Style = old.Style
+			}
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// settingNames returns the names of the settings, for completion.
+func settingNames() []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
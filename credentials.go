@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// netrcLogin is the login of a machine in a .netrc file.
+type netrcLogin struct {
+	login    string
+	password string
+}
+
+// netrcPath returns the path of the user's .netrc file, or the one of NETRC.
+func netrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := ".netrc"
+	if runtime.GOOS == "windows" {
+		name = "_netrc"
+	}
+	return filepath.Join(home, name)
+}
+
+// netrcCredentials returns the login for a host from the user's .netrc file.
+// Only machine entries are used, not the default one, so credentials are
+// only sent to the hosts they're for.
+func netrcCredentials(host string) (netrcLogin, bool) {
+	data, err := os.ReadFile(netrcPath())
+	if err != nil {
+		return netrcLogin{}, false
+	}
+	login, ok := parseNetrc(string(data))[host]
+	return login, ok
+}
+
+// parseNetrc parses the machine entries of a .netrc file.
+func parseNetrc(data string) map[string]netrcLogin {
+	logins := map[string]netrcLogin{}
+	var (
+		machine string
+		login   netrcLogin
+	)
+	flush := func() {
+		if machine != "" {
+			logins[machine] = login
+		}
+		machine, login = "", netrcLogin{}
+	}
+
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			value := func() string {
+				if j+1 < len(fields) {
+					j++
+					return fields[j]
+				}
+				return ""
+			}
+			switch fields[j] {
+			case "machine":
+				flush()
+				machine = value()
+			case "default":
+				flush()
+			case "login":
+				login.login = value()
+			case "password":
+				login.password = value()
+			case "macdef":
+				// Macros run until a blank line.
+				flush()
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			default:
+				if strings.HasPrefix(fields[j], "#") {
+					j = len(fields)
+				}
+			}
+		}
+	}
+	flush()
+	return logins
+}
+
+// gitCredential asks git's credential helpers for the username and password
+// of a URL, without prompting for them.
+func gitCredential(u *url.URL) (string, string, bool) {
+	out, err := runGitCredential("fill", u, "", "")
+	if err != nil {
+		return "", "", false
+	}
+	var username, password string
+	for _, line := range strings.Split(out, "\n") {
+		k, v, _ := strings.Cut(line, "=")
+		switch k {
+		case "username":
+			username = v
+		case "password":
+			password = v
+		}
+	}
+	return username, password, password != ""
+}
+
+// runGitCredential runs git credential with the description of a URL, and
+// its credentials for approving or rejecting them.
+func runGitCredential(action string, u *url.URL, username, password string) (string, error) {
+	var input strings.Builder
+	fmt.Fprintf(&input, "protocol=%s\nhost=%s\n", u.Scheme, u.Host)
+	if username != "" {
+		fmt.Fprintf(&input, "username=%s\npassword=%s\n", username, password)
+	}
+	input.WriteString("\n")
+
+	var stdout bytes.Buffer
+	c := exec.Command("git", "credential", action) //nolint:gosec
+	c.Stdin = strings.NewReader(input.String())
+	c.Stdout = &stdout
+	c.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := c.Run(); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return stdout.String(), nil
+}
+
+// retryWithGitCredential retries a request the server refused with basic
+// authentication, with the credentials of git's credential helpers. The
+// helpers are told whether the credentials worked, so they can store or
+// forget them. After redirects, the credentials are the ones of the server
+// that refused the request, which is asked again directly.
+func retryWithGitCredential(req *http.Request, res *http.Response) (*http.Response, error) {
+	refused := req
+	if res.Request != nil {
+		refused = res.Request
+	}
+	if res.StatusCode != http.StatusUnauthorized || refused.URL.Scheme != "https" ||
+		refused.Header.Get("Authorization") != "" ||
+		!strings.HasPrefix(strings.ToLower(res.Header.Get("WWW-Authenticate")), "basic") {
+		return res, nil
+	}
+	u := refused.URL
+	username, password, ok := gitCredential(u)
+	if !ok {
+		return res, nil
+	}
+	_ = res.Body.Close()
+
+	retry := req.Clone(req.Context())
+	retry.URL, retry.Host = u, ""
+	retry.SetBasicAuth(username, password)
+	res, err := httpClient.Do(retry)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	action := "approve"
+	if res.StatusCode == http.StatusUnauthorized {
+		action = "reject"
+	}
+	_, _ = runGitCredential(action, u, username, password)
+	return res, nil
+}
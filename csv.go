@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// tableFormats are the separators of the formats rendered as tables, by file
+// extension.
+var tableFormats = map[string]rune{
+	"csv": ',',
+	"tsv": '\t',
+}
+
+// tableFormat returns the separator of a file's values if it's rendered as a
+// table.
+func tableFormat(filename string) (rune, bool) {
+	comma, ok := tableFormats[strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))]
+	return comma, ok
+}
+
+// tableMarkdown renders separated values as a fixed-width table, laid out like
+// the tables of stream mode. The first record is the header.
+func tableMarkdown(b []byte, comma rune) (string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\ufeff"))))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("unable to parse table: %w", err)
+	}
+	if len(records) == 0 {
+		return "", nil
+	}
+
+	var widths []int
+	for _, record := range records {
+		for i, cell := range record {
+			w := runewidth.StringWidth(strings.TrimSpace(cell)) + 2
+			if i < len(widths) {
+				widths[i] = max(widths[i], w)
+			} else {
+				widths = append(widths, w)
+			}
+		}
+	}
+	widths = fitTableWidths(widths, streamTableLineBudget())
+	return "```text\n" + formatFixedWidthTable(records[0], widths, records[1:]) + "```\n", nil
+}
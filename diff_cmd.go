@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffContext int
+
+	diffCmd = &cobra.Command{
+		Use:   "diff OLD NEW",
+		Short: "Show the changes between two markdown documents, rendered",
+		Long: paragraph(fmt.Sprintf("\n%s two markdown documents block by block and render the new one, with removed and added paragraphs, list items, code blocks and tables tinted.",
+			keyword("Compare"))),
+		Example: paragraph("glow diff README.old.md README.md\nglow diff --context 1 v1/guide.md v2/guide.md"),
+		Args:    cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runDiff(os.Stdout, args[0], args[1])
+		},
+	}
+)
+
+// sgrReset matches the sequences resetting the text style.
+var sgrReset = regexp.MustCompile(`\x1b\[0?m`)
+
+// Tints of removed and added blocks.
+var (
+	diffDeleteColor = lipgloss.AdaptiveColor{Light: "#FBE3E3", Dark: "#3D1F22"}
+	diffInsertColor = lipgloss.AdaptiveColor{Light: "#DFF5E1", Dark: "#1D3A25"}
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Bold(true)
+	diffInsertStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575")).Bold(true)
+)
+
+func runDiff(w io.Writer, oldArg, newArg string) error {
+	oldContent, err := readDiffSource(oldArg)
+	if err != nil {
+		return err
+	}
+	newContent, err := readDiffSource(newArg)
+	if err != nil {
+		return err
+	}
+	diff := utils.DiffBlocks(utils.MarkdownBlocks(oldContent), utils.MarkdownBlocks(newContent))
+
+	// Leave room for the change markers.
+	defer func(w uint) { width = w }(width)
+	if width > 2 {
+		width -= 2
+	}
+
+	var b bytes.Buffer
+	for i, d := range diff {
+		if !showDiffBlock(diff, i) {
+			if i == 0 || showDiffBlock(diff, i-1) {
+				fmt.Fprintln(&b, sectionStyle.Render("  ⋯"))
+			}
+			continue
+		}
+		out, err := renderContent(d.Text, newArg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(&b, markDiffBlock(trimBlankLines(out), d.Op))
+		fmt.Fprintln(&b)
+	}
+	return writeFiltered(w, b.Bytes(), "ansi")
+}
+
+// readDiffSource reads a markdown document to compare, without its front
+// matter.
+func readDiffSource(arg string) ([]byte, error) {
+	src, err := sourceFromArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	defer src.reader.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(src.reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read from reader: %w", err)
+	}
+	b, err = utils.ConvertToMarkdown(b, src.URL)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return utils.RemoveFrontmatter(b), nil
+}
+
+// showDiffBlock reports whether a block is shown: changed blocks are, and
+// unchanged ones if they're within --context blocks of a change.
+func showDiffBlock(diff []utils.BlockDiff, i int) bool {
+	if diffContext < 0 || diff[i].Op != utils.DiffEqual {
+		return true
+	}
+	for j := max(0, i-diffContext); j <= min(len(diff)-1, i+diffContext); j++ {
+		if diff[j].Op != utils.DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// markDiffBlock prefixes the lines of a rendered block with a change marker
+// and tints the background of changed blocks.
+func markDiffBlock(out string, op utils.DiffOp) string {
+	marker, bg := "  ", ""
+	switch op {
+	case utils.DiffDelete:
+		marker, bg = diffDeleteStyle.Render("-")+" ", backgroundSequence(diffDeleteColor)
+	case utils.DiffInsert:
+		marker, bg = diffInsertStyle.Render("+")+" ", backgroundSequence(diffInsertColor)
+	}
+
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		if bg != "" {
+			// Keep the tint after glamour resets its styles.
+			line = bg + sgrReset.ReplaceAllString(line, "${0}"+bg) + ansi.ResetStyle
+		}
+		lines[i] = marker + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// trimBlankLines removes the blank lines glamour adds around a block.
+func trimBlankLines(out string) string {
+	lines := strings.Split(out, "\n")
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// backgroundSequence returns the escape sequence setting the background to
+// a color, in the terminal's color profile.
+func backgroundSequence(c lipgloss.AdaptiveColor) string {
+	hex := c.Light
+	if utils.HasDarkBackground() {
+		hex = c.Dark
+	}
+	color := lipgloss.ColorProfile().Color(hex)
+	if color == nil {
+		return ""
+	}
+	if seq := color.Sequence(true); seq != "" {
+		return termenv.CSI + seq + "m"
+	}
+	return ""
+}
+
+func init() {
+	diffCmd.Flags().IntVarP(&diffContext, "context", "C", -1, "unchanged blocks to show around changes (default all)")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestMarkdownBlocks(t *testing.T) {
+	md := "# Doc\n\nA para\nspanning lines.\n\n- a\n- b\n\n  more b\n\n```go\nx := 1\n\ny := 2\n```\n"
+	want := []string{
+		"# Doc\n",
+		"A para\nspanning lines.\n",
+		"- a\n",
+		"- b\n\n  more b\n",
+		"```go\nx := 1\n\ny := 2\n```\n",
+	}
+	if got := utils.MarkdownBlocks([]byte(md)); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestDiffBlocks(t *testing.T) {
+	diff := utils.DiffBlocks([]string{"a", "b", "c"}, []string{"a", "B", "c\n", "d"})
+	want := []utils.BlockDiff{
+		{Op: utils.DiffEqual, Text: "a"},
+		{Op: utils.DiffDelete, Text: "b"},
+		{Op: utils.DiffInsert, Text: "B"},
+		{Op: utils.DiffEqual, Text: "c\n"},
+		{Op: utils.DiffInsert, Text: "d"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("expected %+v, got %+v", want, diff)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	defer func(s string, c int) { style, diffContext = s, c }(style, diffContext)
+	style, diffContext = "notty", 0
+
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.md"), filepath.Join(dir, "new.md")
+	if err := os.WriteFile(oldPath, []byte("# Doc\n\nKept.\n\nOld text.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte("# Doc\n\nKept.\n\nNew text.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := runDiff(&b, oldPath, newPath); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{"-   Old text.", "+   New text.", "⋯"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "Kept.") {
+		t.Errorf("expected unchanged blocks out of context to be left out, got %q", out)
+	}
+}
+
+func TestRunDiffRedacts(t *testing.T) {
+	defer func(s string) { style = s }(style)
+	style = "notty"
+	redactSecrets(t)
+
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.md"), filepath.Join(dir, "new.md")
+	if err := os.WriteFile(oldPath, []byte("The token is sk-old1.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte("The token is sk-abc123.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := runDiff(&b, oldPath, newPath); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out := b.String(); strings.Contains(out, "sk-") || !strings.Contains(out, "***") {
+		t.Errorf("expected the secrets to be masked, got %q", out)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	gap "github.com/muesli/go-app-paths"
+)
+
+// cacheHome is where glow caches remote documents, images and the output of
+// fence handlers, set with --cache-home or GLOW_CACHE_HOME.
+var cacheHome string
+
+// glowCacheDir returns the directory glow caches in: --cache-home,
+// GLOW_CACHE_HOME or the user's cache directory.
+func glowCacheDir() (string, error) {
+	if cacheHome != "" {
+		return cacheHome, nil
+	}
+	if dir := os.Getenv("GLOW_CACHE_HOME"); dir != "" {
+		return dir, nil
+	}
+	dir, err := gap.NewScope(gap.User, "glow").CacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to get cache dir: %w", err)
+	}
+	return dir, nil
+}
+
+// glowDataDir returns the directory glow keeps data it needs to keep, like
+// the pinned certificates of Gemini servers, in: GLOW_DATA_HOME or the
+// user's data directory.
+func glowDataDir() (string, error) {
+	if dir := os.Getenv("GLOW_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	dirs, err := gap.NewScope(gap.User, "glow").DataDirs()
+	if err != nil || len(dirs) == 0 {
+		return "", fmt.Errorf("unable to get data dir: %w", err)
+	}
+	return dirs[0], nil
+}
+
+// glowStateDir returns the directory glow keeps its state, like its log, in:
+// GLOW_STATE_HOME, XDG_STATE_HOME or ~/.local/state on Unix, and the data
+// directory elsewhere.
+func glowStateDir() (string, error) {
+	if dir := os.Getenv("GLOW_STATE_HOME"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "glow"), nil
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to get state dir: %w", err)
+		}
+		return filepath.Join(home, ".local", "state", "glow"), nil
+	}
+	return glowDataDir()
+}
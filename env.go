@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const envPrefix = "GLOW_"
+
+// flagEnv returns the environment variable setting a flag of a command:
+// GLOW_WIDTH for --width, and GLOW_EXPORT_FORMAT for --format of glow export,
+// as flags of subcommands can share names with different meanings.
+func flagEnv(cmd *cobra.Command, f *pflag.Flag) string {
+	name := f.Name
+	if cmd.HasParent() && cmd.LocalNonPersistentFlags().Lookup(f.Name) != nil {
+		name = cmd.Name() + "_" + name
+	}
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyFlagEnv sets the flags of a command that weren't given from their
+// environment variables, so they take precedence over the config file but
+// not over the command line.
+func applyFlagEnv(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "help" || f.Name == "version" {
+			return
+		}
+		env := flagEnv(cmd, f)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return
+		}
+		if serr := cmd.Flags().Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("invalid %s: %w", env, serr)
+		}
+	})
+	return err
+}
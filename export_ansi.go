@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/muesli/termenv"
+)
+
+// colorProfiles maps the names accepted by --color-profile to profiles.
+var colorProfiles = map[string]termenv.Profile{
+	"truecolor": termenv.TrueColor,
+	"256":       termenv.ANSI256,
+	"16":        termenv.ANSI,
+	"none":      termenv.Ascii,
+}
+
+func parseColorProfile(name string) (termenv.Profile, error) {
+	p, ok := colorProfiles[strings.ToLower(name)]
+	if !ok {
+		return p, fmt.Errorf("unsupported color profile %q, must be one of: truecolor, 256, 16, none", name)
+	}
+	return p, nil
+}
+
+// exportANSI writes the document rendered for the terminal, using the
+// requested color profile rather than the current terminal's.
+func exportANSI(w io.Writer, md []byte, opts exportOptions) error {
+	profile, err := parseColorProfile(opts.colorProfile)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the style ourselves: glamour's auto style falls back to the
+	// colorless notty style when stdout isn't a terminal, which is the usual
+	// case when exporting.
+	cfg, err := utils.GlamourStyleConfig(opts.style)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	options := []glamour.TermRendererOption{
+		glamour.WithColorProfile(profile),
+		glamour.WithStyles(cfg),
+		glamour.WithWordWrap(int(opts.width)), //nolint:gosec
+		glamour.WithPreservedNewLines(),
+	}
+	if utils.CodeLineNumbers {
+		options = append(options, utils.WithCodeLineNumbers(opts.style, false, int(opts.width), profile)) //nolint:gosec
+	}
+	r, err := glamour.NewTermRenderer(options...)
+	if err != nil {
+		return fmt.Errorf("unable to create renderer: %w", err)
+	}
+
+	out, err := r.RenderBytes(utils.PrepareDefinitionLists(md))
+	if err != nil {
+		return fmt.Errorf("unable to render markdown: %w", err)
+	}
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("unable to write output: %w", err)
+	}
+	return nil
+}
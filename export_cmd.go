@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// exporter writes a markdown document in another format.
+type exporter func(w io.Writer, md []byte, opts exportOptions) error
+
+// exportOptions are the options shared by all exporters.
+type exportOptions struct {
+	// glamour style name or JSON path
+	style string
+	// document title
+	title string
+	// whether to include a table of contents
+	toc bool
+	// word-wrap width, for text based formats
+	width uint
+	// color profile of terminal output
+	colorProfile string
+}
+
+var exporters = map[string]exporter{
+	"html":       exportHTML,
+	"ansi":       exportANSI,
+	"text":       exportText,
+	"man":        exportMan,
+	"jira":       exportJira,
+	"confluence": exportConfluence,
+	"slides":     exportSlides,
+}
+
+var (
+	exportFormat string
+	exportOutput string
+	exportStyle  string
+	exportTOC    bool
+	exportWidth  uint
+	exportColors string
+
+	exportCmd = &cobra.Command{
+		Use:   "export [SOURCE]",
+		Short: "Export markdown to other formats",
+		Long: paragraph(fmt.Sprintf("\n%s markdown to other formats, styled like glow renders it. Supported formats: %s.",
+			keyword("Export"), strings.Join(exportFormats(), ", "))),
+		Example: paragraph("glow export README.md -o README.html\nglow export --toc --style light README.md > README.html\nglow export --format ansi --color-profile 256 NOTES.md > motd\nglow export --format text CHANGELOG.md"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE:    runExport,
+	}
+)
+
+func exportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for f := range exporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	export, ok := exporters[exportFormat]
+	if !ok {
+		return fmt.Errorf("unsupported format %q, must be one of: %s", exportFormat, strings.Join(exportFormats(), ", "))
+	}
+
+	arg := "-"
+	if len(args) > 0 {
+		arg = args[0]
+	} else if yes, err := stdinIsPipe(); err != nil {
+		return err
+	} else if !yes {
+		return errors.New("missing markdown source")
+	}
+
+	opts := exportOptions{
+		style:        exportStyle,
+		toc:          exportTOC,
+		width:        exportWidth,
+		colorProfile: exportColors,
+	}
+	if colorDisabled(colorMode) && !cmd.Flags().Changed("color-profile") {
+		opts.colorProfile = "none"
+	}
+	style, err := exportStyleName(opts.style)
+	if err != nil {
+		return err
+	}
+	opts.style = style
+
+	src, err := sourceFromArg(arg)
+	if err != nil {
+		return err
+	}
+	defer src.reader.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(src.reader)
+	if err != nil {
+		return fmt.Errorf("unable to read from reader: %w", err)
+	}
+	b = prepareExport(b)
+	opts.title = documentTitle(b, src.URL)
+
+	var w io.Writer = os.Stdout
+	if exportOutput != "" && exportOutput != "-" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return fmt.Errorf("unable to create output file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		w = f
+	}
+	var out bytes.Buffer
+	if err := export(&out, b, opts); err != nil {
+		return err
+	}
+	filtered, err := filterOutput(out.Bytes(), exportFormat)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(filtered); err != nil {
+		return fmt.Errorf("unable to write export: %w", err)
+	}
+	return nil
+}
+
+// exportStyleName returns the style documents are exported with: the given
+// one, or else the configured one, with the no-TTY style made automatic.
+func exportStyleName(style string) (string, error) {
+	if style == "" {
+		style = viper.GetString("style")
+	}
+	if style == "" || style == styles.NoTTYStyle {
+		style = styles.AutoStyle
+	}
+	if err := validateStyle(style); err != nil {
+		return "", err
+	}
+	return style, nil
+}
+
+// prepareExport prepares markdown for the exporters, without its front
+// matter.
+func prepareExport(md []byte) []byte {
+	smart := utils.SmartypantsEnabled(md)
+	md = utils.RemoveFrontmatter(md)
+	if smart {
+		md = utils.Smarten(md)
+	}
+	return utils.PrepareMarkdown(md)
+}
+
+// documentTitle returns the first top-level heading of a document or, if
+// there is none, its file name.
+func documentTitle(md []byte, path string) string {
+	for _, h := range utils.Headings(md) {
+		if h.Level == 1 {
+			return h.Text
+		}
+	}
+	if path != "" {
+		return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return "Glow"
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "html", "output format")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "output file (default stdout)")
+	exportCmd.Flags().StringVarP(&exportStyle, "style", "s", "", "style name or JSON path (default from config)")
+	exportCmd.Flags().BoolVar(&exportTOC, "toc", false, "include a table of contents")
+	exportCmd.Flags().UintVarP(&exportWidth, "width", "w", 80, "word-wrap at width (set to 0 to disable)")
+	exportCmd.Flags().StringVar(&exportColors, "color-profile", "truecolor", "color profile of ANSI output: truecolor, 256, 16 or none")
+}
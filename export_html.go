@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/muesli/termenv"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const htmlDocument = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="glow">
+<title>%s</title>
+<style>
+%s</style>
+</head>
+<body>
+<main>
+%s%s</main>
+</body>
+</html>
+`
+
+// htmlBaseCSS lays out the document. Colors and text styles are derived from
+// the glamour style.
+const htmlBaseCSS = `body { margin: 0; padding: 2em 1em; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.6; }
+main { max-width: 50em; margin: 0 auto; }
+code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.9em; }
+code { padding: 0.1em 0.3em; border-radius: 3px; }
+pre { padding: 1em; overflow: auto; border-radius: 6px; }
+pre code { padding: 0; background: none; }
+blockquote { margin: 0; padding: 0 1em; border-left: 0.25em solid currentColor; opacity: 0.85; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border: 1px solid rgba(128, 128, 128, 0.5); }
+hr { border: 0; border-top: 1px solid currentColor; }
+img { max-width: 100%; }
+.anchor { margin-left: 0.3em; text-decoration: none; opacity: 0; }
+h1:hover .anchor, h2:hover .anchor, h3:hover .anchor, h4:hover .anchor, h5:hover .anchor, h6:hover .anchor { opacity: 0.5; }
+nav.toc { margin-bottom: 2em; }
+dt { font-weight: bold; }
+dd { margin: 0 0 0.5em 2em; }
+`
+
+// exportHTML writes a standalone HTML page with the glamour style's colors
+// embedded as CSS.
+func exportHTML(w io.Writer, md []byte, opts exportOptions) error {
+	cfg, err := utils.GlamourStyleConfig(opts.style)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	formatter := newHTMLFormatter()
+	codeStyle := htmlCodeStyle(cfg)
+	gm := newHTMLMarkdown(formatter, codeStyle)
+
+	var body bytes.Buffer
+	ctx := parser.NewContext(parser.WithIDs(utils.NewSlugger()))
+	doc := gm.Parser().Parse(text.NewReader(md), parser.WithContext(ctx))
+	fixFragmentLinks(doc)
+	if err := gm.Renderer().Render(&body, md, doc); err != nil {
+		return fmt.Errorf("unable to render html: %w", err)
+	}
+
+	var css strings.Builder
+	css.WriteString(htmlBaseCSS)
+	css.WriteString(htmlStyleCSS(cfg))
+	if err := formatter.WriteCSS(&css, codeStyle); err != nil {
+		return fmt.Errorf("unable to write code style: %w", err)
+	}
+
+	var toc string
+	if opts.toc {
+		toc = htmlTOC(utils.Headings(md))
+	}
+
+	if _, err := fmt.Fprintf(w, htmlDocument, html.EscapeString(opts.title), css.String(), toc, body.String()); err != nil {
+		return fmt.Errorf("unable to write html: %w", err)
+	}
+	return nil
+}
+
+// newHTMLFormatter returns the chroma formatter for code blocks, styled with
+// CSS classes.
+func newHTMLFormatter() *chromahtml.Formatter {
+	if utils.CodeLineNumbers {
+		// Keep the numbers in a separate column, so they aren't copied along
+		// with the code.
+		return chromahtml.New(
+			chromahtml.WithClasses(true),
+			chromahtml.WithLineNumbers(true),
+			chromahtml.LineNumbersInTable(true),
+		)
+	}
+	return chromahtml.New(chromahtml.WithClasses(true))
+}
+
+// newHTMLMarkdown returns the goldmark configuration for HTML exports, with
+// code blocks highlighted in the given style.
+func newHTMLMarkdown(formatter *chromahtml.Formatter, codeStyle *chroma.Style) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.DefinitionList,
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+		goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(util.Prioritized(&htmlRenderer{formatter, codeStyle}, 100)),
+		),
+	)
+}
+
+// htmlRenderer renders fenced code blocks with syntax highlighting and adds
+// self-links to headings.
+type htmlRenderer struct {
+	formatter *chromahtml.Formatter
+	style     *chroma.Style
+}
+
+func (r *htmlRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+	reg.Register(ast.KindHeading, r.renderHeading)
+}
+
+func (r *htmlRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+
+	var code strings.Builder
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		code.Write(line.Value(source))
+	}
+
+	lexer := lexers.Get(string(n.Language(source)))
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("unable to highlight code: %w", err)
+	}
+	if err := r.formatter.Format(w, r.style, it); err != nil {
+		return ast.WalkStop, fmt.Errorf("unable to highlight code: %w", err)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *htmlRenderer) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	id, _ := n.AttributeString("id")
+	slug, _ := id.([]byte)
+	if entering {
+		fmt.Fprintf(w, "<h%d id=\"%s\">", n.Level, html.EscapeString(string(slug)))
+		return ast.WalkContinue, nil
+	}
+	fmt.Fprintf(w, "<a class=\"anchor\" href=\"#%s\" aria-hidden=\"true\">#</a></h%d>\n", html.EscapeString(string(slug)), n.Level)
+	return ast.WalkContinue, nil
+}
+
+// fixFragmentLinks points links to headings of the document, written like
+// (#Install) or (#install%20steps), at the anchors the headings get, so they
+// keep working in the export.
+func fixFragmentLinks(doc ast.Node) {
+	ids := map[string]bool{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			if id, ok := h.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					ids[string(b)] = true
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		l, ok := n.(*ast.Link)
+		if !entering || !ok || !bytes.HasPrefix(l.Destination, []byte("#")) {
+			return ast.WalkContinue, nil
+		}
+		if anchor, ok := resolveAnchor(string(l.Destination[1:]), ids); ok {
+			l.Destination = []byte("#" + anchor)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// resolveAnchor returns the heading anchor a link fragment refers to, matched
+// as written, case-insensitively or by its slug.
+func resolveAnchor(fragment string, ids map[string]bool) (string, bool) {
+	if decoded, err := url.PathUnescape(fragment); err == nil {
+		fragment = decoded
+	}
+	for _, anchor := range []string{fragment, strings.ToLower(fragment), utils.Slug(fragment)} {
+		if ids[anchor] {
+			return anchor, true
+		}
+	}
+	return "", false
+}
+
+// htmlTOC renders a nested list linking to the given headings.
+func htmlTOC(headings []utils.Heading) string {
+	if len(headings) == 0 {
+		return ""
+	}
+	base := headings[0].Level
+	for _, h := range headings {
+		base = min(base, h.Level)
+	}
+
+	var b strings.Builder
+	b.WriteString("<nav class=\"toc\">\n")
+	depth := 0
+	for i, h := range headings {
+		target := h.Level - base + 1
+		if i > 0 && target <= depth {
+			b.WriteString("</li>\n")
+		}
+		for ; depth > target; depth-- {
+			b.WriteString("</ul>\n</li>\n")
+		}
+		for ; depth < target; depth++ {
+			b.WriteString("<ul>\n")
+			// Skipped heading levels get an empty item.
+			if depth+1 < target {
+				b.WriteString("<li>")
+			}
+		}
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a>", html.EscapeString(h.Slug), html.EscapeString(h.Text))
+	}
+	b.WriteString("</li>\n")
+	for ; depth > 0; depth-- {
+		b.WriteString("</ul>\n")
+		if depth > 1 {
+			b.WriteString("</li>\n")
+		}
+	}
+	b.WriteString("</nav>\n")
+	return b.String()
+}
+
+// htmlStyleCSS maps the colors and text styles of a glamour style to CSS.
+func htmlStyleCSS(cfg ansi.StyleConfig) string {
+	body := cfg.Document.StylePrimitive
+	if body.Color == nil {
+		body.Color = cfg.Text.Color
+	}
+	if body.BackgroundColor == nil {
+		// Terminals provide the background, pages have to pick one that
+		// matches the text color.
+		bg := "#ffffff"
+		if body.Color != nil && isLightColor(*body.Color) {
+			bg = "#1d1d1d"
+		}
+		body.BackgroundColor = &bg
+	}
+
+	rules := []struct {
+		selector string
+		style    ansi.StylePrimitive
+	}{
+		{"body", body},
+		{"h1, h2, h3, h4, h5, h6", cfg.Heading.StylePrimitive},
+		{"h1", cfg.H1.StylePrimitive},
+		{"h2", cfg.H2.StylePrimitive},
+		{"h3", cfg.H3.StylePrimitive},
+		{"h4", cfg.H4.StylePrimitive},
+		{"h5", cfg.H5.StylePrimitive},
+		{"h6", cfg.H6.StylePrimitive},
+		{"p", cfg.Paragraph.StylePrimitive},
+		{"blockquote", cfg.BlockQuote.StylePrimitive},
+		{"strong", cfg.Strong},
+		{"em", cfg.Emph},
+		{"del", cfg.Strikethrough},
+		{"a", cfg.Link},
+		{"a", cfg.LinkText},
+		{"hr", cfg.HorizontalRule},
+		{"code", cfg.Code.StylePrimitive},
+		{"pre", cfg.CodeBlock.StylePrimitive},
+		{"table", cfg.Table.StylePrimitive},
+		{"dt", cfg.DefinitionTerm},
+		{"dd", cfg.DefinitionDescription},
+	}
+
+	var b strings.Builder
+	for _, r := range rules {
+		if decl := cssDeclarations(r.style); decl != "" {
+			fmt.Fprintf(&b, "%s { %s }\n", r.selector, decl)
+		}
+	}
+	return b.String()
+}
+
+func cssDeclarations(s ansi.StylePrimitive) string {
+	var decl []string
+	if s.Color != nil {
+		decl = append(decl, "color: "+cssColor(*s.Color)+";")
+	}
+	if s.BackgroundColor != nil {
+		decl = append(decl, "background-color: "+cssColor(*s.BackgroundColor)+";")
+	}
+	if s.Bold != nil {
+		decl = append(decl, "font-weight: "+map[bool]string{true: "bold", false: "normal"}[*s.Bold]+";")
+	}
+	if s.Italic != nil {
+		decl = append(decl, "font-style: "+map[bool]string{true: "italic", false: "normal"}[*s.Italic]+";")
+	}
+	var decoration []string
+	if s.Underline != nil && *s.Underline {
+		decoration = append(decoration, "underline")
+	}
+	if s.CrossedOut != nil && *s.CrossedOut {
+		decoration = append(decoration, "line-through")
+	}
+	if len(decoration) > 0 {
+		decl = append(decl, "text-decoration: "+strings.Join(decoration, " ")+";")
+	}
+	if s.Upper != nil && *s.Upper {
+		decl = append(decl, "text-transform: uppercase;")
+	} else if s.Lower != nil && *s.Lower {
+		decl = append(decl, "text-transform: lowercase;")
+	}
+	if s.Faint != nil && *s.Faint {
+		decl = append(decl, "opacity: 0.7;")
+	}
+	return strings.Join(decl, " ")
+}
+
+// cssColor converts a glamour color, either hex or an ANSI 256 color index,
+// to a CSS color.
+func cssColor(c string) string {
+	if n, err := strconv.Atoi(c); err == nil && n >= 0 && n < 256 {
+		return termenv.ConvertToRGB(termenv.ANSI256Color(n)).Hex()
+	}
+	return c
+}
+
+func isLightColor(c string) bool {
+	rgb := termenv.ConvertToRGB(termenv.RGBColor(cssColor(c)))
+	l, _, _ := rgb.Lab()
+	return l > 0.5
+}
+
+// htmlCodeStyle returns the chroma style for code blocks: the style's theme
+// or its custom chroma colors.
+func htmlCodeStyle(cfg ansi.StyleConfig) *chroma.Style {
+	rules := cfg.CodeBlock
+	if rules.Chroma == nil {
+		if rules.Theme != "" {
+			return chromastyles.Get(rules.Theme)
+		}
+		return chromastyles.Fallback
+	}
+
+	c := rules.Chroma
+	entries := chroma.StyleEntries{}
+	for token, s := range map[chroma.TokenType]ansi.StylePrimitive{
+		chroma.Text:                c.Text,
+		chroma.Error:               c.Error,
+		chroma.Comment:             c.Comment,
+		chroma.CommentPreproc:      c.CommentPreproc,
+		chroma.Keyword:             c.Keyword,
+		chroma.KeywordReserved:     c.KeywordReserved,
+		chroma.KeywordNamespace:    c.KeywordNamespace,
+		chroma.KeywordType:         c.KeywordType,
+		chroma.Operator:            c.Operator,
+		chroma.Punctuation:         c.Punctuation,
+		chroma.Name:                c.Name,
+		chroma.NameBuiltin:         c.NameBuiltin,
+		chroma.NameTag:             c.NameTag,
+		chroma.NameAttribute:       c.NameAttribute,
+		chroma.NameClass:           c.NameClass,
+		chroma.NameConstant:        c.NameConstant,
+		chroma.NameDecorator:       c.NameDecorator,
+		chroma.NameException:       c.NameException,
+		chroma.NameFunction:        c.NameFunction,
+		chroma.NameOther:           c.NameOther,
+		chroma.Literal:             c.Literal,
+		chroma.LiteralNumber:       c.LiteralNumber,
+		chroma.LiteralDate:         c.LiteralDate,
+		chroma.LiteralString:       c.LiteralString,
+		chroma.LiteralStringEscape: c.LiteralStringEscape,
+		chroma.GenericDeleted:      c.GenericDeleted,
+		chroma.GenericEmph:         c.GenericEmph,
+		chroma.GenericInserted:     c.GenericInserted,
+		chroma.GenericStrong:       c.GenericStrong,
+		chroma.GenericSubheading:   c.GenericSubheading,
+		chroma.Background:          c.Background,
+	} {
+		if entry := chromaEntry(s); entry != "" {
+			entries[token] = entry
+		}
+	}
+	style, err := chroma.NewStyle("glow", entries)
+	if err != nil {
+		return chromastyles.Fallback
+	}
+	return style
+}
+
+// chromaEntry converts a glamour style primitive to a chroma style entry.
+func chromaEntry(s ansi.StylePrimitive) string {
+	var entry []string
+	if s.Color != nil {
+		entry = append(entry, cssColor(*s.Color))
+	}
+	if s.BackgroundColor != nil {
+		entry = append(entry, "bg:"+cssColor(*s.BackgroundColor))
+	}
+	if s.Bold != nil && *s.Bold {
+		entry = append(entry, "bold")
+	}
+	if s.Italic != nil && *s.Italic {
+		entry = append(entry, "italic")
+	}
+	if s.Underline != nil && *s.Underline {
+		entry = append(entry, "underline")
+	}
+	return strings.Join(entry, " ")
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// jiraEscaper escapes characters that start Jira wiki markup.
+var jiraEscaper = strings.NewReplacer(
+	"{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`, "*", `\*`, "_", `\_`,
+)
+
+// exportJira writes the document as Atlassian wiki markup, as used by Jira
+// issues and comments.
+func exportJira(w io.Writer, md []byte, _ exportOptions) error {
+	j := jiraRenderer{source: md}
+	j.blocks(utils.ParseMarkdown(md))
+	if _, err := io.WriteString(w, strings.TrimRight(j.b.String(), "\n")+"\n"); err != nil {
+		return fmt.Errorf("unable to write output: %w", err)
+	}
+	return nil
+}
+
+// jiraRenderer renders a markdown AST to Jira wiki markup.
+type jiraRenderer struct {
+	source []byte
+	b      bytes.Buffer
+	// list markers of the enclosing lists, like "*#"
+	lists string
+}
+
+func (j *jiraRenderer) blocks(n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		j.block(c)
+	}
+}
+
+func (j *jiraRenderer) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		fmt.Fprintf(&j.b, "h%d. %s\n\n", n.Level, j.inline(n))
+
+	case *ast.Paragraph:
+		j.b.WriteString(j.inline(n) + "\n")
+		if j.lists == "" {
+			j.b.WriteString("\n")
+		}
+
+	case *ast.TextBlock:
+		j.b.WriteString(j.inline(n) + "\n")
+
+	case *ast.List:
+		marker := "*"
+		if n.IsOrdered() {
+			marker = "#"
+		}
+		j.lists += marker
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			j.b.WriteString(j.lists + " ")
+			for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+				// Further paragraphs would end the list, so they are joined
+				// with line breaks.
+				if _, ok := c.(*ast.Paragraph); ok && c.PreviousSibling() != nil {
+					j.trimNewlines()
+					j.b.WriteString(` \\ `)
+				}
+				j.block(c)
+			}
+		}
+		j.lists = j.lists[:len(j.lists)-1]
+		if j.lists == "" {
+			j.b.WriteString("\n")
+		}
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		macro := "{code}"
+		if fcb, ok := n.(*ast.FencedCodeBlock); ok && fcb.Language(j.source) != nil {
+			macro = "{code:" + string(fcb.Language(j.source)) + "}"
+		}
+		j.b.WriteString(macro + "\n")
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			j.b.Write(line.Value(j.source))
+		}
+		j.b.WriteString("{code}\n\n")
+
+	case *ast.Blockquote:
+		j.b.WriteString("{quote}\n")
+		j.blocks(n)
+		j.trimNewlines()
+		j.b.WriteString("\n{quote}\n\n")
+
+	case *ast.ThematicBreak:
+		j.b.WriteString("----\n\n")
+
+	case *east.Table:
+		for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+			sep := "|"
+			if _, ok := row.(*east.TableHeader); ok {
+				sep = "||"
+			}
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				j.b.WriteString(sep + " " + strings.TrimSpace(j.inline(cell)) + " ")
+			}
+			j.b.WriteString(sep + "\n")
+		}
+		j.b.WriteString("\n")
+
+	case *east.DefinitionList:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			switch c.(type) {
+			case *east.DefinitionTerm:
+				j.b.WriteString("*" + j.inline(c) + "*\n")
+			case *east.DefinitionDescription:
+				j.blocks(c)
+			}
+		}
+		j.trimNewlines()
+		j.b.WriteString("\n\n")
+
+	case *ast.HTMLBlock:
+		// Jira doesn't render HTML.
+
+	default:
+		j.blocks(n)
+	}
+}
+
+// trimNewlines removes trailing newlines from the output.
+func (j *jiraRenderer) trimNewlines() {
+	j.b.Truncate(len(bytes.TrimRight(j.b.Bytes(), "\n")))
+}
+
+// inline returns the wiki markup of an inline node and its descendants.
+func (j *jiraRenderer) inline(n ast.Node) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch c := c.(type) {
+		case *ast.Text:
+			if !entering {
+				break
+			}
+			b.WriteString(jiraEscaper.Replace(string(c.Segment.Value(j.source))))
+			switch {
+			case c.HardLineBreak():
+				b.WriteString(`\\`)
+			case c.SoftLineBreak():
+				// Newlines are line breaks in wiki markup.
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			if entering {
+				b.WriteString(jiraEscaper.Replace(string(c.Value)))
+			}
+		case *ast.Emphasis:
+			if c.Level == 1 {
+				b.WriteString("_")
+			} else {
+				b.WriteString("*")
+			}
+		case *east.Strikethrough:
+			b.WriteString("-")
+		case *ast.CodeSpan:
+			if entering {
+				b.WriteString("{{" + utils.NodeText(c, j.source) + "}}")
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.Link:
+			if entering {
+				b.WriteString("[")
+			} else {
+				b.WriteString("|" + string(c.Destination) + "]")
+			}
+		case *ast.Image:
+			if entering {
+				b.WriteString("!" + string(c.Destination) + "!")
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			if entering {
+				url := string(c.URL(j.source))
+				if c.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(url, "mailto:") {
+					url = "mailto:" + url
+				}
+				b.WriteString("[" + url + "]")
+			}
+			return ast.WalkSkipChildren, nil
+		case *east.TaskCheckBox:
+			if entering && c.IsChecked {
+				b.WriteString("(/) ")
+			} else if entering {
+				b.WriteString("(x) ")
+			}
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// exportConfluence writes the document in Confluence's XHTML based storage
+// format, with code blocks as code macros.
+func exportConfluence(w io.Writer, md []byte, _ exportOptions) error {
+	gm := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.DefinitionList,
+		),
+		goldmark.WithRendererOptions(
+			gmhtml.WithXHTML(),
+			renderer.WithNodeRenderers(util.Prioritized(confluenceRenderer{}, 100)),
+		),
+	)
+
+	var b bytes.Buffer
+	if err := gm.Convert(md, &b); err != nil {
+		return fmt.Errorf("unable to render confluence storage format: %w", err)
+	}
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("unable to write output: %w", err)
+	}
+	return nil
+}
+
+// confluenceRenderer renders code blocks as Confluence code macros.
+type confluenceRenderer struct{}
+
+func (r confluenceRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+	reg.Register(ast.KindCodeBlock, r.renderCodeBlock)
+}
+
+func (r confluenceRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString(`<ac:structured-macro ac:name="code">`)
+	if n, ok := node.(*ast.FencedCodeBlock); ok && n.Language(source) != nil {
+		fmt.Fprintf(w, `<ac:parameter ac:name="language">%s</ac:parameter>`, html.EscapeString(string(n.Language(source))))
+	}
+
+	var code strings.Builder
+	for i := 0; i < node.Lines().Len(); i++ {
+		line := node.Lines().At(i)
+		code.Write(line.Value(source))
+	}
+	// CDATA sections can't contain their terminator, so split it across two.
+	body := strings.ReplaceAll(code.String(), "]]>", "]]]]><![CDATA[>")
+	fmt.Fprintf(w, "<ac:plain-text-body><![CDATA[%s]]></ac:plain-text-body></ac:structured-macro>\n", body)
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// manTitleRe matches ronn-style man page titles like "glow(1) -- render
+// markdown on the CLI".
+var manTitleRe = regexp.MustCompile(`^(\S+)\((\w+)\)(?:\s+-{1,2}\s+(.+))?$`)
+
+var manEscaper = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+
+// exportMan writes the document as groff man page source. The first level 1
+// heading becomes the title of the man page, level 2 headings its sections.
+func exportMan(w io.Writer, md []byte, opts exportOptions) error {
+	m := manRenderer{source: md}
+	doc := utils.ParseMarkdown(md)
+
+	name, section, desc := opts.title, "1", ""
+	if h, ok := doc.FirstChild().(*ast.Heading); ok && h.Level == 1 {
+		title := strings.TrimSpace(utils.NodeText(h, md))
+		name = title
+		if match := manTitleRe.FindStringSubmatch(title); match != nil {
+			name, section, desc = match[1], match[2], match[3]
+		}
+		doc.RemoveChild(doc, h)
+	}
+
+	m.b.WriteString(`.\" Generated by glow` + "\n")
+	fmt.Fprintf(&m.b, ".TH \"%s\" \"%s\"\n", manEscape(strings.ToUpper(name)), section)
+	if desc != "" {
+		fmt.Fprintf(&m.b, ".SH NAME\n%s \\- %s\n", manEscape(name), manEscape(desc))
+	}
+	m.blocks(doc)
+
+	if _, err := io.WriteString(w, m.b.String()); err != nil {
+		return fmt.Errorf("unable to write output: %w", err)
+	}
+	return nil
+}
+
+// manRenderer renders a markdown AST to groff man macros.
+type manRenderer struct {
+	source []byte
+	b      strings.Builder
+}
+
+func (m *manRenderer) blocks(n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		m.block(c)
+	}
+}
+
+func (m *manRenderer) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		macro := ".SS"
+		text := m.inline(n)
+		if n.Level <= 2 {
+			macro = ".SH"
+			text = strings.ToUpper(text)
+		}
+		m.b.WriteString(macro + " " + text + "\n")
+
+	case *ast.Paragraph:
+		// The first paragraph of list items and definitions continues the
+		// item's tag.
+		if n.PreviousSibling() != nil || !isManItem(n.Parent()) {
+			m.b.WriteString(".PP\n")
+		}
+		m.b.WriteString(m.inline(n) + "\n")
+
+	case *ast.TextBlock:
+		m.b.WriteString(m.inline(n) + "\n")
+
+	case *ast.List:
+		i := n.Start
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			if n.IsOrdered() {
+				fmt.Fprintf(&m.b, ".IP \"%d.\" 4\n", i)
+				i++
+			} else {
+				m.b.WriteString(".IP \\(bu 2\n")
+			}
+			for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+				if _, ok := c.(*ast.List); ok {
+					m.b.WriteString(".RS\n")
+					m.block(c)
+					m.b.WriteString(".RE\n")
+					continue
+				}
+				if _, ok := c.(*ast.Paragraph); ok && c.PreviousSibling() != nil {
+					m.b.WriteString(".IP\n" + m.inline(c) + "\n")
+					continue
+				}
+				m.block(c)
+			}
+		}
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, strings.TrimRight(string(line.Value(m.source)), "\r\n"))
+		}
+		m.preformatted(lines)
+
+	case *ast.Blockquote:
+		m.b.WriteString(".RS 4\n")
+		m.blocks(n)
+		m.b.WriteString(".RE\n")
+
+	case *ast.ThematicBreak:
+		m.b.WriteString(".sp\n")
+
+	case *east.Table:
+		t := textRenderer{source: m.source}
+		m.preformatted(t.table(n))
+
+	case *east.DefinitionList:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			switch c.(type) {
+			case *east.DefinitionTerm:
+				m.b.WriteString(".TP\n" + m.inline(c) + "\n")
+			case *east.DefinitionDescription:
+				m.blocks(c)
+			}
+		}
+
+	case *ast.HTMLBlock:
+		// Raw HTML has no equivalent in man pages.
+
+	default:
+		m.blocks(n)
+	}
+}
+
+// isManItem reports whether a node is rendered as a tagged paragraph.
+func isManItem(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.ListItem, *east.DefinitionDescription:
+		return true
+	}
+	return false
+}
+
+// preformatted writes lines without filling, indented like code blocks.
+func (m *manRenderer) preformatted(lines []string) {
+	m.b.WriteString(".PP\n.RS 4\n.nf\n")
+	for _, l := range lines {
+		m.b.WriteString(manEscapeLine(manEscape(l)) + "\n")
+	}
+	m.b.WriteString(".fi\n.RE\n")
+}
+
+// inline returns the text of an inline node and its descendants, with
+// emphasis and code in italic and bold fonts.
+func (m *manRenderer) inline(n ast.Node) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch c := c.(type) {
+		case *ast.Text:
+			if !entering {
+				break
+			}
+			b.WriteString(manEscape(string(c.Segment.Value(m.source))))
+			switch {
+			case c.HardLineBreak():
+				b.WriteString("\n.br\n")
+			case c.SoftLineBreak():
+				b.WriteByte('\n')
+			}
+		case *ast.String:
+			if entering {
+				b.WriteString(manEscape(string(c.Value)))
+			}
+		case *ast.Emphasis:
+			switch {
+			case !entering:
+				b.WriteString(`\fR`)
+			case c.Level == 1:
+				b.WriteString(`\fI`)
+			default:
+				b.WriteString(`\fB`)
+			}
+		case *ast.CodeSpan:
+			if entering {
+				b.WriteString(`\fB` + manEscape(utils.NodeText(c, m.source)) + `\fR`)
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.Link:
+			if !entering && string(c.Destination) != utils.NodeText(c, m.source) {
+				b.WriteString(" <" + manEscape(string(c.Destination)) + ">")
+			}
+		case *ast.AutoLink:
+			if entering {
+				b.WriteString(manEscape(string(c.URL(m.source))))
+			}
+			return ast.WalkSkipChildren, nil
+		case *east.TaskCheckBox:
+			if entering && c.IsChecked {
+				b.WriteString("[x] ")
+			} else if entering {
+				b.WriteString("[ ] ")
+			}
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	lines := strings.Split(b.String(), "\n")
+	for i, l := range lines {
+		if l != ".br" {
+			lines[i] = manEscapeLine(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// manEscape escapes backslashes and hyphens, which groff would otherwise
+// render as typographic hyphens, breaking copy and paste of options.
+func manEscape(s string) string {
+	return manEscaper.Replace(s)
+}
+
+// manEscapeLine keeps lines of text starting with a control character from
+// being interpreted as macros.
+func manEscapeLine(s string) string {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		return `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// slidesDocument uses the markup of reveal.js decks, but ships its own
+// navigation so that it works offline and without any assets.
+const slidesDocument = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="glow">
+<title>%s</title>
+<style>
+%s</style>
+</head>
+<body>
+<div class="reveal">
+<div class="slides">
+%s</div>
+</div>
+<div class="progress"><span></span></div>
+<script>
+%s</script>
+</body>
+</html>
+`
+
+const slidesCSS = `body { overflow: hidden; font-size: 3.2vmin; }
+.reveal .slides > section { display: none; position: fixed; inset: 0; padding: 5vh 8vw; box-sizing: border-box; overflow: auto; flex-direction: column; justify-content: center; }
+.reveal .slides > section.present { display: flex; }
+.reveal h1 { font-size: 2.6em; }
+.reveal h2 { font-size: 1.9em; }
+.reveal .anchor { display: none; }
+.progress { position: fixed; left: 0; bottom: 0; width: 100%; height: 4px; }
+.progress span { display: block; height: 100%; width: 0; background: currentColor; opacity: 0.5; transition: width 0.2s; }
+`
+
+const slidesScript = `(function () {
+  var slides = document.querySelectorAll(".reveal .slides > section");
+  var current = 0;
+  function show(i) {
+    current = Math.max(0, Math.min(slides.length - 1, i));
+    slides.forEach(function (s, n) { s.classList.toggle("present", n === current); });
+    document.querySelector(".progress span").style.width = ((current + 1) / slides.length * 100) + "%";
+    history.replaceState(null, "", "#/" + (current + 1));
+  }
+  document.addEventListener("keydown", function (e) {
+    switch (e.key) {
+    case "ArrowRight": case "ArrowDown": case "PageDown": case " ": case "n": show(current + 1); break;
+    case "ArrowLeft": case "ArrowUp": case "PageUp": case "p": show(current - 1); break;
+    case "Home": show(0); break;
+    case "End": show(slides.length - 1); break;
+    default: return;
+    }
+    e.preventDefault();
+  });
+  var m = location.hash.match(/^#\/(\d+)/);
+  show(m ? parseInt(m[1], 10) - 1 : 0);
+})();
+`
+
+// exportSlides writes a self-contained HTML slide deck. Slides are separated
+// by thematic breaks (---) or, if there are none, start at every level 1 and
+// 2 heading.
+func exportSlides(w io.Writer, md []byte, opts exportOptions) error {
+	cfg, err := utils.GlamourStyleConfig(opts.style)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	formatter := newHTMLFormatter()
+	codeStyle := htmlCodeStyle(cfg)
+	gm := newHTMLMarkdown(formatter, codeStyle)
+
+	ctx := parser.NewContext(parser.WithIDs(utils.NewSlugger()))
+	doc := gm.Parser().Parse(text.NewReader(md), parser.WithContext(ctx))
+
+	var body bytes.Buffer
+	for _, slide := range splitSlides(doc) {
+		body.WriteString("<section>\n")
+		for _, n := range slide {
+			if err := gm.Renderer().Render(&body, md, n); err != nil {
+				return fmt.Errorf("unable to render slide: %w", err)
+			}
+		}
+		body.WriteString("</section>\n")
+	}
+
+	var css strings.Builder
+	css.WriteString(htmlBaseCSS)
+	css.WriteString(htmlStyleCSS(cfg))
+	css.WriteString(slidesCSS)
+	if err := formatter.WriteCSS(&css, codeStyle); err != nil {
+		return fmt.Errorf("unable to write code style: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, slidesDocument, html.EscapeString(opts.title), css.String(), body.String(), slidesScript); err != nil {
+		return fmt.Errorf("unable to write slides: %w", err)
+	}
+	return nil
+}
+
+// splitSlides groups the top-level blocks of a document into slides.
+func splitSlides(doc ast.Node) [][]ast.Node {
+	breaks := false
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if n.Kind() == ast.KindThematicBreak {
+			breaks = true
+			break
+		}
+	}
+
+	var slides [][]ast.Node
+	var slide []ast.Node
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		switch {
+		case breaks && n.Kind() == ast.KindThematicBreak:
+			slides = append(slides, slide)
+			slide = nil
+			continue
+		case !breaks && n.Kind() == ast.KindHeading && n.(*ast.Heading).Level <= 2 && len(slide) > 0:
+			slides = append(slides, slide)
+			slide = nil
+		}
+		slide = append(slide, n)
+	}
+	slides = append(slides, slide)
+
+	// Drop empty slides, e.g. from a break at the start of the document.
+	filtered := slides[:0]
+	for _, s := range slides {
+		if len(s) > 0 {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
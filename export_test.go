@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportHTML(t *testing.T) {
+	md := "# Title\n\n## Usage\n\n```go\nfunc main() {}\n```\n\n## Usage\n"
+
+	var b bytes.Buffer
+	if err := exportHTML(&b, []byte(md), exportOptions{style: "dark", title: "Title", toc: true}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"<title>Title</title>",
+		`<h2 id="usage">`,
+		`<h2 id="usage-1">`,
+		`<a href="#usage-1">Usage</a>`,
+		`<pre class="chroma">`,
+		"background-color: #1d1d1d;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+}
+
+func TestExportHTMLFragmentLinks(t *testing.T) {
+	md := "# Title\n\nSee [steps](#Install%20Steps), [usage](#Usage) and [away](#missing).\n\n## Install Steps\n\n## Usage\n"
+
+	var b bytes.Buffer
+	if err := exportHTML(&b, []byte(md), exportOptions{style: "dark", title: "Title"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`<a href="#install-steps">steps</a>`,
+		`<a href="#usage">usage</a>`,
+		`<a href="#missing">away</a>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+}
+
+func TestCSSColor(t *testing.T) {
+	for in, want := range map[string]string{
+		"#ff00aa": "#ff00aa",
+		"196":     "#ff0000",
+		"15":      "#ffffff",
+	} {
+		if got := cssColor(in); got != want {
+			t.Errorf("expected %s to be %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestExportANSI(t *testing.T) {
+	for profile, want := range map[string]string{
+		"256":  "\x1b[38;5;39;1m## ",
+		"16":   "\x1b[94;1m## ",
+		"none": "  Some text.",
+	} {
+		var b bytes.Buffer
+		opts := exportOptions{style: "dark", width: 40, colorProfile: profile}
+		if err := exportANSI(&b, []byte("## Usage\n\nSome text.\n"), opts); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %s output to contain %q, got %q", profile, want, b.String())
+		}
+		if profile == "none" && strings.Contains(b.String(), "\x1b[38;") {
+			t.Errorf("expected no colors, got %q", b.String())
+		}
+	}
+
+	var b bytes.Buffer
+	if err := exportANSI(&b, []byte("text"), exportOptions{style: "dark", colorProfile: "8"}); err == nil {
+		t.Error("expected an error for an unsupported color profile")
+	}
+}
+
+func TestExportText(t *testing.T) {
+	md := "# Title\n\nSee [the docs](https://example.com).\n\n- one\n- two\n\n| a | bb |\n|---|----|\n| ccc | d |\n"
+	want := `Title
+=====
+
+See the docs (https://example.com).
+
+* one
+* two
+
++-----+----+
+| a   | bb |
++-----+----+
+| ccc | d  |
++-----+----+
+`
+
+	var b bytes.Buffer
+	if err := exportText(&b, []byte(md), exportOptions{width: 80}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestExportMan(t *testing.T) {
+	md := "# glow(1) -- render markdown\n\n## Synopsis\n\n`glow` [*options*]\n\n.hidden\n"
+	want := `.\" Generated by glow
+.TH "GLOW" "1"
+.SH NAME
+glow \- render markdown
+.SH SYNOPSIS
+.PP
+\fBglow\fR [\fIoptions\fR]
+.PP
+\&.hidden
+`
+
+	var b bytes.Buffer
+	if err := exportMan(&b, []byte(md), exportOptions{title: "glow"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestExportJira(t *testing.T) {
+	md := "## Usage\n\nRun `glow` with **care**, see [docs](https://example.com).\n\n- one\n  1. nested\n- [x] done\n\n| a | b |\n|---|---|\n| c | d |\n"
+	want := `h2. Usage
+
+Run {{glow}} with *care*, see [docs|https://example.com].
+
+* one
+*# nested
+* (/) done
+
+|| a || b ||
+| c | d |
+`
+
+	var b bytes.Buffer
+	if err := exportJira(&b, []byte(md), exportOptions{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestExportConfluence(t *testing.T) {
+	var b bytes.Buffer
+	if err := exportConfluence(&b, []byte("```go\nfmt.Println(\"]]>\")\n```\n"), exportOptions{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := `<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[fmt.Println("]]]]><![CDATA[>")` + "\n]]></ac:plain-text-body></ac:structured-macro>\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestExportSlides(t *testing.T) {
+	for md, want := range map[string]int{
+		"# Title\n\nIntro\n\n## One\n\ntext\n\n### Detail\n\n## Two\n": 3,
+		"# Title\n\n---\n\n## One\n\n## Two\n\n---\n":                  2,
+	} {
+		var b bytes.Buffer
+		if err := exportSlides(&b, []byte(md), exportOptions{style: "dark", title: "Title"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := strings.Count(b.String(), "<section>"); got != want {
+			t.Errorf("expected %d slides, got %d for %q", want, got, md)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// exportText writes the document as plain text, keeping its structure but
+// none of its styling.
+func exportText(w io.Writer, md []byte, opts exportOptions) error {
+	t := textRenderer{source: md}
+	lines := t.blocks(utils.ParseMarkdown(md), int(opts.width)) //nolint:gosec
+	if _, err := io.WriteString(w, strings.Join(lines, "\n")+"\n"); err != nil {
+		return fmt.Errorf("unable to write output: %w", err)
+	}
+	return nil
+}
+
+// textRenderer renders a markdown AST to plain text lines. A width of zero
+// disables wrapping.
+type textRenderer struct {
+	source []byte
+}
+
+// blocks renders the block children of n, separated by blank lines.
+func (t textRenderer) blocks(n ast.Node, width int) []string {
+	var lines []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		b := t.block(c, width)
+		if len(b) == 0 {
+			continue
+		}
+		if len(lines) > 0 && !isTightItem(c) {
+			lines = append(lines, "")
+		}
+		lines = append(lines, b...)
+	}
+	return lines
+}
+
+// isTightItem reports whether a block is part of a tight list, and thus isn't
+// separated by a blank line from the block before it.
+func isTightItem(n ast.Node) bool {
+	if _, ok := n.(*ast.TextBlock); ok {
+		return true
+	}
+	if _, ok := n.Parent().(*ast.ListItem); !ok {
+		return false
+	}
+	l, ok := n.Parent().Parent().(*ast.List)
+	return ok && l.IsTight
+}
+
+func (t textRenderer) block(n ast.Node, width int) []string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		lines := wrapText(t.inline(n), width)
+		underline := "-"
+		if n.Level == 1 {
+			underline = "="
+		}
+		w := 0
+		for _, l := range lines {
+			w = max(w, ansi.StringWidth(l))
+		}
+		return append(lines, strings.Repeat(underline, w))
+
+	case *ast.Paragraph, *ast.TextBlock:
+		return wrapText(t.inline(n), width)
+
+	case *ast.List:
+		var lines []string
+		i := n.Start
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			marker := "* "
+			if n.IsOrdered() {
+				marker = strconv.Itoa(i) + ". "
+				i++
+			}
+			if len(lines) > 0 && !n.IsTight {
+				lines = append(lines, "")
+			}
+			lines = append(lines, prefixLines(t.blocks(item, width-len(marker)), marker, strings.Repeat(" ", len(marker)))...)
+		}
+		return lines
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, strings.TrimRight(string(line.Value(t.source)), "\r\n"))
+		}
+		return prefixLines(lines, "    ", "    ")
+
+	case *ast.Blockquote:
+		return prefixLines(t.blocks(n, width-2), "> ", "> ")
+
+	case *ast.ThematicBreak:
+		if width <= 0 {
+			width = 3
+		}
+		return []string{strings.Repeat("-", width)}
+
+	case *east.Table:
+		return t.table(n)
+
+	case *east.DefinitionList:
+		var lines []string
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			switch c.(type) {
+			case *east.DefinitionTerm:
+				lines = append(lines, wrapText(t.inline(c), width)...)
+			case *east.DefinitionDescription:
+				lines = append(lines, prefixLines(t.blocks(c, width-4), "    ", "    ")...)
+			}
+		}
+		return lines
+
+	case *ast.HTMLBlock:
+		return nil
+	}
+
+	return t.blocks(n, width)
+}
+
+// table renders a table as a fixed-width grid.
+func (t textRenderer) table(n *east.Table) []string {
+	var rows [][]string
+	var widths []int
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		i := 0
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			s := strings.TrimSpace(t.inline(cell))
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], ansi.StringWidth(s))
+			cells = append(cells, s)
+			i++
+		}
+		rows = append(rows, cells)
+	}
+
+	border := "+"
+	for _, w := range widths {
+		border += strings.Repeat("-", w+2) + "+"
+	}
+
+	lines := []string{border}
+	for i, cells := range rows {
+		var b strings.Builder
+		b.WriteString("|")
+		for j, w := range widths {
+			var s string
+			if j < len(cells) {
+				s = cells[j]
+			}
+			b.WriteString(" " + s + strings.Repeat(" ", w-ansi.StringWidth(s)) + " |")
+		}
+		lines = append(lines, b.String())
+		if i == 0 {
+			lines = append(lines, border)
+		}
+	}
+	return append(lines, border)
+}
+
+// inline returns the text of an inline node and its descendants, with links
+// written as "text (url)".
+func (t textRenderer) inline(n ast.Node) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch c := c.(type) {
+		case *ast.Text:
+			if !entering {
+				break
+			}
+			b.Write(c.Segment.Value(t.source))
+			switch {
+			case c.HardLineBreak():
+				b.WriteByte('\n')
+			case c.SoftLineBreak():
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			if entering {
+				b.Write(c.Value)
+			}
+		case *ast.Link:
+			if !entering {
+				writeLinkURL(&b, string(c.Destination), utils.NodeText(c, t.source))
+			}
+		case *ast.Image:
+			if !entering {
+				writeLinkURL(&b, string(c.Destination), "")
+			}
+		case *ast.AutoLink:
+			if entering {
+				b.Write(c.URL(t.source))
+			}
+			return ast.WalkSkipChildren, nil
+		case *east.TaskCheckBox:
+			if entering {
+				if c.IsChecked {
+					b.WriteString("[x] ")
+				} else {
+					b.WriteString("[ ] ")
+				}
+			}
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+func writeLinkURL(b *strings.Builder, url, text string) {
+	if url == "" || url == text {
+		return
+	}
+	b.WriteString(" (" + url + ")")
+}
+
+func wrapText(s string, width int) []string {
+	if width > 0 {
+		s = ansi.Wordwrap(s, width, "")
+	}
+	return strings.Split(s, "\n")
+}
+
+// prefixLines prefixes the first line with first and all others with rest.
+// Blank lines only get the trimmed prefix.
+func prefixLines(lines []string, first, rest string) []string {
+	for i, l := range lines {
+		p := rest
+		if i == 0 {
+			p = first
+		}
+		if l == "" {
+			p = strings.TrimRight(p, " ")
+		}
+		lines[i] = p + l
+	}
+	return lines
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/ui"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// feedSniffSize is how much of an XML document is looked at to tell whether
+// it's a feed.
+const feedSniffSize = 1024
+
+// feedAccept asks servers for feeds.
+const feedAccept = "application/atom+xml, application/rss+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.1"
+
+// feedMediaTypes are the media types feeds are served as. Feeds served as
+// generic XML are told by their root element.
+var feedMediaTypes = []string{
+	"application/rss+xml", "application/atom+xml", "application/feed+xml",
+	"application/rdf+xml", "application/xml", "text/xml",
+}
+
+// feedSource returns a source reading an RSS or Atom feed converted to a
+// digest of its entries, or nil if the response isn't a feed, or one
+// converted based on its extension.
+func feedSource(resp *http.Response, feedURL string) *source {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !slices.Contains(feedMediaTypes, mediaType) || utils.IsConvertibleFile(feedURL) {
+		return nil
+	}
+	body := bufio.NewReaderSize(resp.Body, feedSniffSize)
+	head, _ := body.Peek(feedSniffSize)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+	if !utils.IsFeed(head) {
+		return nil
+	}
+	return &source{reader: &webPageReader{body: resp.Body, url: feedURL}, URL: feedURL, webPage: true}
+}
+
+// fetchFeed gets the feed at a URL, or nil if it isn't one.
+func fetchFeed(feedURL string) (*utils.Feed, error) {
+	if u, err := url.Parse(feedURL); err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil
+	}
+	res, err := httpGetAccepting(feedURL, feedAccept)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get url: %w", err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP status %d", res.StatusCode)
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+	if !utils.IsFeed(b) {
+		return nil, nil
+	}
+	return utils.ParseFeed(b, feedURL) //nolint:wrapcheck
+}
+
+// feedEntryName returns the name an entry is listed by in the TUI, starting
+// with its date so entries are listed in order.
+func feedEntryName(e utils.FeedEntry) string {
+	name := strings.ReplaceAll(e.Title, "\n", " ")
+	if !e.Date.IsZero() {
+		name = e.Date.Format("2006-01-02") + " " + name
+	}
+	return name
+}
+
+// runFeedTUI lists the entries of a feed in the TUI, each opened on its own.
+func runFeedTUI(feed *utils.Feed) error {
+	entries := make([]ui.RemoteFile, len(feed.Entries))
+	for i, e := range feed.Entries {
+		entries[i] = ui.RemoteFile{
+			Path: feedEntryName(e),
+			Fetch: func() (io.ReadCloser, string, error) {
+				// The entry is markdown already, so it's given no URL to
+				// convert it by.
+				return io.NopCloser(strings.NewReader(e.Markdown())), "", nil
+			},
+		}
+	}
+	return runRemoteFilesTUI(entries)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"github.com/muesli/gitcha"
+)
+
+var (
+	// ignored when expanding globs and directories, in addition to the
+	// rules of .gitignore files
+	ignoredFiles = []string{".*", "node_modules"}
+
+	// excludePatterns are the files and directories the config leaves out
+	// when expanding globs and directories, and finding files in the TUI
+	excludePatterns []string
+)
+
+// isGlob reports whether an argument is a file pattern rather than a path.
+func isGlob(arg string) bool {
+	return strings.ContainsAny(arg, "*?[") && !isRemoteArg(arg)
+}
+
+// expandArgs expands globs and, when recursive, directories in the arguments
+// to the files they match, in a stable order. Other arguments are returned
+// as-is.
+func expandArgs(args []string, recursive bool, depth int) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		switch {
+		case isGlob(arg):
+			matches, err := globFiles(arg, depth)
+			if err != nil {
+				return nil, err
+			}
+			log.Debug("expanded pattern", "pattern", arg, "files", len(matches))
+			files = append(files, matches...)
+
+		case recursive && isDir(arg):
+			matches, err := findFiles(arg, utils.DocumentPatterns(), depth)
+			if err != nil {
+				return nil, err
+			}
+			log.Debug("expanded directory", "dir", arg, "files", len(matches))
+			files = append(files, matches...)
+
+		default:
+			files = append(files, arg)
+		}
+	}
+	return files, nil
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// globFiles returns the files matching a pattern. Besides the usual wildcards,
+// ** matches any number of directories.
+func globFiles(pattern string, depth int) ([]string, error) {
+	// Walk from the longest directory prefix without wildcards.
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(parts)-1 && !strings.ContainsAny(parts[i], "*?[") {
+		i++
+	}
+	dir := strings.Join(parts[:i], "/")
+	if dir == "" && i > 0 {
+		dir = "/"
+	} else if dir == "" {
+		dir = "."
+	}
+	rest := parts[i:]
+
+	if _, err := path.Match(strings.Join(rest, "/"), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if !isDir(dir) {
+		return nil, nil
+	}
+
+	candidates, err := findFiles(filepath.FromSlash(dir), []string{"*"}, depth)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range candidates {
+		rel, err := filepath.Rel(filepath.FromSlash(dir), f)
+		if err != nil {
+			continue
+		}
+		if matchGlob(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// matchGlob matches path elements against the elements of a pattern.
+func matchGlob(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// findFiles returns the files in dir matching any of the patterns, respecting
+// .gitignore files. A depth of 1 only includes files directly in dir, 0 means
+// no limit.
+func findFiles(dir string, patterns []string, depth int) ([]string, error) {
+	root, err := filepath.Abs(dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to find files: %w", err)
+	}
+
+	ignored := append(slices.Clone(ignoredFiles), excludePatterns...)
+	log.Debug("finding files", "dir", root, "patterns", patterns, "ignored", ignored, "depth", depth)
+	ch, err := gitcha.FindFilesExcept(root, patterns, ignored)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find files: %w", err)
+	}
+
+	var files []string
+	for res := range ch {
+		if res.Info.IsDir() {
+			continue
+		}
+		rel, err := filepath.Rel(root, res.Path)
+		if err != nil {
+			continue
+		}
+		if depth > 0 && strings.Count(rel, string(filepath.Separator)) >= depth {
+			log.Debug("skipped file deeper than --depth", "path", rel, "depth", depth)
+			continue
+		}
+		files = append(files, filepath.Join(dir, rel))
+	}
+	sort.Strings(files)
+	return files, nil
+}
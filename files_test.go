@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.md", "a.md", true},
+		{"*.md", "docs/a.md", false},
+		{"**/*.md", "a.md", true},
+		{"**/*.md", "docs/api/a.md", true},
+		{"docs/**", "docs/api/a.md", true},
+		{"docs/*/a.md", "docs/api/a.md", true},
+		{"docs/*/a.md", "docs/a.md", false},
+	} {
+		got := matchGlob(strings.Split(tc.pattern, "/"), strings.Split(tc.name, "/"))
+		if got != tc.want {
+			t.Errorf("expected %q matching %q to be %v", tc.pattern, tc.name, tc.want)
+		}
+	}
+}
+
+func TestExpandArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{".git/HEAD", ".gitignore", "b.md", "a.md", "ignored.md", "docs/c.md", "docs/api/d.md", "docs/e.txt", "node_modules/f.md"} {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := "# " + f
+		if f == ".gitignore" {
+			content = "ignored.md\n"
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := func(f string) string { return filepath.Join(dir, f) }
+
+	for _, tc := range []struct {
+		args      []string
+		recursive bool
+		depth     int
+		want      []string
+	}{
+		{[]string{p("*.md")}, false, 0, []string{p("a.md"), p("b.md")}},
+		{[]string{p("**/*.md")}, false, 0, []string{p("a.md"), p("b.md"), p("docs/api/d.md"), p("docs/c.md")}},
+		{[]string{p("docs")}, true, 0, []string{p("docs/api/d.md"), p("docs/c.md")}},
+		{[]string{p("docs")}, true, 1, []string{p("docs/c.md")}},
+		{[]string{p("docs"), "README.md"}, false, 0, []string{p("docs"), "README.md"}},
+	} {
+		got, err := expandArgs(tc.args, tc.recursive, tc.depth)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("expected %v to expand to %v, got %v", tc.args, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Front matter display modes.
+const (
+	frontmatterHide  = "hide"
+	frontmatterRaw   = "raw"
+	frontmatterTable = "table"
+)
+
+func validateFrontmatterMode(mode string) error {
+	switch mode {
+	case frontmatterHide, frontmatterRaw, frontmatterTable:
+		return nil
+	}
+	return fmt.Errorf("invalid front matter mode %q, use %s, %s or %s",
+		mode, frontmatterHide, frontmatterRaw, frontmatterTable)
+}
+
+// frontmatterMarkdown returns the front matter of a document as markdown to be
+// shown before it: verbatim in a code block, or as a table of keys and values.
+// Front matter that can't be parsed into a table is shown verbatim.
+func frontmatterMarkdown(content []byte, mode string) string {
+	meta, format := utils.Frontmatter(content)
+	if format == "" || mode != frontmatterRaw && mode != frontmatterTable {
+		return ""
+	}
+
+	if mode == frontmatterTable {
+		fields, err := utils.ParseFrontmatter(content)
+		if err == nil && len(fields) > 0 {
+			return metadataTable(fields)
+		}
+	}
+	return utils.WrapCodeBlock(strings.TrimRight(string(meta), "\n")+"\n", format) + "\n\n"
+}
+
+// metadataTable returns front matter fields as a markdown table.
+func metadataTable(fields []utils.MetadataField) string {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	var b strings.Builder
+	b.WriteString("| Key | Value |\n|-----|-------|\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "| %s | %s |\n", escape.Replace(f.Key), escape.Replace(f.Value))
+	}
+	return b.String() + "\n"
+}
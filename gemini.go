@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+const (
+	geminiPort = "1965"
+
+	// geminiMaxRedirects is how many redirects are followed, as the Gemini
+	// specification recommends.
+	geminiMaxRedirects = 5
+
+	// geminiKnownHostsFile pins the certificates of Gemini servers, which
+	// are mostly self-signed, to their hosts the first time they're seen.
+	geminiKnownHostsFile = "gemini_known_hosts"
+)
+
+// geminiResponse is the header of a Gemini response, and its body.
+type geminiResponse struct {
+	status int
+	meta   string
+	body   io.ReadCloser
+}
+
+// geminiSource fetches a Gemini document. Gemtext documents are read
+// converted to markdown, unless converted based on their extension.
+func geminiSource(u *url.URL) (*source, error) {
+	if offline {
+		return nil, fmt.Errorf("%s: %w", u, errNotCached)
+	}
+	for redirects := 0; ; redirects++ {
+		res, err := geminiRequest(u)
+		if err != nil {
+			return nil, err
+		}
+
+		switch res.status / 10 {
+		case 2:
+			return geminiDocument(u, res)
+		case 3:
+			_ = res.body.Close()
+			if redirects >= geminiMaxRedirects {
+				return nil, fmt.Errorf("stopped after %d redirects", geminiMaxRedirects)
+			}
+			next, err := u.Parse(res.meta)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redirect of %s: %w", u, err)
+			}
+			if next.Scheme != "gemini" {
+				return nil, fmt.Errorf("%s redirects to %s, which isn't a Gemini URL", u, next)
+			}
+			u = next
+		case 1:
+			_ = res.body.Close()
+			return nil, fmt.Errorf("%s asks for input: %s", u, res.meta)
+		case 6:
+			_ = res.body.Close()
+			return nil, fmt.Errorf("%s requires a client certificate: %s", u, res.meta)
+		default:
+			_ = res.body.Close()
+			return nil, fmt.Errorf("unable to get %s: Gemini status %d: %s", u, res.status, res.meta)
+		}
+	}
+}
+
+// geminiDocument returns the source of a successful response.
+func geminiDocument(u *url.URL, res *geminiResponse) (*source, error) {
+	mediaType, _, err := mime.ParseMediaType(res.meta)
+	if res.meta == "" || err != nil {
+		mediaType = "text/gemini"
+	}
+	if !strings.HasPrefix(mediaType, "text/") {
+		_ = res.body.Close()
+		return nil, fmt.Errorf("%s is %s, not a document", u, mediaType)
+	}
+	if mediaType != "text/gemini" || utils.IsConvertibleFile(u.Path) {
+		return &source{reader: res.body, URL: u.String()}, nil
+	}
+
+	defer res.body.Close() //nolint:errcheck
+	b, err := io.ReadAll(res.body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+	md := utils.GemtextToMarkdown(b, u.String())
+	return &source{reader: io.NopCloser(bytes.NewReader(md)), URL: u.String(), webPage: true}, nil
+}
+
+// geminiRequest sends a request to a Gemini server, whose certificate is
+// trusted on first use.
+func geminiRequest(u *url.URL) (*geminiResponse, error) {
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = geminiPort
+	}
+	addr := net.JoinHostPort(host, port)
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: host,
+		// Gemini servers' certificates are mostly self-signed, so they're
+		// verified against the certificate seen first instead.
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("no certificate")
+			}
+			return verifyGeminiCert(addr, cs.PeerCertificates[0])
+		},
+	}
+	dialer := &tls.Dialer{Config: config}
+	if httpClient.Timeout > 0 {
+		dialer.NetDialer = &net.Dialer{Timeout: httpClient.Timeout}
+	}
+	conn, err := dialer.DialContext(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %w", addr, err)
+	}
+	if httpClient.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(httpClient.Timeout))
+	}
+
+	if _, err := io.WriteString(conn, u.String()+"\r\n"); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to send request: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+	code, meta, _ := strings.Cut(strings.TrimRight(header, "\r\n"), " ")
+	status, err := strconv.Atoi(code)
+	if err != nil || len(code) != 2 {
+		_ = conn.Close()
+		return nil, fmt.Errorf("invalid response header from %s: %q", addr, header)
+	}
+
+	var body io.ReadCloser = struct {
+		io.Reader
+		io.Closer
+	}{r, conn}
+	if maxDownloadSize > 0 {
+		body = &limitedBody{ReadCloser: body, left: maxDownloadSize, url: u.String()}
+	}
+	return &geminiResponse{status: status, meta: strings.TrimSpace(meta), body: body}, nil
+}
+
+// geminiKnownHostsPath returns the file the certificates of Gemini servers
+// are pinned in.
+func geminiKnownHostsPath() (string, error) {
+	dir, err := glowDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, geminiKnownHostsFile), nil
+}
+
+// verifyGeminiCert trusts the certificate of a server the first time it's
+// seen, and then only that certificate until it expires. Each line of the
+// known hosts file has a host, the SHA-256 fingerprint of its certificate
+// and when that expires.
+func verifyGeminiCert(addr string, cert *x509.Certificate) error {
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := "sha256:" + hex.EncodeToString(sum[:])
+
+	path, err := geminiKnownHostsPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to read known hosts: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != addr {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		if fields[1] == fingerprint {
+			return nil
+		}
+		expiry, _ := strconv.ParseInt(fields[2], 10, 64)
+		if time.Now().Before(time.Unix(expiry, 0)) {
+			return fmt.Errorf("the certificate of %s changed since it was first trusted; if that's expected, remove it from %s", addr, path)
+		}
+		// The pinned certificate expired, so the new one is trusted.
+	}
+
+	lines = append(lines, fmt.Sprintf("%s %s %d", addr, fingerprint, cert.NotAfter.Unix()))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("unable to create data dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("unable to write known hosts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ghExtensionName is the name of glow's executable when it's installed as a
+// GitHub CLI extension, which gh runs as `gh glow`.
+const ghExtensionName = "gh-glow"
+
+var (
+	// ghExtension is set when glow runs as a GitHub CLI extension.
+	ghExtension bool
+
+	// gistIDPattern matches the IDs of gists.
+	gistIDPattern = regexp.MustCompile(`^[0-9a-f]{20,32}$`)
+
+	// ghRepoPattern matches repositories and their issues as gh takes them,
+	// like OWNER/REPO or OWNER/REPO#123.
+	ghRepoPattern = regexp.MustCompile(`^([A-Za-z0-9-]+/[\w.-]+)(?:#(\d+))?$`)
+
+	ghTokenOnce sync.Once
+	ghToken     string
+)
+
+// isGHExtension returns whether glow was run by gh as an extension, from the
+// name of its executable.
+func isGHExtension(argv0 string) bool {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	return name == ghExtensionName
+}
+
+// ghArgs returns the sources of the arguments gh glow takes: repositories
+// like OWNER/REPO, their issues and pull requests like OWNER/REPO#123, issues
+// and pull requests of the current repository like 123 or #123, and gist
+// IDs. Local files and other sources are kept as they are.
+func ghArgs(args []string) ([]string, error) {
+	sources := make([]string, 0, len(args))
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil {
+			sources = append(sources, arg)
+			continue
+		}
+		if m := ghRepoPattern.FindStringSubmatch(arg); m != nil {
+			src := githubURL.Hostname() + "/" + m[1]
+			if m[2] != "" {
+				src += "/issues/" + m[2]
+			}
+			sources = append(sources, src)
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(arg, "#")); err == nil && n > 0 {
+			repo, err := ghCurrentRepo()
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, fmt.Sprintf("%s/%s/issues/%d", githubURL.Hostname(), repo, n))
+			continue
+		}
+		if gistIDPattern.MatchString(arg) {
+			sources = append(sources, protoGist+arg)
+			continue
+		}
+		sources = append(sources, arg)
+	}
+	return sources, nil
+}
+
+// ghCurrentRepo returns the repository gh works with: the one of GH_REPO, or
+// else the one of the current directory.
+func ghCurrentRepo() (string, error) {
+	if repo := os.Getenv("GH_REPO"); repo != "" {
+		// GH_REPO may include the host, like HOST/OWNER/REPO.
+		parts := strings.Split(repo, "/")
+		if len(parts) > 2 {
+			parts = parts[len(parts)-2:]
+		}
+		return strings.Join(parts, "/"), nil
+	}
+	out, err := runCommand("gh", "repo", "view", "--json", "nameWithOwner", "--jq", ".nameWithOwner")
+	if err != nil {
+		return "", fmt.Errorf("unable to find the current repository: %w", err)
+	}
+	return out, nil
+}
+
+// ghAuthToken returns the token gh is logged in to GitHub with, if any.
+func ghAuthToken() string {
+	ghTokenOnce.Do(func() {
+		ghToken, _ = runCommand("gh", "auth", "token", "--hostname", githubURL.Hostname())
+	})
+	return ghToken
+}
+
+// runCommand runs a command, like gh, and returns its output.
+func runCommand(name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(name, args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err //nolint:wrapcheck
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+const (
+	protoGist = "gist://"
+	gistHost  = "gist.github.com"
+)
+
+// gistAPI is the base URL of the GitHub API for gists.
+var gistAPI = "https://api.github.com/gists/"
+
+// gistID returns the ID of a gist from its URL, like
+// https://gist.github.com/user/ID, or from gist://ID.
+func gistID(path string) (string, bool) {
+	if id, ok := strings.CutPrefix(path, protoGist); ok {
+		return id, id != "" && !strings.Contains(id, "/")
+	}
+	if !strings.Contains(path, "://") {
+		path = protoHTTPS + path
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Hostname() != gistHost {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) == 0 || len(parts) > 2 || parts[len(parts)-1] == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(parts[len(parts)-1], ".git"), true
+}
+
+type gistFile struct {
+	Filename  string `json:"filename"`
+	RawURL    string `json:"raw_url"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+// findGist fetches a gist using the GitHub API. A gist with one markdown
+// file is read as that file; otherwise its markdown files, or all of them if
+// it has none, are joined under headings with their names.
+func findGist(id string) (*source, error) {
+	var gist struct {
+		HTMLURL string              `json:"html_url"`
+		Files   map[string]gistFile `json:"files"`
+	}
+	if err := getJSON(gistAPI+url.PathEscape(id), &gist); err != nil {
+		return nil, err
+	}
+
+	var all, markdown []gistFile
+	for _, f := range gist.Files {
+		all = append(all, f)
+		if utils.IsMarkdownFile(f.Filename) {
+			markdown = append(markdown, f)
+		}
+	}
+	if len(all) == 0 {
+		return nil, errors.New("gist has no files")
+	}
+	files := markdown
+	if len(files) == 0 {
+		files = all
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+
+	if len(files) == 1 {
+		content, err := gistContent(files[0])
+		if err != nil {
+			return nil, err
+		}
+		return &source{reader: io.NopCloser(strings.NewReader(content)), URL: files[0].RawURL}, nil
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		content, err := gistContent(f)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "## %s\n\n", utils.EscapeMarkdown(f.Filename))
+		if utils.IsMarkdownFile(f.Filename) {
+			b.WriteString(strings.TrimRight(content, "\n") + "\n\n")
+		} else {
+			b.WriteString(utils.CodeBlock(content, f.Filename) + "\n\n")
+		}
+	}
+	return &source{reader: io.NopCloser(strings.NewReader(b.String())), URL: gist.HTMLURL}, nil
+}
+
+// gistContent returns the content of a gist file, which the API leaves out
+// of large files.
+func gistContent(f gistFile) (string, error) {
+	if !f.Truncated {
+		return f.Content, nil
+	}
+	b, err := getBody(f.RawURL)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
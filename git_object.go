@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const protoGit = "git:"
+
+// gitObjectArg splits a source argument naming a file at a ref of the
+// repository it's in, like git:HEAD~3:docs/design.md, into the ref and the
+// path. Refs can't start with a dash, which git would take for an option.
+func gitObjectArg(arg string) (string, string, bool) {
+	spec, ok := strings.CutPrefix(arg, protoGit)
+	if !ok {
+		return "", "", false
+	}
+	ref, path, ok := strings.Cut(spec, ":")
+	if !ok || ref == "" || path == "" || strings.HasPrefix(ref, "-") {
+		return "", "", false
+	}
+	return ref, path, true
+}
+
+// gitObjectArgs returns the sources of files at a ref, for --ref.
+func gitObjectArgs(ref string, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("--ref needs the files to read at the ref")
+	}
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid ref %q", ref)
+	}
+	sources := make([]string, len(args))
+	for i, arg := range args {
+		if _, _, ok := gitObjectArg(arg); ok {
+			sources[i] = arg
+			continue
+		}
+		sources[i] = protoGit + ref + ":" + arg
+	}
+	return sources, nil
+}
+
+// gitObjectSource reads a file as it is at a ref of the repository it's in,
+// from the repository's objects, without checking it out.
+func gitObjectSource(ref, path string) (*source, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get absolute path: %w", err)
+	}
+	// The path is looked up relative to the directory git runs in, which
+	// is the file's, so it can be in another repository.
+	var stdout, stderr bytes.Buffer
+	c := exec.Command("git", "-C", filepath.Dir(abs), "show", "--end-of-options", ref+":./"+filepath.Base(abs)) //nolint:gosec
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("unable to read %s at %s: %s", path, ref, msg)
+		}
+		return nil, fmt.Errorf("unable to read %s at %s: %w", path, ref, err)
+	}
+	return &source{reader: io.NopCloser(&stdout), URL: abs}, nil
+}
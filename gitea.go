@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+const codebergHost = "codeberg.org"
+
+// giteaHosts are the hosts of Gitea and Forgejo instances, from the config.
+// Codeberg and hosts named like gitea.example.com or forgejo.example.com are
+// recognized without it.
+var giteaHosts []string
+
+// isGiteaHost returns whether a host serves Gitea or Forgejo.
+func isGiteaHost(host string) bool {
+	return host == codebergHost ||
+		strings.HasPrefix(host, "gitea.") ||
+		strings.HasPrefix(host, "forgejo.") ||
+		slices.Contains(giteaHosts, host)
+}
+
+// giteaRepo returns the owner and name of the repository a Gitea URL points
+// to.
+func giteaRepo(u *url.URL) (string, string, bool) {
+	owner, repo, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return owner, strings.TrimSuffix(repo, ".git"), true
+}
+
+// findGiteaREADME tries to find the README of a repository's default branch
+// using the Gitea API, which Forgejo and Codeberg share.
+func findGiteaREADME(u *url.URL) (*source, error) {
+	owner, name, ok := giteaRepo(u)
+	if !ok {
+		return nil, fmt.Errorf("invalid url: %s", u.String())
+	}
+	apiURL := fmt.Sprintf("%s://%s/api/v1/repos/%s/%s", u.Scheme, u.Host, url.PathEscape(owner), url.PathEscape(name))
+
+	var repo struct {
+		HTMLURL       string `json:"html_url"`
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := getJSON(apiURL, &repo); err != nil {
+		return nil, err
+	}
+
+	var entries []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	ref := "?ref=" + url.QueryEscape(repo.DefaultBranch)
+	if err := getJSON(apiURL+"/contents"+ref, &entries); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.Type == "file" {
+			files = append(files, e.Name)
+		}
+	}
+
+	if file := readmeFile(files); file != "" {
+		//nolint:bodyclose
+		// it is closed on the caller
+		resp, err := httpGet(apiURL + "/raw/" + url.PathEscape(file) + ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get url: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			rawURL := repo.HTMLURL + "/raw/branch/" + repo.DefaultBranch + "/" + file
+			return &source{reader: resp.Body, URL: rawURL}, nil
+		}
+		_ = resp.Body.Close()
+	}
+
+	return nil, errors.New("can't find README in Gitea repository")
+}
+
+// readmeFile picks the README among the files of a directory: one of the
+// usual names, or else a README in another format Glow reads.
+func readmeFile(files []string) string {
+	for _, name := range readmeNames {
+		if slices.Contains(files, name) {
+			return name
+		}
+	}
+	for _, f := range files {
+		if strings.EqualFold(strings.TrimSuffix(f, path.Ext(f)), "readme") && utils.IsMarkdownFile(f) {
+			return f
+		}
+	}
+	return ""
+}
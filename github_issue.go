@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// githubAPI is the base URL of the GitHub API.
+var githubAPI = "https://api.github.com"
+
+// issuesPerPage is the number of comments fetched at a time.
+const issuesPerPage = 100
+
+// githubIssue returns the repository and number of the issue or pull request
+// a GitHub URL points to, like https://github.com/owner/repo/issues/123.
+func githubIssue(u *url.URL) (string, int, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || (parts[2] != "issues" && parts[2] != "pull") {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(parts[3])
+	if err != nil || n <= 0 {
+		return "", 0, false
+	}
+	return parts[0] + "/" + parts[1], n, true
+}
+
+type githubComment struct {
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// findGitHubIssue fetches an issue or pull request and its comments using
+// the GitHub API, as a markdown document with the comments in order under
+// headers with their author and date.
+func findGitHubIssue(repo string, number int) (*source, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/issues/%d", githubAPI, repo, number)
+
+	var issue struct {
+		githubComment
+		Title       string    `json:"title"`
+		State       string    `json:"state"`
+		HTMLURL     string    `json:"html_url"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+	if err := getJSON(apiURL, &issue); err != nil {
+		return nil, err
+	}
+
+	var comments []githubComment
+	for page := 1; ; page++ {
+		var batch []githubComment
+		if err := getJSON(fmt.Sprintf("%s/comments?per_page=%d&page=%d", apiURL, issuesPerPage, page), &batch); err != nil {
+			return nil, err
+		}
+		comments = append(comments, batch...)
+		if len(batch) < issuesPerPage {
+			break
+		}
+	}
+
+	kind := "issue"
+	if issue.PullRequest != nil {
+		kind = "pull request"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s #%d\n\n", utils.EscapeMarkdown(issue.Title), number)
+	fmt.Fprintf(&b, "*%s %s · opened by **@%s** on %s*\n\n", capitalize(issue.State), kind,
+		utils.EscapeMarkdown(issue.User.Login), issue.CreatedAt.Format(headerDateLayout))
+	writeCommentBody(&b, issue.Body)
+	for _, c := range comments {
+		fmt.Fprintf(&b, "---\n\n### @%s · %s\n\n", utils.EscapeMarkdown(c.User.Login), c.CreatedAt.Format(headerDateLayout))
+		writeCommentBody(&b, c.Body)
+	}
+	return &source{reader: io.NopCloser(strings.NewReader(b.String())), URL: issue.HTMLURL}, nil
+}
+
+func writeCommentBody(b *strings.Builder, body string) {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		body = "*No description provided.*"
+	}
+	b.WriteString(body + "\n\n")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// githubWikiRaw is the base URL of the raw pages of GitHub wikis.
+var githubWikiRaw = "https://raw.githubusercontent.com/wiki"
+
+// wikiHome is the page a wiki opens with.
+const wikiHome = "Home"
+
+// wikiPageExtensions are the formats of wiki pages we can show, in the order
+// they're looked for.
+var wikiPageExtensions = []string{".md", ".markdown", ".rst", ".org", ".asciidoc", ".adoc"}
+
+// githubWikiPage returns the repository and page of a GitHub wiki URL, like
+// https://github.com/owner/repo/wiki/Page-Name. The page is empty for the
+// wiki itself.
+func githubWikiPage(u *url.URL) (string, string, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 3 || len(parts) > 4 || parts[2] != "wiki" {
+		return "", "", false
+	}
+	page := ""
+	if len(parts) == 4 {
+		page = parts[3]
+	}
+	return parts[0] + "/" + parts[1], page, true
+}
+
+// githubWikiArg returns the repository and page of a source argument
+// pointing into a GitHub wiki.
+func githubWikiArg(arg string) (string, string, bool) {
+	if !strings.Contains(arg, "://") {
+		arg = protoHTTPS + arg
+	}
+	u, err := url.Parse(arg)
+	if err != nil || u.Hostname() != githubURL.Hostname() {
+		return "", "", false
+	}
+	return githubWikiPage(u)
+}
+
+// findGitHubWikiPage fetches a page of a GitHub wiki, in whichever format it
+// was written.
+func findGitHubWikiPage(repo, page string) (*source, error) {
+	if page == "" {
+		page = wikiHome
+	}
+	for _, ext := range wikiPageExtensions {
+		rawURL := fmt.Sprintf("%s/%s/%s%s", githubWikiRaw, repo, url.PathEscape(page), ext)
+
+		//nolint:bodyclose
+		// it is closed on the caller
+		resp, err := httpGet(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get url: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return &source{reader: resp.Body, URL: rawURL}, nil
+		}
+		_ = resp.Body.Close()
+	}
+	return nil, fmt.Errorf("can't find page %s in the wiki of %s", page, repo)
+}
+
+// cloneGitHubWiki clones the wiki of a GitHub repository into a temporary
+// directory, so its pages can be browsed and linked between in the TUI. It
+// returns the directory, which the caller removes, and the file of the page.
+func cloneGitHubWiki(repo, page string) (string, string, error) {
+	dir, err := os.MkdirTemp("", "glow-wiki-")
+	if err != nil {
+		return "", "", fmt.Errorf("unable to create temporary directory: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command("git", "clone", "--quiet", "--depth", "1", githubURL.JoinPath(repo+".wiki.git").String(), dir) //nolint:gosec
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		_ = os.RemoveAll(dir)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", "", fmt.Errorf("unable to clone wiki of %s: %w: %s", repo, err, msg)
+		}
+		return "", "", fmt.Errorf("unable to clone wiki of %s: %w", repo, err)
+	}
+
+	if page == "" {
+		page = wikiHome
+	}
+	if path := wikiPageFile(dir, page); path != "" {
+		return dir, path, nil
+	}
+	_ = os.RemoveAll(dir)
+	return "", "", fmt.Errorf("can't find page %s in the wiki of %s", page, repo)
+}
+
+// wikiPageFile returns the file of a page in a cloned wiki. GitHub names
+// files after their pages, with dashes for spaces.
+func wikiPageFile(dir, page string) string {
+	page, _ = url.PathUnescape(page)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		name := e.Name()
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		if !e.IsDir() && utils.IsMarkdownFile(name) && filepath.Ext(name) != "" &&
+			strings.EqualFold(strings.ReplaceAll(base, " ", "-"), strings.ReplaceAll(page, " ", "-")) {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// runWikiTUI browses a GitHub wiki in the TUI, starting at the given page,
+// with wiki links between its pages resolved.
+func runWikiTUI(repo, page string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("browsing wikis requires git")
+	}
+	dir, path, err := cloneGitHubWiki(repo, page)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	wikiLinks = true
+	return runTUI(path, "", "")
+}
@@ -7,54 +7,92 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 )
 
-// findGitLabREADME tries to find the correct README filename in a repository using GitLab API.
+// gitlabHosts are the hosts of self-hosted GitLab instances, from the config.
+// Hosts named like gitlab.example.com are recognized without it.
+var gitlabHosts []string
+
+// isGitLabHost returns whether a host serves GitLab.
+func isGitLabHost(host string) bool {
+	return host == gitlabURL.Hostname() ||
+		strings.HasPrefix(host, "gitlab.") ||
+		slices.Contains(gitlabHosts, host)
+}
+
+// gitlabProject returns the path of the project a GitLab URL points to,
+// including its groups and subgroups, like "group/subgroup/project". Links
+// into projects, like to files or issues, aren't projects.
+func gitlabProject(u *url.URL) (string, bool) {
+	p := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if !strings.Contains(p, "/") || strings.Contains(p, "/-/") {
+		return "", false
+	}
+	return p, true
+}
+
+// gitlabProjectAPI returns the API URL of a project on the GitLab instance a
+// URL points to.
+func gitlabProjectAPI(u *url.URL, project string) string {
+	return fmt.Sprintf("%s://%s/api/v4/projects/%s", u.Scheme, u.Host, url.PathEscape(project))
+}
+
+// findGitLabREADME tries to find the README of a project's default branch
+// using the GitLab API.
 func findGitLabREADME(u *url.URL) (*source, error) {
-	owner, repo, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	projectPath, ok := gitlabProject(u)
 	if !ok {
 		return nil, fmt.Errorf("invalid url: %s", u.String())
 	}
 
-	projectPath := url.QueryEscape(owner + "/" + repo)
-
-	type readme struct {
-		ReadmeURL string `json:"readme_url"`
+	type project struct {
+		WebURL        string `json:"web_url"`
+		DefaultBranch string `json:"default_branch"`
+		ReadmeURL     string `json:"readme_url"`
 	}
 
-	apiURL := fmt.Sprintf("https://%s/api/v4/projects/%s", u.Hostname(), projectPath)
+	apiURL := gitlabProjectAPI(u, projectPath)
 
-	//nolint:bodyclose
-	// it is closed on the caller
-	res, err := http.Get(apiURL) //nolint: gosec,noctx
+	res, err := httpGet(apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get url: %w", err)
 	}
+	defer res.Body.Close() //nolint:errcheck
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read http response body: %w", err)
 	}
 
-	var result readme
+	var result project
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("unable to parse json: %w", err)
 	}
 
-	readmeRawURL := strings.ReplaceAll(result.ReadmeURL, "blob", "raw")
+	if res.StatusCode == http.StatusOK && result.ReadmeURL != "" {
+		// The README is fetched through the API, which private projects
+		// can be read from too, and shown with the URL of the raw file.
+		blob := result.WebURL + "/-/blob/" + result.DefaultBranch + "/"
+		file, ok := strings.CutPrefix(result.ReadmeURL, blob)
+		if !ok {
+			return nil, fmt.Errorf("unexpected README url: %s", result.ReadmeURL)
+		}
+		rawURL := result.WebURL + "/-/raw/" + result.DefaultBranch + "/" + file
+		fileURL := fmt.Sprintf("%s/repository/files/%s/raw?ref=%s", apiURL, url.PathEscape(file), url.QueryEscape(result.DefaultBranch))
 
-	if res.StatusCode == http.StatusOK {
 		//nolint:bodyclose
 		// it is closed on the caller
-		resp, err := http.Get(readmeRawURL) //nolint: gosec,noctx
+		resp, err := httpGet(fileURL)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get url: %w", err)
 		}
 
 		if resp.StatusCode == http.StatusOK {
-			return &source{resp.Body, readmeRawURL}, nil
+			return &source{reader: resp.Body, URL: rawURL}, nil
 		}
+		_ = resp.Body.Close()
 	}
 
 	return nil, errors.New("can't find README in GitLab repository")
@@ -1,10 +1,51 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+func TestMain(m *testing.M) {
+	// Keep documents fetched by the tests out of the user's cache, and the
+	// certificates they trust out of the user's data.
+	dir, err := os.MkdirTemp("", "glow-cache-")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv("XDG_CACHE_HOME", dir)
+	_ = os.Setenv("XDG_DATA_HOME", dir)
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestGlowFlags(t *testing.T) {
+	defer func() { sourcesFile, fromClipboard, gitRef, readability = "", false, "", false }()
 	tt := []struct {
 		args  []string
 		check func() bool
@@ -21,6 +62,12 @@ func TestGlowFlags(t *testing.T) {
 				return style == "light"
 			},
 		},
+		{
+			args: []string{"--wrap=0"},
+			check: func() bool {
+				return width == 0
+			},
+		},
 		{
 			args: []string{"-w", "40"},
 			check: func() bool {
@@ -33,6 +80,30 @@ func TestGlowFlags(t *testing.T) {
 				return stream
 			},
 		},
+		{
+			args: []string{"--sources", "reading.txt"},
+			check: func() bool {
+				return sourcesFile == "reading.txt"
+			},
+		},
+		{
+			args: []string{"--from-clipboard"},
+			check: func() bool {
+				return fromClipboard
+			},
+		},
+		{
+			args: []string{"--ref", "HEAD~3"},
+			check: func() bool {
+				return gitRef == "HEAD~3"
+			},
+		},
+		{
+			args: []string{"--readability"},
+			check: func() bool {
+				return readability
+			},
+		},
 	}
 
 	for _, v := range tt {
@@ -45,3 +116,1414 @@ func TestGlowFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestFlagEnv(t *testing.T) {
+	var (
+		width     int
+		pager     bool
+		format    string
+		sets      []string
+		subFormat string
+	)
+	root := &cobra.Command{Use: "glow"}
+	root.PersistentFlags().IntVarP(&width, "width", "w", 0, "")
+	root.Flags().BoolVarP(&pager, "pager", "p", false, "")
+	root.Flags().StringVar(&format, "format", "", "")
+	root.PersistentFlags().StringArrayVar(&sets, "style-set", nil, "")
+	sub := &cobra.Command{Use: "export"}
+	sub.Flags().StringVar(&subFormat, "format", "", "")
+	root.AddCommand(sub)
+
+	t.Setenv("GLOW_WIDTH", "60")
+	t.Setenv("GLOW_PAGER", "true")
+	t.Setenv("GLOW_FORMAT", "json")
+	t.Setenv("GLOW_STYLE_SET", "h1.color=212")
+	t.Setenv("GLOW_EXPORT_FORMAT", "html")
+	if err := root.ParseFlags([]string{"-w", "40"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyFlagEnv(root); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if width != 40 || !pager || format != "json" || !slices.Equal(sets, []string{"h1.color=212"}) {
+		t.Errorf("expected flags from the command line and environment, got %d, %v, %q, %q", width, pager, format, sets)
+	}
+	if err := applyFlagEnv(sub); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if subFormat != "html" {
+		t.Errorf("expected the subcommand's flag from GLOW_EXPORT_FORMAT, got %q", subFormat)
+	}
+
+	t.Setenv("GLOW_PAGER", "maybe")
+	pager = false
+	root.Flags().Lookup("pager").Changed = false
+	if err := applyFlagEnv(root); err == nil || !strings.Contains(err.Error(), "GLOW_PAGER") {
+		t.Errorf("expected an error naming GLOW_PAGER, got %v", err)
+	}
+}
+
+func TestDebugLog(t *testing.T) {
+	defer func(d bool, f string) {
+		debug, logFile = d, f
+		_ = closeLog()
+		log.SetOutput(io.Discard)
+		log.SetLevel(log.WarnLevel)
+	}(debug, logFile)
+	debug, logFile = true, filepath.Join(t.TempDir(), "debug.log")
+	if err := configureLog(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("# Hi\n"))
+	}))
+	defer srv.Close()
+	res, err := withRequestLog(http.DefaultClient).Get(srv.URL + "/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if _, err := findFiles(t.TempDir(), []string{"*.md"}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"starting", "fetched", "README.md", "status=200", "finding files"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("expected the log to contain %q, got\n%s", want, b)
+		}
+	}
+}
+
+func TestProfile(t *testing.T) {
+	defer func(p bool) {
+		profile = p
+		phaseTimes = map[string]time.Duration{}
+	}(profile)
+	profile = true
+	phaseTimes = map[string]time.Duration{}
+
+	for range 2 {
+		stop := timePhase("render")
+		time.Sleep(5 * time.Millisecond)
+		stop()
+	}
+	if d := phaseTimes["render"]; d < 10*time.Millisecond {
+		t.Errorf("expected the render phase to add up to at least 10ms, got %s", d)
+	}
+
+	phaseTimes["render"] = 30 * time.Millisecond
+	phaseTimes["fetch"] = 10 * time.Millisecond
+	var b bytes.Buffer
+	if err := writeProfile(&b, 100*time.Millisecond); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{"discovery  0s     0.0%", "fetch      10ms   10.0%", "render     30ms   30.0%", "other      60ms   60.0%", "total      100ms  100.0%"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected the report to contain %q, got\n%s", want, b.String())
+		}
+	}
+}
+
+func TestTUIConfigLocale(t *testing.T) {
+	defer func() {
+		viper.Set("locale", "")
+		viper.Set("dateFormat", "")
+	}()
+	viper.Set("locale", "de_DE")
+	viper.Set("dateFormat", "2006-01-02")
+	cfg, err := tuiConfig("", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Locale != "de_DE" || cfg.DateFormat != "2006-01-02" {
+		t.Errorf("expected the locale and date format of the config, got %q and %q", cfg.Locale, cfg.DateFormat)
+	}
+}
+
+func TestAccessibleOverridesStyle(t *testing.T) {
+	defer func(s string, a bool) { style, accessible = s, a }(style, accessible)
+
+	for _, tc := range []struct {
+		accessible, styleFlag, isTerminal bool
+		want                              string
+	}{
+		{false, true, true, "dracula"},
+		{false, false, false, "notty"},
+		{false, true, false, "dracula"},
+		{true, true, true, utils.HighContrastStyle},
+		{true, false, false, utils.HighContrastStyle},
+	} {
+		accessible = tc.accessible
+		if got := documentStyle("dracula", tc.styleFlag, tc.isTerminal, termenv.Ascii); got != tc.want {
+			t.Errorf("%+v: expected the %s style, got %q", tc, tc.want, got)
+		}
+	}
+
+	accessible, style = true, "dracula"
+	cfg, err := tuiConfig("", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cfg.Accessible || cfg.GlamourStyle != utils.HighContrastStyle || cfg.HighPerformancePager {
+		t.Errorf("expected the TUI in accessibility mode, got %+v", cfg)
+	}
+}
+
+func TestDirs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GLOW_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("GLOW_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("GLOW_STATE_HOME", "")
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+
+	for name, tc := range map[string]struct {
+		dir  func() (string, error)
+		want string
+	}{
+		"remote cache":      {remoteCacheDir, filepath.Join(dir, "cache", "remote")},
+		"fence cache":       {fenceCacheDir, filepath.Join(dir, "cache", "fences")},
+		"gemini known host": {geminiKnownHostsPath, filepath.Join(dir, "data", geminiKnownHostsFile)},
+		"log":               {getLogFilePath, filepath.Join(dir, "state", "glow", "glow.log")},
+	} {
+		got, err := tc.dir()
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %s, got %s", name, tc.want, got)
+		}
+	}
+
+	defer func(v string) { cacheHome = v }(cacheHome)
+	cacheHome = filepath.Join(dir, "flag")
+	if got, _ := imageCachePath("https://example.com/a.png"); !strings.HasPrefix(got, filepath.Join(cacheHome, "images")) {
+		t.Errorf("expected images cached in --cache-home, got %s", got)
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	exts, directive := completeDocuments(rootCmd, nil, "")
+	if directive != cobra.ShellCompDirectiveFilterFileExt || !slices.Contains(exts, "md") || !slices.Contains(exts, "rst") {
+		t.Errorf("expected the extensions of documents, got %v, %v", exts, directive)
+	}
+
+	names, _ := completeStyles(rootCmd, nil, "")
+	if len(names) == 0 || names[0] != "auto" || !slices.Contains(names, "dracula") || slices.Contains(names, "notty") {
+		t.Errorf("expected the built-in styles, got %v", names)
+	}
+	if exts, directive := completeStyles(rootCmd, nil, "./"); directive != cobra.ShellCompDirectiveFilterFileExt || !slices.Equal(exts, []string{"json"}) {
+		t.Errorf("expected JSON files for a path, got %v, %v", exts, directive)
+	}
+
+	path := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: x\n---\n# Intro\n\n## Install It\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sections, _ := completeSections(rootCmd, []string{"missing.md", path}, "")
+	if want := []string{"intro\tIntro", "install-it\tInstall It"}; !slices.Equal(sections, want) {
+		t.Errorf("expected %q, got %q", want, sections)
+	}
+}
+
+func TestHelpMarkdown(t *testing.T) {
+	md := helpMarkdown(tocCmd)
+	for _, want := range []string{
+		"# glow toc\n\nPrint the heading tree of a markdown document as indented text, a markdown list or JSON.\n",
+		"## Usage\n\n```sh\nglow toc [SOURCE] [flags]\n```\n",
+		"## Examples\n\n```sh\nglow toc README.md\nglow toc --format markdown --anchors README.md\n",
+		"| `-f, --format string` | output format: text, markdown or json \\(default \"text\"\\) |\n",
+		"| `--depth int` | heading levels to include below the top-most one \\(0 for all\\) |\n",
+		"## Global Flags\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected the help to contain %q, got %q", want, md)
+		}
+	}
+	if md := helpMarkdown(cacheCmd); !strings.Contains(md, "| `clear` | Remove all cached remote documents |\n") || strings.Contains(md, "## Usage") {
+		t.Errorf("expected the subcommands and no usage of a command that doesn't run, got %q", md)
+	}
+}
+
+func TestManPages(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeManPages(dir, rootCmd); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, name := range []string{"glow.1", "glow-toc.1", "glow-cache-list.1"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected the man page %s, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "glow-man.1")); err == nil {
+		t.Error("expected no man page for the hidden man command")
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "glow-toc.1"))
+	if err != nil || !strings.HasPrefix(string(b), ".TH GLOW-TOC 1") || !strings.Contains(string(b), "--depth") {
+		t.Errorf("expected the man page of glow toc with its flags, got %v: %s", err, b)
+	}
+}
+
+func TestReadSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reading.txt")
+	list := "# Reading list\n\nREADME.md\n  https://example.com/post  \n\ngithub.com/charmbracelet/glow\n"
+	if err := os.WriteFile(path, []byte(list), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := readSources(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"README.md", "https://example.com/post", "github.com/charmbracelet/glow"}; !slices.Equal(sources, want) {
+		t.Errorf("expected sources %v, got %v", want, sources)
+	}
+
+	if err := os.WriteFile(path, []byte("# nothing yet\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSources(path); err == nil {
+		t.Error("expected an error for a list without sources")
+	}
+}
+
+func TestGitObjectSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		c := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Glow", "-c", "user.email=glow@example.com"}, args...)...)
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	path := filepath.Join(dir, "docs", "design.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "--quiet")
+	for _, content := range []string{"# Draft\n", "# Design\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git("add", ".")
+		git("commit", "--quiet", "-m", content)
+	}
+
+	src, err := sourceFromArg("git:HEAD~1:" + path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b, _ := io.ReadAll(src.reader); string(b) != "# Draft\n" {
+		t.Errorf("expected the file at the ref, got %q", b)
+	}
+	if src.URL != path {
+		t.Errorf("expected the URL %s, got %s", path, src.URL)
+	}
+	if _, err := sourceFromArg("git:HEAD~5:" + path); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+
+	args, err := gitObjectArgs("v1.0", []string{"README.md", "git:HEAD:docs/a.md"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := []string{"git:v1.0:README.md", "git:HEAD:docs/a.md"}; !slices.Equal(args, want) {
+		t.Errorf("expected %q, got %q", want, args)
+	}
+
+	// Refs that git would take for options aren't passed to it.
+	if _, _, ok := gitObjectArg("git:--output=" + path + ":a.md"); ok {
+		t.Error("expected a ref starting with a dash to be rejected")
+	}
+	if _, err := gitObjectArgs("--output="+path, []string{"a.md"}); err == nil {
+		t.Error("expected --ref starting with a dash to be rejected")
+	}
+	if src, err := gitObjectSource("--format=INJECTED%n", path); err == nil {
+		b, _ := io.ReadAll(src.reader)
+		t.Errorf("expected an error for a ref starting with a dash, got %q", b)
+	}
+}
+
+func TestProjectConfig(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := "style: styles/project.json\nwidth: 100\nexclude: [drafts, docs/old]\nconverters:\n  rst: evil\n" +
+		"linkHandlers:\n  zoommtg: zoom\n"
+	for path, content := range map[string]string{
+		projectConfigName:    config,
+		"docs/guide.md":      "# Guide\n",
+		"docs/old/v1.md":     "# Old\n",
+		"drafts/idea.md":     "# Idea\n",
+		"docs/api/README.md": "# API\n",
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := findProjectConfig(filepath.Join(dir, "docs", "api"))
+	if want := filepath.Join(dir, projectConfigName); path != want {
+		t.Fatalf("expected the project config %s, got %q", want, path)
+	}
+	settings, ignored, err := readProjectConfig(path, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := []string{"converters", "linkHandlers"}; !slices.Equal(ignored, want) {
+		t.Errorf("expected %v to be ignored, got %v", want, ignored)
+	}
+	want := map[string]any{
+		"style":   filepath.Join(dir, "styles", "project.json"),
+		"width":   100,
+		"exclude": []any{"drafts", filepath.Join(dir, "docs", "old")},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("expected %v, got %v", want, settings)
+	}
+
+	// Trusted projects can set link handlers, but still not converters.
+	for _, tc := range []struct {
+		trusted []string
+		want    bool
+	}{
+		{nil, false},
+		{[]string{dir}, true},
+		{[]string{filepath.Dir(dir)}, true},
+		{[]string{filepath.Join(dir, "docs")}, false},
+		{[]string{dir + "-other"}, false},
+	} {
+		if got := trustedProject(path, tc.trusted); got != tc.want {
+			t.Errorf("trustedProject(%v): expected %v, got %v", tc.trusted, tc.want, got)
+		}
+	}
+	settings, ignored, err = readProjectConfig(path, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := []string{"converters"}; !slices.Equal(ignored, want) {
+		t.Errorf("expected %v to be ignored, got %v", want, ignored)
+	}
+	if _, ok := settings["linkHandlers"]; !ok {
+		t.Errorf("expected a trusted project to set linkHandlers, got %v", settings)
+	}
+
+	defer func(v []string) { excludePatterns = v }(excludePatterns)
+	excludePatterns = []string{"drafts", filepath.Join(dir, "docs", "old")}
+	files, err := findFiles(dir, utils.DocumentPatterns(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "docs", "api", "README.md"), filepath.Join(dir, "docs", "guide.md")}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestRenderHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks are Unix commands")
+	}
+	defer func() {
+		preRenderHooks, postRenderHooks, hookTimeout = nil, nil, defaultHookTimeout
+	}()
+	path := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(path, []byte("# ACME\n\nWelcome to ACME.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(v bool) { pager = v }(pager)
+	pager = false
+	render := func() (string, error) {
+		var buf bytes.Buffer
+		err := executeArg(&cobra.Command{}, path, &buf)
+		return ansi.Strip(buf.String()), err
+	}
+
+	preRenderHooks = []string{"sed s/ACME/Acme/g", "sed s/Welcome/Hello/"}
+	postRenderHooks = []string{"tr a-z A-Z"}
+	out, err := render()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out, "HELLO TO ACME.") {
+		t.Errorf("expected the output of the hooks, got %q", out)
+	}
+
+	preRenderHooks, postRenderHooks = []string{"ls /nonexistent-glow-hook"}, nil
+	if _, err := render(); err == nil || !strings.Contains(err.Error(), "pre-render hook ls failed") {
+		t.Errorf("expected the hook to fail, got %v", err)
+	}
+
+	preRenderHooks, hookTimeout = []string{"sleep 5"}, 100*time.Millisecond
+	if _, err := render(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected the hook to time out, got %v", err)
+	}
+}
+
+// redactSecrets makes the tests of a command redact secrets like sk-abc123.
+func redactSecrets(t *testing.T) {
+	t.Helper()
+	if err := utils.SetRedactions([]string{`sk-[a-z0-9]+`}, "***"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = utils.SetRedactions(nil, utils.DefaultRedactReplacement) })
+}
+
+func TestOutputFilters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the filters are Unix commands")
+	}
+	defer func() {
+		outputFilters = nil
+		_ = utils.SetRedactions(nil, utils.DefaultRedactReplacement)
+	}()
+	if err := utils.SetRedactions([]string{`(`}, "***"); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if err := utils.SetRedactions([]string{`sk-[a-z0-9]+`, `\d{3}-\d{4}`}, "***"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Matches split by styles are found, keeping the styles.
+	styled := "key \x1b[1msk-ab\x1b[0m\x1b[2mc1\x1b[0m, call 555-1234.\n"
+	want := "key \x1b[1m***\x1b[0m\x1b[2m\x1b[0m, call ***.\n"
+	if got := string(utils.Redact([]byte(styled))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	outputFilters = []string{"sed s/$/-filtered/"}
+	out, err := filterOutput([]byte("token sk-abc\n"), "html")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if string(out) != "token ***-filtered\n" {
+		t.Errorf("expected the output redacted and filtered, got %q", out)
+	}
+	outputFilters = []string{"env"}
+	if out, _ := filterOutput(nil, "html"); !strings.Contains(string(out), "GLOW_FORMAT=html\n") {
+		t.Errorf("expected filters to be told the format, got %q", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(path, []byte("# Keys\n\nUse sk-live42 to deploy.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outputFilters = nil
+	defer func(v bool) { pager = v }(pager)
+	pager = false
+	var buf bytes.Buffer
+	if err := executeArg(&cobra.Command{}, path, &buf); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := ansi.Strip(buf.String()); strings.Contains(got, "sk-live42") || !strings.Contains(got, "Use *** to deploy.") {
+		t.Errorf("expected the key to be redacted, got %q", got)
+	}
+}
+
+func TestParseOSC52Response(t *testing.T) {
+	for answer, want := range map[string]string{
+		"\x1b]52;c;IyBIZWxsbw==\a":     "# Hello",
+		"\x1b]52;c;IyBIZWxsbw==\x1b\\": "# Hello",
+		"\x1b]52;c;\a":                 "",
+	} {
+		got, err := parseOSC52Response([]byte(answer))
+		if err != nil || got != want {
+			t.Errorf("expected %q to be %q, got %q, %v", answer, want, got, err)
+		}
+	}
+	if _, err := parseOSC52Response([]byte("\x1b[?1;2c")); err == nil {
+		t.Error("expected an error for an answer to another query")
+	}
+}
+
+func TestReadContentSection(t *testing.T) {
+	defer func() { section = "" }()
+	md := "# Project\n\nIntro\n\n## Install\n\nRun it.\n\n### Docker\n\nUse docker.\n\n## Usage\n\nUse it.\n"
+
+	for query, want := range map[string]string{
+		"install":         "## Install\n\nRun it.\n\n### Docker\n\nUse docker.\n\n",
+		"Install/Docker":  "### Docker\n\nUse docker.\n\n",
+		"project/usage":   "## Usage\n\nUse it.\n",
+		"Project/Missing": "",
+	} {
+		section = query
+		got, err := readContent(&source{reader: io.NopCloser(strings.NewReader(md)), URL: "README.md"})
+		if want == "" {
+			if !errors.Is(err, errSectionNotFound) {
+				t.Errorf("expected section %q not to be found, got %v", query, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != want {
+			t.Errorf("expected section %q to be %q, got %q", query, want, got)
+		}
+	}
+}
+
+func TestTOCMarkdown(t *testing.T) {
+	md := []byte("# Project\n\n## Install\n\n### Docker\n\n## Usage\n")
+	want := "**Contents**\n\n- Project\n  - Install\n  - Usage\n\n"
+	if got := tocMarkdown(utils.TableOfContents(md, 2)); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := tocMarkdown(nil); got != "" {
+		t.Errorf("expected no table of contents without headings, got %q", got)
+	}
+}
+
+func TestStyleOverrides(t *testing.T) {
+	defer func() { utils.StyleOverrides = nil }()
+	utils.StyleOverrides = map[string]any{
+		"h1":         map[string]any{"color": 212},
+		"code_block": map[any]any{"theme": "monokai"},
+	}
+
+	cfg, err := utils.GlamourStyleConfig("dark")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.H1.Color == nil || *cfg.H1.Color != "212" {
+		t.Errorf("expected h1 color to be overridden, got %v", cfg.H1.Color)
+	}
+	if cfg.H1.BackgroundColor == nil {
+		t.Error("expected h1 to keep the background color of the base style")
+	}
+	if cfg.CodeBlock.Theme != "monokai" {
+		t.Errorf("expected code block theme to be overridden, got %q", cfg.CodeBlock.Theme)
+	}
+}
+
+func TestSetStyleOverride(t *testing.T) {
+	overrides := map[string]any{"h1": map[string]any{"bold": true}}
+	for _, set := range []string{"h1.color=212", "code_block.theme=dracula", "code_block.margin=0", "h2.prefix=## "} {
+		if err := setStyleOverride(overrides, set); err != nil {
+			t.Fatalf("expected no error for %q, got %v", set, err)
+		}
+	}
+	want := map[string]any{
+		"h1":         map[string]any{"bold": true, "color": float64(212)},
+		"h2":         map[string]any{"prefix": "## "},
+		"code_block": map[string]any{"theme": "dracula", "margin": float64(0)},
+	}
+	if !reflect.DeepEqual(overrides, want) {
+		t.Errorf("expected %v, got %v", want, overrides)
+	}
+
+	for _, set := range []string{"h1", "color=212", "h1.=212"} {
+		if err := setStyleOverride(overrides, set); err == nil {
+			t.Errorf("expected an error for %q", set)
+		}
+	}
+}
+
+func TestCodeLineNumbers(t *testing.T) {
+	defer func(s string, w uint) { style, width, utils.CodeLineNumbers = s, w, false }(style, width)
+	style, width, utils.CodeLineNumbers = "notty", 40, true
+
+	md := "```go\n" +
+		"func main() { fmt.Println(\"a very long line of code that goes on and on\") }\n" +
+		"x := 1\n```\n"
+	out, err := renderContent(md, "test.md")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var code []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			code = append(code, strings.TrimRight(line, " "))
+		}
+		if len(line) > 40 {
+			t.Errorf("expected lines to fit the width, got %q", line)
+		}
+	}
+	if len(code) < 3 || !strings.Contains(code[0], "1  func main()") {
+		t.Fatalf("expected numbered code, got %q", out)
+	}
+	indent := strings.Index(code[0], "func")
+	for _, line := range code[1 : len(code)-1] {
+		if strings.TrimSpace(line[:indent]) != "" {
+			t.Errorf("expected an empty gutter on wrapped lines, got %q", line)
+		}
+	}
+	if last := code[len(code)-1]; !strings.Contains(last, "2  x := 1") {
+		t.Errorf("expected second line to be numbered 2, got %q", last)
+	}
+}
+
+func TestCodeTheme(t *testing.T) {
+	defer func() { utils.CodeTheme = "" }()
+	utils.CodeTheme = "monokai"
+
+	cfg, err := utils.GlamourStyleConfig("dark")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.CodeBlock.Theme != "monokai" || cfg.CodeBlock.Chroma != nil {
+		t.Errorf("expected code blocks to use the monokai theme, got %q", cfg.CodeBlock.Theme)
+	}
+
+	if err := validateCodeTheme("monokai"); err != nil {
+		t.Errorf("expected monokai to be valid, got %v", err)
+	}
+	if err := validateCodeTheme("nope"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for code, want := range map[string]string{
+		"#!/bin/sh\necho hi\n":                     "bash",
+		"#!/usr/bin/env python3\nprint(1)\n":       "python",
+		"package main\n\nfunc main() {}\n":         "go",
+		"def greet(name):\n    return name\n":      "python",
+		"#include <stdio.h>\n":                     "c",
+		`{"name": "glow", "stars": 1}`:             "json",
+		"name: glow\nversion: 2\n":                 "yaml",
+		"Just some prose,\nnothing to see here.\n": "",
+	} {
+		if got := utils.DetectLanguage(code); got != want {
+			t.Errorf("expected %q to be detected as %q, got %q", code, want, got)
+		}
+	}
+}
+
+func TestLabelCodeBlocks(t *testing.T) {
+	md := "```\n#!/bin/bash\necho hi\n```\n\n> ~~~\n> package main\n> ~~~\n\n```ruby\nputs 1\n```\n"
+	want := "```bash\n#!/bin/bash\necho hi\n```\n\n> ~~~go\n> package main\n> ~~~\n\n```ruby\nputs 1\n```\n"
+	if got := string(utils.LabelCodeBlocks([]byte(md))); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	out, ok := utils.RenderDOT("digraph { a -> b; a -> c }")
+	want := "" +
+		"    ┌───┐\n" +
+		"    │ a │\n" +
+		"    └─┬─┘\n" +
+		"  ┌───┤\n" +
+		"  │   └───┐\n" +
+		"  ▼       ▼\n" +
+		"┌───┐   ┌───┐\n" +
+		"│ b │   │ c │\n" +
+		"└───┘   └───┘\n"
+	if !ok || out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+
+	for _, src := range []string{
+		"digraph { a -> b -> a }",
+		"digraph { subgraph cluster { a } }",
+		"not a graph",
+	} {
+		if _, ok := utils.RenderDOT(src); ok {
+			t.Errorf("expected %q to fall back to its source", src)
+		}
+	}
+}
+
+func TestRenderDiagrams(t *testing.T) {
+	md := "> ```dot\n> digraph { a [label=\"A\"] }\n> ```\n\n```dot\ndigraph { a -> b -> a }\n```\n"
+	want := "> ```text\n> ┌───┐\n> │ A │\n> └───┘\n> ```\n\n```dot\ndigraph { a -> b -> a }\n```\n"
+	if got := string(utils.RenderDiagrams([]byte(md))); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestPrepareDefinitionLists(t *testing.T) {
+	md := "Apple\n: A red\n  fruit.\n: A company.\n"
+	nbsp := strings.Repeat(" ", len(utils.DefinitionIndent))
+	want := "**Apple**\n: A red\\\n  " + nbsp + "fruit.\n: A company.\n"
+	if got := string(utils.PrepareDefinitionLists([]byte(md))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	defer func(s string, w uint) { style, width = s, w }(style, width)
+	style, width = "notty", 40
+	out, err := renderContent(md, "test.md")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// The notty style shows bold text between asterisks.
+	for _, line := range []string{"  **Apple**", "      A red", "      fruit.", "      A company."} {
+		if !strings.Contains(strings.ReplaceAll(out, " ", " "), line+" ") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestTasks(t *testing.T) {
+	md := []byte("- [ ] Buy *milk*\n- [x] Walk the dog\n  - [ ] Nested\n\n- not [ ] a task\n")
+	tasks := utils.Tasks(md)
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d: %+v", len(tasks), tasks)
+	}
+	if tasks[0].Text != "Buy milk" || tasks[0].Checked || tasks[1].Text != "Walk the dog" || !tasks[1].Checked {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+	if tasks[2].Line != 2 {
+		t.Errorf("expected the nested task on line 2, got %d", tasks[2].Line)
+	}
+
+	want := "- [x] Buy *milk*\n- [x] Walk the dog\n  - [ ] Nested\n\n- not [ ] a task\n"
+	if got := string(utils.ToggleTask(md, tasks[0])); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	want = "- [ ] Buy *milk*\n- [ ] Walk the dog\n  - [ ] Nested\n\n- not [ ] a task\n"
+	if got := string(utils.ToggleTask(md, tasks[1])); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestResolveWikiLinks(t *testing.T) {
+	dir := "vault"
+	pages := &utils.WikiPages{}
+	pages.Add(filepath.Join(dir, "shopping-list.md"), "Groceries")
+	pages.Add(filepath.Join(dir, "notes", "ideas.md"), "")
+	pages.Add(filepath.Join(dir, "archive", "notes", "ideas.md"), "")
+
+	md := "[[Shopping List]], [[groceries|food]], [[Ideas#Next Steps]], [[archive/notes/ideas]], " +
+		"[[Buy things|Shopping List]], [[Missing]], ![[Shopping List]] and `[[Shopping List]]`\n"
+	want := "[Shopping List](<shopping-list.md>), [food](<shopping-list.md>), " +
+		"[Ideas > Next Steps](<notes/ideas.md#next-steps>), [archive/notes/ideas](<archive/notes/ideas.md>), " +
+		"[Buy things](<shopping-list.md>), [[Missing]], ![[Shopping List]] and `[[Shopping List]]`\n"
+	if got := string(utils.ResolveWikiLinks([]byte(md), dir, pages)); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFrontmatter(t *testing.T) {
+	yml := "---\ntitle: Hello | World\ndate: 2024-01-02\ntags: [a, b]\nauthor:\n  name: Jo\n---\n\n# Doc\n"
+	tml := "+++\ntitle = \"Hello\"\ndate = 2024-01-02\n\n[author]\nname = \"Jo\"\n+++\n\n# Doc\n"
+	for _, md := range []string{yml, tml} {
+		if got := string(utils.RemoveFrontmatter([]byte(md))); got != "# Doc\n" {
+			t.Errorf("expected front matter to be removed, got %q", got)
+		}
+	}
+
+	fields, err := utils.ParseFrontmatter([]byte(tml))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := []utils.MetadataField{
+		{Key: "title", Value: "Hello"},
+		{Key: "date", Value: "2024-01-02"},
+		{Key: "author.name", Value: "Jo"},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected %v, got %v", want, fields)
+	}
+
+	table := "| Key | Value |\n|-----|-------|\n| title | Hello \\| World |\n| date | 2024-01-02 |\n" +
+		"| tags | a, b |\n| author.name | Jo |\n\n"
+	raw := "```yaml\ntitle: Hello | World\ndate: 2024-01-02\ntags: [a, b]\nauthor:\n  name: Jo\n```\n\n"
+	for mode, want := range map[string]string{frontmatterHide: "", frontmatterRaw: raw, frontmatterTable: table} {
+		if got := frontmatterMarkdown([]byte(yml), mode); got != want {
+			t.Errorf("expected %s front matter to be %q, got %q", mode, want, got)
+		}
+	}
+	if got := frontmatterMarkdown([]byte("# Doc\n"), frontmatterTable); got != "" {
+		t.Errorf("expected nothing without front matter, got %q", got)
+	}
+}
+
+func TestSmarten(t *testing.T) {
+	md := "He said \"hi\" -- and 'don't' --- ok...\n\n*\"emph\"* and `\"code\" -- x`\n\n```\n\"a\" -- b\n```\n"
+	want := "He said “hi” – and ‘don’t’ — ok…\n\n*“emph”* and `\"code\" -- x`\n\n```\n\"a\" -- b\n```\n"
+	if got := string(utils.Smarten([]byte(md))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	defer func(v bool) { utils.Smartypants = v }(utils.Smartypants)
+	utils.Smartypants = true
+	if utils.SmartypantsEnabled([]byte("---\nsmartypants: false\n---\n\nText\n")) {
+		t.Error("expected front matter to disable smartypants")
+	}
+	utils.Smartypants = false
+	if !utils.SmartypantsEnabled([]byte("---\nsmartypants: true\n---\n\nText\n")) {
+		t.Error("expected front matter to enable smartypants")
+	}
+	if utils.SmartypantsEnabled([]byte("Text\n")) {
+		t.Error("expected smartypants to be off by default")
+	}
+}
+
+func TestAliases(t *testing.T) {
+	aliases := map[string]string{}
+	for _, set := range []string{":company:=🏢 Acme", ":co:=Co", "WIP=Work in progress", "e.g.=for example"} {
+		if err := setAlias(aliases, set); err != nil {
+			t.Fatalf("expected no error for %q, got %v", set, err)
+		}
+	}
+	for _, set := range []string{"WIP", "=x", " =x"} {
+		if err := setAlias(map[string]string{}, set); err == nil {
+			t.Errorf("expected an error for %q", set)
+		}
+	}
+	defer utils.SetAliases(nil)
+	utils.SetAliases(aliases)
+
+	md := "# WIP at :company:\n\nWIP, WIPE and :co: e.g. here: `WIP` [:co:](https://example.com/WIP)\n\n```\nWIP\n```\n"
+	want := "# Work in progress at 🏢 Acme\n\nWork in progress, WIPE and Co for example here: `WIP` [Co](https://example.com/WIP)\n\n```\nWIP\n```\n"
+	if got := string(utils.ExpandAliases([]byte(md))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestLinkHandlers(t *testing.T) {
+	defer func() { utils.LinkHandlers = nil }()
+	utils.LinkHandlers = map[string]string{
+		"https":                 "firefox",
+		"zoommtg":               "zoom",
+		"*.pdf":                 "zathura",
+		"https://github.com/*":  "gh browse",
+		"file":                  "xdg-open",
+		"*.md":                  "glow",
+		"https://github.com/*/": "unused",
+	}
+	for link, want := range map[string]string{
+		"https://example.com/a":             "firefox",
+		"HTTPS://example.com/a":             "firefox",
+		"zoommtg://zoom.us/join?confno=1":   "zoom",
+		"https://example.com/paper.pdf?x=1": "zathura",
+		"https://github.com/charmbracelet":  "gh browse",
+		"docs/guide.md":                     "glow",
+		"/tmp/image.png":                    "xdg-open",
+		"mailto:hi@example.com":             "",
+	} {
+		if got := utils.LinkHandler(link); got != want {
+			t.Errorf("expected %s to be opened with %q, got %q", link, want, got)
+		}
+	}
+
+	defer func(o bool, l string) { openLinks, linkMode = o, l }(openLinks, linkMode)
+	openLinks, linkMode = true, linksNumbered
+	src := &source{reader: io.NopCloser(strings.NewReader("See [a](https://a.example), [b](b.md) and [a](https://a.example).\n")), URL: "/docs/x.md"}
+	if _, err := readContent(src); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://a.example", "/docs/b.md"}; !slices.Equal(src.links, want) {
+		t.Errorf("expected the numbered links %q, got %q", want, src.links)
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("needs touch as handler")
+	}
+	dir := t.TempDir()
+	utils.LinkHandlers = map[string]string{"file": "touch"}
+	links := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+	var out bytes.Buffer
+	if err := promptOpenLinks(links, strings.NewReader("2\n3\nx\n\n"), &out); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := os.Stat(links[1]); err != nil {
+		t.Errorf("expected the second link to be opened, got %v", err)
+	}
+	if _, err := os.Stat(links[0]); err == nil {
+		t.Error("expected the first link not to be opened")
+	}
+	if !strings.Contains(out.String(), "Open link [1-2]") || !strings.Contains(out.String(), "No link 3.") {
+		t.Errorf("expected prompts and an error for link 3, got %q", out.String())
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	defer viper.Set("commandAliases", nil)
+	viper.Set("commandAliases", map[string]string{
+		"notes":  `--all --width 100 "my notes"`,
+		"config": "--style dark",
+		"broken": `--style "dark`,
+	})
+
+	for args, want := range map[string][]string{
+		"glow notes -p":        {"glow", "--all", "--width", "100", "my notes", "-p"},
+		"glow Notes":           {"glow", "--all", "--width", "100", "my notes"},
+		"glow config":          {"glow", "config"},
+		"glow --width 80 note": {"glow", "--width", "80", "note"},
+		"glow README.md":       {"glow", "README.md"},
+	} {
+		got, err := expandAlias(strings.Fields(args))
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", args, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to expand to %q, got %q", args, want, got)
+		}
+	}
+	if _, err := expandAlias([]string{"glow", "broken"}); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+
+	for s, want := range map[string][]string{
+		`a  b`:              {"a", "b"},
+		`'a b' "c\"d" e\ f`: {"a b", `c"d`, "e f"},
+		`'a\b' ""`:          {`a\b`, ""},
+	} {
+		got, err := splitArgs(s)
+		if err != nil {
+			t.Fatalf("expected no error for %q, got %v", s, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to split into %q, got %q", s, want, got)
+		}
+	}
+}
+
+func TestHardLineBreaks(t *testing.T) {
+	md := "Roses are red \nviolets are blue `a\nb`\n\n> quoted\n> lines\n\nTerm\n: one\n  two\n"
+	want := "Roses are red \\\nviolets are blue `a\nb`\n\n> quoted\\\n> lines\n\nTerm\n: one\\\n  two\n"
+	got := utils.HardLineBreaks([]byte(md))
+	if string(got) != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// Definitions stay indented, as without hard breaks.
+	nbsp := strings.Repeat("\u00a0", len(utils.DefinitionIndent))
+	if got := string(utils.PrepareDefinitionLists(got)); !strings.HasSuffix(got, ": one\\\n  "+nbsp+"two\n") {
+		t.Errorf("expected the definition to stay indented, got %q", got)
+	}
+}
+
+func TestHyperlinks(t *testing.T) {
+	defer func(s string, w uint) { style, width, useHyperlinks = s, w, false }(style, width)
+	style, width, useHyperlinks = "notty", 80, true
+
+	md := "See [the docs](https://example.com/docs), [usage](other.md#usage) and https://charm.sh.\n"
+	out, err := renderContent(md, "/tmp/docs/test.md")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{
+		ansi.SetHyperlink("https://example.com/docs") + "the docs https://example.com/docs" + ansi.ResetHyperlink(),
+		ansi.SetHyperlink("file:///tmp/docs/other.md#usage") + "usage /tmp/docs/other.md#usage" + ansi.ResetHyperlink(),
+		ansi.SetHyperlink("https://charm.sh") + "https://charm.sh" + ansi.ResetHyperlink(),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+
+	if hyperlinksEnabled(hyperlinksAuto, false) || !hyperlinksEnabled(hyperlinksAlways, false) {
+		t.Error("expected hyperlinks only on terminals in auto mode")
+	}
+	if err := validateHyperlinksMode("sometimes"); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+}
+
+func TestNumberLinks(t *testing.T) {
+	md := "See [the *docs*](https://example.com/docs), [usage](other.md \"Usage (more)\"), " +
+		"[ref][r], [again](https://example.com/docs) and [top](#top).\n\n[r]: ./a_b.md\n"
+	want := "See the *docs*\\[1\\], usage\\[2\\], ref\\[3\\], again\\[1\\] and [top](#top).\n\n[r]: ./a_b.md" +
+		"\n\n## References\n\n1. https://example.com/docs\n2. /docs/other.md\n3. /docs/a\\_b.md\n"
+	if got := string(utils.NumberLinks([]byte(md), "/docs/")); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	md = "No links, just [top](#top).\n"
+	if got := string(utils.NumberLinks([]byte(md), "")); got != md {
+		t.Errorf("expected %q to be left alone, got %q", md, got)
+	}
+}
+
+func TestImages(t *testing.T) {
+	defer func(s string, w uint) { style, width, useImages = s, w, "" }(style, width)
+	style, width, useImages = "notty", 80, imagesKitty
+
+	dir := t.TempDir()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pic.png"), b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	md := "![a picture](pic.png)\n\n![missing](gone.png)\n"
+	out, err := renderContent(md, filepath.Join(dir, "test.md"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	lines := strings.Split(out, "\n")
+	var found bool
+	for i, line := range lines {
+		if strings.Contains(line, "pic.png") && i+1 < len(lines) {
+			found = strings.HasPrefix(strings.TrimSpace(lines[i+1]), "\x1b_Gf=100,q=2,c=4,a=T")
+		}
+	}
+	if !found {
+		t.Errorf("expected the image below its line, got %q", out)
+	}
+	if strings.Count(out, "\x1b_G") != 1 {
+		t.Errorf("expected missing images to be left as text, got %q", out)
+	}
+
+	if imageProtocol(imagesAuto, false) != "" || imageProtocol(imagesSixel, false) != imagesSixel {
+		t.Error("expected images only on terminals in auto mode")
+	}
+}
+
+func TestConvertToMarkdown(t *testing.T) {
+	for name, tc := range map[string]struct{ src, want string }{
+		"doc.rst": {
+			"Title\n=====\n\nSome *text* with ``code`` and a `link <https://example.com>`_::\n\n    $ run\n\n.. note:: Careful.\n",
+			"# Title\n\nSome *text* with `code` and a [link](https://example.com):\n\n```\n$ run\n```\n\n> **Note:** Careful.\n",
+		},
+		"doc.org": {
+			"#+TITLE: Doc\n* Section :tag:\nSome *bold* and =code=, see [[https://example.com][here]].\n#+BEGIN_SRC go\nx := 1\n#+END_SRC\n",
+			"# Doc\n\n## Section\n\nSome **bold** and `code`, see [here](https://example.com).\n\n```go\nx := 1\n```\n",
+		},
+		"doc.adoc": {
+			"= Doc\n:v: 2\n\n== Section\n\nVersion {v}, _italic_ and https://example.com[here].\n\n[source,go]\n----\nx := 1\n----\n\n|===\n|A |B\n\n|1 |2\n|===\n",
+			"# Doc\n\n## Section\n\nVersion 2, *italic* and [here](https://example.com).\n\n```go\nx := 1\n```\n\n| A | B |\n| --- | --- |\n| 1 | 2 |\n",
+		},
+		"doc.md": {"# *Kept* as-is\n", "# *Kept* as-is\n"},
+	} {
+		got, err := utils.ConvertToMarkdown([]byte(tc.src), name)
+		if err != nil {
+			t.Fatalf("expected no error for %s, got %v", name, err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("expected %s to convert to %q, got %q", name, tc.want, got)
+		}
+	}
+
+	if !utils.IsMarkdownFile("guide.RST") || !utils.IsMarkdownFile("notes.org") || !utils.IsMarkdownFile("book.adoc") {
+		t.Error("expected converted documents to be rendered as markdown")
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	page := `<html><head><title>Post | Blog</title></head><body>
+<nav><a href="/">Home</a></nav>
+<article><p>Some <strong>bold</strong> text, a <a href="/about">link</a> and <code>x := 1</code>.</p>
+<h2>Code</h2><pre><code class="language-go">fmt.Println("hi")
+</code></pre>
+<ul><li>One<ul><li>Two</li></ul></li></ul>
+<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table></article>
+<div class="comments"><p>A comment long enough to count as content.</p></div>
+</body></html>`
+	want := "# Post \\| Blog\n\nSome **bold** text, a [link](https://example.com/about) and `x := 1`.\n\n## Code\n\n" +
+		"```go\nfmt.Println(\"hi\")\n```\n\n- One\n  - Two\n\n| A | B |\n| --- | --- |\n| 1 | 2 |\n"
+	if got := string(utils.HTMLToMarkdown([]byte(page), "https://example.com/blog/post")); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestHTMLToMarkdownReadability(t *testing.T) {
+	defer func(v bool) { utils.Readability = v }(utils.Readability)
+	page := `<html><head><title>Post</title>
+<meta name="author" content="Jane Doe"><meta property="og:site_name" content="Blog">
+<meta property="article:published_time" content="2025-10-13T09:00:00Z"></head><body>
+<article><h1>Post</h1><p>The first paragraph of the post, long enough to be its content.</p>
+<div class="share"><a href="/a">Share</a> <a href="/b">Tweet</a></div>
+<p>The second paragraph, with <a href="/c">a link</a> in it.</p>
+<ul><li><a href="/d">Related post</a></li><li><a href="/e">Another post</a></li></ul></article>
+</body></html>`
+
+	utils.Readability = true
+	want := "# Post\n\n*By Jane Doe · Blog · Oct 13, 2025*\n\n" +
+		"The first paragraph of the post, long enough to be its content.\n\n" +
+		"The second paragraph, with [a link](https://example.com/c) in it.\n"
+	if got := string(utils.HTMLToMarkdown([]byte(page), "https://example.com/post")); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	utils.Readability = false
+	if got := string(utils.HTMLToMarkdown([]byte(page), "https://example.com/post")); !strings.Contains(got, "Related post") || strings.Contains(got, "Jane Doe") {
+		t.Errorf("expected the full article without a byline, got %q", got)
+	}
+}
+
+func TestFenceHandlers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the handlers are Unix commands")
+	}
+	defer func() {
+		utils.FenceHandlers, utils.FenceCacheDir, utils.FenceSandbox = nil, "", false
+	}()
+	cache := t.TempDir()
+	utils.FenceHandlers = map[string]string{"shout": "tr a-z A-Z", "broken": "false", "env": "env"}
+	utils.FenceCacheDir = cache
+
+	md := "> ```shout\n> hello\n> ```\n\n```broken\nsource\n```\n"
+	want := "> ```text\n> HELLO\n> ```\n\n```broken\nsource\n```\n"
+	if got := string(utils.PrepareMarkdown([]byte(md))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	entries, err := os.ReadDir(cache)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected the output of the handler to be cached, got %v, %v", entries, err)
+	}
+	if err := os.WriteFile(filepath.Join(cache, entries[0].Name()), []byte("CACHED\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(utils.PrepareMarkdown([]byte(md))); !strings.Contains(got, "> CACHED\n") {
+		t.Errorf("expected the cached output, got %q", got)
+	}
+
+	t.Setenv("GLOW_FENCE_SECRET", "hunter2")
+	utils.FenceCacheDir, utils.FenceSandbox = "", true
+	got := string(utils.PrepareMarkdown([]byte("```env\nx\n```\n")))
+	if strings.Contains(got, "hunter2") || !strings.Contains(got, "HOME=") {
+		t.Errorf("expected the handler to run with the sandbox's environment, got %q", got)
+	}
+}
+
+func TestEmailToMarkdown(t *testing.T) {
+	patch := "From: Jane Doe <jane@example.com>\r\nTo: list@example.com\r\n" +
+		"Subject: =?UTF-8?Q?[PATCH]_Fix_caf=C3=A9_typo?=\r\nDate: Mon, 13 Oct 2025 09:00:00 +0000\r\n\r\n" +
+		"Fixes the typo.\r\n\r\n---\r\n README.md | 2 +-\r\n\r\ndiff --git a/README.md b/README.md\r\n-cafe\r\n+caf\u00e9\r\n-- \r\n2.43.0\r\n"
+	want := "# \\[PATCH\\] Fix café typo\n\n| Header | Value |\n|--------|-------|\n" +
+		"| From | Jane Doe \\<jane@example.com\\> |\n| To | \\<list@example.com\\> |\n| Date | Mon, 13 Oct 2025 09:00:00 \\+0000 |\n\n" +
+		"Fixes the typo.\n\n\\---\n\n```diff\n README.md | 2 +-\n\ndiff --git a/README.md b/README.md\n-cafe\n+café\n-- \n2.43.0\n```\n\n"
+	if got := string(utils.EmailToMarkdown([]byte(patch))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	newsletter := "From: news@example.com\nSubject: News\nContent-Type: multipart/mixed; boundary=b1\n\n" +
+		"--b1\nContent-Type: multipart/alternative; boundary=b2\n\n" +
+		"--b2\nContent-Type: text/plain; charset=utf-8\n\nPlain news.\n" +
+		"--b2\nContent-Type: text/markdown; charset=utf-8\nContent-Transfer-Encoding: base64\n\nIyMgKk1hcmtkb3duKiBuZXdz\n" +
+		"--b2--\n--b1\nContent-Type: application/pdf\nContent-Disposition: attachment; filename=\"issue.pdf\"\n\nJVBERi0=\n--b1--\n"
+	got := string(utils.EmailToMarkdown([]byte(newsletter)))
+	for _, w := range []string{"# News\n", "## *Markdown* news\n", "**Attachments:** issue.pdf\n"} {
+		if !strings.Contains(got, w) {
+			t.Errorf("expected the newsletter to contain %q, got %q", w, got)
+		}
+	}
+	if strings.Contains(got, "Plain news") {
+		t.Errorf("expected the markdown part to be preferred, got %q", got)
+	}
+}
+
+func TestWebPageSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/notes.php" {
+			fmt.Fprint(w, "<html><body><h1>Notes</h1><p>Converted.</p></body></html>")
+			return
+		}
+		// markdown served as HTML is read as-is
+		fmt.Fprint(w, "# Raw\n\nMarkdown.\n")
+	}))
+	defer srv.Close()
+
+	for path, want := range map[string]string{
+		"/notes.php": "# Notes\n\nConverted.\n",
+		"/raw":       "# Raw\n\nMarkdown.\n",
+	} {
+		src, err := sourceFromArg(srv.URL + path)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got, err := readContent(src)
+		_ = src.reader.Close()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %s to read as %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestArchiveSource(t *testing.T) {
+	files := map[string]string{
+		"bundle/CHANGELOG.md":     "# Changes\n",
+		"bundle/docs/README.md":   "# Docs\n",
+		"bundle/README.md":        "# Bundle\n",
+		"bundle/bin/glow":         "binary",
+		"bundle/docs/install.rst": "Install\n=======\n",
+	}
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	var tarred bytes.Buffer
+	gw := gzip.NewWriter(&tarred)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if name == "bundle/README.md" {
+			// the tarball has no README
+			f, _ := zw.Create(name)
+			_, _ = io.WriteString(f, content)
+			continue
+		}
+		f, _ := zw.Create(name)
+		_, _ = io.WriteString(f, content)
+		_ = tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		_, _ = io.WriteString(tw, content)
+	}
+	_ = zw.Close()
+	_ = tw.Close()
+	_ = gw.Close()
+
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "bundle.zip")
+	if err := os.WriteFile(zipPath, zipped.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	src, err := sourceFromArg(zipPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b, _ := io.ReadAll(src.reader); string(b) != "# Bundle\n" {
+		t.Errorf("expected the top-level README to be read, got %q", b)
+	}
+	if want := filepath.Join(zipPath, "bundle", "README.md"); src.URL != want {
+		t.Errorf("expected the URL %s, got %s", want, src.URL)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		_, _ = w.Write(tarred.Bytes())
+	}))
+	defer srv.Close()
+	tarFiles, err := archiveFiles(srv.URL + "/bundle.tar.gz?download=1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var names []string
+	for _, f := range tarFiles {
+		names = append(names, f.name)
+	}
+	if want := []string{"bundle/CHANGELOG.md", "bundle/docs/README.md", "bundle/docs/install.rst"}; !slices.Equal(names, want) {
+		t.Errorf("expected the markdown files %q, got %q", want, names)
+	}
+	src, err = sourceFromArg(srv.URL + "/bundle.tar.gz")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b, _ := io.ReadAll(src.reader); string(b) != "# Docs\n" {
+		t.Errorf("expected the nested README to be read, got %q", b)
+	}
+}
+
+func TestTableMarkdown(t *testing.T) {
+	defer func(w uint, f string) { width, inputFormat = w, f }(width, inputFormat)
+	width = 80
+
+	want := "```text\n" +
+		"| name  | note   |\n" +
+		"|-------|--------|\n" +
+		"| Alice | a, \"b\" |\n" +
+		"| Bob   |        |\n" +
+		"```\n"
+	got, err := readContent(&source{reader: io.NopCloser(strings.NewReader("name,note\nAlice,\"a, \"\"b\"\"\"\nBob\n")), URL: "people.csv"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != want {
+		t.Errorf("expected table %q, got %q", want, got)
+	}
+
+	inputFormat = "tsv"
+	got, err = readContent(&source{reader: io.NopCloser(strings.NewReader("name\tnote\nAlice\ta, \"b\"\nBob\n"))})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != want {
+		t.Errorf("expected table %q, got %q", want, got)
+	}
+}
+
+func TestPrettyData(t *testing.T) {
+	defer func(p bool) { utils.PrettyData = p }(utils.PrettyData)
+	utils.PrettyData = true
+
+	for name, tc := range map[string]struct{ in, want string }{
+		"config.json": {`{"a":1,"b":[true]}`, "```json\n{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n```"},
+		"config.yml":  {"a:    1\nb:\n    - x # note\n", "```yaml\na: 1\nb:\n  - x # note\n```"},
+		"config.toml": {"[server]\nport=80\n", "```toml\n[server]\n  port = 80\n```"},
+		"broken.json": {"{\"a\":", "```json\n{\"a\":\n```"},
+		"":            {"[1, 2]", "```json\n[\n  1,\n  2\n]\n```"},
+	} {
+		got, err := readContent(&source{reader: io.NopCloser(strings.NewReader(tc.in)), URL: name})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("expected %q to read as %q, got %q", name, tc.want, got)
+		}
+	}
+}
+
+func TestColorProfile(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	if p := colorProfile(colorNever, true); p != termenv.Ascii {
+		t.Errorf("expected no colors with never, got %v", p)
+	}
+	if p := colorProfile(colorAuto, false); p != termenv.Ascii {
+		t.Errorf("expected no colors when not writing to a terminal, got %v", p)
+	}
+	if p := colorProfile(colorAlways, false); p == termenv.Ascii {
+		t.Error("expected colors with always")
+	}
+
+	t.Setenv("CLICOLOR_FORCE", "1")
+	if p := colorProfile(colorAuto, false); p == termenv.Ascii {
+		t.Error("expected colors with CLICOLOR_FORCE")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if p := colorProfile(colorAuto, true); p != termenv.Ascii {
+		t.Errorf("expected NO_COLOR to win over CLICOLOR_FORCE, got %v", p)
+	}
+	if p := colorProfile(colorAlways, true); p == termenv.Ascii {
+		t.Error("expected always to win over NO_COLOR")
+	}
+	if !colorDisabled(colorAuto) || colorDisabled(colorAlways) {
+		t.Error("expected NO_COLOR to disable colors of exports in auto mode only")
+	}
+}
+
+func TestParseWidth(t *testing.T) {
+	for s, want := range map[string]uint{
+		"60":           60,
+		"0":            0,
+		"auto":         200,
+		"50%":          100,
+		"auto,max=120": 120,
+		"25%,max=120":  50,
+	} {
+		spec, err := parseWidth(s)
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %v", s, err)
+		}
+		if got := spec.resolve(200); got != want {
+			t.Errorf("expected %q to be %d columns, got %d", s, want, got)
+		}
+	}
+	if spec, _ := parseWidth("50%"); spec.resolve(0) != fallbackWidth {
+		t.Error("expected relative widths to fall back without a terminal")
+	}
+	for _, s := range []string{"wide", "0%", "120%", "auto,max=0", "-1"} {
+		if _, err := parseWidth(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
+
+func TestDocumentHeader(t *testing.T) {
+	for md, want := range map[string]string{
+		"---\ntitle: A_b\ndate: 2024-03-02\n---\n# Other\n":        "**A\\_b** · Mar 2, 2024 · 1 min read · post.md\n\n---\n\n",
+		"+++\ndate = 2024-03-02T10:00:00Z\n+++\n# Title\n":         "**Title** · Mar 2, 2024 · 1 min read · post.md\n\n---\n\n",
+		"---\ndate: someday\n---\n" + strings.Repeat("word ", 450): "someday · 3 min read · post.md\n\n---\n\n",
+	} {
+		if got := documentHeader([]byte(md), "post.md"); got != want {
+			t.Errorf("expected header %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepIgnoreCase    bool
+	grepFixedStrings  bool
+	grepFilesWithHits bool
+
+	errNoMatches = errors.New("no matches found")
+
+	grepCmd = &cobra.Command{
+		Use:   "grep PATTERN [PATH...]",
+		Short: "Search markdown files and show the matches rendered",
+		Long: paragraph(fmt.Sprintf("\n%s markdown files for a regular expression and show the matching paragraphs, list items and code rendered, with the matches highlighted and the sections they are in. Directories are searched recursively.",
+			keyword("Search"))),
+		Example: paragraph("glow grep TODO\nglow grep -i 'install(ation)?' docs README.md"),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runGrep(os.Stdout, args[0], args[1:])
+		},
+	}
+)
+
+func runGrep(w io.Writer, pattern string, paths []string) error {
+	if grepFixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if grepIgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := expandArgs(paths, true, depth)
+	if err != nil {
+		return err
+	}
+
+	var (
+		b     bytes.Buffer
+		found bool
+	)
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+		content, err = utils.ConvertToMarkdown(content, path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		source := utils.RemoveFrontmatter(content)
+		skipped := strings.Count(string(content[:len(content)-len(source)]), "\n")
+
+		matches := utils.GrepBlocks(source, re)
+		if len(matches) == 0 {
+			continue
+		}
+		found = true
+		if grepFilesWithHits {
+			fmt.Fprintln(&b, path)
+			continue
+		}
+
+		for _, m := range matches {
+			out, err := renderContent(m.Source, path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(&b, grepHeader(path, m.Line+skipped+1, m.Headings))
+			fmt.Fprint(&b, highlightMatches(out, re))
+		}
+	}
+	if !found {
+		return errNoMatches
+	}
+	return writeFiltered(w, b.Bytes(), "ansi")
+}
+
+// grepHeader returns the styled location of a match: its file, line and the
+// sections it's in.
+func grepHeader(path string, line int, headings []string) string {
+	header := separatorStyle.Render(fmt.Sprintf("%s:%d", path, line))
+	if len(headings) > 0 {
+		header += " " + sectionStyle.Render(strings.Join(headings, " › "))
+	}
+	return header
+}
+
+// highlightMatches highlights the text matching a search in rendered output,
+// leaving its escape sequences intact.
+func highlightMatches(out string, re *regexp.Regexp) string {
+	on := ansi.Style{}.Reverse().String()
+	off := ansi.Style{}.NoReverse().String()
+
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		plain, offsets := plainText(line)
+		locs := re.FindAllStringIndex(plain, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
+			if loc[0] == loc[1] {
+				continue
+			}
+			start, stop := offsets[loc[0]], offsets[loc[1]-1]+1
+			b.WriteString(line[last:start])
+			b.WriteString(on + line[start:stop] + off)
+			last = stop
+		}
+		b.WriteString(line[last:])
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainText strips the escape sequences of a line, and returns the offset
+// of every byte of the text in the line.
+func plainText(line string) (string, []int) {
+	var (
+		plain   strings.Builder
+		offsets []int
+		state   byte
+	)
+	for i := 0; i < len(line); {
+		seq, width, n, newState := ansi.DecodeSequence(line[i:], state, nil)
+		if width > 0 {
+			plain.WriteString(seq)
+			for j := range n {
+				offsets = append(offsets, i+j)
+			}
+		}
+		state = newState
+		i += n
+	}
+	return plain.String(), offsets
+}
+
+func init() {
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "match case-insensitively")
+	grepCmd.Flags().BoolVarP(&grepFixedStrings, "fixed-strings", "F", false, "match the pattern literally instead of as a regular expression")
+	grepCmd.Flags().BoolVarP(&grepFilesWithHits, "files-with-matches", "l", false, "only print the paths of files with matches")
+}
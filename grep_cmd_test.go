@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestGrepBlocks(t *testing.T) {
+	md := "# Guide\n\n## Install\n\nRun the installer.\n\n- one\n  - install step\n\n" +
+		"```sh\necho hi\nmake install\n```\n\n## Usage\n\nNothing here.\n"
+	matches := utils.GrepBlocks([]byte(md), regexp.MustCompile("install"))
+	want := []utils.BlockMatch{
+		{Line: 4, Headings: []string{"Guide", "Install"}, Source: "Run the installer.\n"},
+		{Line: 7, Headings: []string{"Guide", "Install"}, Source: "- install step\n"},
+		{Line: 11, Headings: []string{"Guide", "Install"}, Source: "```sh\nmake install\n```"},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("expected %+v, got %+v", want, matches)
+	}
+
+	// A matching heading is shown under its parents only.
+	matches = utils.GrepBlocks([]byte(md), regexp.MustCompile("Usage"))
+	if len(matches) != 1 || !reflect.DeepEqual(matches[0].Headings, []string{"Guide"}) {
+		t.Errorf("expected the heading under its parent, got %+v", matches)
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	line := "\x1b[1mfoo\x1b[0m bar foo"
+	want := "\x1b[1m\x1b[7mfoo\x1b[27m\x1b[0m bar \x1b[7mfoo\x1b[27m"
+	if got := highlightMatches(line, regexp.MustCompile("foo")); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	// Escape sequences don't match.
+	if got := highlightMatches(line, regexp.MustCompile("1m")); got != line {
+		t.Errorf("expected %q to be left alone, got %q", line, got)
+	}
+}
+
+func TestRunGrep(t *testing.T) {
+	defer func() { grepFilesWithHits = false }()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("---\ntitle: a\n---\n# A\n\nHello there.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.md"), []byte("# B\n\nGoodbye.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := runGrep(&b, "there", []string{dir}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(b.String(), "a.md:6") || strings.Contains(b.String(), "b.md") {
+		t.Errorf("expected a match on line 6 of a.md, got %q", b.String())
+	}
+
+	b.Reset()
+	grepFilesWithHits = true
+	if err := runGrep(&b, "(?i)goodbye", []string{dir}); err != nil || b.String() != filepath.Join(dir, "b.md")+"\n" {
+		t.Errorf("expected b.md to be listed, got %q, %v", b.String(), err)
+	}
+	if err := runGrep(&b, "nowhere", []string{dir}); !errors.Is(err, errNoMatches) {
+		t.Errorf("expected no matches, got %v", err)
+	}
+}
+
+func TestRunGrepRedacts(t *testing.T) {
+	redactSecrets(t)
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte("# Doc\n\nThe token is sk-abc123.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := runGrep(&b, "token", []string{path}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out := b.String(); strings.Contains(out, "sk-abc123") || !strings.Contains(out, "***") {
+		t.Errorf("expected the secret to be masked, got %q", out)
+	}
+}
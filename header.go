@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// headerDateLayout is how dates are shown in document headers.
+const headerDateLayout = "Jan 2, 2006"
+
+// frontmatterDateLayouts are the layouts of dates written as text in front
+// matter.
+var frontmatterDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// documentHeader returns a line of markdown shown above a document, like
+// static site generators show above posts: its title, date, reading time and
+// source. Title and date are taken from the front matter if it has them.
+func documentHeader(content []byte, srcURL string) string {
+	meta, _ := utils.FrontmatterData(content)
+	body := utils.RemoveFrontmatter(content)
+
+	var parts []string
+	title, _ := meta["title"].(string)
+	if title == "" {
+		title = utils.DocumentTitle(body)
+	}
+	if title != "" {
+		parts = append(parts, "**"+utils.EscapeMarkdown(title)+"**")
+	}
+	if date := headerDate(meta["date"]); date != "" {
+		parts = append(parts, date)
+	}
+	minutes := max(1, utils.Stats(body).ReadingTime())
+	parts = append(parts, fmt.Sprintf("%d min read", minutes))
+	if srcURL != "" {
+		parts = append(parts, utils.EscapeMarkdown(srcURL))
+	}
+	return strings.Join(parts, " · ") + "\n\n---\n\n"
+}
+
+// headerDate formats a front matter date. Dates that can't be parsed are
+// shown as they're written.
+func headerDate(v any) string {
+	switch d := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return d.Format(headerDateLayout)
+	}
+	s := strings.TrimSpace(fmt.Sprint(v))
+	for _, layout := range frontmatterDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(headerDateLayout)
+		}
+	}
+	return utils.EscapeMarkdown(s)
+}
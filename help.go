@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// helpMarkdown returns the help of a command as markdown: its description,
+// usage, examples, subcommands and flags.
+func helpMarkdown(cmd *cobra.Command) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", cmd.CommandPath())
+	desc := strings.TrimSpace(ansi.Strip(cmd.Long))
+	if desc == "" {
+		desc = cmd.Short
+	}
+	// Descriptions are wrapped for the plain help.
+	fmt.Fprintf(&b, "%s\n\n", strings.Join(strings.Fields(desc), " "))
+
+	if cmd.Runnable() {
+		fmt.Fprintf(&b, "## Usage\n\n```sh\n%s\n```\n\n", cmd.UseLine())
+	}
+	if cmd.HasExample() {
+		var lines []string
+		for _, line := range strings.Split(ansi.Strip(cmd.Example), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		fmt.Fprintf(&b, "## Examples\n\n```sh\n%s\n```\n\n", strings.Join(lines, "\n"))
+	}
+	if cmd.HasAvailableSubCommands() {
+		b.WriteString("## Commands\n\n| Command | Description |\n| --- | --- |\n")
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				fmt.Fprintf(&b, "| `%s` | %s |\n", sub.Name(), utils.EscapeMarkdown(sub.Short))
+			}
+		}
+		b.WriteString("\n")
+	}
+	writeFlagsTable(&b, "Flags", cmd.LocalFlags())
+	writeFlagsTable(&b, "Global Flags", cmd.InheritedFlags())
+	if cmd.HasAvailableSubCommands() {
+		fmt.Fprintf(&b, "Use `%s [command] --help` for more information about a command.\n", cmd.CommandPath())
+	}
+	return b.String()
+}
+
+func writeFlagsTable(b *strings.Builder, title string, flags *pflag.FlagSet) {
+	if !flags.HasAvailableFlags() {
+		return
+	}
+	fmt.Fprintf(b, "## %s\n\n| Flag | Description |\n| --- | --- |\n", title)
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		name := "--" + f.Name
+		if f.Shorthand != "" {
+			name = "-" + f.Shorthand + ", " + name
+		}
+		varname, usage := pflag.UnquoteUsage(f)
+		if varname != "" {
+			name += " " + varname
+		}
+		switch {
+		case f.DefValue == "" || f.DefValue == "false" || f.DefValue == "0" || f.DefValue == "0s" || f.DefValue == "[]":
+		case f.Value.Type() == "string":
+			usage += fmt.Sprintf(" (default %q)", f.DefValue)
+		default:
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintf(b, "| `%s` | %s |\n", name, utils.EscapeMarkdown(usage))
+	})
+	b.WriteString("\n")
+}
+
+// setHelp renders the help of commands with glamour when it's shown in a
+// terminal. Elsewhere, like when piped, the plain help is kept.
+func setHelp(cmd *cobra.Command) {
+	plain := cmd.HelpFunc()
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			plain(c, args)
+			return
+		}
+		style := viper.GetString("style")
+		if validateStyle(style) != nil {
+			style = "auto"
+		}
+		r, err := glamour.NewTermRenderer(
+			glamour.WithColorProfile(lipgloss.ColorProfile()),
+			utils.GlamourStyle(style, false),
+			glamour.WithWordWrap(helpWidth()),
+		)
+		if err != nil {
+			plain(c, args)
+			return
+		}
+		out, err := r.Render(helpMarkdown(c))
+		if err != nil {
+			plain(c, args)
+			return
+		}
+		fmt.Fprint(c.OutOrStdout(), out)
+	})
+}
+
+// helpWidth returns the width help is wrapped at: the terminal's, up to the
+// default maximum.
+func helpWidth() int {
+	if w := terminalWidth(); w > 0 {
+		return min(w, defaultMaxWidth)
+	}
+	return fallbackWidth
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+const defaultHookTimeout = 10 * time.Second
+
+var (
+	// preRenderHooks are commands the markdown of documents is piped
+	// through before rendering, and postRenderHooks the ones their rendered
+	// output is piped through, from the config
+	preRenderHooks  []string
+	postRenderHooks []string
+
+	// outputFilters are commands all of glow's output is piped through, after
+	// redaction: of the CLI, the pager, the TUI, streams, exports, grep, diff
+	// and ast, the server and the MCP server's tools
+	outputFilters []string
+
+	// hookTimeout limits how long each hook may run, 0 for no limit
+	hookTimeout = defaultHookTimeout
+)
+
+// runHooks pipes a document through hook commands, one after another, with
+// env added to their environment. A hook that fails or runs out of time
+// stops rendering, as the document would be incomplete without it.
+func runHooks(kind string, hooks []string, b []byte, env ...string) ([]byte, error) {
+	for _, hook := range hooks {
+		args := strings.Fields(hook)
+		if len(args) == 0 {
+			continue
+		}
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if hookTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, hookTimeout)
+		}
+		var stdout, stderr bytes.Buffer
+		c := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+		c.Stdin = bytes.NewReader(b)
+		c.Stdout = &stdout
+		c.Stderr = &stderr
+		c.Env = append(os.Environ(), env...)
+		// Processes the hook started may keep its output open.
+		c.WaitDelay = time.Second
+		err := c.Run()
+		cancel()
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, fmt.Errorf("%s hook %s timed out after %s", kind, args[0], hookTimeout)
+		case err != nil:
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%s hook %s failed: %w: %s", kind, args[0], err, msg)
+			}
+			return nil, fmt.Errorf("%s hook %s failed: %w", kind, args[0], err)
+		}
+		b = stdout.Bytes()
+	}
+	return b, nil
+}
+
+// preRender runs the pre-render hooks on the markdown of a document.
+func preRender(b []byte, srcURL string) ([]byte, error) {
+	return runHooks("pre-render", preRenderHooks, b, "GLOW_SOURCE="+srcURL)
+}
+
+// postRender runs the post-render hooks on the rendered output of a
+// document.
+func postRender(out, srcURL string) (string, error) {
+	b, err := runHooks("post-render", postRenderHooks, []byte(out), "GLOW_SOURCE="+srcURL)
+	return string(b), err
+}
+
+// filterOutput redacts output, and pipes it through the output filters,
+// which are told its format, like ansi or html, in GLOW_FORMAT.
+func filterOutput(out []byte, format string) ([]byte, error) {
+	return runHooks("output", outputFilters, utils.Redact(out), "GLOW_FORMAT="+format)
+}
+
+// writeFiltered writes the output of a command to w, after redacting and
+// filtering it like filterOutput.
+func writeFiltered(w io.Writer, out []byte, format string) error {
+	filtered, err := filterOutput(out, format)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(filtered); err != nil {
+		return fmt.Errorf("unable to write output: %w", err)
+	}
+	return nil
+}
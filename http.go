@@ -0,0 +1,342 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// defaultMaxRedirects is how many redirects are followed by default, like
+// by Go's default client.
+const defaultMaxRedirects = 10
+
+// documentAccept asks servers that can for markdown, or else text.
+const documentAccept = "text/markdown, text/x-markdown;q=0.9, text/plain;q=0.8, text/html;q=0.7, */*;q=0.1"
+
+var (
+	// httpClient fetches remote documents and images.
+	httpClient = http.DefaultClient
+
+	// maxDownloadSize is the size remote documents are limited to, if not
+	// zero.
+	maxDownloadSize int64
+
+	// acceptTypes are the media types remote documents may have, like
+	// text/markdown or text/*. Binary ones are refused if empty.
+	acceptTypes []string
+)
+
+// binaryMediaTypes are media types of remote files that can't be documents.
+var binaryMediaTypes = []string{
+	"image/*", "audio/*", "video/*", "font/*",
+	"application/octet-stream", "application/pdf", "application/zip",
+	"application/gzip", "application/x-tar", "application/wasm",
+}
+
+// httpOptions configure the HTTP client, for networks that can't be reached
+// otherwise, like behind a corporate proxy.
+type httpOptions struct {
+	// Proxy is the URL of the proxy requests go through. The proxy of the
+	// environment, like from HTTPS_PROXY, is used if empty.
+	Proxy     string
+	UserAgent string
+	// Headers are added to all requests.
+	Headers map[string]string
+
+	// CACert is a PEM file of certificate authorities trusted besides the
+	// system's.
+	CACert string
+	// ClientCert and ClientKey are PEM files of a certificate presented to
+	// servers asking for one.
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+
+	// Timeout limits how long requests may take, including reading their
+	// response, if not zero.
+	Timeout time.Duration
+	// MaxRedirects is how many redirects are followed.
+	MaxRedirects int
+}
+
+// newHTTPClient returns an HTTP client configured with the options. It's the
+// default client if there's nothing to configure.
+func newHTTPClient(o httpOptions) (*http.Client, error) {
+	customTransport := o.Proxy != "" || o.UserAgent != "" || len(o.Headers) > 0 ||
+		o.CACert != "" || o.ClientCert != "" || o.ClientKey != "" || o.InsecureSkipVerify
+	if !customTransport && o.Timeout == 0 && o.MaxRedirects == defaultMaxRedirects {
+		return http.DefaultClient, nil
+	}
+
+	client := &http.Client{
+		Timeout: o.Timeout,
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) > o.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", o.MaxRedirects)
+			}
+			return nil
+		},
+	}
+	if !customTransport {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify, //nolint:gosec
+	}
+	if o.CACert != "" {
+		pem, err := os.ReadFile(utils.ExpandPath(o.CACert))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if o.ClientCert != "" || o.ClientKey != "" {
+		if o.ClientCert == "" || o.ClientKey == "" {
+			return nil, errors.New("a client certificate needs both tlsClientCert and tlsClientKey")
+		}
+		cert, err := tls.LoadX509KeyPair(utils.ExpandPath(o.ClientCert), utils.ExpandPath(o.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client.Transport = &headerTransport{
+		base:      transport,
+		userAgent: o.UserAgent,
+		headers:   o.Headers,
+	}
+	return client, nil
+}
+
+// headerTransport adds the configured headers to requests.
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req) //nolint:wrapcheck
+}
+
+// fetchRetries is how many times a request failing with a server error or a
+// temporary network error is retried, waiting fetchBackoff before the first
+// retry and twice as long before each next one.
+var (
+	fetchRetries = 2
+	fetchBackoff = 500 * time.Millisecond
+)
+
+// retryTransport retries requests that may succeed when made again, like
+// when a server is briefly unavailable. Only requests without a body, like
+// GET and HEAD, are retried.
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := fetchBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if attempt >= fetchRetries || !retryable(req, res, err) {
+			return res, err //nolint:wrapcheck
+		}
+		if res != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096)) //nolint:mnd
+			_ = res.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err() //nolint:wrapcheck
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a request is worth retrying after it failed with
+// err or got res: server errors and temporary network errors are, client
+// errors like a missing document aren't.
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead || req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout() ||
+			errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	switch res.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// withRetries returns a copy of a client retrying failed requests.
+func withRetries(c *http.Client) *http.Client {
+	retried := *c
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	retried.Transport = &retryTransport{base: base}
+	return &retried
+}
+
+// parseSize parses a size in bytes, optionally with a unit like 10MB, or
+// 512K. Units are powers of 1024.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(s, u.suffix); ok {
+			v, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return int64(v * float64(u.size)), nil
+		}
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return v, nil
+}
+
+// limitDownload makes reading a response fail once it's larger than the
+// maximum download size, or right away if it says it is.
+func limitDownload(res *http.Response) error {
+	if maxDownloadSize <= 0 {
+		return nil
+	}
+	if res.ContentLength > maxDownloadSize {
+		return fmt.Errorf("%s is larger than the maximum download size of %d bytes", res.Request.URL, maxDownloadSize)
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, left: maxDownloadSize, url: res.Request.URL.String()}
+	return nil
+}
+
+// limitedBody fails reading a response body beyond a size.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+	url  string
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left < 0 {
+		return 0, fmt.Errorf("%s is larger than the maximum download size of %d bytes", b.url, maxDownloadSize)
+	}
+	// Read one byte beyond the limit to tell whether there's more.
+	if int64(len(p)) > b.left+1 {
+		p = p[:b.left+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	if b.left < 0 {
+		return n + int(b.left), fmt.Errorf("%s is larger than the maximum download size of %d bytes", b.url, maxDownloadSize)
+	}
+	return n, err //nolint:wrapcheck
+}
+
+// checkContentType returns an error for a response whose media type isn't
+// one of the accepted types, or, if there are none, is binary.
+func checkContentType(res *http.Response) error {
+	header := res.Header.Get("Content-Type")
+	if header == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+	if len(acceptTypes) > 0 {
+		if !matchesMediaType(mediaType, acceptTypes) {
+			return fmt.Errorf("%s is %s, which isn't one of the accepted types: %s",
+				res.Request.URL, mediaType, strings.Join(acceptTypes, ", "))
+		}
+		return nil
+	}
+	if matchesMediaType(mediaType, binaryMediaTypes) {
+		return fmt.Errorf("%s is %s, not a document", res.Request.URL, mediaType)
+	}
+	return nil
+}
+
+// matchesMediaType returns whether a media type is one of the patterns, like
+// text/markdown, text/* or */*.
+func matchesMediaType(mediaType string, patterns []string) bool {
+	kind, _, _ := strings.Cut(mediaType, "/")
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "*/*" || p == mediaType || p == kind+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// documentAcceptHeader returns the Accept header remote documents are
+// requested with.
+func documentAcceptHeader() string {
+	if len(acceptTypes) > 0 {
+		return strings.Join(acceptTypes, ", ")
+	}
+	return documentAccept
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Hyperlink modes.
+const (
+	hyperlinksAuto   = "auto"
+	hyperlinksAlways = "always"
+	hyperlinksNever  = "never"
+)
+
+// Link display modes.
+const (
+	linksInline   = "inline"
+	linksNumbered = "numbered"
+)
+
+func validateLinkMode(mode string) error {
+	switch mode {
+	case linksInline, linksNumbered:
+		return nil
+	}
+	return fmt.Errorf("invalid link mode %q, use %s or %s", mode, linksInline, linksNumbered)
+}
+
+func validateHyperlinksMode(mode string) error {
+	switch mode {
+	case hyperlinksAuto, hyperlinksAlways, hyperlinksNever:
+		return nil
+	}
+	return fmt.Errorf("invalid hyperlinks mode %q, use %s, %s or %s",
+		mode, hyperlinksAuto, hyperlinksAlways, hyperlinksNever)
+}
+
+// hyperlinksEnabled reports whether to emit hyperlinks. In auto mode we only
+// do so when writing to a terminal that's known to support them, as others
+// may print the escape sequences.
+func hyperlinksEnabled(mode string, isTerminal bool) bool {
+	switch mode {
+	case hyperlinksAlways:
+		return true
+	case hyperlinksNever:
+		return false
+	}
+	return isTerminal && terminalSupportsHyperlinks()
+}
+
+// terminalSupportsHyperlinks guesses from the environment whether the
+// terminal supports OSC 8 hyperlinks.
+func terminalSupportsHyperlinks() bool {
+	term := os.Getenv("TERM")
+	if term == "dumb" {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby", "rio":
+		return true
+	}
+	for _, env := range []string{"WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "DOMTERM"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	for _, t := range []string{"kitty", "foot", "alacritty", "wezterm", "ghostty"} {
+		if strings.Contains(term, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// hyperlink is a URL as printed by glamour, the texts of the links it was
+// printed for, and where it points to.
+type hyperlink struct {
+	printed string
+	texts   []string
+	target  string
+}
+
+// addHyperlinks turns the URLs glamour printed after links, together with
+// the link text right before them, into OSC 8 hyperlinks.
+func addHyperlinks(out, content, srcURL string) string {
+	baseURL := renderBaseURL(srcURL)
+	byPrinted := map[string]*hyperlink{}
+	for _, l := range utils.Links([]byte(content)) {
+		if strings.HasPrefix(l.Destination, "#") || l.Destination == "" {
+			// Glamour doesn't print anchors.
+			continue
+		}
+		printed := utils.ResolveRelativeURL(baseURL, l.Destination)
+		h, ok := byPrinted[printed]
+		if !ok {
+			h = &hyperlink{printed: printed, target: hyperlinkTarget(l.Destination, srcURL)}
+			byPrinted[printed] = h
+		}
+		if l.Text != "" && l.Text != printed {
+			h.texts = append(h.texts, l.Text)
+		}
+	}
+	if len(byPrinted) == 0 {
+		return out
+	}
+
+	// Longer URLs first, so that a URL that's a prefix of another doesn't
+	// match in its place.
+	printed := make([]string, 0, len(byPrinted))
+	for p := range byPrinted {
+		printed = append(printed, regexp.QuoteMeta(p))
+	}
+	sort.Slice(printed, func(i, j int) bool { return len(printed[i]) > len(printed[j]) })
+	re := regexp.MustCompile(strings.Join(printed, "|"))
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(out, -1) {
+		if loc[0] < last {
+			continue
+		}
+		h := byPrinted[out[loc[0]:loc[1]]]
+		start := loc[0]
+		lineStart := strings.LastIndexByte(out[:start], '\n') + 1
+		if lineStart < last {
+			lineStart = last
+		}
+		for _, text := range h.texts {
+			before := out[lineStart:start]
+			if i := strings.LastIndex(before, text); i >= 0 && ansi.Strip(before[i:]) == text+" " {
+				start = lineStart + i
+				break
+			}
+		}
+
+		b.WriteString(out[last:start])
+		b.WriteString(ansi.SetHyperlink(h.target))
+		b.WriteString(out[start:loc[1]])
+		b.WriteString(ansi.ResetHyperlink())
+		last = loc[1]
+	}
+	b.WriteString(out[last:])
+	return b.String()
+}
+
+// hyperlinkTarget returns the URL a link destination points to: relative
+// links of a local document point to files next to it.
+func hyperlinkTarget(dest, srcURL string) string {
+	u, err := url.Parse(dest)
+	if err != nil || u.IsAbs() {
+		return dest
+	}
+	if isURL(srcURL) {
+		base, err := url.Parse(srcURL)
+		if err != nil {
+			return dest
+		}
+		return base.ResolveReference(u).String()
+	}
+
+	path := u.Path
+	if !filepath.IsAbs(path) {
+		dir := "."
+		if srcURL != "" {
+			dir = filepath.Dir(srcURL)
+		}
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path), Fragment: u.Fragment}).String()
+}
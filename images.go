@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register image decoders
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/ansi/sixel"
+)
+
+// Image modes: never, auto or the graphics protocol to use.
+const (
+	imagesNever = "never"
+	imagesAuto  = "auto"
+	imagesKitty = "kitty"
+	imagesITerm = "iterm"
+	imagesSixel = "sixel"
+)
+
+const (
+	// maxImageSize is the largest image file we load, in bytes.
+	maxImageSize = 10 << 20
+	// maxImagePixels is the largest image we decode, in pixels.
+	maxImagePixels = 8192 * 8192
+	imageTimeout   = 10 * time.Second
+
+	// We can't know the size of a terminal cell in pixels, so we assume a
+	// common one to size images.
+	cellWidth = 10
+)
+
+func validateImagesMode(mode string) error {
+	switch mode {
+	case imagesNever, imagesAuto, imagesKitty, imagesITerm, imagesSixel:
+		return nil
+	}
+	return fmt.Errorf("invalid images mode %q, use %s, %s, %s, %s or %s",
+		mode, imagesNever, imagesAuto, imagesKitty, imagesITerm, imagesSixel)
+}
+
+// imageProtocol returns the graphics protocol to show images with, if any.
+// In auto mode we only show images when writing to a terminal that's known
+// to support one.
+func imageProtocol(mode string, isTerminal bool) string {
+	switch mode {
+	case imagesNever:
+		return ""
+	case imagesAuto:
+		if !isTerminal {
+			return ""
+		}
+		return detectImageProtocol()
+	}
+	return mode
+}
+
+// detectImageProtocol guesses from the environment which graphics protocol
+// the terminal supports.
+func detectImageProtocol() string {
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", strings.Contains(term, "kitty"), strings.Contains(term, "ghostty"):
+		return imagesKitty
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app", os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return imagesITerm
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"), strings.Contains(term, "sixel"):
+		return imagesSixel
+	}
+	return ""
+}
+
+// renderedImage is an image URL as printed by glamour and where to load the
+// image from.
+type renderedImage struct {
+	printed  string
+	location string
+}
+
+// addImages shows images below the lines glamour printed their URLs on.
+// Images that can't be loaded are left as text.
+func addImages(out, content, srcURL, protocol string) string {
+	baseURL := renderBaseURL(srcURL)
+	var images []renderedImage
+	for _, l := range utils.Links([]byte(content)) {
+		if l.Image && l.Destination != "" {
+			images = append(images, renderedImage{
+				printed:  utils.ResolveRelativeURL(baseURL, l.Destination),
+				location: imageLocation(l.Destination, srcURL),
+			})
+		}
+	}
+	if len(images) == 0 {
+		return out
+	}
+
+	maxCols := int(width) - 4 //nolint:gosec
+	if maxCols <= 0 {
+		maxCols = 76
+	}
+	sequences := map[string]string{}
+
+	lines := strings.Split(out, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+
+		for _, img := range images {
+			if !strings.Contains(line, img.printed) {
+				continue
+			}
+			seq, ok := sequences[img.location]
+			if !ok {
+				var err error
+				seq, err = imageSequence(img.location, protocol, maxCols)
+				if err != nil {
+					log.Debug("unable to show image", "image", img.location, "error", err)
+				}
+				sequences[img.location] = seq
+			}
+			if seq != "" {
+				plain := ansi.Strip(line)
+				indent := len(plain) - len(strings.TrimLeft(plain, " "))
+				b.WriteString("\n" + strings.Repeat(" ", indent) + seq)
+			}
+		}
+	}
+	return b.String()
+}
+
+// imageLocation returns the URL or path to load an image from.
+func imageLocation(dest, srcURL string) string {
+	u, err := url.Parse(dest)
+	if err != nil || u.IsAbs() {
+		return dest
+	}
+	if isURL(srcURL) {
+		base, err := url.Parse(srcURL)
+		if err != nil {
+			return dest
+		}
+		return base.ResolveReference(u).String()
+	}
+	if filepath.IsAbs(u.Path) || srcURL == "" {
+		return u.Path
+	}
+	return filepath.Join(filepath.Dir(srcURL), u.Path)
+}
+
+// imageSequence loads an image and returns the escape sequence showing it
+// with the given protocol, at most maxCols cells wide.
+func imageSequence(location, protocol string, maxCols int) (string, error) {
+	data, err := loadImage(location)
+	if err != nil {
+		return "", err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return "", fmt.Errorf("image is too large: %dx%d", cfg.Width, cfg.Height)
+	}
+	cols := min(maxCols, (cfg.Width+cellWidth-1)/cellWidth)
+
+	if protocol == imagesITerm {
+		// iTerm2 decodes images itself, so we pass the file on as it is.
+		return ansi.ITerm2(iterm2.File{
+			Inline:  true,
+			Width:   iterm2.Cells(cols),
+			Height:  iterm2.Auto,
+			Size:    int64(len(data)),
+			Content: []byte(base64.StdEncoding.EncodeToString(data)),
+		}), nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode image: %w", err)
+	}
+	var b bytes.Buffer
+	switch protocol {
+	case imagesKitty:
+		err = kitty.EncodeGraphics(&b, img, &kitty.Options{
+			Action:       kitty.TransmitAndPut,
+			Transmission: kitty.Direct,
+			Format:       kitty.PNG,
+			Columns:      cols,
+			Chunk:        true,
+			Quite:        2,
+		})
+		if err != nil {
+			return "", fmt.Errorf("unable to encode image: %w", err)
+		}
+		return b.String(), nil
+	case imagesSixel:
+		if err := (&sixel.Encoder{}).Encode(&b, scaleImage(img, cols*cellWidth)); err != nil {
+			return "", fmt.Errorf("unable to encode image: %w", err)
+		}
+		return ansi.SixelGraphics(0, 1, 0, b.Bytes()), nil
+	}
+	return "", fmt.Errorf("unknown image protocol %q", protocol)
+}
+
+// loadImage reads an image file, or downloads it. Downloads are cached.
+func loadImage(location string) ([]byte, error) {
+	if !isURL(location) {
+		info, err := os.Stat(location)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stat image: %w", err)
+		}
+		if info.Size() > maxImageSize {
+			return nil, errors.New("image file is too large")
+		}
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read image: %w", err)
+		}
+		return data, nil
+	}
+
+	cache, cacheErr := imageCachePath(location)
+	if cacheErr == nil {
+		if data, err := os.ReadFile(cache); err == nil {
+			return data, nil
+		}
+	}
+
+	client := &http.Client{Timeout: imageTimeout, Transport: httpClient.Transport}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download image: %w", err)
+	}
+	// Images of private repositories need their token, too.
+	authorize(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download image: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download image: HTTP status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to download image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, errors.New("image file is too large")
+	}
+
+	if cacheErr == nil {
+		if err := os.MkdirAll(filepath.Dir(cache), 0o755); err == nil { //nolint:gosec
+			_ = os.WriteFile(cache, data, 0o644) //nolint:gosec
+		}
+	}
+	return data, nil
+}
+
+// imageCachePath returns where a downloaded image is cached.
+func imageCachePath(u string) (string, error) {
+	dir, err := glowCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(dir, "images", hex.EncodeToString(sum[:])), nil
+}
+
+// scaleImage scales an image down to the given width, keeping its aspect
+// ratio.
+func scaleImage(img image.Image, w int) image.Image {
+	b := img.Bounds()
+	if w <= 0 || b.Dx() <= w {
+		return img
+	}
+	h := max(1, b.Dy()*w/b.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return dst
+}
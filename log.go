@@ -3,17 +3,33 @@ package main
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/log"
-	gap "github.com/muesli/go-app-paths"
+	"github.com/spf13/viper"
+)
+
+var (
+	// debug logs why glow does what it does, like which files it finds,
+	// how long fetching and rendering take and what streaming commits, to
+	// stderr or --log-file
+	debug bool
+
+	// logFile is the file glow logs to, instead of glow.log in the state
+	// directory, or stderr with --debug
+	logFile string
+
+	// closeLog closes the file glow logs to
+	closeLog = func() error { return nil }
 )
 
 func getLogFilePath() (string, error) {
-	dir, err := gap.NewScope(gap.User, "glow").CacheDir()
+	dir, err := glowStateDir()
 	if err != nil {
-		return "", fmt.Errorf("unable to get cache dir: %w", err)
+		return "", err
 	}
 	return filepath.Join(dir, "glow.log"), nil
 }
@@ -21,20 +37,93 @@ func getLogFilePath() (string, error) {
 func setupLog() (func() error, error) {
 	log.SetOutput(io.Discard)
 	// Log to file, if set
-	logFile, err := getLogFilePath()
+	path, err := getLogFilePath()
 	if err != nil {
 		return nil, err
 	}
-	if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil { //nolint:gosec
+	if err := openLog(path); err != nil {
 		// log disabled
 		return func() error { return nil }, nil //nolint:nilerr
 	}
-	f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec
+	log.SetLevel(log.WarnLevel)
+	return func() error { return closeLog() }, nil
+}
+
+// openLog switches logging to a file, appending to it.
+func openLog(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return err //nolint:wrapcheck
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec
 	if err != nil {
-		// log disabled
-		return func() error { return nil }, nil //nolint:nilerr
+		return err //nolint:wrapcheck
 	}
+	_ = closeLog()
 	log.SetOutput(f)
-	log.SetLevel(log.DebugLevel)
-	return f.Close, nil
+	closeLog = f.Close
+	return nil
+}
+
+// configureLog applies --debug and --log-file, once flags are parsed.
+func configureLog() error {
+	if logFile != "" {
+		if err := openLog(logFile); err != nil {
+			return fmt.Errorf("unable to open log file: %w", err)
+		}
+	} else if debug {
+		_ = closeLog()
+		closeLog = func() error { return nil }
+		log.SetOutput(os.Stderr)
+	}
+	if debug {
+		log.SetLevel(log.DebugLevel)
+		log.SetReportTimestamp(true)
+		log.Debug("starting", "version", Version, "config", viper.ConfigFileUsed(), "args", os.Args[1:])
+	}
+	return nil
+}
+
+// logToFileForTUI moves debug logs from stderr to glow.log while the TUI
+// is shown, as they would garble it.
+func logToFileForTUI() {
+	if !debug || logFile != "" {
+		return
+	}
+	path, err := getLogFilePath()
+	if err != nil {
+		return
+	}
+	log.Debug("logging to file while the TUI is shown", "path", path)
+	if err := openLog(path); err != nil {
+		log.SetOutput(io.Discard)
+	}
+}
+
+// timedTransport logs the requests of a client, with their status and how
+// long they took.
+type timedTransport struct {
+	base http.RoundTripper
+}
+
+func (t *timedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		log.Debug("fetch failed", "method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start), "error", err)
+		return nil, err //nolint:wrapcheck
+	}
+	log.Debug("fetched", "method", req.Method, "url", req.URL.Redacted(), "status", res.StatusCode,
+		"type", res.Header.Get("Content-Type"), "duration", time.Since(start))
+	return res, nil
+}
+
+// withRequestLog returns a copy of a client logging its requests.
+func withRequestLog(c *http.Client) *http.Client {
+	logged := *c
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	logged.Transport = &timedTransport{base: base}
+	return &logged
 }
@@ -2,17 +2,23 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
 	"github.com/caarlos0/env/v11"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/glamour/styles"
@@ -21,7 +27,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	gap "github.com/muesli/go-app-paths"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"golang.org/x/term"
 )
@@ -35,6 +43,8 @@ var (
 	readmeNames      = []string{"README.md", "README", "Readme.md", "Readme", "readme.md", "readme"}
 	configFile       string
 	pager            bool
+	paginate         bool
+	showHeader       bool
 	tui              bool
 	style            string
 	width            uint
@@ -43,21 +53,56 @@ var (
 	preserveNewLines bool
 	mouse            bool
 	stream           bool
+	accessible       bool
+	separator        string
+	recursive        bool
+	depth            int
+	sourcesFile      string
+	fromClipboard    bool
+	gitRef           string
+	section          string
+	toc              int
+	styleSets        []string
+	aliasSets        []string
+	codeTheme        string
+	codeLineNumbers  bool
+	detectLanguage   bool
+	wikiLinks        bool
+	frontmatterMode  string
+	smartypants      bool
+	readability      bool
+	hardBreaks       bool
+	prettyData       bool
+	hyperlinks       string
+	colorMode        string
+	linkMode         string
+	imagesMode       string
+	useImages        string
+	inputFormat      string
+	useHyperlinks    bool
 
 	rootCmd = &cobra.Command{
-		Use:   "glow [SOURCE|DIR]",
+		Use:   "glow [SOURCE...|DIR]",
 		Short: "Render markdown on the CLI, with pizzazz!",
 		Long: paragraph(
 			fmt.Sprintf("\nRender markdown on the CLI, %s!", keyword("with pizzazz")),
 		),
-		SilenceErrors:    false,
-		SilenceUsage:     true,
-		TraverseChildren: true,
-		Args:             cobra.MaximumNArgs(1),
-		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
-			return nil, cobra.ShellCompDirectiveDefault
-		},
+		Example:           paragraph("glow README.md\nglow -p docs/\nglow github.com/charmbracelet/glow\ncurl -s https://example.com/notes.md | glow -"),
+		SilenceErrors:     false,
+		SilenceUsage:      true,
+		TraverseChildren:  true,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeDocuments,
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := applyFlagEnv(cmd); err != nil {
+				return err
+			}
+			if err := configureLog(); err != nil {
+				return err
+			}
+			if err := startProfile(); err != nil {
+				return err
+			}
 			return validateOptions(cmd)
 		},
 		RunE: execute,
@@ -68,6 +113,10 @@ var (
 type source struct {
 	reader io.ReadCloser
 	URL    string
+	// webPage is set for web pages, which are read converted to markdown.
+	webPage bool
+	// links are the numbered links of the document, with --open-links.
+	links []string
 }
 
 // sourceFromArg parses an argument and creates a readable source for it.
@@ -77,31 +126,75 @@ func sourceFromArg(arg string) (*source, error) {
 		return &source{reader: os.Stdin}, nil
 	}
 
-	// a GitHub or GitLab URL (even without the protocol):
+	// a file at a ref of its repository:
+	if ref, path, ok := gitObjectArg(arg); ok {
+		return gitObjectSource(ref, path)
+	}
+
+	// a GitHub, GitLab, Gitea or Bitbucket repository, a gist (even
+	// without the protocol) or a package:
 	src, err := readmeURL(arg)
 	if src != nil && err == nil {
 		// if there's an error, try next methods...
 		return src, nil
 	}
+	if err != nil && isRemoteSpec(arg) {
+		// packages, releases and files of repositories can't be anything else
+		return nil, err
+	}
 
-	// HTTP(S) URLs:
+	// HTTP(S), Gemini, SSH, object storage URLs, container images and URLs
+	// of configured schemes:
 	if u, err := url.ParseRequestURI(arg); err == nil && strings.Contains(arg, "://") { //nolint:nestif
+		if u.Scheme == "gemini" {
+			return geminiSource(u)
+		}
+		if slices.Contains(blobSchemes, u.Scheme) {
+			return blobSource(u)
+		}
+		if u.Scheme == "ssh" {
+			return sshSource(sshURLArg(u))
+		}
+		if u.Scheme == "oci" {
+			return ociSource(arg)
+		}
+		if command, ok := schemeHandlers[u.Scheme]; ok && u.Scheme != "http" && u.Scheme != "https" {
+			return schemeSource(command, arg)
+		}
 		if u.Scheme != "" {
 			if u.Scheme != "http" && u.Scheme != "https" {
 				return nil, fmt.Errorf("%s is not a supported protocol", u.Scheme)
 			}
+			if isArchiveArg(arg) {
+				return archiveSource(arg)
+			}
 			// consumer of the source is responsible for closing the ReadCloser.
-			resp, err := http.Get(u.String()) //nolint: noctx,bodyclose
+			resp, err := httpGetAccepting(u.String(), documentAcceptHeader()) //nolint:bodyclose
 			if err != nil {
 				return nil, fmt.Errorf("unable to get url: %w", err)
 			}
 			if resp.StatusCode != http.StatusOK {
 				return nil, fmt.Errorf("HTTP status %d", resp.StatusCode)
 			}
-			return &source{resp.Body, u.String()}, nil
+			if err := checkContentType(resp); err != nil {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+			if src := feedSource(resp, u.String()); src != nil {
+				return src, nil
+			}
+			if src := webPageSource(resp, u.String()); src != nil {
+				return src, nil
+			}
+			return &source{reader: resp.Body, URL: u.String()}, nil
 		}
 	}
 
+	// a file on a server, like user@host:docs/notes.md:
+	if dest, path, ok := scpArg(arg); ok {
+		return sshSource(dest, "", path)
+	}
+
 	// a directory:
 	if len(arg) == 0 {
 		// use the current working dir if no argument was supplied
@@ -122,7 +215,7 @@ func sourceFromArg(arg string) (*source, error) {
 					}
 
 					u, _ := filepath.Abs(path)
-					src = &source{r, u}
+					src = &source{reader: r, URL: u}
 
 					// abort filepath.Walk
 					return errors.New("source found")
@@ -138,6 +231,9 @@ func sourceFromArg(arg string) (*source, error) {
 		return nil, errors.New("missing markdown source")
 	}
 
+	if isArchiveArg(arg) {
+		return archiveSource(arg)
+	}
 	r, err := os.Open(arg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to open file: %w", err)
@@ -146,7 +242,52 @@ func sourceFromArg(arg string) (*source, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to get absolute path: %w", err)
 	}
-	return &source{r, u}, nil
+	return &source{reader: r, URL: u}, nil
+}
+
+// webPageSource returns a source reading a web page converted to markdown,
+// or nil if the response isn't an HTML page, or one converted based on its
+// extension.
+func webPageSource(resp *http.Response, pageURL string) *source {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil
+	}
+	if utils.IsConvertibleFile(pageURL) {
+		return nil
+	}
+	return &source{reader: &webPageReader{body: resp.Body, url: pageURL}, URL: pageURL, webPage: true}
+}
+
+// webPageReader reads a web page or feed converted to markdown. Pages that
+// turn out not to be HTML, like markdown served as HTML, are read as-is.
+type webPageReader struct {
+	body io.ReadCloser
+	url  string
+	r    io.Reader
+}
+
+func (p *webPageReader) Read(b []byte) (int, error) {
+	if p.r == nil {
+		page, err := io.ReadAll(p.body)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read web page: %w", err)
+		}
+		if utils.IsFeed(page) {
+			page = utils.FeedToMarkdown(page, p.url)
+		} else if strings.HasPrefix(http.DetectContentType(page), "text/html") {
+			page, err = utils.ConvertFormat(page, "html", p.url)
+			if err != nil {
+				return 0, err //nolint:wrapcheck
+			}
+		}
+		p.r = bytes.NewReader(page)
+	}
+	return p.r.Read(b) //nolint:wrapcheck
+}
+
+func (p *webPageReader) Close() error {
+	return p.body.Close() //nolint:wrapcheck
 }
 
 // validateStyle checks if the style is a default style, if not, checks that
@@ -163,15 +304,155 @@ func validateStyle(style string) error {
 	return nil
 }
 
+// validateCodeTheme checks that a chroma theme exists. An empty theme keeps the
+// colors of the glamour style.
+func validateCodeTheme(theme string) error {
+	if _, ok := chromastyles.Registry[theme]; theme != "" && !ok {
+		return fmt.Errorf("unknown code theme %q, see \"glow style themes\" for a list", theme)
+	}
+	return nil
+}
+
+// setAlias adds an alias like "WIP=Work in progress" to the aliases.
+func setAlias(aliases map[string]string, set string) error {
+	from, to, ok := strings.Cut(set, "=")
+	if !ok || strings.TrimSpace(from) == "" {
+		return fmt.Errorf("invalid alias %q, must be alias=replacement", set)
+	}
+	aliases[strings.TrimSpace(from)] = to
+	return nil
+}
+
+// setStyleOverride adds an override like "h2.color=212" to the overrides.
+// Values are parsed as JSON if possible, so numbers and booleans can be set.
+func setStyleOverride(overrides map[string]any, set string) error {
+	key, value, ok := strings.Cut(set, "=")
+	path := strings.Split(key, ".")
+	if !ok || len(path) < 2 || slices.Contains(path, "") {
+		return fmt.Errorf("invalid style override %q, must be element.property=value", set)
+	}
+
+	m := overrides
+	for _, k := range path[:len(path)-1] {
+		sub, ok := m[k].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[k] = sub
+		}
+		m = sub
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		v = value
+	}
+	m[path[len(path)-1]] = v
+	return nil
+}
+
+// documentStyle returns the style documents are rendered with, given the
+// configured one.
+func documentStyle(style string, styleFlag, isTerminal bool, profile termenv.Profile) string {
+	// Accessibility mode always uses the high contrast style
+	if accessible {
+		return utils.HighContrastStyle
+	}
+	// We want to use a special no-TTY style, when stdout is not a terminal
+	// and there was no specific style passed by arg, unless colors are forced
+	if !isTerminal && profile == termenv.Ascii && !styleFlag {
+		return "notty"
+	}
+	return style
+}
+
 func validateOptions(cmd *cobra.Command) error {
 	// grab config values from Viper
-	width = viper.GetUint("width")
+	widthOpt, err := parseWidth(viper.GetString("width"))
+	if err != nil {
+		return err
+	}
 	mouse = viper.GetBool("mouse")
+	accessible = viper.GetBool("accessible")
 	pager = viper.GetBool("pager")
+	paginate = viper.GetBool("paginate")
+	showHeader = viper.GetBool("header")
 	tui = viper.GetBool("tui")
 	showAllFiles = viper.GetBool("all")
 	preserveNewLines = viper.GetBool("preserveNewLines")
 	showLineNumbers = viper.GetBool("showLineNumbers")
+	separator = viper.GetString("separator")
+	wikiLinks = viper.GetBool("wikiLinks")
+	excludePatterns = viper.GetStringSlice("exclude")
+	frontmatterMode = viper.GetString("frontmatter")
+	if err := validateFrontmatterMode(frontmatterMode); err != nil {
+		return err
+	}
+	linkMode = viper.GetString("links")
+	if err := validateLinkMode(linkMode); err != nil {
+		return err
+	}
+	utils.LinkHandlers = viper.GetStringMapString("linkHandlers")
+	if openLinks {
+		// The links are opened by their number.
+		linkMode = linksNumbered
+	}
+	utils.CodeLineNumbers = viper.GetBool("codeLineNumbers")
+	utils.LanguageDetection = viper.GetBool("detectLanguage")
+	utils.Smartypants = viper.GetBool("smartypants")
+	utils.Readability = viper.GetBool("readability")
+	utils.PrettyData = viper.GetBool("pretty")
+	utils.HardBreaks = viper.GetBool("hardBreaks")
+	aliases := map[string]string{}
+	for _, set := range append(viper.GetStringSlice("aliases"), aliasSets...) {
+		if err := setAlias(aliases, set); err != nil {
+			return err
+		}
+	}
+	utils.SetAliases(aliases)
+	utils.Converters = viper.GetStringMapString("converters")
+	preRenderHooks = viper.GetStringSlice("preRenderHooks")
+	postRenderHooks = viper.GetStringSlice("postRenderHooks")
+	outputFilters = viper.GetStringSlice("outputFilters")
+	if err := utils.SetRedactions(viper.GetStringSlice("redact"), viper.GetString("redactReplacement")); err != nil {
+		return err //nolint:wrapcheck
+	}
+	hookTimeout = viper.GetDuration("hookTimeout")
+	schemeHandlers = viper.GetStringMapString("schemeHandlers")
+	utils.FenceHandlers = viper.GetStringMapString("fenceHandlers")
+	utils.FenceTimeout = viper.GetDuration("fenceTimeout")
+	utils.FenceSandbox = viper.GetBool("fenceSandbox")
+	utils.FenceCacheDir = ""
+	if viper.GetBool("fenceCache") {
+		if utils.FenceCacheDir, err = fenceCacheDir(); err != nil {
+			return err
+		}
+	}
+	gitlabHosts = viper.GetStringSlice("gitlabHosts")
+	giteaHosts = viper.GetStringSlice("giteaHosts")
+	hostTokens = viper.GetStringMapString("tokens")
+	offline = viper.GetBool("offline")
+	cacheTTL = viper.GetDuration("cacheTTL")
+	acceptTypes = viper.GetStringSlice("acceptTypes")
+	if maxDownloadSize, err = parseSize(viper.GetString("maxDownloadSize")); err != nil {
+		return err
+	}
+	if httpClient, err = newHTTPClient(httpOptions{
+		Proxy:              viper.GetString("httpProxy"),
+		UserAgent:          viper.GetString("userAgent"),
+		Headers:            viper.GetStringMapString("httpHeaders"),
+		CACert:             viper.GetString("tlsCACert"),
+		ClientCert:         viper.GetString("tlsClientCert"),
+		ClientKey:          viper.GetString("tlsClientKey"),
+		InsecureSkipVerify: viper.GetBool("tlsInsecureSkipVerify"),
+		Timeout:            viper.GetDuration("httpTimeout"),
+		MaxRedirects:       viper.GetInt("maxRedirects"),
+	}); err != nil {
+		return err
+	}
+	if debug {
+		httpClient = withRequestLog(httpClient)
+	}
+	httpClient = withRetries(httpClient)
 
 	if pager && tui {
 		return errors.New("cannot use both pager and tui")
@@ -179,6 +460,9 @@ func validateOptions(cmd *cobra.Command) error {
 	if stream && pager {
 		return errors.New("cannot use both stream and pager")
 	}
+	if openLinks && (pager || tui || stream) {
+		return errors.New("cannot use open-links with pager, tui or stream")
+	}
 	if stream && tui {
 		return errors.New("cannot use both stream and tui")
 	}
@@ -188,30 +472,47 @@ func validateOptions(cmd *cobra.Command) error {
 	if err := validateStyle(style); err != nil {
 		return err
 	}
+	overrides := viper.GetStringMap("styleOverrides")
+	if overrides == nil {
+		overrides = map[string]any{}
+	}
+	for _, set := range styleSets {
+		if err := setStyleOverride(overrides, set); err != nil {
+			return err
+		}
+	}
+	utils.StyleOverrides = overrides
 
-	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
-	// We want to use a special no-TTY style, when stdout is not a terminal
-	// and there was no specific style passed by arg
-	if !isTerminal && !cmd.Flags().Changed("style") {
-		style = "notty"
+	utils.CodeTheme = viper.GetString("codeTheme")
+	if err := validateCodeTheme(utils.CodeTheme); err != nil {
+		return err
 	}
 
-	// Detect terminal width
-	if !cmd.Flags().Changed("width") { //nolint:nestif
-		if isTerminal && width == 0 {
-			w, _, err := term.GetSize(int(os.Stdout.Fd()))
-			if err == nil {
-				width = uint(w) //nolint:gosec
-			}
+	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
+	colorMode = viper.GetString("color")
+	if err := validateColorMode(colorMode); err != nil {
+		return err
+	}
+	profile := colorProfile(colorMode, isTerminal)
+	lipgloss.SetColorProfile(profile)
+	hyperlinks = viper.GetString("hyperlinks")
+	if err := validateHyperlinksMode(hyperlinks); err != nil {
+		return err
+	}
+	// Pagers may not pass hyperlinks through, so only emit them there if asked.
+	useHyperlinks = hyperlinksEnabled(hyperlinks, isTerminal && !pager && !tui)
+	imagesMode = viper.GetString("images")
+	if err := validateImagesMode(imagesMode); err != nil {
+		return err
+	}
+	useImages = imageProtocol(imagesMode, isTerminal && !pager && !tui)
+	style = documentStyle(style, cmd.Flags().Changed("style"), isTerminal, profile)
 
-			if width > 120 {
-				width = 120
-			}
-		}
-		if width == 0 {
-			width = 80
-		}
+	// Detect terminal width, up to 120 columns unless asked for otherwise
+	if widthOpt == (widthSpec{}) && !cmd.Flags().Changed("width") {
+		widthOpt = widthSpec{percent: 100, max: defaultMaxWidth}
 	}
+	width = widthOpt.resolve(terminalWidth())
 	return nil
 }
 
@@ -227,6 +528,19 @@ func stdinIsPipe() (bool, error) {
 }
 
 func execute(cmd *cobra.Command, args []string) error {
+	if ghExtension {
+		var err error
+		if args, err = ghArgs(args); err != nil {
+			return err
+		}
+	}
+	if gitRef != "" {
+		var err error
+		if args, err = gitObjectArgs(gitRef, args); err != nil {
+			return err
+		}
+	}
+
 	if stream {
 		if len(args) > 1 {
 			return errors.New("stream mode accepts only stdin as source")
@@ -248,6 +562,35 @@ func execute(cmd *cobra.Command, args []string) error {
 		return executeStreamCLI(src, os.Stdout)
 	}
 
+	if fromClipboard {
+		if len(args) > 0 {
+			return errors.New("--from-clipboard takes no sources")
+		}
+		content, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		if tui || cmd.Flags().Changed("tui") {
+			return runTUI("", content, "")
+		}
+		return executeCLI(cmd, &source{reader: io.NopCloser(strings.NewReader(content))}, os.Stdout)
+	}
+
+	if sourcesFile != "" {
+		list, err := readSources(sourcesFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, list...)
+		if tui || cmd.Flags().Changed("tui") {
+			return runSourcesTUI(args)
+		}
+		if len(args) == 1 {
+			return executeArg(cmd, args[0], os.Stdout)
+		}
+		return executeArgs(cmd, args, os.Stdout)
+	}
+
 	// if stdin is a pipe then use stdin for input. note that you can also
 	// explicitly use a - to read from stdin.
 	if yes, err := stdinIsPipe(); err != nil {
@@ -258,39 +601,73 @@ func execute(cmd *cobra.Command, args []string) error {
 		return executeCLI(cmd, src, os.Stdout)
 	}
 
+	if recursive || slices.ContainsFunc(args, isGlob) {
+		stop := timePhase("discovery")
+		files, err := expandArgs(args, recursive, depth)
+		stop()
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return errors.New("no matching files found")
+		}
+		if len(files) == 1 {
+			return executeArg(cmd, files[0], os.Stdout)
+		}
+		return executeArgs(cmd, files, os.Stdout)
+	}
+
 	switch len(args) {
 	// TUI running on cwd
 	case 0:
-		return runTUI("", "")
+		return runTUI("", "", "")
 
 	// TUI with possible dir argument
 	case 1:
 		// Validate that the argument is a directory. If it's not treat it as
-		// an argument to the non-TUI version of Glow (via fallthrough).
+		// an argument to the non-TUI version of Glow.
 		info, err := os.Stat(args[0])
 		if err == nil && info.IsDir() {
 			p, err := filepath.Abs(args[0])
 			if err == nil {
-				return runTUI(p, "")
+				return runTUI(p, "", "")
 			}
 		}
-		fallthrough
+		return executeArg(cmd, args[0], os.Stdout)
 
-	// CLI
+	// CLI with multiple sources
 	default:
-		for _, arg := range args {
-			if err := executeArg(cmd, arg, os.Stdout); err != nil {
-				return err
-			}
-		}
+		return executeArgs(cmd, args, os.Stdout)
 	}
-
-	return nil
 }
 
 func executeArg(cmd *cobra.Command, arg string, w io.Writer) error {
+	// list the markdown files of archives
+	if (tui || cmd.Flags().Changed("tui")) && isArchiveArg(arg) {
+		return runArchiveTUI(arg)
+	}
+
+	// download remote documents from within the TUI, so we can show progress
+	if (tui || cmd.Flags().Changed("tui")) && isRemoteArg(arg) {
+		if repo, page, ok := githubWikiArg(arg); ok {
+			return runWikiTUI(repo, page)
+		}
+		// list the documents of repositories, or the entries of feeds, with --all
+		if cmd.Flags().Changed("all") && showAllFiles {
+			if repoTreeArg(arg) {
+				return runRepoTUI(arg)
+			}
+			if feed, err := fetchFeed(arg); err == nil && feed != nil {
+				return runFeedTUI(feed)
+			}
+		}
+		return runTUI("", "", arg)
+	}
+
 	// create an io.Reader from the markdown source in cli-args
+	stop := timePhase("fetch")
 	src, err := sourceFromArg(arg)
+	stop()
 	if err != nil {
 		return err
 	}
@@ -299,81 +676,293 @@ func executeArg(cmd *cobra.Command, arg string, w io.Writer) error {
 }
 
 func executeCLI(cmd *cobra.Command, src *source, w io.Writer) error {
+	start := time.Now()
+	content, err := readContent(src)
+	if err != nil {
+		return err
+	}
+	log.Debug("read", "source", src.URL, "bytes", len(content), "duration", time.Since(start))
+	start, stop := time.Now(), timePhase("render")
+	out, err := renderContent(content, sourceName(src))
+	if err != nil {
+		return err
+	}
+	if out, err = postRender(out, src.URL); err != nil {
+		return err
+	}
+	stop()
+	log.Debug("rendered", "source", src.URL, "width", width, "duration", time.Since(start))
+
+	path := ""
+	if !isURL(src.URL) {
+		path = src.URL
+	}
+	if !openLinks {
+		return display(cmd, out, content, path, w)
+	}
+	r, err := promptTerminal()
+	if err != nil {
+		return err
+	}
+	defer r.Close() //nolint:errcheck
+	if err := display(cmd, out, content, path, w); err != nil {
+		return err
+	}
+	return promptOpenLinks(src.links, r, os.Stderr)
+}
+
+// executeArgs renders multiple sources, one after another, each with a
+// header showing its path.
+func executeArgs(cmd *cobra.Command, args []string, w io.Writer) error {
+	if openLinks {
+		return errors.New("--open-links takes a single document")
+	}
+	var out, content strings.Builder
+	for _, arg := range args {
+		stop := timePhase("fetch")
+		src, err := sourceFromArg(arg)
+		stop()
+		if err != nil {
+			return err
+		}
+		c, err := readContent(src)
+		_ = src.reader.Close()
+		if errors.Is(err, errSectionNotFound) {
+			// Only show the files that have the section.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		start, stop := time.Now(), timePhase("render")
+		o, err := renderContent(c, sourceName(src))
+		if err != nil {
+			return err
+		}
+		if o, err = postRender(o, src.URL); err != nil {
+			return err
+		}
+		stop()
+		log.Debug("rendered", "source", src.URL, "width", width, "duration", time.Since(start))
+
+		out.WriteString(renderSeparator(arg) + o)
+		content.WriteString(markdownSeparator(arg) + c + "\n\n")
+	}
+	if out.Len() == 0 && section != "" {
+		return fmt.Errorf("%w: %s", errSectionNotFound, section)
+	}
+	return display(cmd, out.String(), content.String(), "", w)
+}
+
+var errSectionNotFound = errors.New("section not found")
+
+// readContent reads a source as markdown. Source code is wrapped in a code
+// block.
+func readContent(src *source) (string, error) {
+	stop := timePhase("fetch")
 	b, err := io.ReadAll(src.reader)
+	stop()
 	if err != nil {
-		return fmt.Errorf("unable to read from reader: %w", err)
+		return "", fmt.Errorf("unable to read from reader: %w", err)
+	}
+	defer timePhase("parse")()
+	name := sourceName(src)
+	if name == "" && utils.IsJSON(b) {
+		// JSON piped from web APIs and other tools
+		name = "-.json"
+	}
+	if comma, ok := tableFormat(name); ok {
+		return tableMarkdown(b, comma)
+	}
+	b, err = utils.ConvertToMarkdown(b, name)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	if src.webPage || utils.IsMarkdownFile(name) {
+		if b, err = preRender(b, src.URL); err != nil {
+			return "", err
+		}
 	}
 
+	smart := utils.SmartypantsEnabled(b)
+	var header, meta string
+	if !tui {
+		meta = frontmatterMarkdown(b, frontmatterMode)
+	}
+	if showHeader && !tui && (src.webPage || utils.IsMarkdownFile(name)) {
+		header = documentHeader(b, src.URL)
+	}
 	b = utils.RemoveFrontmatter(b)
+	if !src.webPage && !utils.IsMarkdownFile(name) {
+		return utils.CodeBlock(string(b), name), nil
+	}
+	if section != "" {
+		s, ok := utils.Section(b, section)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", errSectionNotFound, section)
+		}
+		// The front matter describes the whole document, not the section.
+		b, meta = s, ""
+	}
+	if smart {
+		b = utils.Smarten(b)
+	}
+	if linkMode == linksNumbered && !tui {
+		if openLinks {
+			src.links = utils.LinkReferences(b, renderBaseURL(src.URL))
+		}
+		b = utils.NumberLinks(b, renderBaseURL(src.URL))
+	}
+	b = utils.PrepareMarkdown(b)
+	// The TUI shows the table of contents in its outline sidebar instead.
+	if toc > 0 && !tui {
+		return header + meta + tocMarkdown(utils.TableOfContents(b, toc)) + string(b), nil
+	}
+	return header + meta + string(b), nil
+}
 
-	// render
-	var baseURL string
-	u, err := url.ParseRequestURI(src.URL)
-	if err == nil {
-		u.Path = filepath.Dir(u.Path)
-		baseURL = u.String() + "/"
+// sourceName returns the name of a source its format is told by: its path
+// or URL, with the extension given by --as if set.
+func sourceName(src *source) string {
+	if inputFormat == "" {
+		return src.URL
 	}
+	return strings.TrimSuffix(src.URL, filepath.Ext(src.URL)) + "." + strings.TrimPrefix(inputFormat, ".")
+}
 
-	isCode := !utils.IsMarkdownFile(src.URL)
+// renderContent renders markdown read from the given source URL.
+func renderContent(content string, srcURL string) (string, error) {
+	baseURL := renderBaseURL(srcURL)
+	isCode := !utils.IsMarkdownFile(srcURL)
 
 	// initialize glamour
-	r, err := glamour.NewTermRenderer(
+	options := []glamour.TermRendererOption{
 		glamour.WithColorProfile(lipgloss.ColorProfile()),
 		utils.GlamourStyle(style, isCode),
 		glamour.WithWordWrap(int(width)), //nolint:gosec
 		glamour.WithBaseURL(baseURL),
 		glamour.WithPreservedNewLines(),
-	)
+	}
+	if utils.CodeLineNumbers {
+		options = append(options, utils.WithCodeLineNumbers(style, isCode, int(width), lipgloss.ColorProfile())) //nolint:gosec
+	}
+	r, err := glamour.NewTermRenderer(options...)
 	if err != nil {
-		return fmt.Errorf("unable to create renderer: %w", err)
+		return "", fmt.Errorf("unable to create renderer: %w", err)
 	}
 
-	content := string(b)
-	ext := filepath.Ext(src.URL)
+	out, err := r.RenderBytes(utils.PrepareDefinitionLists([]byte(content)))
+	if err != nil {
+		return "", fmt.Errorf("unable to render markdown: %w", err)
+	}
 	if isCode {
-		content = utils.WrapCodeBlock(string(b), ext)
+		return string(out), nil
 	}
+	rendered := string(out)
+	if useHyperlinks {
+		rendered = addHyperlinks(rendered, content, srcURL)
+	}
+	if useImages != "" {
+		rendered = addImages(rendered, content, srcURL, useImages)
+	}
+	return rendered, nil
+}
 
-	out, err := r.Render(content)
+// renderBaseURL returns the URL relative links of a document are resolved
+// against when rendering it.
+func renderBaseURL(srcURL string) string {
+	u, err := url.ParseRequestURI(srcURL)
 	if err != nil {
-		return fmt.Errorf("unable to render markdown: %w", err)
+		return ""
 	}
+	u.Path = filepath.Dir(u.Path)
+	return u.String() + "/"
+}
 
-	// display
+// display shows rendered output in the pager, the TUI or writes it to w.
+func display(cmd *cobra.Command, out, content, path string, w io.Writer) error {
+	if !tui && !cmd.Flags().Changed("tui") || pager || cmd.Flags().Changed("pager") {
+		b, err := filterOutput([]byte(out), "ansi")
+		if err != nil {
+			return err
+		}
+		out = string(b)
+	}
 	switch {
 	case pager || cmd.Flags().Changed("pager"):
-		pagerCmd := os.Getenv("PAGER")
-		if pagerCmd == "" {
-			pagerCmd = "less -r"
-		}
-
-		pa := strings.Split(pagerCmd, " ")
-		c := exec.Command(pa[0], pa[1:]...) //nolint:gosec
-		c.Stdin = strings.NewReader(out)
-		c.Stdout = os.Stdout
-		if err := c.Run(); err != nil {
-			return fmt.Errorf("unable to run command: %w", err)
-		}
-		return nil
+		return runPager(out)
 	case tui || cmd.Flags().Changed("tui"):
-		path := ""
-		if !isURL(src.URL) {
-			path = src.URL
-		}
-		return runTUI(path, content)
+		return runTUI(path, content, "")
+	case paginate && w == os.Stdout && exceedsTerminal(out):
+		return runPager(out)
 	default:
-		if _, err = fmt.Fprint(w, out); err != nil {
+		defer timePhase("write")()
+		if _, err := fmt.Fprint(w, out); err != nil {
 			return fmt.Errorf("unable to write to writer: %w", err)
 		}
 		return nil
 	}
 }
 
-func runTUI(path string, content string) error {
+// runPager shows rendered output in $PAGER, or less.
+func runPager(out string) error {
+	pagerCmd := os.Getenv("PAGER")
+	if pagerCmd == "" {
+		pagerCmd = "less -r"
+	}
+
+	pa := strings.Split(pagerCmd, " ")
+	c := exec.Command(pa[0], pa[1:]...) //nolint:gosec
+	c.Stdin = strings.NewReader(out)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("unable to run command: %w", err)
+	}
+	return nil
+}
+
+// exceedsTerminal returns whether output is taller than the terminal stdout
+// is, like git decides whether to page. It's false if stdout isn't a
+// terminal.
+func exceedsTerminal(out string) bool {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return false
+	}
+	_, height, err := term.GetSize(fd)
+	if err != nil {
+		return false
+	}
+	return strings.Count(out, "\n") >= height
+}
+
+func runTUI(path string, content string, remote string) error {
+	cfg, err := tuiConfig(path, remote)
+	if err != nil {
+		return err
+	}
+	return runProgram(cfg, content)
+}
+
+// runRemoteFilesTUI lists documents read when they're opened in the TUI, like
+// those of a remote repository or the entries of a feed.
+func runRemoteFilesTUI(files []ui.RemoteFile) error {
+	cfg, err := tuiConfig("", "")
+	if err != nil {
+		return err
+	}
+	cfg.RemoteFiles = files
+	return runProgram(cfg, "")
+}
+
+// tuiConfig returns the configuration of the TUI, showing the given path or
+// remote document.
+func tuiConfig(path string, remote string) (ui.Config, error) {
 	// Read environment to get debugging stuff
 	cfg, err := env.ParseAs[ui.Config]()
 	if err != nil {
-		return fmt.Errorf("error parsing config: %v", err)
+		return cfg, fmt.Errorf("error parsing config: %v", err)
 	}
 
 	// use style set in env, or auto if unset
@@ -382,12 +971,58 @@ func runTUI(path string, content string) error {
 	}
 
 	cfg.Path = path
+	if remote != "" {
+		cfg.Remote = remote
+		cfg.Fetch = func() (io.ReadCloser, string, error) {
+			src, err := sourceFromArg(remote)
+			if err != nil {
+				return nil, "", err
+			}
+			return src.reader, src.URL, nil
+		}
+	}
 	cfg.ShowAllFiles = showAllFiles
 	cfg.ShowLineNumbers = showLineNumbers
 	cfg.GlamourMaxWidth = width
 	cfg.EnableMouse = mouse
 	cfg.PreserveNewLines = preserveNewLines
+	cfg.MarginLeft = max(0, viper.GetInt("marginLeft"))
+	cfg.MarginRight = max(0, viper.GetInt("marginRight"))
+	cfg.MaxWidth = viper.GetUint("maxWidth")
+	cfg.Padding = max(0, viper.GetInt("padding"))
+	cfg.TOCDepth = toc
+	cfg.WikiLinks = wikiLinks
+	cfg.Exclude = excludePatterns
+	cfg.HTTPTransport = httpClient.Transport
+	cfg.OutputFilter = func(out string) (string, error) {
+		b, err := filterOutput([]byte(out), "ansi")
+		return string(b), err
+	}
+	if accessible {
+		cfg.Accessible = true
+		cfg.GlamourStyle = utils.HighContrastStyle
+		cfg.HighPerformancePager = false
+	}
+	cfg.NoteHeading = viper.GetString("noteHeading")
+	cfg.NoteInbox = viper.GetString("noteInbox")
+	if locale := viper.GetString("locale"); locale != "" {
+		cfg.Locale = locale
+	}
+	if layout := viper.GetString("dateFormat"); layout != "" {
+		cfg.DateFormat = layout
+	}
+	if lang := viper.GetString("spellLang"); lang != "" {
+		cfg.SpellLang = lang
+	}
+	cfg.DictionaryPath = viper.GetString("dictionaries")
+	if cfg.DictionaryPath == "" {
+		cfg.DictionaryPath = filepath.Join(filepath.Dir(configFilePath()), "dictionaries")
+	}
+	return cfg, nil
+}
 
+func runProgram(cfg ui.Config, content string) error {
+	logToFileForTUI()
 	// Run Bubble Tea program
 	if _, err := ui.NewProgram(cfg, content).Run(); err != nil {
 		return fmt.Errorf("unable to run tui program: %w", err)
@@ -402,11 +1037,26 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	if err := rootCmd.Execute(); err != nil {
-		_ = closer()
+	registerCompletions()
+	setHelp(rootCmd)
+	if isGHExtension(os.Args[0]) {
+		ghExtension = true
+		rootCmd.Use = "gh glow [OWNER/REPO[#NUMBER]|NUMBER|GIST|SOURCE...]"
+	}
+	args, err := expandAlias(os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	rootCmd.SetArgs(args[1:])
+	err = rootCmd.Execute()
+	if perr := stopProfile(os.Stderr); perr != nil {
+		fmt.Fprintln(os.Stderr, perr)
+	}
 	_ = closer()
+	if err != nil {
+		os.Exit(1)
+	}
 }
 
 func init() {
@@ -424,18 +1074,64 @@ func init() {
 	// "Glow Classic" cli arguments
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", fmt.Sprintf("config file (default %s)", viper.GetViper().ConfigFileUsed()))
 	rootCmd.Flags().BoolVarP(&pager, "pager", "p", false, "display with pager")
+	rootCmd.Flags().BoolVar(&openLinks, "open-links", false, "number the links of the document and ask which to open once it's shown")
+	rootCmd.Flags().BoolVar(&paginate, "paginate", false, "display with pager when the output is taller than the terminal")
 	rootCmd.Flags().BoolVarP(&tui, "tui", "t", false, "display with tui")
 	rootCmd.Flags().StringVarP(&style, "style", "s", styles.AutoStyle, "style name or JSON path")
-	rootCmd.Flags().UintVarP(&width, "width", "w", 0, "word-wrap at width (set to 0 to disable)")
+	rootCmd.PersistentFlags().StringVar(&codeTheme, "code-theme", "", "syntax highlighting theme of code blocks, like monokai (default from style)")
+	rootCmd.PersistentFlags().BoolVar(&detectLanguage, "detect-language", true, "guess the language of code blocks without one, for highlighting")
+	rootCmd.PersistentFlags().BoolVar(&smartypants, "smartypants", false, "use typographic quotes, dashes and ellipses (front matter can override)")
+	rootCmd.PersistentFlags().BoolVar(&readability, "readability", false, "read web pages in reader view, without link lists and with their byline")
+	rootCmd.PersistentFlags().BoolVar(&hardBreaks, "hard-breaks", false, "keep line breaks within paragraphs instead of reflowing them")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", colorAuto, "use colors: auto, always or never (auto honors NO_COLOR and CLICOLOR_FORCE)")
+	rootCmd.PersistentFlags().BoolVar(&prettyData, "pretty", false, "pretty-print JSON, YAML and TOML files")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "read remote documents from the cache only")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", 0, "read remote documents from the cache when fetched within this long, like 1h")
+	rootCmd.PersistentFlags().BoolVar(&codeLineNumbers, "code-line-numbers", false, "show line numbers in code blocks")
+	rootCmd.PersistentFlags().StringArrayVar(&styleSets, "style-set", nil, "override a style element, like h2.color=212 (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&aliasSets, "alias", nil, "replace a shortcode or abbreviation in documents, like WIP=\"Work in progress\" (repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "log what glow does and how long it takes to stderr, or --log-file")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "file to log to (default glow.log in the state directory)")
+	rootCmd.PersistentFlags().BoolVar(&profile, "profile", false, "print how long discovering, fetching, parsing, rendering and writing took to stderr")
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpu-profile", "", "write a pprof CPU profile to file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "mem-profile", "", "write a pprof memory profile to file")
+	rootCmd.PersistentFlags().StringVar(&cacheHome, "cache-home", "", "directory to cache remote documents, images and fence output in")
+	rootCmd.Flags().VarP(newWidthValue(&width), "width", "w", `word-wrap at width in columns, a percentage of the terminal's or "auto", optionally capped like "90%,max=100", also --wrap (set to 0 to disable)`)
+	rootCmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		// --wrap=0 reads better than --width=0 when piping unwrapped output.
+		if name == "wrap" {
+			name = "width"
+		}
+		return pflag.NormalizedName(name)
+	})
 	rootCmd.Flags().BoolVarP(&showAllFiles, "all", "a", false, "show system files and directories (TUI-mode only)")
 	rootCmd.Flags().BoolVarP(&showLineNumbers, "line-numbers", "l", false, "show line numbers (TUI-mode only)")
 	rootCmd.Flags().BoolVarP(&preserveNewLines, "preserve-new-lines", "n", false, "preserve newlines in the output")
 	rootCmd.Flags().BoolVarP(&mouse, "mouse", "m", false, "enable mouse wheel (TUI-mode only)")
 	rootCmd.Flags().BoolVar(&stream, "stream", false, "stream markdown from stdin to stdout (append-only; fixed-width table rendering)")
+	rootCmd.Flags().BoolVar(&accessible, "accessible", false, "accessibility mode: high contrast, no animations or alternate screen")
+	rootCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "render all markdown files in directories, recursively")
+	rootCmd.Flags().IntVar(&depth, "depth", 0, "maximum directory depth of --recursive and ** patterns (0 for no limit)")
+	rootCmd.Flags().StringVar(&sourcesFile, "sources", "", "read sources from a file, one per line, or from stdin with -")
+	rootCmd.Flags().BoolVar(&fromClipboard, "from-clipboard", false, "render the contents of the clipboard")
+	rootCmd.Flags().StringVar(&gitRef, "ref", "", "render files as they are at a git ref, like HEAD~3 or v1.0.0")
+	rootCmd.Flags().StringVar(&section, "section", "", `only render the section under a heading, given by title, anchor or path like "Usage/Docker"`)
+	rootCmd.Flags().IntVar(&toc, "toc", 0, "show a table of contents with headings down to the given depth")
+	rootCmd.Flags().Lookup("toc").NoOptDefVal = "6"
+	rootCmd.Flags().BoolVar(&showHeader, "header", false, "show a header with the title, date, reading time and source of documents")
+	rootCmd.Flags().StringVar(&frontmatterMode, "frontmatter", frontmatterHide, "show the front matter of documents: hide, raw or table")
+	rootCmd.Flags().BoolVar(&wikiLinks, "wiki-links", false, "resolve [[wiki links]] against the markdown files found (TUI-mode only)")
+	rootCmd.Flags().StringVar(&hyperlinks, "hyperlinks", hyperlinksAuto, "make links clickable in terminals that support it: auto, always or never")
+	rootCmd.Flags().StringVar(&linkMode, "links", linksInline, "show link URLs inline, or numbered, like text[1], with a list of references at the end")
+	rootCmd.Flags().StringVar(&imagesMode, "images", imagesNever, "show images in terminals that support it: never, auto, kitty, iterm or sixel")
+	rootCmd.Flags().StringVar(&inputFormat, "as", "", "read sources as the format of the given file extension, like csv, rst or go (default from the extension)")
+	rootCmd.Flags().IntVar(&releaseCount, "releases", 1, "number of latest releases shown of repo@releases sources")
+	rootCmd.Flags().StringVar(&separator, "separator", "", `header shown before each of multiple files, {path} is replaced by the file's path ("none" to disable)`)
 	_ = rootCmd.Flags().MarkHidden("mouse")
 
 	// Config bindings
 	_ = viper.BindPFlag("pager", rootCmd.Flags().Lookup("pager"))
+	_ = viper.BindPFlag("paginate", rootCmd.Flags().Lookup("paginate"))
 	_ = viper.BindPFlag("tui", rootCmd.Flags().Lookup("tui"))
 	_ = viper.BindPFlag("style", rootCmd.Flags().Lookup("style"))
 	_ = viper.BindPFlag("width", rootCmd.Flags().Lookup("width"))
@@ -445,12 +1141,41 @@ func init() {
 	_ = viper.BindPFlag("showLineNumbers", rootCmd.Flags().Lookup("line-numbers"))
 	_ = viper.BindPFlag("all", rootCmd.Flags().Lookup("all"))
 	_ = viper.BindPFlag("stream", rootCmd.Flags().Lookup("stream"))
+	_ = viper.BindPFlag("accessible", rootCmd.Flags().Lookup("accessible"))
+	_ = viper.BindPFlag("separator", rootCmd.Flags().Lookup("separator"))
+	_ = viper.BindPFlag("frontmatter", rootCmd.Flags().Lookup("frontmatter"))
+	_ = viper.BindPFlag("header", rootCmd.Flags().Lookup("header"))
+	_ = viper.BindPFlag("hyperlinks", rootCmd.Flags().Lookup("hyperlinks"))
+	_ = viper.BindPFlag("links", rootCmd.Flags().Lookup("links"))
+	_ = viper.BindPFlag("images", rootCmd.Flags().Lookup("images"))
+	_ = viper.BindPFlag("wikiLinks", rootCmd.Flags().Lookup("wiki-links"))
+	_ = viper.BindPFlag("codeTheme", rootCmd.PersistentFlags().Lookup("code-theme"))
+	_ = viper.BindPFlag("detectLanguage", rootCmd.PersistentFlags().Lookup("detect-language"))
+	_ = viper.BindPFlag("smartypants", rootCmd.PersistentFlags().Lookup("smartypants"))
+	_ = viper.BindPFlag("readability", rootCmd.PersistentFlags().Lookup("readability"))
+	_ = viper.BindPFlag("hardBreaks", rootCmd.PersistentFlags().Lookup("hard-breaks"))
+	_ = viper.BindPFlag("color", rootCmd.PersistentFlags().Lookup("color"))
+	_ = viper.BindPFlag("pretty", rootCmd.PersistentFlags().Lookup("pretty"))
+	_ = viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+	_ = viper.BindPFlag("cacheTTL", rootCmd.PersistentFlags().Lookup("cache-ttl"))
+	_ = viper.BindPFlag("codeLineNumbers", rootCmd.PersistentFlags().Lookup("code-line-numbers"))
 
 	viper.SetDefault("style", styles.AutoStyle)
 	viper.SetDefault("width", 0)
 	viper.SetDefault("all", true)
+	viper.SetDefault("detectLanguage", true)
+	viper.SetDefault("frontmatter", frontmatterHide)
+	viper.SetDefault("hyperlinks", hyperlinksAuto)
+	viper.SetDefault("links", linksInline)
+	viper.SetDefault("images", imagesNever)
+	viper.SetDefault("noteHeading", "Notes")
+	viper.SetDefault("maxRedirects", defaultMaxRedirects)
+	viper.SetDefault("hookTimeout", defaultHookTimeout)
+	viper.SetDefault("redactReplacement", utils.DefaultRedactReplacement)
+	viper.SetDefault("fenceTimeout", defaultHookTimeout)
+	viper.SetDefault("fenceCache", true)
 
-	rootCmd.AddCommand(configCmd, manCmd)
+	rootCmd.AddCommand(configCmd, manCmd, exportCmd, styleCmd, astCmd, grepCmd, diffCmd, tocCmd, metaCmd, statsCmd, cacheCmd, serveCmd, mcpCmd)
 }
 
 func tryLoadConfigFromDefaultPlaces() {
@@ -483,6 +1208,7 @@ func tryLoadConfigFromDefaultPlaces() {
 			log.Warn("Could not parse configuration file", "err", err)
 		}
 	}
+	loadProjectConfig()
 
 	if used := viper.ConfigFileUsed(); used != "" {
 		log.Debug("Using configuration file", "path", viper.ConfigFileUsed())
@@ -2,28 +2,83 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	mcobra "github.com/muesli/mango-cobra"
 	"github.com/muesli/roff"
 	"github.com/spf13/cobra"
 )
 
+var manDir string
+
 var manCmd = &cobra.Command{
 	Use:                   "man",
 	Short:                 "Generates manpages",
+	Long:                  paragraph("\nGenerates the manpage of glow and its commands. With --dir, each command gets a manpage of its own, like glow-export.1."),
+	Example:               paragraph("glow man > glow.1\nglow man --dir ./man"),
 	SilenceUsage:          true,
 	DisableFlagsInUseLine: true,
 	Hidden:                true,
 	Args:                  cobra.NoArgs,
 	RunE: func(*cobra.Command, []string) error {
-		manPage, err := mcobra.NewManPage(1, rootCmd)
-		if err != nil {
-			return fmt.Errorf("unable to instantiate man page: %w", err)
-		}
-		if _, err := fmt.Fprint(os.Stdout, manPage.Build(roff.NewDocument())); err != nil {
-			return fmt.Errorf("unable to build man page: %w", err)
+		if manDir != "" {
+			return writeManPages(manDir, rootCmd)
 		}
-		return nil
+		return writeManPage(os.Stdout, rootCmd)
 	},
 }
+
+// writeManPage writes the manpage of a command, with its subcommands.
+func writeManPage(w io.Writer, cmd *cobra.Command) error {
+	manPage, err := mcobra.NewManPage(1, cmd)
+	if err != nil {
+		return fmt.Errorf("unable to instantiate man page: %w", err)
+	}
+	// Pages of subcommands are named after their command path.
+	manPage.Root.Name = manPageName(cmd)
+	if _, err := fmt.Fprint(w, manPage.Build(roff.NewDocument())); err != nil {
+		return fmt.Errorf("unable to build man page: %w", err)
+	}
+	return nil
+}
+
+// writeManPages writes the manpages of a command and each of its visible
+// subcommands to a directory.
+func writeManPages(dir string, cmd *cobra.Command) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec
+		return fmt.Errorf("unable to create man page directory: %w", err)
+	}
+	f, err := os.Create(filepath.Join(dir, manPageName(cmd)+".1"))
+	if err != nil {
+		return fmt.Errorf("unable to create man page: %w", err)
+	}
+	if err := writeManPage(f, cmd); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write man page: %w", err)
+	}
+	for _, sub := range cmd.Commands() {
+		if !sub.IsAvailableCommand() {
+			continue
+		}
+		if err := writeManPages(dir, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manPageName returns the name of the manpage of a command, like
+// glow-cache-list.
+func manPageName(cmd *cobra.Command) string {
+	return strings.ReplaceAll(cmd.CommandPath(), " ", "-")
+}
+
+func init() {
+	manCmd.Flags().StringVar(&manDir, "dir", "", "write a manpage for each command to this directory")
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// mcpProtocolVersion is the latest version of the Model Context Protocol the
+// server speaks.
+const mcpProtocolVersion = "2025-06-18"
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve glow's rendering to coding agents over MCP",
+	Long: paragraph(fmt.Sprintf("\n%s a Model Context Protocol server on stdin and stdout, with tools rendering markdown, previewing files and streaming previews, so agents can show nicely rendered results.",
+		keyword("Run"))),
+	Example: paragraph("glow mcp"),
+	Args:    cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return newMCPServer().serve(os.Stdin, os.Stdout)
+	},
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// mcpTool is a tool offered to agents.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// mcpRenderArgs are the arguments of the tools rendering documents.
+type mcpRenderArgs struct {
+	Markdown string `json:"markdown"`
+	Path     string `json:"path"`
+	Format   string `json:"format"`
+	Width    *uint  `json:"width"`
+	Style    string `json:"style"`
+}
+
+// mcpStreamArgs are the arguments of stream_preview.
+type mcpStreamArgs struct {
+	Session string `json:"session"`
+	Chunk   string `json:"chunk"`
+	Final   bool   `json:"final"`
+}
+
+// mcpServer serves MCP requests, one JSON-RPC message per line.
+type mcpServer struct {
+	streams map[string]*streamRenderer
+}
+
+func newMCPServer() *mcpServer {
+	return &mcpServer{streams: map[string]*streamRenderer{}}
+}
+
+func (s *mcpServer) serve(r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRenderRequestSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			if err := enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, "parse error"}}); err != nil {
+				return fmt.Errorf("unable to write response: %w", err)
+			}
+			continue
+		}
+		if len(req.ID) == 0 {
+			// notifications, like notifications/initialized, need no
+			// response
+			continue
+		}
+
+		res := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+		result, err := s.handle(req)
+		var rpcErr *rpcError
+		switch {
+		case errors.As(err, &rpcErr):
+			res.Error = rpcErr
+		case err != nil:
+			res.Error = &rpcError{rpcInvalidRequest, err.Error()}
+		default:
+			res.Result = result
+		}
+		if err := enc.Encode(res); err != nil {
+			return fmt.Errorf("unable to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read request: %w", err)
+	}
+	return nil
+}
+
+func (s *mcpServer) handle(req rpcRequest) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := params.ProtocolVersion
+		if version == "" || version > mcpProtocolVersion {
+			version = mcpProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "glow", "version": Version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools()}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		text, err := s.callTool(params.Name, params.Arguments)
+		if errors.Is(err, errUnknownTool) {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		if err != nil {
+			// Failing tools are reported to the agent, not as protocol
+			// errors.
+			return mcpToolResult(err.Error(), true), nil
+		}
+		return mcpToolResult(text, false), nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, "method not found: " + req.Method}
+}
+
+var errUnknownTool = errors.New("unknown tool")
+
+func mcpToolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func mcpTools() []mcpTool {
+	renderOptions := map[string]any{
+		"format": map[string]any{"type": "string", "enum": exportFormats(), "description": "output format, ansi by default"},
+		"width":  map[string]any{"type": "integer", "minimum": 0, "description": "word-wrap width, 80 by default"},
+		"style":  map[string]any{"type": "string", "description": "glamour style name or JSON path, the configured one by default"},
+	}
+	withProperty := func(name string, schema map[string]any) map[string]any {
+		props := map[string]any{name: schema}
+		for k, v := range renderOptions {
+			props[k] = v
+		}
+		return map[string]any{"type": "object", "properties": props, "required": []string{name}}
+	}
+	return []mcpTool{
+		{
+			Name:        "render_markdown",
+			Description: "Render markdown to show to the user, styled like glow renders it in the terminal.",
+			InputSchema: withProperty("markdown", map[string]any{"type": "string", "description": "markdown to render"}),
+		},
+		{
+			Name:        "preview_file",
+			Description: "Render a document glow can read, like a local file, a URL or a GitHub repository's README.",
+			InputSchema: withProperty("path", map[string]any{"type": "string", "description": "path or URL of the document"}),
+		},
+		{
+			Name:        "stream_preview",
+			Description: "Render markdown written in chunks, like a response being generated. Returns the rendered lines added since the previous chunk of the session.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"session": map[string]any{"type": "string", "description": "name of the preview, chunks of a session are rendered together"},
+					"chunk":   map[string]any{"type": "string", "description": "markdown appended to the preview"},
+					"final":   map[string]any{"type": "boolean", "description": "whether this is the last chunk, which ends the session"},
+				},
+				"required": []string{"session", "chunk"},
+			},
+		},
+	}
+}
+
+func (s *mcpServer) callTool(name string, arguments json.RawMessage) (string, error) {
+	switch name {
+	case "render_markdown", "preview_file":
+		var args mcpRenderArgs
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return mcpRender(name, args)
+	case "stream_preview":
+		var args mcpStreamArgs
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return s.streamPreview(args)
+	}
+	return "", fmt.Errorf("%w: %s", errUnknownTool, name)
+}
+
+// mcpRender renders markdown, or the document at a path, with the options of
+// the arguments.
+func mcpRender(tool string, args mcpRenderArgs) (string, error) {
+	format := args.Format
+	if format == "" {
+		format = "ansi"
+	}
+	export, ok := exporters[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported format %q, must be one of: %s", format, strings.Join(exportFormats(), ", "))
+	}
+	opts := exportOptions{width: 80, colorProfile: "truecolor"}
+	if args.Width != nil {
+		opts.width = *args.Width
+	}
+	style, err := exportStyleName(args.Style)
+	if err != nil {
+		return "", err
+	}
+	opts.style = style
+
+	var md []byte
+	if tool == "preview_file" {
+		// Stdin is the protocol's transport, which reading "-" would consume.
+		switch strings.TrimSpace(args.Path) {
+		case "":
+			return "", errors.New("missing path")
+		case "-":
+			return "", errors.New("preview_file can't read stdin, pass the markdown to render_markdown instead")
+		}
+		src, err := sourceFromArg(args.Path)
+		if err != nil {
+			return "", err
+		}
+		defer src.reader.Close() //nolint:errcheck
+		content, err := readContent(src)
+		if err != nil {
+			return "", err
+		}
+		md = []byte(content)
+		opts.title = documentTitle(md, src.URL)
+	} else {
+		md = prepareExport([]byte(args.Markdown))
+		opts.title = documentTitle(md, "")
+	}
+
+	var out bytes.Buffer
+	if err := export(&out, md, opts); err != nil {
+		return "", err
+	}
+	filtered, err := filterOutput(out.Bytes(), format)
+	return string(filtered), err
+}
+
+// streamPreview appends a chunk to a preview, and returns what that adds to
+// its rendering.
+func (s *mcpServer) streamPreview(args mcpStreamArgs) (string, error) {
+	if args.Session == "" {
+		return "", errors.New("missing session")
+	}
+	sr, ok := s.streams[args.Session]
+	if !ok {
+		var err error
+		if sr, err = newStreamRenderer(); err != nil {
+			return "", err
+		}
+		s.streams[args.Session] = sr
+	}
+	if args.Final {
+		delete(s.streams, args.Session)
+	}
+	sr.write([]byte(args.Chunk))
+	out, err := sr.commit(args.Final)
+	if err != nil || out == "" {
+		return out, err
+	}
+	filtered, err := filterOutput([]byte(out), "ansi")
+	return string(filtered), err
+}
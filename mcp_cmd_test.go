@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMCPServer(t *testing.T) {
+	doc := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(doc, []byte("# Notes\n\nRead me.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"render_markdown","arguments":{"markdown":"# Hi\n\nSome *text*.","format":"text"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"preview_file","arguments":{"path":` + strings.ReplaceAll(`"`+doc+`"`, `\`, `\\`) + `,"format":"text"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"render_markdown","arguments":{"markdown":"x","format":"pdf"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"stream_preview","arguments":{"session":"a","chunk":"# Title\n\nFirst"}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"stream_preview","arguments":{"session":"a","chunk":" paragraph.\n","final":true}}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"resources/list"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"name":"preview_file","arguments":{"path":"-"}}}`,
+		`{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{"name":"preview_file","arguments":{"path":""}}}`,
+	}
+
+	var out bytes.Buffer
+	if err := newMCPServer().serve(strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	type response struct {
+		ID     json.RawMessage `json:"id"`
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			Tools           []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	var responses []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, r)
+	}
+	if len(responses) != 11 {
+		t.Fatalf("expected a response for each request but the notification, got %d", len(responses))
+	}
+
+	if v := responses[0].Result.ProtocolVersion; v != "2025-03-26" {
+		t.Errorf("expected the client's protocol version, got %q", v)
+	}
+	if tools := responses[1].Result.Tools; len(tools) != 3 || tools[0].Name != "render_markdown" {
+		t.Errorf("unexpected tools %v", tools)
+	}
+	text := func(i int) string {
+		if len(responses[i].Result.Content) == 0 {
+			return ""
+		}
+		return responses[i].Result.Content[0].Text
+	}
+	if got := text(2); !strings.Contains(got, "Hi") || !strings.Contains(got, "Some text.") {
+		t.Errorf("unexpected rendering %q", got)
+	}
+	if got := text(3); !strings.Contains(got, "Read me.") {
+		t.Errorf("unexpected preview %q", got)
+	}
+	if !responses[4].Result.IsError || !strings.Contains(text(4), "unsupported format") {
+		t.Errorf("expected a tool error, got %q", text(4))
+	}
+	if first, rest := text(5), text(6); !strings.Contains(first, "Title") || strings.Contains(first, "paragraph") || !strings.Contains(rest, "First paragraph.") {
+		t.Errorf("expected the preview to be streamed, got %q and %q", first, rest)
+	}
+	if responses[7].Error == nil || responses[7].Error.Code != rpcMethodNotFound {
+		t.Errorf("expected an unknown method to be an error, got %v", responses[7].Error)
+	}
+	if responses[8].Error == nil || responses[8].Error.Code != rpcParseError {
+		t.Errorf("expected a parse error, got %v", responses[8].Error)
+	}
+	// Stdin is the transport, so it's never read as a document.
+	for _, i := range []int{9, 10} {
+		if !responses[i].Result.IsError {
+			t.Errorf("expected a tool error for response %d, got %q", i, text(i))
+		}
+	}
+}
+
+func TestMCPOutputFilters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the filters are Unix commands")
+	}
+	defer func() { outputFilters = nil }()
+	outputFilters = []string{"sed s/^/filtered:/"}
+
+	out, err := mcpRender("render_markdown", mcpRenderArgs{Markdown: "Hello.", Format: "text"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out, "filtered:") {
+		t.Errorf("expected the rendering to be filtered, got %q", out)
+	}
+
+	out, err = newMCPServer().streamPreview(mcpStreamArgs{Session: "a", Chunk: "Hello.\n", Final: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out, "filtered:") {
+		t.Errorf("expected the preview to be filtered, got %q", out)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// Metadata formats.
+const (
+	metaJSON = "json"
+	metaYAML = "yaml"
+)
+
+var (
+	metaFormat string
+	metaQuery  string
+
+	metaCmd = &cobra.Command{
+		Use:   "meta [SOURCE]",
+		Short: "Print the front matter of a markdown document",
+		Long: paragraph(fmt.Sprintf("\n%s the YAML or TOML front matter of a markdown document as JSON or YAML, or the value of a single field.",
+			keyword("Print"))),
+		Example: paragraph("glow meta post.md\nglow meta --format yaml post.md\nglow meta --query author.name post.md"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			content, err := readSourceArg(args)
+			if err != nil {
+				return err
+			}
+			return writeMeta(os.Stdout, content)
+		},
+	}
+)
+
+// writeMeta writes the front matter of a document, or the field given by
+// --query, in the --format. Scalar fields are written as plain text and
+// lists of them one item per line, for use in scripts.
+func writeMeta(w io.Writer, content []byte) error {
+	if metaFormat != metaJSON && metaFormat != metaYAML {
+		return fmt.Errorf("invalid metadata format %q, use %s or %s", metaFormat, metaJSON, metaYAML)
+	}
+	data, err := utils.FrontmatterData(content)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var v any = data
+	if data == nil {
+		v = map[string]any{}
+	}
+	if metaQuery != "" {
+		field, ok := utils.LookupField(data, metaQuery)
+		if !ok {
+			return fmt.Errorf("no front matter field %q", metaQuery)
+		}
+		if values, ok := field.([]any); ok && isScalarList(values) {
+			for _, item := range values {
+				fmt.Fprintln(w, utils.FormatMetadataValue(item))
+			}
+			return nil
+		}
+		if _, ok := field.(map[string]any); !ok {
+			_, err := fmt.Fprintln(w, utils.FormatMetadataValue(field))
+			return err //nolint:wrapcheck
+		}
+		v = field
+	}
+
+	if metaFormat == metaYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("unable to encode front matter: %w", err)
+		}
+		return enc.Close() //nolint:wrapcheck
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("unable to encode front matter: %w", err)
+	}
+	return nil
+}
+
+func isScalarList(values []any) bool {
+	for _, v := range values {
+		switch v.(type) {
+		case []any, map[string]any:
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	metaCmd.Flags().StringVarP(&metaFormat, "format", "f", metaJSON, "output format: json or yaml")
+	metaCmd.Flags().StringVarP(&metaQuery, "query", "q", "", "only print the value of a field, like title or author.name")
+}
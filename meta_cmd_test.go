@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteMeta(t *testing.T) {
+	defer func() { metaFormat, metaQuery = metaJSON, "" }()
+	md := "---\ntitle: Hello\ndate: 2024-05-01\ntags: [a, b]\nauthor:\n  name: Sam\n---\n# Hello\n"
+
+	for _, tc := range []struct {
+		format, query, want string
+	}{
+		{metaJSON, "title", "Hello\n"},
+		{metaJSON, "date", "2024-05-01\n"},
+		{metaJSON, "tags", "a\nb\n"},
+		{metaJSON, "author.name", "Sam\n"},
+		{metaJSON, "author", "{\n  \"name\": \"Sam\"\n}\n"},
+		{metaYAML, "author", "name: Sam\n"},
+	} {
+		metaFormat, metaQuery = tc.format, tc.query
+		var b bytes.Buffer
+		if err := writeMeta(&b, []byte(md)); err != nil {
+			t.Fatalf("expected no error for %q, got %v", tc.query, err)
+		}
+		if b.String() != tc.want {
+			t.Errorf("expected %q for %q, got %q", tc.want, tc.query, b.String())
+		}
+	}
+
+	metaFormat, metaQuery = metaJSON, ""
+	var b bytes.Buffer
+	if err := writeMeta(&b, []byte("+++\ntitle = \"T\"\n+++\nbody\n")); err != nil || b.String() != "{\n  \"title\": \"T\"\n}\n" {
+		t.Errorf("expected TOML front matter as JSON, got %q, %v", b.String(), err)
+	}
+	b.Reset()
+	if err := writeMeta(&b, []byte("# No front matter\n")); err != nil || b.String() != "{}\n" {
+		t.Errorf("expected an empty object, got %q, %v", b.String(), err)
+	}
+
+	metaQuery = "missing"
+	if err := writeMeta(&b, []byte(md)); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+const (
+	protoOCI = "oci://"
+
+	// dockerHubRegistry is the registry of images without one, like
+	// alpine:3.20.
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// maxOCIReadmeSize limits the size of README layers read from images.
+	maxOCIReadmeSize = 1 << 20
+
+	ociAnnotationDescription   = "org.opencontainers.image.description"
+	ociAnnotationDocumentation = "org.opencontainers.image.documentation"
+	ociAnnotationTitle         = "org.opencontainers.image.title"
+	ociAnnotationPrefix        = "org.opencontainers.image."
+)
+
+// ociManifestTypes are the media types of the manifests and indexes of
+// images.
+var ociManifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// ociRef is a reference to an image in a registry.
+type ociRef struct {
+	registry   string
+	repository string
+	reference  string
+}
+
+func (r ociRef) String() string {
+	sep := ":"
+	if strings.Contains(r.reference, ":") {
+		sep = "@"
+	}
+	return r.registry + "/" + r.repository + sep + r.reference
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+// ociManifest is an image manifest, or an index of manifests.
+type ociManifest struct {
+	MediaType   string            `json:"mediaType"`
+	Config      ociDescriptor     `json:"config"`
+	Layers      []ociDescriptor   `json:"layers"`
+	Manifests   []ociDescriptor   `json:"manifests"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// parseOCIRef parses an image reference like oci://ghcr.io/owner/image:tag,
+// or oci://alpine on Docker Hub.
+func parseOCIRef(arg string) (ociRef, error) {
+	name := strings.TrimPrefix(arg, protoOCI)
+	ref := ociRef{reference: "latest"}
+	if n, digest, ok := strings.Cut(name, "@"); ok {
+		name, ref.reference = n, digest
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.reference = name[:i], name[i+1:]
+	}
+
+	first, rest, ok := strings.Cut(name, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.registry, ref.repository = first, rest
+	} else {
+		ref.registry, ref.repository = dockerHubRegistry, name
+		if !ok {
+			ref.repository = "library/" + name
+		}
+	}
+	if ref.repository == "" || ref.reference == "" {
+		return ref, fmt.Errorf("invalid image reference %q", arg)
+	}
+	return ref, nil
+}
+
+// ociSource reads the documentation of an image: a README layer, like of
+// artifacts pushed with ORAS, or else its description and the other
+// annotations and labels describing it.
+func ociSource(arg string) (*source, error) {
+	ref, err := parseOCIRef(arg)
+	if err != nil {
+		return nil, err
+	}
+	if offline {
+		return nil, fmt.Errorf("%s: %w", arg, errNotCached)
+	}
+	c := &registryClient{ref: ref}
+
+	var manifest ociManifest
+	if err := c.getJSON("manifests/"+ref.reference, strings.Join(ociManifestTypes, ", "), &manifest); err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{}
+	for k, v := range manifest.Annotations {
+		annotations[k] = v
+	}
+	if len(manifest.Manifests) > 0 {
+		// The annotations of an index describe the image; its first
+		// manifest for a platform stands in for the others.
+		desc := manifest.Manifests[0]
+		for _, m := range manifest.Manifests {
+			if m.Platform != nil && m.Platform.OS != "unknown" {
+				desc = m
+				break
+			}
+		}
+		manifest = ociManifest{}
+		if err := c.getJSON("manifests/"+desc.Digest, strings.Join(ociManifestTypes, ", "), &manifest); err != nil {
+			return nil, err
+		}
+		for k, v := range manifest.Annotations {
+			if _, ok := annotations[k]; !ok {
+				annotations[k] = v
+			}
+		}
+	}
+
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ociAnnotationTitle]
+		if title == "" || !utils.IsMarkdownFile(title) || layer.Size > maxOCIReadmeSize {
+			continue
+		}
+		b, err := c.get("blobs/"+layer.Digest, "")
+		if err != nil {
+			return nil, err
+		}
+		return &source{reader: io.NopCloser(bytes.NewReader(b)), URL: "oci://" + ref.String() + "/" + title}, nil
+	}
+
+	// Images built with Docker have their description as a label of their
+	// config.
+	if strings.Contains(manifest.Config.MediaType, "image.config") || strings.Contains(manifest.Config.MediaType, "container.image") {
+		var config struct {
+			Config struct {
+				Labels map[string]string `json:"Labels"`
+			} `json:"config"`
+		}
+		if err := c.getJSON("blobs/"+manifest.Config.Digest, "", &config); err == nil {
+			for k, v := range config.Config.Labels {
+				if _, ok := annotations[k]; !ok {
+					annotations[k] = v
+				}
+			}
+		}
+	}
+
+	md := ociMarkdown(ref, annotations)
+	return &source{reader: io.NopCloser(strings.NewReader(md)), URL: "oci://" + ref.String()}, nil
+}
+
+// ociMarkdown describes an image with its annotations: its description, a
+// link to its documentation, and the other OCI annotations in a table.
+func ociMarkdown(ref ociRef, annotations map[string]string) string {
+	var b strings.Builder
+	title := annotations[ociAnnotationTitle]
+	if title == "" {
+		title = ref.repository
+	}
+	fmt.Fprintf(&b, "# %s\n\n", utils.EscapeMarkdown(title))
+	fmt.Fprintf(&b, "`%s`\n\n", ref)
+	if desc := strings.TrimSpace(annotations[ociAnnotationDescription]); desc != "" {
+		b.WriteString(desc + "\n\n")
+	} else {
+		b.WriteString("*No description provided.*\n\n")
+	}
+	if doc := annotations[ociAnnotationDocumentation]; doc != "" {
+		fmt.Fprintf(&b, "Documentation: <%s>\n\n", doc)
+	}
+
+	var keys []string
+	for k := range annotations {
+		if strings.HasPrefix(k, ociAnnotationPrefix) && k != ociAnnotationDescription && k != ociAnnotationTitle && k != ociAnnotationDocumentation {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return b.String()
+	}
+	sort.Strings(keys)
+	escape := strings.NewReplacer("|", `\|`)
+	b.WriteString("| Annotation | Value |\n|------------|-------|\n")
+	for _, k := range keys {
+		v := annotations[k]
+		if !strings.HasPrefix(v, "http://") && !strings.HasPrefix(v, "https://") {
+			v = utils.EscapeMarkdown(v)
+		}
+		fmt.Fprintf(&b, "| %s | %s |\n", strings.TrimPrefix(k, ociAnnotationPrefix), escape.Replace(strings.Join(strings.Fields(v), " ")))
+	}
+	return b.String()
+}
+
+// registryClient makes requests to the registry of an image, with a token
+// for pulling it if the registry asks for one.
+type registryClient struct {
+	ref   ociRef
+	token string
+}
+
+func (c *registryClient) baseURL() string {
+	scheme := "https"
+	if host, _, _ := strings.Cut(c.ref.registry, ":"); host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return scheme + "://" + c.ref.registry + "/v2/" + c.ref.repository + "/"
+}
+
+func (c *registryClient) getJSON(path, accept string, v any) error {
+	b, err := c.get(path, accept)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("unable to parse json: %w", err)
+	}
+	return nil
+}
+
+func (c *registryClient) get(path, accept string) ([]byte, error) {
+	res, err := c.do(path, accept)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := res.Header.Get("WWW-Authenticate")
+		_ = res.Body.Close()
+		if err := c.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		if res, err = c.do(path, accept); err != nil {
+			return nil, err
+		}
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s not found", c.ref)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("access to %s denied, log in to %s with docker login", c.ref, c.ref.registry)
+	default:
+		return nil, fmt.Errorf("unable to get %s: HTTP status %d", c.ref, res.StatusCode)
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response: %w", err)
+	}
+	return b, nil
+}
+
+func (c *registryClient) do(path, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.baseURL()+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s: %w", c.ref, err)
+	}
+	if err := limitDownload(res); err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// authenticate gets a token for pulling the image, as asked for by the
+// challenge of the registry, with the credentials of docker login if there
+// are any.
+func (c *registryClient) authenticate(challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("access to %s denied, the registry asks for %s authentication", c.ref, scheme)
+	}
+	values := map[string]string{}
+	for _, p := range strings.Split(params, ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok {
+			values[strings.ToLower(k)] = strings.Trim(v, `"`)
+		}
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return fmt.Errorf("invalid authentication challenge of %s: %q", c.ref.registry, challenge)
+	}
+	q := realm.Query()
+	if values["service"] != "" {
+		q.Set("service", values["service"])
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.repository + ":pull"
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	if auth := dockerAuth(c.ref.registry); auth != "" {
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to authenticate to %s: %w", c.ref.registry, err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to authenticate to %s: HTTP status %d", c.ref.registry, res.StatusCode)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return fmt.Errorf("unable to parse token: %w", err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return errors.New("the registry returned no token")
+	}
+	return nil
+}
+
+// dockerAuth returns the credentials docker login stored for a registry,
+// base64 encoded like for basic authentication.
+func dockerAuth(registry string) string {
+	path := os.Getenv("DOCKER_CONFIG")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(path, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ""
+	}
+	keys := []string{registry, "https://" + registry}
+	if registry == dockerHubRegistry {
+		keys = append(keys, "https://index.docker.io/v1/", "docker.io")
+	}
+	for _, k := range keys {
+		if auth := config.Auths[k].Auth; auth != "" {
+			if _, err := base64.StdEncoding.DecodeString(auth); err == nil {
+				return auth
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"golang.org/x/term"
+)
+
+// openLinks asks which of the numbered links of a document to open, once
+// it's shown, and opens them with their link handler.
+var openLinks bool
+
+// promptTerminal returns where to read answers to prompts from: stdin if
+// it's a terminal, or else the terminal itself, as stdin may be the
+// document.
+func promptTerminal() (io.ReadCloser, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return io.NopCloser(os.Stdin), nil
+	}
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.New("--open-links needs a terminal to ask which link to open")
+	}
+	return f, nil
+}
+
+// promptOpenLinks asks for the numbers of links to open until it's given
+// none, and opens them.
+func promptOpenLinks(links []string, r io.Reader, w io.Writer) error {
+	if len(links) == 0 {
+		return nil
+	}
+	s := bufio.NewScanner(r)
+	for {
+		fmt.Fprintf(w, "Open link [1-%d] (enter to quit): ", len(links))
+		if !s.Scan() {
+			fmt.Fprintln(w)
+			return s.Err() //nolint:wrapcheck
+		}
+		answer := strings.TrimSpace(s.Text())
+		if answer == "" || answer == "q" {
+			return nil
+		}
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(links) {
+			fmt.Fprintf(w, "No link %s.\n", answer)
+			continue
+		}
+		if err := openLink(links[n-1]); err != nil {
+			fmt.Fprintln(w, err)
+		}
+	}
+}
+
+// openLink opens a link with its link handler, which gets the terminal
+// until it exits.
+func openLink(link string) error {
+	cmd, err := utils.LinkCommand(link)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unable to open %s with %s: %w", link, cmd.Args[0], err)
+	}
+	return nil
+}
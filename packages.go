@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// protoPkg reads the README of a package from its registry, like
+// pkg:npm/react or pkg:go/github.com/spf13/cobra, after package URLs.
+const protoPkg = "pkg:"
+
+// Base URLs of the package registries.
+var (
+	npmRegistry = "https://registry.npmjs.org"
+	pypiAPI     = "https://pypi.org/pypi"
+	cratesAPI   = "https://crates.io/api/v1/crates"
+	goModuleURL = "https://"
+)
+
+// packageSpec splits a package given like pkg:npm/name into its ecosystem and
+// name, dropping the version and qualifiers of package URLs.
+func packageSpec(path string) (string, string, bool) {
+	spec, ok := strings.CutPrefix(path, protoPkg)
+	if !ok {
+		return "", "", false
+	}
+	spec, _, _ = strings.Cut(spec, "?")
+	spec, _, _ = strings.Cut(spec, "#")
+	eco, name, ok := strings.Cut(strings.TrimPrefix(spec, "//"), "/")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	// Versions follow the name, which may start with an npm scope.
+	if i := strings.LastIndex(name, "@"); i > 0 {
+		name = name[:i]
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return strings.ToLower(eco), name, true
+}
+
+// findPackageREADME fetches the README of a package from its registry.
+func findPackageREADME(path string) (*source, error) {
+	eco, name, ok := packageSpec(path)
+	if !ok {
+		return nil, fmt.Errorf("invalid package: %s", path)
+	}
+	// Ecosystems are named as in package URLs, or as commonly known.
+	switch eco {
+	case "go", "golang":
+		return findGoModuleREADME(name)
+	case "npm":
+		return findNpmREADME(name)
+	case "pypi", "pip":
+		return findPyPIREADME(name)
+	case "cargo", "crates":
+		return findCrateREADME(name)
+	}
+	return nil, fmt.Errorf("unsupported package ecosystem: %s", eco)
+}
+
+// packageSource returns a README as a source, converting it to markdown
+// based on its file name.
+func packageSource(readme []byte, filename, u string) (*source, error) {
+	if len(strings.TrimSpace(string(readme))) == 0 {
+		return nil, errors.New("package has no README")
+	}
+	md, err := utils.ConvertToMarkdown(readme, filename)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &source{reader: io.NopCloser(strings.NewReader(string(md))), URL: u}, nil
+}
+
+func findNpmREADME(name string) (*source, error) {
+	var pkg struct {
+		Readme         string `json:"readme"`
+		ReadmeFilename string `json:"readmeFilename"`
+	}
+	if err := getJSON(npmRegistry+"/"+strings.Replace(url.PathEscape(name), "%40", "@", 1), &pkg); err != nil {
+		return nil, err
+	}
+	filename := pkg.ReadmeFilename
+	if filename == "" {
+		filename = "README.md"
+	}
+	return packageSource([]byte(pkg.Readme), filename, "https://www.npmjs.com/package/"+name)
+}
+
+func findPyPIREADME(name string) (*source, error) {
+	var pkg struct {
+		Info struct {
+			Description            string `json:"description"`
+			DescriptionContentType string `json:"description_content_type"`
+			PackageURL             string `json:"package_url"`
+		} `json:"info"`
+	}
+	if err := getJSON(pypiAPI+"/"+url.PathEscape(name)+"/json", &pkg); err != nil {
+		return nil, err
+	}
+	// PyPI descriptions are reStructuredText unless they say otherwise.
+	filename := "README.rst"
+	switch contentType, _, _ := strings.Cut(pkg.Info.DescriptionContentType, ";"); strings.TrimSpace(contentType) {
+	case "text/markdown":
+		filename = "README.md"
+	case "text/plain":
+		filename = "README.txt"
+	}
+	return packageSource([]byte(pkg.Info.Description), filename, pkg.Info.PackageURL)
+}
+
+func findCrateREADME(name string) (*source, error) {
+	apiURL := cratesAPI + "/" + url.PathEscape(name)
+	var crate struct {
+		Crate struct {
+			MaxStableVersion string `json:"max_stable_version"`
+			MaxVersion       string `json:"max_version"`
+		} `json:"crate"`
+	}
+	if err := getJSON(apiURL, &crate); err != nil {
+		return nil, err
+	}
+	version := crate.Crate.MaxStableVersion
+	if version == "" {
+		version = crate.Crate.MaxVersion
+	}
+	// crates.io serves READMEs rendered as HTML.
+	readme, err := getBody(apiURL + "/" + url.PathEscape(version) + "/readme")
+	if err != nil {
+		return nil, err
+	}
+	return packageSource(readme, "README.html", "https://crates.io/crates/"+name)
+}
+
+// goImportPattern matches the go-import meta tag of a module's page, which
+// points to its repository.
+var goImportPattern = regexp.MustCompile(`<meta\s+name="go-import"\s+content="([^"]+)"`)
+
+// goModuleRepo returns the repository of a Go module from its go-get page.
+func goModuleRepo(page []byte, module string) (string, bool) {
+	for _, m := range goImportPattern.FindAllSubmatch(page, -1) {
+		fields := strings.Fields(string(m[1]))
+		if len(fields) == 3 && fields[1] == "git" &&
+			(module == fields[0] || strings.HasPrefix(module, fields[0]+"/")) {
+			return strings.TrimSuffix(fields[2], ".git"), true
+		}
+	}
+	return "", false
+}
+
+// findGoModuleREADME finds the README of a Go module in its repository, which
+// is looked up like the go command does for modules not hosted on one of the
+// known forges.
+func findGoModuleREADME(module string) (*source, error) {
+	repo := module
+	if host, _, _ := strings.Cut(module, "/"); host != githubURL.Hostname() && !isRepoHost(host) {
+		page, err := getBody(goModuleURL + module + "?go-get=1")
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if repo, ok = goModuleRepo(page, module); !ok {
+			return nil, fmt.Errorf("can't find the repository of %s", module)
+		}
+	}
+	// Modules in subdirectories of repositories are read from the root.
+	if !strings.Contains(repo, "://") {
+		repo = protoHTTPS + repo
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse url: %w", err)
+	}
+	if parts := strings.Split(strings.Trim(u.Path, "/"), "/"); len(parts) > 2 && !isGitLabHost(u.Hostname()) {
+		u.Path = "/" + parts[0] + "/" + parts[1]
+	}
+	src, err := readmeURL(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return nil, fmt.Errorf("can't find README of %s", module)
+	}
+	return src, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	// profile prints how long each phase of the run took once it's done,
+	// and cpuProfile and memProfile are the files pprof profiles are written
+	// to
+	profile    bool
+	cpuProfile string
+	memProfile string
+
+	profileStart time.Time
+	phaseMu      sync.Mutex
+	phaseTimes   = map[string]time.Duration{}
+)
+
+// profilePhases are the phases of a run, in the order they happen.
+var profilePhases = []string{"discovery", "fetch", "parse", "render", "write"}
+
+// timePhase starts timing a phase of the run and returns the function that
+// stops it. Phases that happen more than once, like for every file, add up.
+func timePhase(phase string) func() {
+	if !profile {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		phaseMu.Lock()
+		phaseTimes[phase] += time.Since(start)
+		phaseMu.Unlock()
+	}
+}
+
+// startProfile starts timing the run, and the CPU profile if one is asked
+// for.
+func startProfile() error {
+	profileStart = time.Now()
+	if cpuProfile == "" {
+		return nil
+	}
+	f, err := os.Create(cpuProfile)
+	if err != nil {
+		return fmt.Errorf("unable to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to start CPU profile: %w", err)
+	}
+	return nil
+}
+
+// stopProfile stops the CPU profile, writes the memory profile and prints
+// the timing report, as asked for.
+func stopProfile(w io.Writer) error {
+	if cpuProfile != "" {
+		pprof.StopCPUProfile()
+	}
+	if memProfile != "" {
+		f, err := os.Create(memProfile)
+		if err != nil {
+			return fmt.Errorf("unable to create memory profile: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("unable to write memory profile: %w", err)
+		}
+	}
+	if profile && !profileStart.IsZero() {
+		return writeProfile(w, time.Since(profileStart))
+	}
+	return nil
+}
+
+// writeProfile writes how long each phase took, and its share of the run.
+// The time of the run not spent in any phase is shown as other, like
+// checking options or the time spent in the TUI.
+func writeProfile(w io.Writer, total time.Duration) error {
+	phaseMu.Lock()
+	defer phaseMu.Unlock()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "phase\ttime\tshare")
+	other := total
+	row := func(phase string, d time.Duration) {
+		share := 0.0
+		if total > 0 {
+			share = float64(d) / float64(total) * 100
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.1f%%\n", phase, d.Round(time.Microsecond), share)
+	}
+	for _, phase := range profilePhases {
+		d := phaseTimes[phase]
+		other -= d
+		row(phase, d)
+	}
+	row("other", max(other, 0))
+	row("total", total)
+	return tw.Flush() //nolint:wrapcheck
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+const projectConfigName = ".glow.yml"
+
+// projectConfigKeys are the settings a project config can set. Settings that
+// run commands or send credentials, like converters and tokens, are left to
+// the user's config, so rendering a cloned repository can't run its code.
+var projectConfigKeys = []string{
+	"style", "styleOverrides", "codeTheme", "width", "exclude",
+	"frontmatter", "wikiLinks", "smartypants", "hardBreaks", "aliases",
+}
+
+// trustedProjectConfigKeys are the settings only the project configs of the
+// user's trustedProjects can set, as they run commands.
+var trustedProjectConfigKeys = []string{"linkHandlers"}
+
+// findProjectConfig returns the path of the project config in a directory or
+// the closest of its ancestors, if there is one.
+func findProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, projectConfigName)
+		if st, err := os.Stat(path); err == nil && st.Mode().IsRegular() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readProjectConfig reads the settings of a project config, and returns the
+// ones it isn't allowed to set, which are left out. Paths of styles and
+// excludes are made relative to the directory of the config, rather than the
+// one glow runs in.
+func readProjectConfig(path string, trusted bool) (map[string]any, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read project config: %w", err)
+	}
+	var settings map[string]any
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse project config %s: %w", path, err)
+	}
+
+	allowed := projectConfigKeys
+	if trusted {
+		allowed = append(slices.Clip(allowed), trustedProjectConfigKeys...)
+	}
+	var ignored []string
+
+	dir := filepath.Dir(path)
+	if d, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = d
+	}
+	for key, value := range settings {
+		if !slices.ContainsFunc(allowed, func(k string) bool { return strings.EqualFold(k, key) }) {
+			ignored = append(ignored, key)
+			delete(settings, key)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "style":
+			if s, ok := value.(string); ok && strings.HasSuffix(s, ".json") &&
+				!filepath.IsAbs(s) && !strings.HasPrefix(s, "~") {
+				settings[key] = filepath.Join(dir, s)
+			}
+		case "exclude":
+			patterns, ok := value.([]any)
+			if !ok {
+				return nil, nil, fmt.Errorf("unable to parse project config %s: exclude must be a list of patterns", path)
+			}
+			for i, p := range patterns {
+				// Patterns of paths are matched against absolute paths.
+				if s, ok := p.(string); ok && strings.Contains(s, "/") {
+					patterns[i] = filepath.Join(dir, filepath.FromSlash(s))
+				}
+			}
+		}
+	}
+	slices.Sort(ignored)
+	return settings, ignored, nil
+}
+
+// trustedProject reports whether the project config at path is in one of the
+// directories of the user's trustedProjects.
+func trustedProject(path string, trusted []string) bool {
+	dir := filepath.Dir(path)
+	if d, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = d
+	}
+	for _, t := range trusted {
+		t, err := filepath.Abs(utils.ExpandPath(t))
+		if err != nil {
+			continue
+		}
+		if d, err := filepath.EvalSymlinks(t); err == nil {
+			t = d
+		}
+		if rel, err := filepath.Rel(t, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadProjectConfig merges the settings of the project config of the current
+// directory over the ones of the user's config.
+func loadProjectConfig() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	path := findProjectConfig(cwd)
+	if path == "" {
+		return
+	}
+	// trustedProjects is read from the user's config only, as the project's
+	// isn't merged yet.
+	trusted := trustedProject(path, viper.GetStringSlice("trustedProjects"))
+	settings, ignored, err := readProjectConfig(path, trusted)
+	if err != nil {
+		log.Warn("Could not load project configuration", "err", err)
+		return
+	}
+	if len(ignored) > 0 {
+		fmt.Fprintf(os.Stderr, "Ignoring settings of project config %s: %s\n", path, strings.Join(ignored, ", "))
+		if !trusted && slices.ContainsFunc(ignored, func(k string) bool { return strings.EqualFold(k, "linkHandlers") }) {
+			fmt.Fprintf(os.Stderr, "Add %s to trustedProjects in your config to let it set linkHandlers\n", filepath.Dir(path))
+		}
+	}
+	if err := viper.MergeConfigMap(settings); err != nil {
+		log.Warn("Could not load project configuration", "err", err)
+		return
+	}
+	log.Debug("Using project configuration file", "path", path)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// releasesSuffix follows a repository to read its release notes instead of
+// its README, like github.com/owner/repo@releases, or
+// github.com/owner/repo@releases/v1.0.0 for a given release.
+const releasesSuffix = "@releases"
+
+// releaseCount is the number of latest releases shown.
+var releaseCount int
+
+type release struct {
+	Name string
+	Tag  string
+	Body string
+	Date time.Time
+}
+
+// releaseSpec splits a source argument into a repository and the tag of the
+// release asked for, which is empty for the latest ones.
+func releaseSpec(path string) (string, string, bool) {
+	repo, spec, ok := strings.Cut(path, releasesSuffix)
+	if !ok || (spec != "" && !strings.HasPrefix(spec, "/")) {
+		return "", "", false
+	}
+	return repo, strings.TrimPrefix(spec, "/"), true
+}
+
+// githubReleasesPath returns the repository and tag of a link to GitHub
+// releases, like https://github.com/owner/repo/releases or
+// https://github.com/owner/repo/releases/tag/v1.0.0.
+func githubReleasesPath(u *url.URL) (string, string, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 3 || parts[2] != "releases" {
+		return "", "", false
+	}
+	repo := u.Scheme + "://" + u.Host + "/" + parts[0] + "/" + parts[1]
+	switch {
+	case len(parts) == 3:
+		return repo, "", true
+	case len(parts) == 5 && parts[3] == "tag":
+		return repo, parts[4], true
+	}
+	return "", "", false
+}
+
+// findReleases fetches the release notes of a GitHub repository or GitLab
+// project. There's no source for repositories of other hosts.
+func findReleases(repo, tag string) (*source, error) {
+	u, err := repoURL(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []release
+	switch {
+	case u.Hostname() == githubURL.Hostname():
+		owner, name, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		releases, err = githubReleases(owner+"/"+name, tag)
+	case isGitLabHost(u.Hostname()):
+		project, ok := gitlabProject(u)
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		releases, err = gitlabReleases(gitlabProjectAPI(u, project), tag)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, errors.New("repository has no releases")
+	}
+	md := releasesMarkdown(releases)
+	return &source{reader: io.NopCloser(strings.NewReader(md)), URL: u.String()}, nil
+}
+
+func githubReleases(repo, tag string) ([]release, error) {
+	type githubRelease struct {
+		Name        string    `json:"name"`
+		TagName     string    `json:"tag_name"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/releases", githubAPI, repo)
+
+	var found []githubRelease
+	switch {
+	case tag != "":
+		var r githubRelease
+		if err := getJSON(apiURL+"/tags/"+url.PathEscape(tag), &r); err != nil {
+			return nil, err
+		}
+		found = append(found, r)
+	case releaseCount <= 1:
+		var r githubRelease
+		if err := getJSON(apiURL+"/latest", &r); err != nil {
+			return nil, err
+		}
+		found = append(found, r)
+	default:
+		if err := getJSON(fmt.Sprintf("%s?per_page=%d", apiURL, min(releaseCount, 100)), &found); err != nil {
+			return nil, err
+		}
+	}
+
+	releases := make([]release, len(found))
+	for i, r := range found {
+		releases[i] = release{Name: r.Name, Tag: r.TagName, Body: r.Body, Date: r.PublishedAt}
+	}
+	return releases, nil
+}
+
+func gitlabReleases(apiURL, tag string) ([]release, error) {
+	type gitlabRelease struct {
+		Name        string    `json:"name"`
+		TagName     string    `json:"tag_name"`
+		Description string    `json:"description"`
+		ReleasedAt  time.Time `json:"released_at"`
+	}
+
+	var found []gitlabRelease
+	if tag != "" {
+		var r gitlabRelease
+		if err := getJSON(apiURL+"/releases/"+url.PathEscape(tag), &r); err != nil {
+			return nil, err
+		}
+		found = append(found, r)
+	} else if err := getJSON(fmt.Sprintf("%s/releases?per_page=%d", apiURL, min(max(releaseCount, 1), 100)), &found); err != nil {
+		return nil, err
+	}
+
+	releases := make([]release, len(found))
+	for i, r := range found {
+		releases[i] = release{Name: r.Name, Tag: r.TagName, Body: r.Description, Date: r.ReleasedAt}
+	}
+	return releases, nil
+}
+
+// releasesMarkdown returns release notes as a markdown document, each under
+// a heading with its name, tag and date.
+func releasesMarkdown(releases []release) string {
+	var b strings.Builder
+	for i, r := range releases {
+		if i > 0 {
+			b.WriteString("---\n\n")
+		}
+		name := r.Name
+		if name == "" {
+			name = r.Tag
+		}
+		fmt.Fprintf(&b, "# %s\n\n", utils.EscapeMarkdown(name))
+		meta := utils.EscapeMarkdown(r.Tag)
+		if !r.Date.IsZero() {
+			meta += " · " + r.Date.Format(headerDateLayout)
+		}
+		fmt.Fprintf(&b, "*%s*\n\n", meta)
+		writeCommentBody(&b, r.Body)
+	}
+	return b.String()
+}
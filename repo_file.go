@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// githubRaw is the base URL of raw files of GitHub repositories.
+var githubRaw = "https://raw.githubusercontent.com"
+
+// defaultRef is the ref of a repository's default branch.
+const defaultRef = "HEAD"
+
+// repoFileSpec splits a source argument naming a file of a repository into the
+// repository, the ref and the file, like github.com/owner/repo@v2.1.0:docs/configuration.md.
+// Files of the default branch are given like github.com/owner/repo:README.md.
+func repoFileSpec(path string) (string, string, string, bool) {
+	proto := ""
+	if i := strings.Index(path, "://"); i >= 0 {
+		proto, path = path[:i+3], path[i+3:]
+	}
+	// Hosts can have ports, so the separators are looked for after them.
+	slash := strings.Index(path, "/")
+	if slash < 0 {
+		return "", "", "", false
+	}
+	repo, spec := path, ""
+	if at := strings.Index(path[slash:], "@"); at >= 0 {
+		repo, spec = path[:slash+at], path[slash+at+1:]
+	} else if colon := strings.Index(path[slash:], ":"); colon >= 0 {
+		repo, spec = path[:slash+colon], path[slash+colon:]
+	}
+	ref, file, _ := strings.Cut(spec, ":")
+	file = strings.Trim(file, "/")
+	if file == "" {
+		return "", "", "", false
+	}
+	return proto + repo, ref, file, true
+}
+
+// repoURL returns the URL of a repository given like github://owner/repo,
+// gitlab://group/project, or by its URL, which may lack the protocol.
+func repoURL(repo string) (*url.URL, error) {
+	var u *url.URL
+	switch {
+	case strings.HasPrefix(repo, protoGithub):
+		u = githubReadmeURL(repo)
+	case strings.HasPrefix(repo, protoGitlab):
+		u = gitlabReadmeURL(repo)
+	default:
+		if !strings.Contains(repo, "://") {
+			repo = protoHTTPS + repo
+		}
+		u, _ = url.Parse(repo)
+	}
+	if u == nil {
+		return nil, fmt.Errorf("invalid repository: %s", repo)
+	}
+	return u, nil
+}
+
+// findRepoFile fetches a file of a repository at a ref, which is a branch,
+// tag or commit, or the default branch if empty. There's no source for
+// repositories of unknown hosts.
+func findRepoFile(repo, ref, file string) (*source, error) {
+	u, err := repoURL(repo)
+	if err != nil {
+		return nil, err
+	}
+	escapedFile := escapePath(file)
+
+	switch host := u.Hostname(); {
+	case host == githubURL.Hostname():
+		owner, name, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		name = strings.TrimSuffix(name, ".git")
+		apiURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s", githubAPI, owner, name, escapedFile)
+		if ref != "" {
+			apiURL += "?ref=" + url.QueryEscape(ref)
+		} else {
+			ref = defaultRef
+		}
+		rawURL := fmt.Sprintf("%s/%s/%s/%s/%s", githubRaw, owner, name, escapePath(ref), escapedFile)
+		return repoFileSource(apiURL, "application/vnd.github.raw", rawURL)
+
+	case isGitLabHost(host):
+		project, ok := gitlabProject(u)
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		if ref == "" {
+			ref = defaultRef
+		}
+		apiURL := fmt.Sprintf("%s/repository/files/%s/raw?ref=%s", gitlabProjectAPI(u, project), url.PathEscape(file), url.QueryEscape(ref))
+		rawURL := fmt.Sprintf("%s://%s/%s/-/raw/%s/%s", u.Scheme, u.Host, project, escapePath(ref), escapedFile)
+		return repoFileSource(apiURL, "", rawURL)
+
+	case isGiteaHost(host):
+		owner, name, ok := giteaRepo(u)
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		apiURL := fmt.Sprintf("%s://%s/api/v1/repos/%s/%s/raw/%s", u.Scheme, u.Host, url.PathEscape(owner), url.PathEscape(name), escapedFile)
+		if ref != "" {
+			apiURL += "?ref=" + url.QueryEscape(ref)
+		} else {
+			ref = defaultRef
+		}
+		rawURL := fmt.Sprintf("%s://%s/%s/%s/raw/%s/%s", u.Scheme, u.Host, owner, name, escapePath(ref), escapedFile)
+		return repoFileSource(apiURL, "", rawURL)
+
+	case host == bitbucketHost:
+		workspace, name, ok := bitbucketRepo(u)
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		apiURL := fmt.Sprintf("%s/repositories/%s/%s", bitbucketAPI, url.PathEscape(workspace), url.PathEscape(name))
+		if ref == "" {
+			var repo struct {
+				MainBranch struct {
+					Name string `json:"name"`
+				} `json:"mainbranch"`
+			}
+			if err := getJSON(apiURL, &repo); err != nil {
+				return nil, err
+			}
+			ref = repo.MainBranch.Name
+		}
+		rawURL := fmt.Sprintf("https://%s/%s/%s/raw/%s/%s", bitbucketHost, workspace, name, escapePath(ref), escapedFile)
+		return repoFileSource(apiURL+"/src/"+url.PathEscape(ref)+"/"+escapedFile, "", rawURL)
+	}
+	return nil, nil
+}
+
+// repoFileSource gets a file through an API, shown with the URL of the raw
+// file.
+func repoFileSource(apiURL, accept, rawURL string) (*source, error) {
+	//nolint:bodyclose
+	// it is closed on the caller
+	res, err := httpGetAccepting(apiURL, accept)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get url: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unable to get %s: HTTP status %d", rawURL, res.StatusCode)
+	}
+	return &source{reader: res.Body, URL: rawURL}, nil
+}
+
+// escapePath escapes the segments of a slash-separated path.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/ui"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// treePageSize is the number of files listed at a time, where paginated.
+const treePageSize = 100
+
+// isDocumentPath returns whether a file of a repository is a document listed
+// in the TUI, like local files are.
+func isDocumentPath(p string) bool {
+	base := path.Base(p)
+	for _, pattern := range utils.DocumentPatterns() {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// repoTreeArg returns whether a source argument is a repository whose
+// documents can be listed.
+func repoTreeArg(arg string) bool {
+	u, err := repoURL(arg)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return host == githubURL.Hostname() || isGitLabHost(host) || isGiteaHost(host)
+}
+
+// repoDocuments lists the documents of a repository's default branch, using
+// the API of its host.
+func repoDocuments(u *url.URL) ([]string, error) {
+	var files []string
+	switch host := u.Hostname(); {
+	case host == githubURL.Hostname():
+		owner, name, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+		if !ok || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"tree"`
+		}
+		apiURL := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1", githubAPI, owner, strings.TrimSuffix(name, ".git"), defaultRef)
+		if err := getJSON(apiURL, &tree); err != nil {
+			return nil, err
+		}
+		for _, f := range tree.Tree {
+			if f.Type == "blob" {
+				files = append(files, f.Path)
+			}
+		}
+
+	case isGitLabHost(host):
+		project, ok := gitlabProject(u)
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		for page := 1; ; page++ {
+			var batch []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			}
+			apiURL := fmt.Sprintf("%s/repository/tree?recursive=true&per_page=%d&page=%d", gitlabProjectAPI(u, project), treePageSize, page)
+			if err := getJSON(apiURL, &batch); err != nil {
+				return nil, err
+			}
+			for _, f := range batch {
+				if f.Type == "blob" {
+					files = append(files, f.Path)
+				}
+			}
+			if len(batch) < treePageSize {
+				break
+			}
+		}
+
+	case isGiteaHost(host):
+		owner, name, ok := giteaRepo(u)
+		if !ok {
+			return nil, fmt.Errorf("invalid url: %s", u.String())
+		}
+		apiURL := fmt.Sprintf("%s://%s/api/v1/repos/%s/%s", u.Scheme, u.Host, url.PathEscape(owner), url.PathEscape(name))
+		var repo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := getJSON(apiURL, &repo); err != nil {
+			return nil, err
+		}
+		for page := 1; ; page++ {
+			var tree struct {
+				Tree []struct {
+					Path string `json:"path"`
+					Type string `json:"type"`
+				} `json:"tree"`
+				Truncated bool `json:"truncated"`
+			}
+			treeURL := fmt.Sprintf("%s/git/trees/%s?recursive=true&per_page=%d&page=%d", apiURL, url.PathEscape(repo.DefaultBranch), treePageSize, page)
+			if err := getJSON(treeURL, &tree); err != nil {
+				return nil, err
+			}
+			for _, f := range tree.Tree {
+				if f.Type == "blob" {
+					files = append(files, f.Path)
+				}
+			}
+			if !tree.Truncated {
+				break
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("browsing %s repositories isn't supported", host)
+	}
+
+	files = slices.DeleteFunc(files, func(f string) bool { return !isDocumentPath(f) })
+	if len(files) == 0 {
+		return nil, errors.New("repository has no documents")
+	}
+	return files, nil
+}
+
+// runRepoTUI lists the documents of a remote repository in the TUI, each
+// downloaded when it's opened.
+func runRepoTUI(repo string) error {
+	u, err := repoURL(repo)
+	if err != nil {
+		return err
+	}
+	files, err := repoDocuments(u)
+	if err != nil {
+		return err
+	}
+
+	remoteFiles := make([]ui.RemoteFile, len(files))
+	for i, file := range files {
+		remoteFiles[i] = ui.RemoteFile{
+			Path: file,
+			Fetch: func() (io.ReadCloser, string, error) {
+				src, err := findRepoFile(repo, "", file)
+				if err != nil {
+					return nil, "", err
+				}
+				return src.reader, src.URL, nil
+			},
+		}
+	}
+	return runRemoteFilesTUI(remoteFiles)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// schemeHandlers are commands reading the documents of URL schemes glow
+// doesn't support itself, like "notion": "notion-md", from the config.
+var schemeHandlers map[string]string
+
+// schemeSource reads a document with the command handling the scheme of its
+// URL. The URL is passed as the command's last argument, and the markdown is
+// read from its stdout.
+func schemeSource(command, arg string) (*source, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty scheme handler command")
+	}
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(args[0], append(args[1:], arg)...) //nolint:gosec
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("unable to read %s with %s: %w: %s", arg, args[0], err, msg)
+		}
+		return nil, fmt.Errorf("unable to read %s with %s: %w", arg, args[0], err)
+	}
+	return &source{reader: io.NopCloser(&stdout), URL: arg}, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	noSeparator   = "none"
+	pathVariable  = "{path}"
+	separatorRule = "─"
+)
+
+// separatorText returns the header shown before a file when rendering
+// multiple files, or an empty string if headers are disabled.
+func separatorText(path string) string {
+	switch separator {
+	case noSeparator:
+		return ""
+	case "":
+		return path
+	}
+	return strings.ReplaceAll(separator, pathVariable, path)
+}
+
+// renderSeparator renders the styled header of a file. The default header is
+// a rule spanning the output width with the path embedded.
+func renderSeparator(path string) string {
+	text := separatorText(path)
+	switch {
+	case text == "":
+		return "\n"
+	case separator != "":
+		return "\n" + separatorStyle.Render(text) + "\n"
+	}
+
+	rule := separatorRule
+	if accessible {
+		rule = "-"
+	}
+	prefix := strings.Repeat(rule, 2) + " " + text + " "
+	fill := max(2, int(width)-lipgloss.Width(prefix)) //nolint:gosec
+	return "\n" + separatorStyle.Render(prefix+strings.Repeat(rule, fill)) + "\n"
+}
+
+// markdownSeparator returns the header of a file in markdown, used when
+// multiple files are shown in the TUI.
+func markdownSeparator(path string) string {
+	text := separatorText(path)
+	if text == "" {
+		return ""
+	}
+	return "---\n\n**" + text + "**\n\n"
+}
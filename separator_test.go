@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestSeparatorText(t *testing.T) {
+	defer func() { separator = "" }()
+
+	for sep, want := range map[string]string{
+		"":               "docs/a.md",
+		"none":           "",
+		"=== {path} ===": "=== docs/a.md ===",
+		"next file":      "next file",
+	} {
+		separator = sep
+		if got := separatorText("docs/a.md"); got != want {
+			t.Errorf("expected separator %q to be %q, got %q", sep, want, got)
+		}
+	}
+}
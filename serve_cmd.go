@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/spf13/cobra"
+)
+
+// maxRenderRequestSize limits the markdown posted to the server.
+const maxRenderRequestSize = 10 << 20
+
+// outputContentTypes are the content types of the formats rendered by the
+// server, by format. Other formats are served as plain text.
+var outputContentTypes = map[string]string{
+	"html":   "text/html; charset=utf-8",
+	"slides": "text/html; charset=utf-8",
+}
+
+var (
+	serveListen string
+	serveStyle  string
+	serveWidth  uint
+	serveColors string
+
+	serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an HTTP endpoint rendering markdown",
+		Long: paragraph(fmt.Sprintf("\n%s markdown POSTed to the server, returning it rendered with the configured style. The output parameter picks the format: %s.",
+			keyword("Render"), strings.Join(exportFormats(), ", "))),
+		Example: paragraph("glow serve --listen :8080\ncurl --data-binary @README.md 'localhost:8080/?output=html'"),
+		Args:    cobra.NoArgs,
+		RunE:    runServe,
+	}
+)
+
+func runServe(cmd *cobra.Command, _ []string) error {
+	opts := exportOptions{
+		style:        serveStyle,
+		width:        serveWidth,
+		colorProfile: serveColors,
+	}
+	if colorDisabled(colorMode) && !cmd.Flags().Changed("color-profile") {
+		opts.colorProfile = "none"
+	}
+	style, err := exportStyleName(opts.style)
+	if err != nil {
+		return err
+	}
+	opts.style = style
+	if _, err := parseColorProfile(opts.colorProfile); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              serveListen,
+		Handler:           newRenderHandler(opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", serveListen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("unable to serve: %w", err)
+	}
+	return nil
+}
+
+// newRenderHandler returns a handler rendering markdown POSTed to it. The
+// output, width, title and toc parameters override the server's options,
+// and the style parameter picks one of the built-in styles.
+func newRenderHandler(defaults exportOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "POST markdown to render it", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		format := q.Get("output")
+		if format == "" {
+			format = "ansi"
+		}
+		export, ok := exporters[format]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported output %q, must be one of: %s", format, strings.Join(exportFormats(), ", ")), http.StatusBadRequest)
+			return
+		}
+
+		opts := defaults
+		if s := q.Get("style"); s != "" {
+			// Styles are only picked by name, not read from the server's
+			// files.
+			if styles.DefaultStyles[s] == nil && s != styles.AutoStyle {
+				http.Error(w, fmt.Sprintf("unknown style %q", s), http.StatusBadRequest)
+				return
+			}
+			opts.style = s
+		}
+		if s := q.Get("width"); s != "" {
+			width, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid width %q", s), http.StatusBadRequest)
+				return
+			}
+			opts.width = uint(width)
+		}
+		if s := q.Get("toc"); s != "" {
+			toc, err := strconv.ParseBool(s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid toc %q", s), http.StatusBadRequest)
+				return
+			}
+			opts.toc = toc
+		}
+
+		b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRenderRequestSize))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to read markdown: %v", err), http.StatusRequestEntityTooLarge)
+			return
+		}
+		b = prepareExport(b)
+		opts.title = q.Get("title")
+		if opts.title == "" {
+			opts.title = documentTitle(b, "")
+		}
+
+		var out strings.Builder
+		if err := export(&out, b, opts); err != nil {
+			http.Error(w, fmt.Sprintf("unable to render markdown: %v", err), http.StatusInternalServerError)
+			return
+		}
+		filtered, err := filterOutput([]byte(out.String()), format)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unable to render markdown: %v", err), http.StatusInternalServerError)
+			return
+		}
+		contentType, ok := outputContentTypes[format]
+		if !ok {
+			contentType = "text/plain; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(filtered)
+	})
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&serveListen, "listen", "l", "localhost:8080", "address to listen on")
+	serveCmd.Flags().StringVarP(&serveStyle, "style", "s", "", "style name or JSON path (default from config)")
+	serveCmd.Flags().UintVarP(&serveWidth, "width", "w", 80, "word-wrap at width (set to 0 to disable)")
+	serveCmd.Flags().StringVar(&serveColors, "color-profile", "truecolor", "color profile of ANSI output: truecolor, 256, 16 or none")
+}
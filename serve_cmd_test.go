@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	srv := httptest.NewServer(newRenderHandler(exportOptions{style: "dark", width: 40, colorProfile: "none"}))
+	defer srv.Close()
+
+	post := func(query, md string) (*http.Response, string) {
+		t.Helper()
+		res, err := http.Post(srv.URL+"/?"+query, "text/markdown", strings.NewReader(md)) //nolint:noctx
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close() //nolint:errcheck
+		b, _ := io.ReadAll(res.Body)
+		return res, string(b)
+	}
+
+	md := "# Title\n\nSome *text*.\n"
+	for query, want := range map[string]string{
+		"":                        "Title",
+		"output=text":             "Some text.",
+		"output=html&title=Notes": "<title>Notes</title>",
+		"output=html":             "<title>Title</title>",
+	} {
+		res, body := post(query, md)
+		if res.StatusCode != http.StatusOK || !strings.Contains(body, want) {
+			t.Errorf("expected %q to render %q, got %d: %q", query, want, res.StatusCode, body)
+		}
+		html := strings.Contains(query, "html")
+		if got := res.Header.Get("Content-Type"); strings.HasPrefix(got, "text/html") != html {
+			t.Errorf("unexpected content type %q for %q", got, query)
+		}
+	}
+
+	for _, query := range []string{"output=pdf", "style=/etc/passwd", "width=wide"} {
+		if res, _ := post(query, md); res.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected %q to be a bad request, got %d", query, res.StatusCode)
+		}
+	}
+
+	res, err := http.Get(srv.URL) //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusMethodNotAllowed || res.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("expected GET not to be allowed, got %d", res.StatusCode)
+	}
+}
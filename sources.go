@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/ui"
+)
+
+// readSources reads a list of sources, one path or URL per line, from a file
+// or from stdin with -. Blank lines and lines starting with # are skipped.
+func readSources(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open sources: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		r = f
+	}
+
+	var sources []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sources = append(sources, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read sources: %w", err)
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no sources listed")
+	}
+	return sources, nil
+}
+
+// runSourcesTUI lists sources in the TUI, each read when it's opened.
+func runSourcesTUI(sources []string) error {
+	files := make([]ui.RemoteFile, len(sources))
+	for i, arg := range sources {
+		files[i] = ui.RemoteFile{
+			Path: arg,
+			Fetch: func() (io.ReadCloser, string, error) {
+				src, err := sourceFromArg(arg)
+				if err != nil {
+					return nil, "", err
+				}
+				return src.reader, src.URL, nil
+			},
+		}
+	}
+	return runRemoteFilesTUI(files)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// scpArg splits an scp-like source argument, [user@]host:path, into the
+// destination it's read from over SSH and the path of the file there.
+func scpArg(arg string) (string, string, bool) {
+	if strings.Contains(arg, "://") || strings.HasPrefix(arg, protoPkg) || strings.HasPrefix(arg, protoGit) {
+		return "", "", false
+	}
+	dest, path, ok := strings.Cut(arg, ":")
+	// Like for scp and git, a slash before the colon makes it a local path,
+	// and so does a drive letter.
+	if !ok || path == "" || strings.ContainsAny(dest, `/\`) || len(dest) < 2 {
+		return "", "", false
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return "", "", false
+	}
+	return dest, path, true
+}
+
+func isSCPArg(arg string) bool {
+	_, _, ok := scpArg(arg)
+	return ok
+}
+
+// sshURLArg returns the destination, port and path of an ssh:// URL. Paths
+// starting with /~ are relative to the home directory, like for git.
+func sshURLArg(u *url.URL) (string, string, string) {
+	dest := u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		dest = u.User.Username() + "@" + dest
+	}
+	path := u.Path
+	if strings.HasPrefix(path, "/~") {
+		path = path[1:]
+	}
+	return dest, u.Port(), path
+}
+
+// sshSource reads a file on a server with ssh, so the user's SSH config and
+// agent are used, like by scp.
+func sshSource(dest, port, path string) (*source, error) {
+	docURL := "ssh://" + dest
+	if port != "" {
+		docURL += ":" + port
+	}
+	if strings.HasPrefix(path, "/") {
+		docURL += path
+	} else {
+		docURL += "/~/" + strings.TrimPrefix(path, "~/")
+	}
+	if offline {
+		return nil, fmt.Errorf("%s: %w", docURL, errNotCached)
+	}
+
+	args := []string{}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, "--", dest, "cat -- "+sshRemotePath(path))
+	var stdout, stderr bytes.Buffer
+	c := exec.Command("ssh", args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("unable to read %s: %w: %s", docURL, err, msg)
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", docURL, err)
+	}
+	if maxDownloadSize > 0 && int64(stdout.Len()) > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than the maximum download size of %d bytes", docURL, maxDownloadSize)
+	}
+	return &source{reader: io.NopCloser(&stdout), URL: docURL}, nil
+}
+
+// sshRemotePath quotes a path for the remote shell, leaving a leading ~/
+// for it to expand.
+func sshRemotePath(path string) string {
+	prefix := ""
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		prefix, path = "~/", rest
+	}
+	return prefix + "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+// Statistics formats.
+const (
+	statsTable = "table"
+	statsJSON  = "json"
+)
+
+var (
+	statsFormat string
+
+	statsCmd = &cobra.Command{
+		Use:   "stats [PATH...]",
+		Short: "Print statistics of markdown documents",
+		Long: paragraph(fmt.Sprintf("\n%s the words, headings, code blocks, links and images of markdown documents and their estimated reading time, per file and in total. Directories are read recursively.",
+			keyword("Count"))),
+		Example: paragraph("glow stats README.md\nglow stats --format json docs"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runStats(os.Stdout, args)
+		},
+	}
+)
+
+// fileStats are the statistics of a document, or of all of them.
+type fileStats struct {
+	Path string `json:"path,omitempty"`
+	utils.DocumentStats
+	// ReadingTime is the estimated reading time in minutes.
+	ReadingTime int `json:"readingTime"`
+}
+
+func newFileStats(path string, s utils.DocumentStats) fileStats {
+	return fileStats{
+		Path:          path,
+		DocumentStats: s,
+		ReadingTime:   s.ReadingTime(),
+	}
+}
+
+func runStats(w io.Writer, paths []string) error {
+	if statsFormat != statsTable && statsFormat != statsJSON {
+		return fmt.Errorf("invalid statistics format %q, use %s or %s", statsFormat, statsTable, statsJSON)
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := expandArgs(paths, true, depth)
+	if err != nil {
+		return err
+	}
+
+	var (
+		rows  []fileStats
+		total utils.DocumentStats
+	)
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+		content, err = utils.ConvertToMarkdown(content, path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		s := utils.Stats(utils.RemoveFrontmatter(content))
+		rows = append(rows, newFileStats(path, s))
+		total.Add(s)
+	}
+
+	if statsFormat == statsJSON {
+		if rows == nil {
+			rows = []fileStats{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Files []fileStats `json:"files"`
+			Total fileStats   `json:"total"`
+		}{rows, newFileStats("", total)}); err != nil {
+			return fmt.Errorf("unable to encode statistics: %w", err)
+		}
+		return nil
+	}
+
+	writeStatsTable(w, rows, newFileStats("Total", total))
+	return nil
+}
+
+// writeStatsTable writes the statistics of documents as aligned columns,
+// followed by their total if there's more than one.
+func writeStatsTable(w io.Writer, rows []fileStats, total fileStats) {
+	cells := [][]string{{"File", "Words", "Headings", "Code", "Links", "Images", "Reading"}}
+	if len(rows) > 1 {
+		rows = append(rows, total)
+	}
+	for _, r := range rows {
+		cells = append(cells, []string{
+			r.Path,
+			strconv.Itoa(r.Words),
+			strconv.Itoa(r.Headings),
+			strconv.Itoa(r.CodeBlocks),
+			strconv.Itoa(r.Links),
+			strconv.Itoa(r.Images),
+			fmt.Sprintf("%d min", r.ReadingTime),
+		})
+	}
+
+	widths := make([]int, len(cells[0]))
+	for _, row := range cells {
+		for i, c := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(c))
+		}
+	}
+	for n, row := range cells {
+		var b strings.Builder
+		for i, c := range row {
+			pad := strings.Repeat(" ", widths[i]-ansi.StringWidth(c))
+			if i == 0 {
+				// Paths are aligned left, numbers right.
+				b.WriteString(c + pad)
+			} else {
+				b.WriteString("  " + pad + c)
+			}
+		}
+		line := b.String()
+		if n == 0 || (len(rows) > 1 && n == len(cells)-1) {
+			line = separatorStyle.Render(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func init() {
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", statsTable, "output format (table or json)")
+}
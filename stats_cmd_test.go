@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestStats(t *testing.T) {
+	md := "# Title\n\nSome *prose* with a [link](https://example.com) and <https://charm.sh>.\n\n![A long image description](a.png)\n\n```go\nfunc main() {}\n```\n\nInline `code` isn't counted.\n"
+	want := utils.DocumentStats{Words: 10, Headings: 1, CodeBlocks: 1, Links: 2, Images: 1}
+	if got := utils.Stats([]byte(md)); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	defer func() { statsFormat = statsTable }()
+	dir := t.TempDir()
+	long := "# Long\n\n" + strings.Repeat("word ", 250) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "long.md"), []byte(long), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "short.md"), []byte("---\ntitle: Front matter\n---\nTwo words\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	statsFormat = statsJSON
+	var b bytes.Buffer
+	if err := runStats(&b, []string{dir}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var out struct {
+		Files []fileStats `json:"files"`
+		Total fileStats   `json:"total"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &out); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if len(out.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(out.Files))
+	}
+	if out.Total.Words != 253 || out.Total.Headings != 1 || out.Total.ReadingTime != 2 {
+		t.Errorf("unexpected total %+v", out.Total)
+	}
+
+	statsFormat = statsTable
+	b.Reset()
+	if err := runStats(&b, []string{dir}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(b.String(), "Total") || !strings.Contains(b.String(), "2 min") {
+		t.Errorf("expected a total row, got %q", b.String())
+	}
+
+	statsFormat = "xml"
+	if err := runStats(&b, []string{dir}); err == nil {
+		t.Error("expected an error for an invalid format")
+	}
+}
@@ -2,15 +2,17 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
 	"github.com/mattn/go-runewidth"
 )
 
@@ -118,29 +120,31 @@ func executeStreamCLI(src *source, w io.Writer) error {
 	ticker := time.NewTicker(streamRenderInterval)
 	defer ticker.Stop()
 
-	layouts := newStreamTableLayouts()
-	var input bytes.Buffer
-	lastRendered := ""
+	sr, err := newStreamRenderer()
+	if err != nil {
+		return err
+	}
 	dirty := false
 
 	emit := func(final bool) error {
-		rendered, err := renderStreamSnapshot(input.String(), layouts, final)
+		start, stop := time.Now(), timePhase("render")
+		out, err := sr.commit(final)
+		stop()
 		if err != nil {
 			return err
 		}
-		rendered = normalizeStreamOutput(rendered)
-		if rendered == lastRendered {
+		log.Debug("stream render", "buffered", len(sr.pending), "final", final, "output", len(out), "duration", time.Since(start))
+		if out == "" {
 			return nil
 		}
-
-		delta := streamDelta(lastRendered, rendered)
-		if delta == "" {
-			return nil
+		filtered, err := filterOutput([]byte(out), "ansi")
+		if err != nil {
+			return err
 		}
-		if _, err := io.WriteString(w, delta); err != nil {
+		defer timePhase("write")()
+		if _, err := w.Write(filtered); err != nil {
 			return fmt.Errorf("unable to write stream output: %w", err)
 		}
-		lastRendered = rendered
 		return nil
 	}
 
@@ -151,16 +155,14 @@ func executeStreamCLI(src *source, w io.Writer) error {
 				return fmt.Errorf("unable to read from reader: %w", chunk.err)
 			}
 			if len(chunk.data) > 0 {
-				if _, err := input.Write(chunk.data); err != nil {
-					return fmt.Errorf("unable to buffer stream input: %w", err)
-				}
+				sr.write(chunk.data)
 				dirty = true
 			}
 			if chunk.eof {
 				if err := emit(true); err != nil {
 					return err
 				}
-				if lastRendered != "" {
+				if sr.wrote {
 					if _, err := io.WriteString(w, "\n\n"); err != nil {
 						return fmt.Errorf("unable to write stream output: %w", err)
 					}
@@ -202,162 +204,364 @@ func readStream(r io.Reader, out chan<- streamChunk) {
 	}
 }
 
-func renderStreamSnapshot(content string, layouts *streamTableLayouts, final bool) (string, error) {
-	if !final && !strings.Contains(content, "\n") {
-		return "", nil
-	}
+// streamRenderer renders markdown as it streams in. Input is committed in
+// blocks that later input can't change, and each block is rendered once, on
+// its own, so the work of a tick only depends on what's new.
+//
+// Until the first blank line, lines are committed one at a time, for output
+// that doesn't separate its blocks, like logs. Code blocks and tables are
+// committed as their lines arrive.
+type streamRenderer struct {
+	r       *glamour.TermRenderer
+	layouts *streamTableLayouts
+
+	// pending is the input that isn't committed yet.
+	pending string
+
+	// blocks is set by the first blank line.
+	blocks bool
+
+	// fence is the opening line of an open code block, and fenceLines
+	// whether any of its code is committed.
+	fence      string
+	fenceLines bool
+
+	// table are the column widths of an open table.
+	table  []int
+	tables int
+
+	// join is whether the next line may continue the last one, without a
+	// blank line in between, and list whether the last block is a list,
+	// which the items after a blank line continue.
+	join bool
+	list bool
+
+	wrote bool
+}
+
+// streamBlock is a committed part of the input.
+type streamBlock struct {
+	// lines is how many lines of the input the block takes.
+	lines int
+	// markdown is what's rendered of them, if anything.
+	markdown string
+	// joined blocks continue the previous one, rather than following it
+	// after a blank line.
+	joined bool
+	// before and after are blank lines the block starts and ends with.
+	before, after int
+	boundary      string
+}
 
-	prepared := preprocessStreamMarkdown(content, layouts, final)
+func newStreamRenderer() (*streamRenderer, error) {
 	options := []glamour.TermRendererOption{
 		glamour.WithColorProfile(lipgloss.ColorProfile()),
 		utils.GlamourStyle(style, false),
 		glamour.WithWordWrap(int(width)), //nolint:gosec
 		glamour.WithPreservedNewLines(),
 	}
+	if utils.CodeLineNumbers {
+		options = append(options, utils.WithCodeLineNumbers(style, false, int(width), lipgloss.ColorProfile())) //nolint:gosec
+	}
 	r, err := glamour.NewTermRenderer(options...)
 	if err != nil {
-		return "", fmt.Errorf("unable to create renderer: %w", err)
+		return nil, fmt.Errorf("unable to create renderer: %w", err)
 	}
+	return &streamRenderer{r: r, layouts: newStreamTableLayouts()}, nil
+}
 
-	out, err := r.Render(prepared)
-	if err != nil {
-		return "", fmt.Errorf("unable to render markdown: %w", err)
-	}
-	return out, nil
+func (s *streamRenderer) write(p []byte) {
+	s.pending += string(p)
 }
 
-func normalizeStreamOutput(s string) string {
-	if s == "" {
-		return s
+// commit renders the blocks of the pending input that are complete, and
+// returns what they add to the output. With final, all of the input is
+// complete.
+func (s *streamRenderer) commit(final bool) (string, error) {
+	text := s.pending
+	if !final {
+		i := strings.LastIndexByte(text, '\n')
+		if i < 0 {
+			return "", nil
+		}
+		text = text[:i+1]
+	}
+	if text == "" {
+		return "", nil
 	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	partial := s.pending[len(text):]
 
-	lines := strings.Split(s, "\n")
-	for i, line := range lines {
-		lines[i] = strings.TrimRight(line, " \t")
+	var out strings.Builder
+	consumed := 0
+	for len(lines) > 0 {
+		b := s.next(lines, partial, final)
+		if b.lines == 0 {
+			log.Debug("stream hold", "lines", len(lines))
+			break
+		}
+		for _, line := range lines[:b.lines] {
+			consumed += len(line) + 1
+		}
+		lines = lines[b.lines:]
+		if b.markdown == "" && b.before+b.after == 0 {
+			continue
+		}
+
+		log.Debug("stream commit", "boundary", b.boundary, "lines", b.lines, "joined", b.joined)
+		block := make([]string, b.before, b.before+1+b.after)
+		if b.markdown != "" {
+			rendered, err := s.render(b.markdown)
+			if err != nil {
+				return "", err
+			}
+			if rendered != "" {
+				block = append(block, rendered)
+			}
+		}
+		block = append(block, make([]string, b.after)...)
+		if len(block) == 0 {
+			continue
+		}
+		switch {
+		case !s.wrote:
+			out.WriteString("\n")
+		case b.joined:
+			out.WriteString("\n")
+		default:
+			out.WriteString("\n\n")
+		}
+		out.WriteString(strings.Join(block, "\n"))
+		s.wrote = true
 	}
-	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	s.pending = s.pending[min(consumed, len(s.pending)):]
+	return out.String(), nil
 }
 
-func streamDelta(prev, next string) string {
-	if prev == "" {
-		return next
+// next returns the block at the start of lines, or one of no lines if the
+// block isn't complete yet. partial is the incomplete line that follows.
+func (s *streamRenderer) next(lines []string, partial string, final bool) streamBlock {
+	if s.fence != "" {
+		return s.nextCode(lines, final)
 	}
-	if strings.HasPrefix(next, prev) {
-		return next[len(prev):]
+	if s.table != nil {
+		if isTableRowLine(lines[0]) {
+			row := normalizeCells(parseTableCells(lines[0]), len(s.table))
+			return streamBlock{lines: 1, markdown: "```text\n" + formatTableRow(row, s.table) + "```\n", joined: true, boundary: "table row"}
+		}
+		s.table = nil
 	}
 
-	limit := min(len(prev), len(next))
-	i := 0
-	for i < limit && prev[i] == next[i] {
-		i++
+	line := lines[0]
+	if strings.TrimSpace(line) == "" {
+		s.blocks, s.join = true, false
+		return streamBlock{lines: 1}
 	}
-
-	// Keep append-only chunks aligned to full lines.
-	if j := strings.LastIndex(next[:i], "\n"); j >= 0 {
-		i = j + 1
-	} else {
-		i = 0
+	if fence, lang, ok := fenceOpening(line); ok {
+		s.list = false
+		if !utils.DrawsFence(lang) {
+			s.fence, s.fenceLines, s.join = line, false, false
+			return streamBlock{lines: 1}
+		}
+		// Drawings need all of their code.
+		for i := 1; i < len(lines); i++ {
+			if closesFence(lines[i], fence) {
+				return streamBlock{lines: i + 1, markdown: strings.Join(lines[:i+1], "\n") + "\n", boundary: "code block"}
+			}
+		}
+		if final {
+			return streamBlock{lines: len(lines), markdown: strings.Join(lines, "\n") + "\n", boundary: "code block"}
+		}
+		return streamBlock{}
+	}
+	if isTableHeaderLine(line) {
+		if len(lines) == 1 && !final {
+			return streamBlock{}
+		}
+		if headers := parseTableCells(line); len(headers) > 0 && len(lines) > 1 && isTableSeparatorLine(lines[1]) {
+			s.table = s.layouts.layout(s.tables, headers)
+			s.tables++
+			s.join, s.list = false, false
+			return streamBlock{lines: 2, markdown: "```text\n" + formatFixedWidthTable(headers, s.table, nil) + "```\n", boundary: "table"}
+		}
 	}
-	return next[i:]
+	if !s.blocks {
+		return s.nextLine(lines, final)
+	}
+	return s.nextBlock(lines, partial, final)
 }
 
-func preprocessStreamMarkdown(content string, layouts *streamTableLayouts, final bool) string {
-	processable := content
-	if !final {
-		lastNewline := strings.LastIndex(processable, "\n")
-		if lastNewline < 0 {
-			return ""
+// nextCode commits the code of an open code block as it arrives. Blank
+// lines at its end are held, as they could end the block.
+func (s *streamRenderer) nextCode(lines []string, final bool) streamBlock {
+	fence, _, _ := fenceOpening(s.fence)
+	n, closed := len(lines), false
+	for i, line := range lines {
+		if closesFence(line, fence) {
+			n, closed = i, true
+			break
+		}
+	}
+	code := lines[:n]
+	if !closed && !final {
+		for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+			code = code[:len(code)-1]
 		}
-		processable = processable[:lastNewline+1]
 	}
 
-	lines := strings.Split(processable, "\n")
-	if len(lines) > 0 && lines[len(lines)-1] == "" {
-		lines = lines[:len(lines)-1]
+	// Blank lines at the edges of the code would be lost with the blank
+	// lines around the rendered block.
+	b := streamBlock{lines: len(code), joined: s.fenceLines, boundary: "code"}
+	for len(code) > 0 && strings.TrimSpace(code[0]) == "" {
+		code = code[1:]
+		b.before++
 	}
-	if !final && len(lines) > 0 {
-		// Emit only up to the most recent blank-line boundary when possible.
-		// This keeps block-level markdown (lists, paragraphs, headings) from
-		// retroactively changing already-emitted output in stream mode.
-		commitCount := 0
-		for i := len(lines) - 1; i >= 0; i-- {
-			if strings.TrimSpace(lines[i]) == "" {
-				commitCount = i + 1
-				break
-			}
+	for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+		code = code[:len(code)-1]
+		b.after++
+	}
+	if len(code) > 0 {
+		b.markdown = s.fence + "\n" + strings.Join(code, "\n") + "\n" + fence + "\n"
+	}
+	if b.lines > 0 {
+		s.fenceLines = true
+	}
+	if closed || final {
+		s.fence = ""
+		if closed {
+			b.lines++
+		}
+	}
+	return b
+}
+
+// nextLine commits a line once the one after it arrived, which could make
+// it a heading.
+func (s *streamRenderer) nextLine(lines []string, final bool) streamBlock {
+	if len(lines) < 2 && !final {
+		return streamBlock{}
+	}
+	b := streamBlock{lines: 1, boundary: "line"}
+	heading := strings.HasPrefix(strings.TrimSpace(lines[0]), "#")
+	if len(lines) > 1 && isSetextUnderlineLine(lines[1]) {
+		b.lines, heading = 2, true
+	}
+	b.markdown = strings.Join(lines[:b.lines], "\n") + "\n"
+	b.joined = s.join && !heading
+	s.join = !heading
+	return b
+}
+
+// nextBlock commits the lines up to a blank line, once the line after it
+// shows that the block ends there: lines that are indented continue it, like
+// the paragraphs of list items.
+func (s *streamRenderer) nextBlock(lines []string, partial string, final bool) streamBlock {
+	list := isListItemLine(lines[0])
+	b := streamBlock{boundary: "blank line", joined: s.list && list}
+	for i := 1; i < len(lines); i++ {
+		if _, _, ok := fenceOpening(lines[i]); ok || isTableStart(lines[i:]) {
+			b.lines = i
+			break
+		}
+		if strings.TrimSpace(lines[i]) != "" {
+			continue
 		}
 
-		if commitCount == 0 {
-			// Fallback for continuous logs without blank lines: keep one line
-			// buffered to reduce churn from multi-line constructs.
-			commitCount = len(lines) - 1
-			if commitCount > 0 && isSetextUnderlineLine(lines[commitCount-1]) {
-				commitCount--
+		next := partial
+		for _, line := range lines[i+1:] {
+			if strings.TrimSpace(line) != "" {
+				next = line
+				break
 			}
 		}
-		// Stream table rows as they arrive once a table block has started.
-		// Table rows are converted to fixed-width text lines, so completed
-		// rows can be emitted immediately without changing prior lines.
-		if commitCount < len(lines) {
-			if n := committedTablePrefixLen(lines[commitCount:]); n > 0 {
-				commitCount += n
+		if strings.TrimSpace(next) == "" {
+			if !final {
+				return streamBlock{}
 			}
+			b.lines = i
+			break
 		}
-		if commitCount < 0 {
-			commitCount = 0
+		if next[0] != ' ' && next[0] != '\t' {
+			b.lines = i
+			break
 		}
-		lines = lines[:commitCount]
 	}
-	var b strings.Builder
-	tableIdx := 0
-
-	for i := 0; i < len(lines); {
-		if i+1 < len(lines) && isTableHeaderLine(lines[i]) && isTableSeparatorLine(lines[i+1]) {
-			headers := parseTableCells(lines[i])
-			if len(headers) == 0 {
-				b.WriteString(lines[i])
-				b.WriteRune('\n')
-				i++
-				continue
-			}
-
-			widths := layouts.layout(tableIdx, headers)
-			tableIdx++
-
-			rows := make([][]string, 0)
-			j := i + 2
-			for j < len(lines) {
-				line := lines[j]
-				if !isTableRowLine(line) {
-					break
-				}
-				rows = append(rows, parseTableCells(line))
-				j++
-			}
+	if b.lines == 0 {
+		if !final {
+			return streamBlock{}
+		}
+		b.lines = len(lines)
+	}
+	b.markdown = strings.Join(lines[:b.lines], "\n") + "\n"
+	s.list = list
+	return b
+}
 
-			committedRows := len(rows)
+// render renders a block on its own, without the blank lines around it.
+func (s *streamRenderer) render(markdown string) (string, error) {
+	md := []byte(markdown)
+	if utils.Smartypants {
+		md = utils.Smarten(md)
+	}
+	out, err := s.r.RenderBytes(utils.PrepareDefinitionLists(utils.PrepareMarkdown(md)))
+	if err != nil {
+		return "", fmt.Errorf("unable to render markdown: %w", err)
+	}
+	return trimBlankLines(normalizeStreamOutput(string(out))), nil
+}
 
-			if committedRows > 0 {
-				b.WriteString("```text\n")
-				b.WriteString(formatFixedWidthTable(headers, widths, rows[:committedRows]))
-				b.WriteString("```\n")
-			}
+func normalizeStreamOutput(s string) string {
+	if s == "" {
+		return s
+	}
 
-			i = j
-			continue
-		}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
 
-		b.WriteString(lines[i])
-		b.WriteRune('\n')
-		i++
+// fenceOpening returns the fence and language of a line opening a fenced
+// code block.
+func fenceOpening(line string) (fence, lang string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return "", "", false
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+	if n < 3 {
+		return "", "", false
+	}
+	info := strings.TrimSpace(trimmed[n:])
+	if trimmed[0] == '`' && strings.Contains(info, "`") {
+		return "", "", false
 	}
+	if fields := strings.Fields(info); len(fields) > 0 {
+		lang = fields[0]
+	}
+	return trimmed[:n], lang, true
+}
 
-	out := b.String()
-	if hasUnclosedCodeFence(out) {
-		out += "\n```\n"
+// closesFence reports whether a line closes the code block opened by fence.
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
 	}
+	rest := strings.TrimLeft(trimmed, fence[:1])
+	return len(trimmed)-len(rest) >= len(fence) && strings.TrimSpace(rest) == ""
+}
 
-	return out
+func isTableStart(lines []string) bool {
+	return len(lines) > 1 && isTableHeaderLine(lines[0]) && isTableSeparatorLine(lines[1]) &&
+		len(parseTableCells(lines[0])) > 0
+}
+
+var listItemPattern = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])(\s|$)`)
+
+func isListItemLine(s string) bool {
+	return listItemPattern.MatchString(s)
 }
 
 func formatFixedWidthTable(headers []string, widths []int, rows [][]string) string {
@@ -480,6 +684,11 @@ func breakWord(word string, width int) []string {
 	remaining := word
 	for runewidth.StringWidth(remaining) > width {
 		part := runewidth.Truncate(remaining, width, "")
+		if part == "" {
+			// A character wider than the cell gets a line of its own.
+			_, size := utf8.DecodeRuneInString(remaining)
+			part = remaining[:size]
+		}
 		parts = append(parts, part)
 		remaining = strings.TrimPrefix(remaining, part)
 	}
@@ -551,21 +760,6 @@ func isSetextUnderlineLine(s string) bool {
 	return true
 }
 
-func committedTablePrefixLen(lines []string) int {
-	if len(lines) < 2 {
-		return 0
-	}
-	if !isTableHeaderLine(lines[0]) || !isTableSeparatorLine(lines[1]) {
-		return 0
-	}
-
-	n := 2
-	for n < len(lines) && isTableRowLine(lines[n]) {
-		n++
-	}
-	return n
-}
-
 func parseTableCells(line string) []string {
 	trimmed := strings.TrimSpace(line)
 	if trimmed == "" {
@@ -599,14 +793,3 @@ func parseTableCells(line string) []string {
 
 	return parts
 }
-
-func hasUnclosedCodeFence(s string) bool {
-	open := false
-	for _, line := range strings.Split(s, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "```") {
-			open = !open
-		}
-	}
-	return open
-}
@@ -9,7 +9,9 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"unicode"
 
+	"github.com/charmbracelet/x/ansi"
 	"github.com/mattn/go-runewidth"
 )
 
@@ -50,20 +52,21 @@ func TestStreamTableLayoutFitsTerminalWidth(t *testing.T) {
 	}
 }
 
-func TestPreprocessStreamsTableRowsAsTheyArrive(t *testing.T) {
-	layouts := newStreamTableLayouts()
+func TestStreamRendererStreamsTableRowsAsTheyArrive(t *testing.T) {
+	sr := newTestStreamRenderer(t)
 
-	first := "\n| id | note |\n| --- | --- |\n| 1 | hello world |\n"
-	out := preprocessStreamMarkdown(first, layouts, false)
+	out := streamCommit(t, sr, "\n| id | note |\n| --- | --- |\n| 1 | hello world |\n", false)
 	if !strings.Contains(out, "hello") || !strings.Contains(out, "world") {
 		t.Fatalf("expected first row to be emitted immediately, output:\n%s", out)
 	}
 
-	second := first + "| 2 | second row |\n"
-	out = preprocessStreamMarkdown(second, layouts, false)
+	out = streamCommit(t, sr, "| 2 | second row |\n", false)
 	if !strings.Contains(out, "second") || !strings.Contains(out, "row") {
 		t.Fatalf("expected second row to be emitted immediately, output:\n%s", out)
 	}
+	if strings.Contains(out, "hello") {
+		t.Fatalf("expected only the new row to be rendered, output:\n%s", out)
+	}
 }
 
 func TestTableFormattingWrapsWithinFixedWidth(t *testing.T) {
@@ -87,54 +90,31 @@ func TestTableFormattingWrapsWithinFixedWidth(t *testing.T) {
 	}
 }
 
-func TestStreamSnapshotPrefixForNewlineInput(t *testing.T) {
-	layouts := newStreamTableLayouts()
-	style = "dark"
-	width = 80
-
-	first, err := renderStreamSnapshot("a\nb\n", layouts, false)
-	if err != nil {
-		t.Fatalf("unexpected render error: %v", err)
-	}
-	second, err := renderStreamSnapshot("a\nb\nc\n", layouts, false)
-	if err != nil {
-		t.Fatalf("unexpected render error: %v", err)
-	}
-
-	first = normalizeStreamOutput(first)
-	second = normalizeStreamOutput(second)
-
-	if !strings.HasPrefix(second, first) {
-		t.Fatalf("expected second snapshot to extend first\nfirst:\n%q\nsecond:\n%q", first, second)
+func TestTableFormattingBreaksWideCharacters(t *testing.T) {
+	row := formatTableRow([]string{"世界"}, []int{3})
+	if !strings.Contains(row, "世") || !strings.Contains(row, "界") {
+		t.Fatalf("expected characters wider than the cell on lines of their own, got %q", row)
 	}
 }
 
-func TestStreamSnapshotPrefixForSetextHeading(t *testing.T) {
-	layouts := newStreamTableLayouts()
-	style = "dark"
-	width = 80
+func TestStreamRendererHoldsSetextHeading(t *testing.T) {
+	sr := newTestStreamRenderer(t)
 
-	first, err := renderStreamSnapshot("Title\n", layouts, false)
-	if err != nil {
-		t.Fatalf("unexpected render error: %v", err)
+	if out := streamCommit(t, sr, "Title\n", false); out != "" {
+		t.Fatalf("expected a lone line to be held, got %q", out)
 	}
-	second, err := renderStreamSnapshot("Title\n=====\n", layouts, false)
-	if err != nil {
-		t.Fatalf("unexpected render error: %v", err)
+	out := streamCommit(t, sr, "=====\n", false) + streamCommit(t, sr, "", true)
+	if c := strings.Count(out, "Title"); c != 1 {
+		t.Fatalf("expected the heading exactly once, got %d in %q", c, out)
 	}
-
-	first = normalizeStreamOutput(first)
-	second = normalizeStreamOutput(second)
-
-	if !strings.HasPrefix(second, first) {
-		t.Fatalf("expected second snapshot to extend first\nfirst:\n%q\nsecond:\n%q", first, second)
+	if strings.Contains(out, "=====") {
+		t.Fatalf("expected the underline to make a heading, got %q", out)
 	}
 }
 
-func TestPreprocessCommitsOnlyToBlankLineBoundary(t *testing.T) {
-	layouts := newStreamTableLayouts()
-	in := "a\nb\n\nc\n"
-	out := preprocessStreamMarkdown(in, layouts, false)
+func TestStreamRendererCommitsOnlyToBlankLineBoundary(t *testing.T) {
+	sr := newTestStreamRenderer(t)
+	out := streamCommit(t, sr, "a\nb\n\nc\n", false)
 
 	if strings.Contains(out, "c") {
 		t.Fatalf("expected trailing block to remain buffered, output:\n%s", out)
@@ -142,37 +122,103 @@ func TestPreprocessCommitsOnlyToBlankLineBoundary(t *testing.T) {
 	if !strings.Contains(out, "a") || !strings.Contains(out, "b") {
 		t.Fatalf("expected committed block to be present, output:\n%s", out)
 	}
+	if out := streamCommit(t, sr, "", true); !strings.Contains(out, "c") {
+		t.Fatalf("expected the rest to be committed at the end, output:\n%s", out)
+	}
 }
 
-func TestStreamDeltaUsesCommonPrefix(t *testing.T) {
-	prev := "a\nb\nc\n"
-	next := "a\nb\nX\nc\n"
-	got := streamDelta(prev, next)
+func TestStreamRendererStreamsCode(t *testing.T) {
+	sr := newTestStreamRenderer(t)
 
-	if got != "X\nc\n" {
-		t.Fatalf("unexpected delta: %q", got)
+	out := streamCommit(t, sr, "Intro\n\n```go\nfirst := 1\n", false)
+	if !strings.Contains(out, "first") {
+		t.Fatalf("expected code to be emitted as it arrives, output:\n%s", out)
+	}
+	out = streamCommit(t, sr, "\nsecond := 2\n```\n", false)
+	if !strings.Contains(out, "second") || strings.Contains(out, "first") {
+		t.Fatalf("expected only the new code to be rendered, output:\n%s", out)
 	}
 }
 
-func FuzzStreamDeltaAppendOnly(f *testing.F) {
-	f.Add("a\nb\n", "a\nb\nc\n")
-	f.Add("Title\n", "Title\n=====\n")
-	f.Add("", "hello\n")
-	f.Add("abc", "xyz")
+func TestStreamRendererChunksMatchWhole(t *testing.T) {
+	in := "Title\n=====\n\nSome *text*\nthat wraps.\n\n" +
+		"- one\n\n  more of one\n- two\n\n1. first\n\n2. second\n\n" +
+		"```go\nfunc main() {\n\n}\n```\n\n| a | b |\n| --- | --- |\n| 1 | 2 |\nafter\n\n> quote\n"
+
+	whole := streamCommit(t, newTestStreamRenderer(t), in, true)
+
+	sr := newTestStreamRenderer(t)
+	var chunked strings.Builder
+	for _, line := range strings.SplitAfter(in, "\n") {
+		chunked.WriteString(streamCommit(t, sr, line, false))
+	}
+	chunked.WriteString(streamCommit(t, sr, "", true))
+
+	if got, want := plainStreamOutput(chunked.String()), plainStreamOutput(whole); got != want {
+		t.Fatalf("expected streamed output to match rendering at once\nstreamed:\n%s\nwhole:\n%s", got, want)
+	}
+}
 
-	f.Fuzz(func(t *testing.T, prev, next string) {
-		delta := streamDelta(prev, next)
+func FuzzStreamRendererAppendOnly(f *testing.F) {
+	f.Add("a\nb\n", 2)
+	f.Add("Title\n=====\n", 6)
+	f.Add("- a\n\n  b\n- c\n", 5)
+	f.Add("```\ncode\n\nmore\n```\n", 9)
+	f.Add("| a |\n| --- |\n| 1 |\n", 3)
 
-		if strings.HasPrefix(next, prev) && delta != next[len(prev):] {
-			t.Fatalf("prefix case must emit exact suffix: prev=%q next=%q delta=%q", prev, next, delta)
+	f.Fuzz(func(t *testing.T, in string, split int) {
+		if split < 0 || split > len(in) {
+			return
+		}
+		// glamour wraps lines too long for the width depending on the
+		// lines around them, and control characters throw off highlighting.
+		for _, line := range strings.Split(in, "\n") {
+			if len(line) > 40 || strings.ContainsFunc(line, unicode.IsControl) {
+				return
+			}
 		}
+		whole := streamCommit(t, newTestStreamRenderer(t), in, true)
 
-		if delta != "" && !strings.HasSuffix(next, delta) && !strings.Contains(next, delta) {
-			t.Fatalf("delta must come from next snapshot: next=%q delta=%q", next, delta)
+		sr := newTestStreamRenderer(t)
+		chunked := streamCommit(t, sr, in[:split], false)
+		chunked += streamCommit(t, sr, in[split:], true)
+		if got, want := plainStreamOutput(chunked), plainStreamOutput(whole); got != want {
+			t.Fatalf("streamed output differs: in=%q split=%d\nstreamed: %q\nwhole:    %q", in, split, got, want)
 		}
 	})
 }
 
+// plainStreamOutput is what output looks like, without its styles, which
+// differ on blank lines.
+func plainStreamOutput(s string) string {
+	lines := strings.Split(ansi.Strip(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func newTestStreamRenderer(t *testing.T) *streamRenderer {
+	t.Helper()
+	style = "dark"
+	width = 80
+	sr, err := newStreamRenderer()
+	if err != nil {
+		t.Fatalf("unable to create renderer: %v", err)
+	}
+	return sr
+}
+
+func streamCommit(t *testing.T, sr *streamRenderer, in string, final bool) string {
+	t.Helper()
+	sr.write([]byte(in))
+	out, err := sr.commit(final)
+	if err != nil {
+		t.Fatalf("unexpected render error: %v", err)
+	}
+	return out
+}
+
 func TestStreamPTYNoReplayFixture(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping PTY integration test in short mode")
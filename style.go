@@ -11,4 +11,11 @@ var (
 			Width(78).
 			Padding(0, 0, 0, 2).
 			Render
+
+	separatorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#04B575")).
+			Bold(true)
+
+	sectionStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#8E8E8E", Dark: "#747373"})
 )
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/editor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// styleSampleDocument is the document styles are previewed with.
+const styleSampleDocument = "# Heading 1\n\n## Heading 2\n\n### Heading 3\n\n" +
+	"A paragraph with **strong**, *emphasized*, ~~deleted~~ and `inline code`, " +
+	"plus a [link](https://github.com/charmbracelet/glow).\n\n" +
+	"> A block quote.\n\n" +
+	"- An item\n- Another item\n  1. A nested, numbered item\n- [x] A task\n\n" +
+	"```go\nfunc main() {\n\tfmt.Println(\"Hello, world!\") // greet\n}\n```\n\n" +
+	"| Name | Value |\n|------|-------|\n| foo  | 42    |\n\n---\n\n" +
+	"Term\n: Definition\n"
+
+var (
+	styleBase       string
+	styleForce      bool
+	styleSamplePath string
+
+	styleCmd = &cobra.Command{
+		Use:   "style",
+		Short: "Create and edit custom styles",
+		Long:  paragraph(fmt.Sprintf("\n%s your own glamour style, starting from a built-in one.", keyword("Create"))),
+		Example: paragraph("glow style init\nglow style init --from dracula mystyle.json\n" +
+			"glow style edit mystyle.json\nglow style themes"),
+		Args: cobra.NoArgs,
+	}
+
+	styleInitCmd = &cobra.Command{
+		Use:   "init [FILE]",
+		Short: "Write the active style to a JSON file",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			path := stylePathFromArgs(args)
+			if err := writeStyle(path); err != nil {
+				return err
+			}
+			fmt.Println("Wrote style to:", path)
+			fmt.Println("Use it with: glow --style", path)
+			return nil
+		},
+	}
+
+	styleEditCmd = &cobra.Command{
+		Use:   "edit [FILE]",
+		Short: "Edit a style in your editor and preview it",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return editStyle(stylePathFromArgs(args))
+		},
+	}
+
+	styleThemesCmd = &cobra.Command{
+		Use:   "themes",
+		Short: "List the syntax highlighting themes for --code-theme",
+		Args:  cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			for _, name := range chromastyles.Names() {
+				fmt.Println(name)
+			}
+		},
+	}
+)
+
+// stylePathFromArgs returns the style file given as argument or, by default,
+// style.json next to the config file.
+func stylePathFromArgs(args []string) string {
+	if len(args) > 0 {
+		return utils.ExpandPath(args[0])
+	}
+	return filepath.Join(filepath.Dir(configFilePath()), "style.json")
+}
+
+// writeStyle writes the active style, or the one given by --from, to path.
+func writeStyle(path string) error {
+	base := styleBase
+	if base == "" {
+		base = viper.GetString("style")
+	}
+	if base == "" || base == styles.NoTTYStyle {
+		base = styles.AutoStyle
+	}
+	if err := validateStyle(base); err != nil {
+		return err
+	}
+
+	cfg, err := utils.GlamourStyleConfig(base)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode style: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return fmt.Errorf("unable to create directory: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if styleForce {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644) //nolint:gosec
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create style file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("unable to write style file: %w", err)
+	}
+	return nil
+}
+
+// editStyle opens a style in the editor and previews it, until the user is
+// done editing. The style is created first if it doesn't exist.
+func editStyle(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := writeStyle(path); err != nil {
+			return err
+		}
+	}
+
+	sample := styleSampleDocument
+	if styleSamplePath != "" {
+		b, err := os.ReadFile(utils.ExpandPath(styleSamplePath))
+		if err != nil {
+			return fmt.Errorf("unable to read sample document: %w", err)
+		}
+		sample = string(b)
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	for {
+		c, err := editor.Cmd("Glow", path)
+		if err != nil {
+			return fmt.Errorf("unable to open editor: %w", err)
+		}
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("unable to run command: %w", err)
+		}
+
+		out, err := previewStyle(path, sample)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		fmt.Print(out)
+
+		fmt.Print("Edit again? [Y/n] ")
+		answer, err := input.ReadString('\n')
+		if err != nil || strings.EqualFold(strings.TrimSpace(answer), "n") {
+			break
+		}
+	}
+
+	fmt.Println("Use it with: glow --style", path)
+	return nil
+}
+
+// previewStyle renders a document with a style file.
+func previewStyle(path, sample string) (string, error) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		glamour.WithStylesFromJSONFile(path),
+		glamour.WithWordWrap(int(width)), //nolint:gosec
+	)
+	if err != nil {
+		return "", fmt.Errorf("unable to load style: %w", err)
+	}
+	out, err := r.Render(sample)
+	if err != nil {
+		return "", fmt.Errorf("unable to render markdown: %w", err)
+	}
+	return out, nil
+}
+
+func init() {
+	styleInitCmd.Flags().StringVar(&styleBase, "from", "", "style to start from (default from config)")
+	styleInitCmd.Flags().BoolVarP(&styleForce, "force", "f", false, "overwrite an existing style file")
+	styleEditCmd.Flags().StringVar(&styleBase, "from", "", "style to start from, if the file doesn't exist yet (default from config)")
+	styleEditCmd.Flags().StringVar(&styleSamplePath, "sample", "", "markdown file to preview the style with")
+	styleCmd.AddCommand(styleInitCmd, styleEditCmd, styleThemesCmd)
+}
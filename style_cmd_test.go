@@ -0,0 +1,32 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteStyle(t *testing.T) {
+	defer func() { styleBase, styleForce = "", false }()
+	path := filepath.Join(t.TempDir(), "styles", "mystyle.json")
+
+	styleBase = "dracula"
+	if err := writeStyle(path); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := writeStyle(path); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected existing style not to be overwritten, got %v", err)
+	}
+	styleForce = true
+	if err := writeStyle(path); err != nil {
+		t.Fatalf("expected style to be overwritten, got %v", err)
+	}
+
+	out, err := previewStyle(path, "# Title\n")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out, "Title") {
+		t.Errorf("expected preview to contain the sample, got %q", out)
+	}
+}
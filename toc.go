@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// tocMarkdown returns a table of contents as an indented markdown list, or an
+// empty string if there are no headings.
+func tocMarkdown(headings []utils.Heading) string {
+	if len(headings) == 0 {
+		return ""
+	}
+	base := headings[0].Level
+	for _, h := range headings {
+		base = min(base, h.Level)
+	}
+
+	var b strings.Builder
+	b.WriteString("**Contents**\n\n")
+	for _, h := range headings {
+		b.WriteString(strings.Repeat("  ", h.Level-base) + "- " + h.Text + "\n")
+	}
+	return b.String() + "\n"
+}
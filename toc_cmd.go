@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+)
+
+// Outline formats.
+const (
+	outlineText     = "text"
+	outlineMarkdown = "markdown"
+	outlineJSON     = "json"
+)
+
+var (
+	outlineFormat  string
+	outlineAnchors bool
+	outlineDepth   int
+
+	tocCmd = &cobra.Command{
+		Use:   "toc [SOURCE]",
+		Short: "Print the outline of a markdown document",
+		Long: paragraph(fmt.Sprintf("\n%s the heading tree of a markdown document as indented text, a markdown list or JSON.",
+			keyword("Print"))),
+		Example: paragraph("glow toc README.md\nglow toc --format markdown --anchors README.md\nglow toc --format json --depth 2 README.md"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			content, err := readSourceArg(args)
+			if err != nil {
+				return err
+			}
+			return writeOutline(os.Stdout, content)
+		},
+	}
+)
+
+// outlineHeading is a heading and the headings of its subsections.
+type outlineHeading struct {
+	Text     string            `json:"text"`
+	Level    int               `json:"level"`
+	Anchor   string            `json:"anchor"`
+	Line     int               `json:"line"`
+	Children []*outlineHeading `json:"children,omitempty"`
+}
+
+// writeOutline writes the headings of a document in the --format.
+func writeOutline(w io.Writer, content []byte) error {
+	source := utils.RemoveFrontmatter(content)
+	skipped := strings.Count(string(content[:len(content)-len(source)]), "\n")
+	headings := utils.TableOfContents(source, outlineDepth)
+
+	switch outlineFormat {
+	case outlineJSON:
+		tree := outlineTree(headings, skipped)
+		if tree == nil {
+			tree = []*outlineHeading{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tree); err != nil {
+			return fmt.Errorf("unable to encode outline: %w", err)
+		}
+		return nil
+	case outlineText, outlineMarkdown:
+	default:
+		return fmt.Errorf("invalid outline format %q, use %s, %s or %s",
+			outlineFormat, outlineText, outlineMarkdown, outlineJSON)
+	}
+
+	base := 0
+	for i, h := range headings {
+		if i == 0 || h.Level < base {
+			base = h.Level
+		}
+	}
+	for _, h := range headings {
+		indent := strings.Repeat("  ", h.Level-base)
+		switch {
+		case outlineFormat == outlineMarkdown && outlineAnchors:
+			fmt.Fprintf(w, "%s- [%s](#%s)\n", indent, h.Text, h.Slug)
+		case outlineFormat == outlineMarkdown:
+			fmt.Fprintf(w, "%s- %s\n", indent, h.Text)
+		case outlineAnchors:
+			fmt.Fprintf(w, "%s%s #%s\n", indent, h.Text, h.Slug)
+		default:
+			fmt.Fprintf(w, "%s%s\n", indent, h.Text)
+		}
+	}
+	return nil
+}
+
+// outlineTree nests headings under the closest preceding heading of a
+// higher level. Lines are one-based, skipped lines of front matter included.
+func outlineTree(headings []utils.Heading, skipped int) []*outlineHeading {
+	var (
+		roots []*outlineHeading
+		stack []*outlineHeading
+	)
+	for _, h := range headings {
+		node := &outlineHeading{Text: h.Text, Level: h.Level, Anchor: h.Slug, Line: h.Line + skipped + 1}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, node)
+	}
+	return roots
+}
+
+func init() {
+	tocCmd.Flags().StringVarP(&outlineFormat, "format", "f", outlineText, "output format: text, markdown or json")
+	tocCmd.Flags().BoolVar(&outlineAnchors, "anchors", false, "include the anchors of headings")
+	tocCmd.Flags().IntVar(&outlineDepth, "depth", 0, "heading levels to include below the top-most one (0 for all)")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteOutline(t *testing.T) {
+	defer func() { outlineFormat, outlineAnchors, outlineDepth = outlineText, false, 0 }()
+	md := "---\ntitle: x\n---\n## Doc\n\n### Install\n\n#### Go\n\n### Usage\n"
+
+	for _, tc := range []struct {
+		format  string
+		anchors bool
+		depth   int
+		want    string
+	}{
+		{outlineText, false, 0, "Doc\n  Install\n    Go\n  Usage\n"},
+		{outlineText, true, 2, "Doc #doc\n  Install #install\n  Usage #usage\n"},
+		{outlineMarkdown, true, 0, "- [Doc](#doc)\n  - [Install](#install)\n    - [Go](#go)\n  - [Usage](#usage)\n"},
+	} {
+		outlineFormat, outlineAnchors, outlineDepth = tc.format, tc.anchors, tc.depth
+		var b bytes.Buffer
+		if err := writeOutline(&b, []byte(md)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if b.String() != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, b.String())
+		}
+	}
+
+	outlineFormat, outlineDepth = outlineJSON, 0
+	var b bytes.Buffer
+	if err := writeOutline(&b, []byte(md)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var tree []outlineHeading
+	if err := json.Unmarshal(b.Bytes(), &tree); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if len(tree) != 1 || len(tree[0].Children) != 2 || tree[0].Children[0].Children[0].Text != "Go" {
+		t.Errorf("expected a nested outline, got %+v", tree)
+	}
+	if tree[0].Line != 4 || tree[0].Children[1].Line != 10 {
+		t.Errorf("expected lines of the file, got %+v", tree)
+	}
+
+	outlineFormat = "yaml"
+	if err := writeOutline(&b, []byte(md)); err == nil {
+		t.Error("expected an error for an invalid format")
+	}
+}
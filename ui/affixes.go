@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// affixes are the prefix and suffix rules of a hunspell dictionary, which
+// turn the stems of its .dic file into the words they stand for, like
+// "document/DSG" into "documented", "documents" and "documenting".
+// Compounding and the other, rarer options of the format aren't supported.
+type affixes struct {
+	flagType string
+	aliases  []string
+	prefixes map[string][]affixRule
+	suffixes map[string][]affixRule
+}
+
+// affixRule adds a prefix or suffix to stems matching its condition, in
+// place of the characters it strips.
+type affixRule struct {
+	strip, add string
+	cond       *regexp.Regexp
+	cross      bool
+}
+
+// readAffixes reads the .aff file of a hunspell dictionary.
+func readAffixes(path string) (*affixes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open affix file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	a := &affixes{
+		prefixes: map[string][]affixRule{},
+		suffixes: map[string][]affixRule{},
+	}
+	// Rules follow a header with their flag, whether they combine with
+	// affixes of the other kind, and how many of them there are.
+	remaining := map[string]int{}
+	cross := map[string]bool{}
+	aliasCount := false
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "FLAG":
+			a.flagType = fields[1]
+		case "AF":
+			// The first AF line is the number of aliases.
+			if aliasCount {
+				a.aliases = append(a.aliases, fields[1])
+			}
+			aliasCount = true
+		case "PFX", "SFX":
+			if len(fields) < 4 {
+				continue
+			}
+			key := fields[0] + fields[1]
+			if remaining[key] == 0 {
+				n, err := strconv.Atoi(fields[3])
+				if err != nil {
+					continue
+				}
+				remaining[key], cross[key] = n, fields[2] == "Y"
+				continue
+			}
+			remaining[key]--
+			cond := "."
+			if len(fields) > 4 {
+				cond = fields[4]
+			}
+			rule, err := newAffixRule(fields[0] == "PFX", fields[2], fields[3], cond)
+			if err != nil {
+				continue
+			}
+			rule.cross = cross[key]
+			if fields[0] == "PFX" {
+				a.prefixes[fields[1]] = append(a.prefixes[fields[1]], rule)
+			} else {
+				a.suffixes[fields[1]] = append(a.suffixes[fields[1]], rule)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read affix file: %w", err)
+	}
+	return a, nil
+}
+
+// newAffixRule parses a rule. "0" stands for nothing to strip or add, the
+// flags of the affix itself after a slash are ignored, and the condition is
+// a pattern of characters, classes like [^aeiou] and dots.
+func newAffixRule(prefix bool, strip, add, cond string) (affixRule, error) {
+	if strip == "0" {
+		strip = ""
+	}
+	add, _, _ = strings.Cut(add, "/")
+	if add == "0" {
+		add = ""
+	}
+
+	var b strings.Builder
+	inClass := false
+	for _, r := range cond {
+		switch {
+		case r == '[' && !inClass:
+			inClass = true
+			b.WriteRune(r)
+		case r == ']' && inClass:
+			inClass = false
+			b.WriteRune(r)
+		case r == '.' && !inClass:
+			b.WriteRune(r)
+		case r == '^' && inClass:
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern := "(?:" + b.String() + ")$"
+	if prefix {
+		pattern = "^(?:" + b.String() + ")"
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return affixRule{}, fmt.Errorf("invalid affix condition %q: %w", cond, err)
+	}
+	return affixRule{strip: strip, add: add, cond: re}, nil
+}
+
+// flags splits the flags of a stem, as declared by the FLAG option.
+func (a *affixes) flags(s string) []string {
+	if n, err := strconv.Atoi(s); err == nil && len(a.aliases) > 0 {
+		if n < 1 || n > len(a.aliases) {
+			return nil
+		}
+		s = a.aliases[n-1]
+	}
+	var flags []string
+	switch a.flagType {
+	case "long":
+		for i := 0; i+1 < len(s); i += 2 {
+			flags = append(flags, s[i:i+2])
+		}
+	case "num":
+		flags = strings.Split(s, ",")
+	default:
+		for _, r := range s {
+			flags = append(flags, string(r))
+		}
+	}
+	return flags
+}
+
+// expand returns the words a stem with the given flags stands for, the stem
+// included.
+func (a *affixes) expand(stem, flags string) []string {
+	words := []string{stem}
+	if flags == "" {
+		return words
+	}
+	fs := a.flags(flags)
+
+	crossable := []string{stem}
+	for _, f := range fs {
+		for _, r := range a.suffixes[f] {
+			if !strings.HasSuffix(stem, r.strip) || !r.cond.MatchString(stem) {
+				continue
+			}
+			w := stem[:len(stem)-len(r.strip)] + r.add
+			words = append(words, w)
+			if r.cross {
+				crossable = append(crossable, w)
+			}
+		}
+	}
+	for _, f := range fs {
+		for _, r := range a.prefixes[f] {
+			bases := crossable
+			if !r.cross {
+				bases = []string{stem}
+			}
+			for _, base := range bases {
+				if strings.HasPrefix(base, r.strip) && r.cond.MatchString(base) {
+					words = append(words, r.add+base[len(r.strip):])
+				}
+			}
+		}
+	}
+	return words
+}
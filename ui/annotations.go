@@ -0,0 +1,346 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/reflow/truncate"
+)
+
+// annotationsFileName is the sidecar file annotations are stored in. There's
+// one per directory, keyed by document file name.
+const annotationsFileName = ".glow-annotations.json"
+
+var (
+	highlightStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("#1B1B1B")).Background(yellowGreen)
+	annotatedHighlightStyle = highlightStyle.Underline(true)
+	annotationListStyle     = footnotePopupStyle
+)
+
+// annotation is a highlighted piece of text in a document, optionally with
+// a note attached. Since documents change, annotations are anchored by
+// content rather than position: the highlighted text, the source line it was
+// found on, and which occurrence of the text it was.
+type annotation struct {
+	Text       string    `json:"text"`
+	Context    string    `json:"context,omitempty"`
+	Occurrence int       `json:"occurrence"`
+	Note       string    `json:"note,omitempty"`
+	Created    time.Time `json:"created"`
+}
+
+// resolvedAnnotation is an annotation located in the rendered document. If
+// the anchor text can no longer be found the annotation is orphaned.
+type resolvedAnnotation struct {
+	annotation
+	line, col int
+	orphaned  bool
+}
+
+type (
+	annotationsLoadedMsg struct {
+		path        string
+		annotations []annotation
+	}
+	annotationsSavedMsg struct{ err error }
+)
+
+// annotationList is the popup listing the annotations of a document.
+type annotationList struct {
+	cursor int
+}
+
+func annotationsPath(docPath string) string {
+	return filepath.Join(filepath.Dir(docPath), annotationsFileName)
+}
+
+func readAnnotationsFile(path string) (map[string][]annotation, error) {
+	all := map[string][]annotation{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read annotations: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("unable to parse annotations: %w", err)
+	}
+	return all, nil
+}
+
+func loadAnnotations(docPath string) tea.Cmd {
+	return func() tea.Msg {
+		all, err := readAnnotationsFile(annotationsPath(docPath))
+		if err != nil {
+			log.Error("unable to load annotations", "path", docPath, "error", err)
+			return nil
+		}
+		return annotationsLoadedMsg{docPath, all[filepath.Base(docPath)]}
+	}
+}
+
+func saveAnnotations(docPath string, annotations []annotation) tea.Cmd {
+	return func() tea.Msg {
+		path := annotationsPath(docPath)
+		all, err := readAnnotationsFile(path)
+		if err != nil {
+			return annotationsSavedMsg{err}
+		}
+
+		key := filepath.Base(docPath)
+		if len(annotations) == 0 {
+			delete(all, key)
+		} else {
+			all[key] = annotations
+		}
+
+		data, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			return annotationsSavedMsg{fmt.Errorf("unable to encode annotations: %w", err)}
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil { //nolint:gosec
+			return annotationsSavedMsg{fmt.Errorf("unable to write annotations: %w", err)}
+		}
+		return annotationsSavedMsg{}
+	}
+}
+
+// textOccurrences returns the line and column of every occurrence of text in
+// the given lines, ignoring any styling.
+func textOccurrences(lines []string, text string) [][2]int {
+	var occ [][2]int
+	if text == "" {
+		return occ
+	}
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		offset := 0
+		for {
+			j := strings.Index(plain[offset:], text)
+			if j < 0 {
+				break
+			}
+			occ = append(occ, [2]int{i, ansi.StringWidth(plain[:offset+j])})
+			offset += j + len(text)
+		}
+	}
+	return occ
+}
+
+// sourceOccurrence returns which occurrence of text in the source the first
+// occurrence on the given context line is, or -1 if the context line can't be
+// found anymore.
+func sourceOccurrence(source, text, context string) int {
+	if context == "" {
+		return -1
+	}
+	n := 0
+	for _, line := range strings.Split(source, "\n") {
+		if strings.TrimSpace(line) == context && strings.Contains(line, text) {
+			return n
+		}
+		n += strings.Count(line, text)
+	}
+	return -1
+}
+
+// sourceContext returns the source line holding the nth occurrence of text.
+func sourceContext(source, text string, n int) string {
+	for _, line := range strings.Split(source, "\n") {
+		c := strings.Count(line, text)
+		if n < c {
+			return strings.TrimSpace(line)
+		}
+		n -= c
+	}
+	return ""
+}
+
+// resolveAnnotations locates annotations in the rendered lines. If the
+// document changed since an annotation was made we prefer the occurrence on
+// the original source line, then the original occurrence index, and finally
+// the last occurrence of the text.
+func resolveAnnotations(source string, lines []string, annotations []annotation) []resolvedAnnotation {
+	resolved := make([]resolvedAnnotation, 0, len(annotations))
+	for _, a := range annotations {
+		r := resolvedAnnotation{annotation: a}
+		occ := textOccurrences(lines, a.Text)
+		if len(occ) == 0 {
+			r.orphaned = true
+			resolved = append(resolved, r)
+			continue
+		}
+
+		idx := sourceOccurrence(source, a.Text, a.Context)
+		if idx < 0 {
+			idx = a.Occurrence
+		}
+		idx = min(idx, len(occ)-1)
+		r.line, r.col = occ[idx][0], occ[idx][1]
+		resolved = append(resolved, r)
+	}
+	return resolved
+}
+
+// highlightRange restyles the given columns of a rendered line.
+func highlightRange(line string, col, width int, style lipgloss.Style) string {
+	left := ansi.Cut(line, 0, col)
+	mid := ansi.Strip(ansi.Cut(line, col, col+width))
+	right := ansi.Cut(line, col+width, ansi.StringWidth(line))
+	return left + ansi.ResetStyle + style.Render(mid) + right
+}
+
+// decorateLines applies annotation highlights to the rendered lines.
+func decorateLines(lines []string, resolved []resolvedAnnotation) []string {
+	if len(resolved) == 0 {
+		return lines
+	}
+	out := append([]string(nil), lines...)
+	for _, r := range resolved {
+		if r.orphaned || r.line >= len(out) {
+			continue
+		}
+		style := highlightStyle
+		if r.Note != "" {
+			style = annotatedHighlightStyle
+		}
+		out[r.line] = highlightRange(out[r.line], r.col, ansi.StringWidth(r.Text), style)
+	}
+	return out
+}
+
+// refreshContent updates the viewport with the rendered document and its
+// highlights.
+func (m *pagerModel) refreshContent() {
+	m.resolved = resolveAnnotations(m.currentDocument.Body, m.lines, m.annotations)
+	lines := decorateLines(underlineMisspellings(m.lines, m.misspelled), m.resolved)
+	if m.tasks != nil {
+		lines = m.tasks.decorate(lines)
+	}
+	if m.follow != nil {
+		lines = m.follow.decorate(lines)
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// startHighlight asks for text to highlight, then for an optional note.
+func (m *pagerModel) startHighlight() tea.Cmd {
+	if m.currentDocument.localPath == "" {
+		return m.showStatusMessage(pagerStatusMessage{tr("Only local files can be annotated"), true})
+	}
+	return m.startPrompt(tr("Highlight:"), func(m *pagerModel, text string) tea.Cmd {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil
+		}
+
+		occ := textOccurrences(m.lines, text)
+		if len(occ) == 0 {
+			return m.showStatusMessage(pagerStatusMessage{tr("Text not found"), true})
+		}
+		// Prefer the first occurrence in view.
+		idx := 0
+		for i, o := range occ {
+			if o[0] >= m.viewport.YOffset {
+				idx = i
+				break
+			}
+		}
+
+		a := annotation{
+			Text:       text,
+			Context:    sourceContext(m.currentDocument.Body, text, idx),
+			Occurrence: idx,
+			Created:    time.Now(),
+		}
+		return m.startPrompt(tr("Note (optional):"), func(m *pagerModel, note string) tea.Cmd {
+			a.Note = strings.TrimSpace(note)
+			m.annotations = append(m.annotations, a)
+			m.refreshContent()
+			return saveAnnotations(m.currentDocument.localPath, m.annotations)
+		})
+	})
+}
+
+func (m *pagerModel) showAnnotationList() tea.Cmd {
+	if len(m.annotations) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{tr("No annotations"), false})
+	}
+	m.annotationList = &annotationList{}
+	return m.enterOverlay()
+}
+
+func (m *pagerModel) closeAnnotationList() tea.Cmd {
+	m.annotationList = nil
+	return m.leaveOverlay()
+}
+
+// handleAnnotationListKeys handles keystrokes while the annotation list is
+// open.
+func (m *pagerModel) handleAnnotationListKeys(msg tea.KeyMsg) tea.Cmd {
+	l := m.annotationList
+	switch msg.String() {
+	case "up", "k":
+		l.cursor = max(0, l.cursor-1)
+	case "down", "j":
+		l.cursor = min(len(m.resolved)-1, l.cursor+1)
+	case keyEnter:
+		r := m.resolved[l.cursor]
+		cmd := m.closeAnnotationList()
+		if r.orphaned {
+			return tea.Batch(cmd, m.showStatusMessage(pagerStatusMessage{tr("Highlighted text is missing"), true}))
+		}
+		m.viewport.SetYOffset(r.line)
+		return tea.Batch(cmd, m.syncViewport())
+	case "d", "x":
+		m.annotations = append(m.annotations[:l.cursor:l.cursor], m.annotations[l.cursor+1:]...)
+		m.refreshContent()
+		save := saveAnnotations(m.currentDocument.localPath, m.annotations)
+		if len(m.annotations) == 0 {
+			return tea.Batch(save, m.closeAnnotationList())
+		}
+		l.cursor = min(l.cursor, len(m.annotations)-1)
+		return save
+	case "q", keyEsc, "M":
+		return m.closeAnnotationList()
+	}
+	return nil
+}
+
+// annotationListView renders the popup listing the document's annotations.
+func (m pagerModel) annotationListView() string {
+	width := max(10, min(footnotePopupMaxWidth+10, m.viewport.Width-4))
+	var b strings.Builder
+	for i, r := range m.resolved {
+		where := fmt.Sprintf("%4d", r.line+1)
+		if r.orphaned {
+			where = "   ?"
+		}
+		entry := r.Text
+		if r.Note != "" {
+			entry += " — " + r.Note
+		}
+		entry = truncate.StringWithTail(entry, uint(max(0, width-8)), ellipsis) //nolint:gosec
+
+		if i == m.annotationList.cursor {
+			b.WriteString(fuchsiaFg("› " + where + "  " + entry))
+		} else {
+			b.WriteString(grayFg("  "+where) + "  " + entry)
+		}
+		if i < len(m.resolved)-1 {
+			b.WriteRune('\n')
+		}
+	}
+	return annotationListStyle.Width(width).Render(b.String())
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTextOccurrences(t *testing.T) {
+	lines := []string{"  foo and foo", "  \x1b[1mfoo\x1b[0m", "  界 foo", "  nothing"}
+	want := [][2]int{{0, 2}, {0, 10}, {1, 2}, {2, 5}}
+	if got := textOccurrences(lines, "foo"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := textOccurrences(lines, ""); len(got) != 0 {
+		t.Errorf("expected no occurrences of empty text, got %v", got)
+	}
+}
+
+func TestResolveAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		source string
+		a      annotation
+		want   resolvedAnnotation
+	}{
+		{
+			name:   "unchanged",
+			source: "one foo\ntwo foo\n",
+			a:      annotation{Text: "foo", Context: "two foo", Occurrence: 1},
+			want:   resolvedAnnotation{line: 1, col: 6},
+		},
+		{
+			name:   "line inserted before",
+			source: "zero foo\none foo\ntwo foo\n",
+			a:      annotation{Text: "foo", Context: "two foo", Occurrence: 1},
+			want:   resolvedAnnotation{line: 2, col: 6},
+		},
+		{
+			name:   "context line changed",
+			source: "one foo\ntwo foo, edited\n",
+			a:      annotation{Text: "foo", Context: "two foo", Occurrence: 1},
+			want:   resolvedAnnotation{line: 1, col: 6},
+		},
+		{
+			name:   "occurrences removed",
+			source: "one foo\n",
+			a:      annotation{Text: "foo", Context: "three foo", Occurrence: 2},
+			want:   resolvedAnnotation{line: 0, col: 6},
+		},
+		{
+			name:   "text removed",
+			source: "one bar\n",
+			a:      annotation{Text: "foo", Context: "one foo"},
+			want:   resolvedAnnotation{orphaned: true},
+		},
+	} {
+		// Rendering indents the lines of the source.
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSuffix(tc.source, "\n"), "\n") {
+			lines = append(lines, "  "+line)
+		}
+		got := resolveAnnotations(tc.source, lines, []annotation{tc.a})
+		tc.want.annotation = tc.a
+		if len(got) != 1 || !reflect.DeepEqual(got[0], tc.want) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package ui
 
+import "net/http"
+
 // Config contains TUI-specific configuration.
 type Config struct {
 	ShowAllFiles     bool
@@ -11,9 +13,58 @@ type Config struct {
 	EnableMouse      bool
 	PreserveNewLines bool
 
+	// Pager layout: blank columns left and right of the document, the
+	// maximum document width, centered if the terminal is wider, and blank
+	// lines above and below it
+	MarginLeft  int
+	MarginRight int
+	MaxWidth    uint
+	Padding     int
+
+	// Open the outline sidebar with headings down to this depth when showing
+	// a document, 0 to keep it closed
+	TOCDepth int
+
+	// Accessibility mode: no alternate screen, no animations and plain ASCII
+	// borders
+	Accessible bool
+
+	// Notes appended from the pager
+	NoteHeading string
+	NoteInbox   string
+
+	// Locale of the TUI's messages, dates and numbers, e.g. de_DE
+	Locale string `env:"GLOW_LOCALE"`
+
+	// Layout of dates, like "2006-01-02 15:04", instead of the locale's
+	DateFormat string `env:"GLOW_DATE_FORMAT"`
+
+	// Spell checking language and directory of user dictionaries
+	SpellLang      string `env:"GLOW_SPELL_LANG"`
+	DictionaryPath string
+
+	// Resolve [[wiki links]] against the local markdown files that were found
+	WikiLinks bool
+
+	// Patterns of files and directories left out when finding files
+	Exclude []string
+
 	// Working directory or file path
 	Path string
 
+	// Remote document to download and show, if any
+	Remote string
+	Fetch  Fetcher `env:"-"`
+
+	// Documents listed instead of local files, like of a remote repository
+	RemoteFiles []RemoteFile `env:"-"`
+
+	// Transport of HTTP requests, like of link checks
+	HTTPTransport http.RoundTripper `env:"-"`
+
+	// Filters rendered documents before they're shown, like redacting them
+	OutputFilter func(string) (string, error) `env:"-"`
+
 	// For debugging the UI
 	HighPerformancePager bool `env:"GLOW_HIGH_PERFORMANCE_PAGER" envDefault:"true"`
 	GlamourEnabled       bool `env:"GLOW_ENABLE_GLAMOUR"         envDefault:"true"`
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+const fetchTickInterval = 100 * time.Millisecond
+
+// Fetcher opens a remote document, returning its contents and the URL it was
+// resolved to.
+type Fetcher func() (io.ReadCloser, string, error)
+
+// RemoteFile is a document listed instead of local files, like one of a
+// remote repository or an entry of a feed, which is read when it's opened.
+type RemoteFile struct {
+	Path  string
+	Fetch Fetcher
+}
+
+// remoteFilesMsg lists the documents of a remote repository.
+type remoteFilesMsg []RemoteFile
+
+func listRemoteFiles(files []RemoteFile) tea.Cmd {
+	return func() tea.Msg {
+		return remoteFilesMsg(files)
+	}
+}
+
+// readRemoteMarkdown downloads a document of a remote repository.
+func readRemoteMarkdown(md *markdown) tea.Msg {
+	r, url, err := md.fetch()
+	if err != nil {
+		log.Debug("error fetching remote file", "error", err)
+		return errMsg{err}
+	}
+	defer r.Close() //nolint:errcheck
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errMsg{fmt.Errorf("unable to read response: %w", err)}
+	}
+	data, err = utils.ConvertToMarkdown(data, url)
+	if err != nil {
+		return errMsg{err}
+	}
+	md.Body = string(data)
+	return fetchedMarkdownMsg(md)
+}
+
+var fetchFrames = []string{"|", "/", "-", "\\"}
+
+// remoteFetch is the state of a document being downloaded.
+type remoteFetch struct {
+	name  string
+	read  *atomic.Int64
+	ticks int
+	err   error
+}
+
+type (
+	fetchTickMsg     struct{}
+	remoteFetchedMsg struct {
+		url  string
+		body string
+		err  error
+	}
+)
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err //nolint:wrapcheck
+}
+
+func fetchTick() tea.Cmd {
+	return tea.Tick(fetchTickInterval, func(time.Time) tea.Msg {
+		return fetchTickMsg{}
+	})
+}
+
+// fetchRemote downloads a remote document, keeping track of the bytes
+// transferred.
+func fetchRemote(fetch Fetcher, read *atomic.Int64) tea.Cmd {
+	return func() tea.Msg {
+		read.Store(0)
+		r, url, err := fetch()
+		if err != nil {
+			return remoteFetchedMsg{err: err}
+		}
+		defer r.Close() //nolint:errcheck
+
+		b, err := io.ReadAll(countingReader{r, read})
+		if err != nil {
+			return remoteFetchedMsg{err: fmt.Errorf("unable to read response: %w", err)}
+		}
+		b, err = utils.ConvertToMarkdown(b, url)
+		if err != nil {
+			return remoteFetchedMsg{err: err}
+		}
+		return remoteFetchedMsg{url: url, body: string(b)}
+	}
+}
+
+// startFetch starts, or retries, downloading the remote document.
+func (m *model) startFetch() tea.Cmd {
+	m.fetch.err = nil
+	m.fetch.ticks = 0
+	return tea.Batch(fetchRemote(m.common.cfg.Fetch, m.fetch.read), fetchTick())
+}
+
+func (m model) updateFetch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			if m.fetch.err != nil {
+				return m, m.startFetch()
+			}
+		case "q", keyEsc, "ctrl+c":
+			return m, tea.Quit
+		}
+
+	case tea.WindowSizeMsg:
+		m.common.width = msg.Width
+		m.common.height = msg.Height
+		m.stash.setSize(msg.Width, msg.Height)
+		m.pager.setSize(msg.Width, msg.Height)
+
+	case fetchTickMsg:
+		if m.fetch.err == nil {
+			m.fetch.ticks++
+			return m, fetchTick()
+		}
+
+	case remoteFetchedMsg:
+		if msg.err != nil {
+			log.Error("unable to fetch document", "source", m.fetch.name, "error", msg.err)
+			m.fetch.err = msg.err
+			return m, nil
+		}
+		m.pager.setDocument(markdown{Body: msg.body, Note: msg.url})
+		return m, renderWithGlamour(m.pager, string(utils.RemoveFrontmatter([]byte(m.pager.currentDocument.Body))))
+
+	case contentRenderedMsg:
+		m.state = stateShowDocument
+		var cmd tea.Cmd
+		m.pager, cmd = m.pager.update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m model) fetchView() string {
+	f := m.fetch
+	if f.err != nil {
+		s := fmt.Sprintf("%s\n\n%s\n\n%v\n\n%s",
+			errorTitleStyle.Render(tr("ERROR")),
+			f.name,
+			f.err,
+			subtleStyle.Render(tr("r retry • q quit")),
+		)
+		return "\n" + indent(s, 3)
+	}
+
+	indicator := " "
+	if !m.common.cfg.Accessible {
+		indicator = fetchFrames[f.ticks%len(fetchFrames)]
+	}
+	status := trf("Fetching %s...", f.name)
+	if n := f.read.Load(); n > 0 {
+		status += " " + formatSize(n)
+	}
+	return "\n" + indent(" "+indicator+" "+status, stashIndent)
+}
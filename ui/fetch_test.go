@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFetchProgressAndRetry(t *testing.T) {
+	body := "# Doc\n\n" + strings.Repeat("text ", 500)
+	fetches := 0
+	fetch := func() (io.ReadCloser, string, error) {
+		fetches++
+		if fetches == 1 {
+			return nil, "", errors.New("connection reset")
+		}
+		return io.NopCloser(strings.NewReader(body)), "https://example.com/doc.md", nil
+	}
+	m := newModel(Config{GlamourStyle: "notty", Remote: "https://example.com/doc.md", Fetch: fetch}, "").(model)
+	if m.state != stateFetching {
+		t.Fatalf("expected the document to be fetched, got state %v", m.state)
+	}
+
+	next, _ := m.Update(fetchRemote(m.common.cfg.Fetch, m.fetch.read)())
+	m = next.(model)
+	if m.fetch.err == nil || !strings.Contains(m.fetchView(), "connection reset") || !strings.Contains(m.fetchView(), "r retry") {
+		t.Fatalf("expected the error and how to retry, got %q", m.fetchView())
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = next.(model)
+	if cmd == nil || m.fetch.err != nil {
+		t.Fatal("expected r to retry the fetch")
+	}
+	msg, ok := fetchRemote(m.common.cfg.Fetch, m.fetch.read)().(remoteFetchedMsg)
+	if !ok || msg.err != nil || msg.body != body {
+		t.Fatalf("expected the document, got %+v", msg)
+	}
+	if n := m.fetch.read.Load(); n != int64(len(body)) {
+		t.Errorf("expected %d bytes to be counted, got %d", len(body), n)
+	}
+	if view := m.fetchView(); !strings.Contains(view, formatSize(int64(len(body)))) {
+		t.Errorf("expected the bytes transferred in %q", view)
+	}
+
+	next, _ = m.Update(msg)
+	if doc := next.(model).pager.currentDocument; doc.Body != body || doc.Note != "https://example.com/doc.md" {
+		t.Errorf("expected the fetched document to be shown, got %+v", doc)
+	}
+}
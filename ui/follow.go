@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// followMode holds the state of the pager's link following mode, in which
+// links can be opened: local markdown documents, including resolved wiki
+// links, in the pager and other links with their link handler.
+type followMode struct {
+	links []utils.Link
+	selection
+}
+
+// visitedDocument is a document we followed a link away from.
+type visitedDocument struct {
+	doc    markdown
+	offset int
+}
+
+// resolveWikiLinks turns wiki links of the current document into links to the
+// local files that were found, if enabled.
+func (m pagerModel) resolveWikiLinks(source []byte) []byte {
+	if !m.common.cfg.WikiLinks || m.currentDocument.localPath == "" {
+		return source
+	}
+	return utils.ResolveWikiLinks(source, filepath.Dir(m.currentDocument.localPath), m.common.wikiPages)
+}
+
+// localDocumentPath returns the markdown file a link points to, relative to
+// the current document, and the anchor of the link.
+func (m pagerModel) localDocumentPath(dest string) (path, anchor string, ok bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Path == "" || !utils.IsMarkdownFile(u.Path) {
+		return "", "", false
+	}
+	path = u.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(m.currentDocument.localPath), path)
+	}
+	return path, u.Fragment, true
+}
+
+// linkTarget returns what a link of the current document opens: the path of
+// a local file, relative to the current document, or a URL. Links to anchors,
+// and relative links of remote documents, have none.
+func (m pagerModel) linkTarget(dest string) (string, bool) {
+	u, err := url.Parse(dest)
+	switch {
+	case err != nil || dest == "" || strings.HasPrefix(dest, "#"):
+		return "", false
+	case u.Scheme != "" && u.Scheme != "file":
+		return dest, true
+	case m.currentDocument.localPath == "":
+		return "", false
+	}
+	p := u.Path
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(filepath.Dir(m.currentDocument.localPath), p)
+	}
+	return p, true
+}
+
+// enterFollowMode selects the first link at or below the top of the
+// viewport.
+func (m *pagerModel) enterFollowMode() tea.Cmd {
+	f := &followMode{}
+	source := m.resolveWikiLinks(utils.RemoveFrontmatter([]byte(m.currentDocument.Body)))
+	var texts []string
+	for _, l := range utils.Links(source) {
+		if _, ok := m.linkTarget(l.Destination); ok && !l.Image {
+			f.links = append(f.links, l)
+			texts = append(texts, l.Text)
+		}
+	}
+	if len(f.links) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{tr("No links found."), false})
+	}
+
+	f.locate(m.lines, texts)
+	f.selectFrom(m.viewport.YOffset)
+	m.follow = f
+	m.scrollToSelection(f.selection)
+	m.refreshContent()
+	return m.syncViewport()
+}
+
+func (m *pagerModel) exitFollowMode() tea.Cmd {
+	m.follow = nil
+	m.refreshContent()
+	return m.syncViewport()
+}
+
+// handleFollowKeys handles keystrokes in link following mode.
+func (m *pagerModel) handleFollowKeys(msg tea.KeyMsg) tea.Cmd {
+	f := m.follow
+	switch key := msg.String(); {
+	case f.move(key):
+	case key == keyEnter:
+		dest := f.links[f.index].Destination
+		if _, _, ok := m.localDocumentPath(dest); ok {
+			if h := utils.LinkHandler(dest); h == "" || h == utils.GlowHandler {
+				return m.openLinkedDocument(dest)
+			}
+		}
+		target, _ := m.linkTarget(dest)
+		return openLink(target)
+	case key == "q" || key == keyEsc || key == "w":
+		return m.exitFollowMode()
+	default:
+		return nil
+	}
+
+	m.scrollToSelection(f.selection)
+	m.refreshContent()
+	return m.syncViewport()
+}
+
+// openLinkedDocument shows the document a link points to, remembering where
+// we came from.
+func (m *pagerModel) openLinkedDocument(dest string) tea.Cmd {
+	path, anchor, _ := m.localDocumentPath(dest)
+	info, err := os.Stat(path)
+	if err != nil && filepath.Ext(path) == "" {
+		// Wikis link to pages without their extension.
+		if matches, _ := filepath.Glob(path + ".*"); len(matches) > 0 {
+			path = matches[0]
+			info, err = os.Stat(path)
+		}
+	}
+	if err != nil || info.IsDir() {
+		return m.showStatusMessage(pagerStatusMessage{tr("File not found"), true})
+	}
+
+	history := append(m.history, visitedDocument{m.currentDocument, m.viewport.YOffset})
+	m.unload()
+	m.history = history
+	m.jumpAnchor = anchor
+
+	cwd, _ := os.Getwd()
+	return loadLocalMarkdown(&markdown{
+		localPath: path,
+		Note:      stripAbsolutePath(path, cwd),
+		Modtime:   info.ModTime(),
+		Size:      info.Size(),
+	})
+}
+
+type linkOpenedMsg struct{ err error }
+
+// openLink opens a link with its link handler, which gets the terminal until
+// it exits.
+func openLink(link string) tea.Cmd {
+	cb := func(err error) tea.Msg {
+		return linkOpenedMsg{err}
+	}
+	cmd, err := utils.LinkCommand(link, "--tui")
+	if err != nil {
+		return func() tea.Msg { return cb(err) }
+	}
+	return tea.ExecProcess(cmd, cb)
+}
+
+// returnToPreviousDocument shows the document we last followed a link away
+// from, at the position we left it.
+func (m *pagerModel) returnToPreviousDocument() tea.Cmd {
+	if len(m.history) == 0 {
+		return nil
+	}
+	last := m.history[len(m.history)-1]
+	history := m.history[:len(m.history)-1]
+	m.unload()
+	m.history = history
+	m.jumpOffset = last.offset
+	return loadLocalMarkdown(&last.doc)
+}
+
+// jumpToPendingPosition scrolls a newly rendered document to the anchor of the
+// link it was opened with, or back to where we left it.
+func (m *pagerModel) jumpToPendingPosition() tea.Cmd {
+	if m.jumpAnchor == "" && m.jumpOffset == 0 {
+		return nil
+	}
+	line := m.jumpOffset
+	if m.jumpAnchor != "" {
+		headings := utils.Headings(utils.RemoveFrontmatter([]byte(m.currentDocument.Body)))
+		found := headingLines(m.lines, headings)
+		for i, h := range headings {
+			if h.Slug == m.jumpAnchor && found[i] >= 0 {
+				line = found[i]
+				break
+			}
+		}
+	}
+	m.jumpAnchor, m.jumpOffset = "", 0
+	m.viewport.SetYOffset(line)
+	return m.syncViewport()
+}
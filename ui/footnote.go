@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const footnotePopupMaxWidth = 60
+
+var (
+	footnoteDefPattern = regexp.MustCompile(`^\s{0,3}\[\^([^\]\s]+)\]:\s?(.*)$`)
+	footnoteRefPattern = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+
+	footnotePopupStyle = lipgloss.NewStyle().
+				Border(popupBorder).
+				BorderForeground(fuchsia).
+				Padding(0, 1)
+	footnoteLabelStyle = lipgloss.NewStyle().Foreground(fuchsia).Bold(true)
+)
+
+// footnoteRef is the position of a footnote reference in the rendered
+// document.
+type footnoteRef struct {
+	label string
+	line  int
+	col   int
+}
+
+// footnotePopup is the footnote preview currently displayed in the pager.
+type footnotePopup struct {
+	ref  footnoteRef
+	text string
+}
+
+// parseFootnotes collects footnote definitions from markdown source, keyed by
+// label. Indented lines directly following a definition are treated as its
+// continuation. Fenced code blocks are skipped.
+func parseFootnotes(md string) map[string]string {
+	notes := map[string]string{}
+	var current, fence string
+
+	for _, line := range strings.Split(md, "\n") {
+		if fence != "" {
+			if closesCodeFence(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = codeFence(line); fence != "" {
+			current = ""
+			continue
+		}
+		if m := footnoteDefPattern.FindStringSubmatch(line); m != nil {
+			current = m[1]
+			notes[current] = strings.TrimSpace(m[2])
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		if strings.TrimSpace(line) == "" || !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			current = ""
+			continue
+		}
+		notes[current] = strings.TrimSpace(notes[current] + " " + strings.TrimSpace(line))
+	}
+
+	return notes
+}
+
+// findFootnoteRefs returns the positions of all footnote references in the
+// rendered lines, skipping the definitions themselves.
+func findFootnoteRefs(lines []string) []footnoteRef {
+	var refs []footnoteRef
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		for _, loc := range footnoteRefPattern.FindAllStringSubmatchIndex(plain, -1) {
+			if loc[1] < len(plain) && plain[loc[1]] == ':' {
+				continue
+			}
+			refs = append(refs, footnoteRef{
+				label: plain[loc[2]:loc[3]],
+				line:  i,
+				col:   ansi.StringWidth(plain[:loc[0]]),
+			})
+		}
+	}
+	return refs
+}
+
+// findFootnoteDefinition returns the rendered line on which the footnote with
+// the given label is defined, or -1 if it can't be found.
+func findFootnoteDefinition(lines []string, label string) int {
+	prefix := "[^" + label + "]:"
+	for i, line := range lines {
+		if strings.Contains(ansi.Strip(line), prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// footnotePopupView renders the preview box for a footnote.
+func footnotePopupView(p footnotePopup, maxWidth int) string {
+	w := min(footnotePopupMaxWidth, maxWidth-4)
+	text := p.text
+	if text == "" {
+		text = subtleStyle.Render(tr("Footnote not found."))
+	}
+	body := footnoteLabelStyle.Render("["+p.ref.label+"]") + " " + text
+	return footnotePopupStyle.Width(max(1, w)).Render(body)
+}
+
+// overlay draws box on top of view with its top-left corner at the given row
+// and column.
+func overlay(view, box string, row, col int) string {
+	lines := strings.Split(view, "\n")
+	for i, boxLine := range strings.Split(box, "\n") {
+		y := row + i
+		if y < 0 || y >= len(lines) {
+			continue
+		}
+		line := lines[y]
+		left := ansi.Truncate(line, col, "")
+		if pad := col - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := ansi.TruncateLeft(line, col+ansi.StringWidth(boxLine), "")
+		lines[y] = left + ansi.ResetStyle + boxLine + right
+	}
+	return strings.Join(lines, "\n")
+}
+
+// visibleFootnoteRefs returns the footnote references in the visible part of
+// the document.
+func (m pagerModel) visibleFootnoteRefs() []footnoteRef {
+	top := m.viewport.YOffset
+	bottom := top + m.viewport.Height
+
+	var refs []footnoteRef
+	for _, r := range findFootnoteRefs(m.lines) {
+		if r.line >= top && r.line < bottom {
+			refs = append(refs, r)
+		}
+	}
+	return refs
+}
+
+// previewFootnote opens a preview of the first (or last) footnote reference
+// in view. If a preview is already open it moves on to the next (or previous)
+// reference.
+func (m *pagerModel) previewFootnote(forward bool) tea.Cmd {
+	refs := m.visibleFootnoteRefs()
+	if len(refs) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{tr("No footnotes in view"), false})
+	}
+
+	idx := 0
+	if !forward {
+		idx = len(refs) - 1
+	}
+	if m.footnote != nil {
+		for i, r := range refs {
+			if r != m.footnote.ref {
+				continue
+			}
+			if forward {
+				idx = (i + 1) % len(refs)
+			} else {
+				idx = (i - 1 + len(refs)) % len(refs)
+			}
+			break
+		}
+	}
+
+	ref := refs[idx]
+	m.footnote = &footnotePopup{
+		ref:  ref,
+		text: parseFootnotes(m.currentDocument.Body)[ref.label],
+	}
+	return m.enterOverlay()
+}
+
+func (m *pagerModel) closeFootnote() tea.Cmd {
+	m.footnote = nil
+	return m.leaveOverlay()
+}
+
+// handleFootnoteKeys handles keystrokes while a footnote preview is open.
+func (m *pagerModel) handleFootnoteKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "]", "tab":
+		return m.previewFootnote(true)
+	case "[", "shift+tab":
+		return m.previewFootnote(false)
+	case keyEnter:
+		line := findFootnoteDefinition(m.lines, m.footnote.ref.label)
+		if line < 0 {
+			return m.showStatusMessage(pagerStatusMessage{tr("Footnote definition not found"), true})
+		}
+		m.footnoteReturns = append(m.footnoteReturns, m.viewport.YOffset)
+		cmd := m.closeFootnote()
+		m.viewport.SetYOffset(line)
+		return tea.Batch(cmd, m.syncViewport(), m.showStatusMessage(pagerStatusMessage{tr("Press backspace to jump back"), false}))
+	case "q", keyEsc, "backspace":
+		return m.closeFootnote()
+	}
+	return nil
+}
+
+// returnFromFootnote scrolls back to where we were before following the most
+// recent footnote.
+func (m *pagerModel) returnFromFootnote() tea.Cmd {
+	if len(m.footnoteReturns) == 0 {
+		return nil
+	}
+	last := len(m.footnoteReturns) - 1
+	m.viewport.SetYOffset(m.footnoteReturns[last])
+	m.footnoteReturns = m.footnoteReturns[:last]
+	return m.syncViewport()
+}
+
+// overlayView draws any open popup on top of the viewport view.
+func (m pagerModel) overlayView(view string) string {
+	if m.table != nil {
+		return m.tableOverlayView(view)
+	}
+	if m.annotationList != nil {
+		return overlay(view, m.annotationListView(), 1, 2)
+	}
+	if m.linkCheck != nil {
+		return overlay(view, m.linkCheckView(), 1, 2)
+	}
+	if m.outline != nil {
+		return m.outlineOverlayView(view)
+	}
+	if m.footnote == nil {
+		return view
+	}
+
+	box := footnotePopupView(*m.footnote, m.viewport.Width)
+	boxHeight := lipgloss.Height(box)
+	boxWidth := lipgloss.Width(box)
+
+	row := m.footnote.ref.line - m.viewport.YOffset + 1
+	if row+boxHeight > m.viewport.Height {
+		row = max(0, m.footnote.ref.line-m.viewport.YOffset-boxHeight)
+	}
+	col := max(0, min(m.footnote.ref.col, m.viewport.Width-boxWidth))
+
+	return overlay(view, box, row, col)
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFootnotes(t *testing.T) {
+	for _, tc := range []struct {
+		md   string
+		want map[string]string
+	}{
+		{"Text[^1].\n\n[^1]: The note.\n", map[string]string{"1": "The note."}},
+		{"[^a]: First line\n    continued\n\tand tabbed\n", map[string]string{"a": "First line continued and tabbed"}},
+		{"[^a]: Note\nNot indented\n    not a continuation\n", map[string]string{"a": "Note"}},
+		{"[^a]: Note\n\n    after a blank line\n", map[string]string{"a": "Note"}},
+		{"   [^x]: Indented three\n    [^y]: Indented four\n", map[string]string{"x": "Indented three [^y]: Indented four"}},
+		{"[^a]: One\n[^b]: Two\n", map[string]string{"a": "One", "b": "Two"}},
+		{"No footnotes [^here] at all.\n", map[string]string{}},
+		{"```md\n[^a]: In code\n```\n~~~\n[^b]: Tilde\n```\n~~~~\n[^c]: Real\n", map[string]string{"c": "Real"}},
+		{"[^a]: Note\n```\n    code\n```\n", map[string]string{"a": "Note"}},
+	} {
+		if got := parseFootnotes(tc.md); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseFootnotes(%q): expected %v, got %v", tc.md, tc.want, got)
+		}
+	}
+}
+
+func TestFindFootnoteRefs(t *testing.T) {
+	lines := []string{
+		"  Some text[^1] and more[^note].",
+		"  \x1b[1mbold\x1b[0m[^2]",
+		"  [^1]: The definition.",
+		"  Nothing here.",
+		"  界[^wide]",
+	}
+	want := []footnoteRef{
+		{label: "1", line: 0, col: 11},
+		{label: "note", line: 0, col: 24},
+		{label: "2", line: 1, col: 6},
+		{label: "wide", line: 4, col: 4},
+	}
+	if got := findFootnoteRefs(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		view     string
+		box      string
+		row, col int
+		want     string
+	}{
+		{
+			name: "middle",
+			view: "aaaaaa\nbbbbbb\ncccccc",
+			box:  "XX\nYY",
+			row:  1, col: 2,
+			want: "aaaaaa\nbb\x1b[mXXbb\ncc\x1b[mYYcc",
+		},
+		{
+			name: "past the end of a line",
+			view: "ab\ncd",
+			box:  "XX",
+			row:  0, col: 4,
+			want: "ab  \x1b[mXX\ncd",
+		},
+		{
+			name: "below the view",
+			view: "ab\ncd",
+			box:  "XX\nYY",
+			row:  1, col: 0,
+			want: "ab\n\x1b[mXX",
+		},
+		{
+			name: "styled line",
+			view: "\x1b[1mabcd\x1b[0m",
+			box:  "X",
+			row:  0, col: 1,
+			want: "\x1b[1ma\x1b[0m\x1b[mX\x1b[1mcd\x1b[0m",
+		},
+	} {
+		if got := overlay(tc.view, tc.box, tc.row, tc.col); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
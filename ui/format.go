@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeFormat is how a locale writes dates and numbers.
+type localeFormat struct {
+	// Layout of dates with their time
+	date string
+	// Decimal and thousands separators
+	decimal string
+	group   string
+}
+
+// defaultFormat is used when no locale is set, or one without a format
+// below.
+var defaultFormat = localeFormat{date: "02 Jan 2006 15:04 MST", decimal: ".", group: ","}
+
+// localeFormats are the formats of locales, by language and territory like
+// en_GB, or by language for the rest of its territories.
+var localeFormats = map[string]localeFormat{
+	"en":    {date: "Jan 2, 2006 3:04 PM", decimal: ".", group: ","},
+	"en_AU": {date: "2 Jan 2006 15:04", decimal: ".", group: ","},
+	"en_GB": {date: "2 Jan 2006 15:04", decimal: ".", group: ","},
+	"en_IE": {date: "2 Jan 2006 15:04", decimal: ".", group: ","},
+	"en_IN": {date: "2 Jan 2006 15:04", decimal: ".", group: ","},
+	"en_NZ": {date: "2 Jan 2006 15:04", decimal: ".", group: ","},
+	"de":    {date: "02.01.2006 15:04", decimal: ",", group: "."},
+	"de_CH": {date: "02.01.2006 15:04", decimal: ".", group: "’"},
+	"es":    {date: "02/01/2006 15:04", decimal: ",", group: "."},
+	"fr":    {date: "02/01/2006 15:04", decimal: ",", group: " "},
+	"it":    {date: "02/01/2006 15:04", decimal: ",", group: "."},
+	"ja":    {date: "2006/01/02 15:04", decimal: ".", group: ","},
+	"nl":    {date: "02-01-2006 15:04", decimal: ",", group: "."},
+	"pl":    {date: "02.01.2006 15:04", decimal: ",", group: " "},
+	"pt":    {date: "02/01/2006 15:04", decimal: ",", group: "."},
+	"ru":    {date: "02.01.2006 15:04", decimal: ",", group: " "},
+	"sv":    {date: "2006-01-02 15:04", decimal: ",", group: " "},
+	"zh":    {date: "2006/01/02 15:04", decimal: ".", group: ","},
+}
+
+var (
+	// dateFormat is the layout dates are written with, and numberFormat the
+	// separators of numbers.
+	dateFormat   = defaultFormat.date
+	numberFormat = defaultFormat
+)
+
+// setFormatLocale sets how dates and numbers are written: in the format of
+// locale or, if that's empty, of the locale of LC_TIME and LC_NUMERIC. A
+// date layout overrides the one of the locale.
+func setFormatLocale(locale, dateLayout string) {
+	timeLocale, numericLocale := locale, locale
+	if locale == "" {
+		timeLocale, numericLocale = envLocale("LC_TIME"), envLocale("LC_NUMERIC")
+	}
+	dateFormat = lookupFormat(timeLocale).date
+	if dateLayout != "" {
+		dateFormat = dateLayout
+	}
+	numberFormat = lookupFormat(numericLocale)
+}
+
+// lookupFormat returns the format of a locale like de_DE.UTF-8, falling
+// back to the one of its language.
+func lookupFormat(locale string) localeFormat {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale = strings.ReplaceAll(locale, "-", "_")
+	if f, ok := localeFormats[locale]; ok {
+		return f
+	}
+	lang, _, _ := strings.Cut(locale, "_")
+	if f, ok := localeFormats[strings.ToLower(lang)]; ok {
+		return f
+	}
+	return defaultFormat
+}
+
+// formatDate writes a date with its time.
+func formatDate(t time.Time) string {
+	return t.Format(dateFormat)
+}
+
+// formatNumber writes a number with the given number of decimals, with the
+// separators of the locale.
+func formatNumber(n float64, decimals int) string {
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(numberFormat.group)
+		}
+		b.WriteRune(r)
+	}
+	if frac != "" {
+		b.WriteString(numberFormat.decimal)
+		b.WriteString(frac)
+	}
+	return sign + b.String()
+}
+
+// formatSize writes a size in bytes in SI units, like 1.2 MB, with a decimal
+// for sizes below 10 of their unit.
+func formatSize(n int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB", "PB"}
+	size, unit := float64(n), 0
+	for size >= 1000 && unit < len(units)-1 {
+		size /= 1000
+		unit++
+	}
+	decimals := 0
+	if unit > 0 && size < 10 {
+		decimals = 1
+	}
+	return formatNumber(size, decimals) + " " + units[unit]
+}
+
+// formatReadingTime writes an estimated reading time in minutes.
+func formatReadingTime(minutes int) string {
+	return trf("%s min read", formatNumber(float64(max(1, minutes)), 0))
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Translations of the TUI are stored in locales/<lang>.json, where lang is
+// a language code like "de" or a language and territory like "pt_BR". Each
+// file maps the English messages, as they appear in the source, to their
+// translation. See locales/README.md for how to add a language.
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// messages is the catalog of the active locale. Messages missing from it are
+// shown in English.
+var messages map[string]string
+
+// setLocale activates the message catalog for locale, e.g. "de_DE.UTF-8",
+// falling back to the bare language and then to English. An empty locale is
+// derived from the environment. It returns the catalog that was selected.
+func setLocale(locale string) string {
+	if locale == "" {
+		locale = systemLocale()
+	}
+	locale, _, _ = strings.Cut(locale, ".")
+	locale = strings.ReplaceAll(locale, "-", "_")
+
+	messages = nil
+	candidates := []string{locale}
+	if lang, _, ok := strings.Cut(locale, "_"); ok {
+		candidates = append(candidates, lang)
+	}
+	for _, c := range candidates {
+		catalog, err := loadCatalog(c)
+		if err != nil {
+			continue
+		}
+		log.Debug("using locale", "locale", c)
+		messages = catalog
+		return c
+	}
+	return "en"
+}
+
+func loadCatalog(lang string) (map[string]string, error) {
+	data, err := locales.ReadFile(path.Join("locales", lang+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read catalog: %w", err)
+	}
+	var catalog map[string]string
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		log.Error("unable to parse catalog", "locale", lang, "error", err)
+		return nil, fmt.Errorf("unable to parse catalog: %w", err)
+	}
+	return catalog, nil
+}
+
+// systemLocale returns the locale of messages set in the environment, e.g.
+// en_US for LANG=en_US.UTF-8, or an empty string if there is none.
+func systemLocale() string {
+	return envLocale("LC_MESSAGES")
+}
+
+// envLocale returns the locale of a category, like LC_TIME, set in the
+// environment.
+func envLocale(category string) string {
+	for _, v := range []string{"LC_ALL", category, "LANG"} {
+		locale, _, _ := strings.Cut(os.Getenv(v), ".")
+		if locale != "" && locale != "C" && locale != "POSIX" {
+			return locale
+		}
+	}
+	return ""
+}
+
+// tr returns the translation of an English message.
+func tr(msg string) string {
+	if s, ok := messages[msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// trf translates a format string and formats it.
+func trf(format string, a ...any) string {
+	return fmt.Sprintf(tr(format), a...)
+}
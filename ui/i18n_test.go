@@ -0,0 +1,54 @@
+package ui
+
+import "testing"
+
+func TestSetLocale(t *testing.T) {
+	t.Cleanup(func() { messages = nil })
+
+	for _, tc := range []struct {
+		locale, env, want string
+	}{
+		{locale: "de_DE.UTF-8", want: "de"},
+		{locale: "fr-CA", want: "fr"},
+		{locale: "es", want: "es"},
+		{locale: "xx_YY", want: "en"},
+		{locale: "C", want: "en"},
+		{env: "fr_FR.UTF-8", want: "fr"},
+		{env: "C", want: "en"},
+	} {
+		t.Setenv("LC_ALL", tc.env)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", "")
+		if got := setLocale(tc.locale); got != tc.want {
+			t.Errorf("setLocale(%q) with LC_ALL=%q: expected %q, got %q", tc.locale, tc.env, tc.want, got)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	t.Cleanup(func() { messages = nil })
+
+	setLocale("de_DE")
+	for _, tc := range []struct {
+		got, want string
+	}{
+		{tr("No links found."), "Keine Links gefunden."},
+		{tr("missing anchor"), "Anker fehlt"},
+		{tr("Not a message of the catalog"), "Not a message of the catalog"},
+		{trf("%d links, %d broken", 12, 3), "12 Links, 3 defekt"},
+		{trf("HTTP %d", 404), "HTTP 404"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, tc.got)
+		}
+	}
+
+	// Unknown locales fall back to English.
+	setLocale("xx")
+	if got := trf("%d links, %d broken", 12, 3); got != "12 links, 3 broken" {
+		t.Errorf("expected the English message, got %q", got)
+	}
+	if got := tr("missing anchor"); got != "missing anchor" {
+		t.Errorf("expected the English message, got %q", got)
+	}
+}
@@ -0,0 +1,284 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/muesli/reflow/truncate"
+)
+
+const (
+	linkCheckTimeout     = 10 * time.Second
+	linkCheckConcurrency = 8
+)
+
+// linkStatus is the outcome of checking a link.
+type linkStatus int
+
+const (
+	linkOK linkStatus = iota
+	linkBroken
+	linkSkipped
+)
+
+func (s linkStatus) String() string {
+	switch s {
+	case linkBroken:
+		return redFg("✗")
+	case linkSkipped:
+		return grayFg("–")
+	default:
+		return greenFg("✓")
+	}
+}
+
+// linkResult is the result of checking a single link.
+type linkResult struct {
+	link   utils.Link
+	status linkStatus
+	reason string
+}
+
+type linkCheckMsg struct {
+	path     string
+	results  []linkResult
+	external bool
+}
+
+// linkCheckPanel is the popup listing link check results.
+type linkCheckPanel struct {
+	results  []linkResult
+	external bool
+	cursor   int
+	offset   int
+}
+
+func (p linkCheckPanel) broken() int {
+	var n int
+	for _, r := range p.results {
+		if r.status == linkBroken {
+			n++
+		}
+	}
+	return n
+}
+
+// checkLinks validates the links of a markdown document: relative files must
+// exist, anchors must match a heading, and, if external is set, HTTP links
+// must respond successfully, requested through the transport.
+func checkLinks(body, docPath string, external bool, transport http.RoundTripper) tea.Cmd {
+	return func() tea.Msg {
+		source := utils.RemoveFrontmatter([]byte(body))
+		links := utils.Links(source)
+		results := make([]linkResult, len(links))
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, linkCheckConcurrency)
+		client := &http.Client{Timeout: linkCheckTimeout, Transport: transport}
+		for i, l := range links {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				status, reason := checkLink(client, l.Destination, source, docPath, external)
+				results[i] = linkResult{l, status, reason}
+			}()
+		}
+		wg.Wait()
+		return linkCheckMsg{docPath, results, external}
+	}
+}
+
+func checkLink(client *http.Client, dest string, source []byte, docPath string, external bool) (linkStatus, string) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return linkBroken, tr("malformed URL")
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		if !external {
+			return linkSkipped, tr("external")
+		}
+		return checkHTTPLink(client, u.String())
+	case "":
+	default:
+		return linkSkipped, u.Scheme
+	}
+
+	// In-document anchor
+	if u.Path == "" {
+		if !hasAnchor(source, u.Fragment) {
+			return linkBroken, tr("missing anchor")
+		}
+		return linkOK, ""
+	}
+
+	if docPath == "" {
+		return linkSkipped, tr("no base path")
+	}
+	path := u.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(docPath), path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return linkBroken, tr("missing file")
+	}
+	if u.Fragment != "" && !info.IsDir() && utils.IsMarkdownFile(path) {
+		target, err := os.ReadFile(path)
+		if err != nil {
+			return linkBroken, tr("unreadable file")
+		}
+		if !hasAnchor(utils.RemoveFrontmatter(target), u.Fragment) {
+			return linkBroken, tr("missing anchor")
+		}
+	}
+	return linkOK, ""
+}
+
+func hasAnchor(source []byte, anchor string) bool {
+	if anchor == "" {
+		return true
+	}
+	anchor = strings.ToLower(anchor)
+	for _, h := range utils.Headings(source) {
+		if h.Slug == anchor {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHTTPLink issues a HEAD request, falling back to GET for servers that
+// don't support HEAD.
+func checkHTTPLink(client *http.Client, u string) (linkStatus, string) {
+	status := 0
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(context.Background(), method, u, nil)
+		if err != nil {
+			return linkBroken, tr("malformed URL")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return linkBroken, tr("unreachable")
+		}
+		_ = resp.Body.Close()
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+	if status >= http.StatusBadRequest {
+		return linkBroken, fmt.Sprintf("HTTP %d", status)
+	}
+	return linkOK, ""
+}
+
+func (m *pagerModel) startLinkCheck(external bool) tea.Cmd {
+	return tea.Batch(
+		m.showStatusMessage(pagerStatusMessage{tr("Checking links..."), false}),
+		checkLinks(string(m.resolveWikiLinks([]byte(m.currentDocument.Body))), m.currentDocument.localPath, external, m.common.cfg.HTTPTransport),
+	)
+}
+
+func (m *pagerModel) closeLinkCheck() tea.Cmd {
+	m.linkCheck = nil
+	return m.leaveOverlay()
+}
+
+// handleLinkCheckKeys handles keystrokes while the link check results are
+// shown.
+func (m *pagerModel) handleLinkCheckKeys(msg tea.KeyMsg) tea.Cmd {
+	p := m.linkCheck
+	switch msg.String() {
+	case "up", "k":
+		p.cursor = max(0, p.cursor-1)
+	case "down", "j":
+		p.cursor = min(len(p.results)-1, p.cursor+1)
+	case "n":
+		// Jump to the next broken link.
+		for i := 1; i <= len(p.results); i++ {
+			j := (p.cursor + i) % len(p.results)
+			if p.results[j].status == linkBroken {
+				p.cursor = j
+				break
+			}
+		}
+	case "x":
+		cmd := m.closeLinkCheck()
+		return tea.Batch(cmd, m.startLinkCheck(!p.external))
+	case keyEnter:
+		text := p.results[p.cursor].link.Text
+		cmd := m.closeLinkCheck()
+		if occ := textOccurrences(m.lines, text); text != "" && len(occ) > 0 {
+			m.viewport.SetYOffset(occ[0][0])
+		}
+		return tea.Batch(cmd, m.syncViewport())
+	case "q", keyEsc, "L":
+		return m.closeLinkCheck()
+	}
+
+	// Keep the cursor in view.
+	height := m.linkCheckHeight()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+height {
+		p.offset = p.cursor - height + 1
+	}
+	return nil
+}
+
+// linkCheckHeight returns the number of results visible in the panel.
+func (m pagerModel) linkCheckHeight() int {
+	return max(1, m.viewport.Height-6)
+}
+
+// linkCheckView renders the link check results panel.
+func (m pagerModel) linkCheckView() string {
+	p := m.linkCheck
+	width := max(20, m.viewport.Width-6)
+
+	mode := tr("local only, x: check external")
+	if p.external {
+		mode = tr("with external, x: local only")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n\n",
+		footnoteLabelStyle.Render(trf("%d links, %d broken", len(p.results), p.broken())),
+		subtleStyle.Render("("+mode+")"),
+	)
+	if len(p.results) == 0 {
+		b.WriteString(grayFg(tr("No links found.")))
+	}
+
+	end := min(len(p.results), p.offset+m.linkCheckHeight())
+	for i := p.offset; i < end; i++ {
+		r := p.results[i]
+		entry := fmt.Sprintf("%4d  %s", r.link.Line+1, r.link.Destination)
+		if r.reason != "" {
+			entry += "  (" + r.reason + ")"
+		}
+		entry = truncate.StringWithTail(entry, uint(max(0, width-6)), ellipsis) //nolint:gosec
+		if i == p.cursor {
+			entry = fuchsiaFg("› " + entry)
+		} else {
+			entry = "  " + entry
+		}
+		b.WriteString(r.status.String() + " " + entry)
+		if i < end-1 {
+			b.WriteRune('\n')
+		}
+	}
+	return annotationListStyle.Width(width).Render(b.String())
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "other.md"), []byte("---\ntitle: Other\n---\n# Other Doc\n\n## Setup\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	body := "# Intro\n\n## Getting Started\n\n" +
+		"- [anchor](#getting-started)\n" +
+		"- [missing anchor](#nowhere)\n" +
+		"- [file](other.md)\n" +
+		"- [file anchor](other.md#setup)\n" +
+		"- [missing file anchor](other.md#teardown)\n" +
+		"- [missing file](missing.md)\n" +
+		"- [directory](docs)\n" +
+		"- [mail](mailto:a@example.com)\n" +
+		"- [ok](" + srv.URL + "/ok)\n" +
+		"- [get only](" + srv.URL + "/get-only)\n" +
+		"- [not found](" + srv.URL + "/gone)\n"
+
+	for _, tc := range []struct {
+		external bool
+		docPath  string
+		want     map[string]linkStatus
+	}{
+		{
+			external: true,
+			docPath:  filepath.Join(dir, "doc.md"),
+			want: map[string]linkStatus{
+				"#getting-started":     linkOK,
+				"#nowhere":             linkBroken,
+				"other.md":             linkOK,
+				"other.md#setup":       linkOK,
+				"other.md#teardown":    linkBroken,
+				"missing.md":           linkBroken,
+				"docs":                 linkOK,
+				"mailto:a@example.com": linkSkipped,
+				srv.URL + "/ok":        linkOK,
+				srv.URL + "/get-only":  linkOK,
+				srv.URL + "/gone":      linkBroken,
+			},
+		},
+		{
+			external: false,
+			docPath:  "",
+			want: map[string]linkStatus{
+				"#getting-started":     linkOK,
+				"#nowhere":             linkBroken,
+				"other.md":             linkSkipped,
+				"other.md#setup":       linkSkipped,
+				"other.md#teardown":    linkSkipped,
+				"missing.md":           linkSkipped,
+				"docs":                 linkSkipped,
+				"mailto:a@example.com": linkSkipped,
+				srv.URL + "/ok":        linkSkipped,
+				srv.URL + "/get-only":  linkSkipped,
+				srv.URL + "/gone":      linkSkipped,
+			},
+		},
+	} {
+		msg, ok := checkLinks(body, tc.docPath, tc.external, nil)().(linkCheckMsg)
+		if !ok {
+			t.Fatal("expected a link check message")
+		}
+		if len(msg.results) != len(tc.want) {
+			t.Fatalf("expected %d results, got %d", len(tc.want), len(msg.results))
+		}
+		for _, r := range msg.results {
+			want, ok := tc.want[r.link.Destination]
+			if !ok {
+				t.Errorf("unexpected link %q", r.link.Destination)
+				continue
+			}
+			if r.status != want {
+				t.Errorf("external=%v: expected %q to be %d, got %d (%s)", tc.external, r.link.Destination, want, r.status, r.reason)
+			}
+		}
+	}
+}
@@ -18,6 +18,10 @@ type markdown struct {
 	// those that have been stashed in this session.
 	localPath string
 
+	// Downloads the document of a remote repository, instead of reading it
+	// from localPath.
+	fetch Fetcher
+
 	// Value we filter against. This exists so that we can maintain positions
 	// of filtered items if notes are edited while a filter is active. This
 	// field is ephemeral, and should only be referenced during filtering.
@@ -26,6 +30,8 @@ type markdown struct {
 	Body    string
 	Note    string
 	Modtime time.Time
+	// Size of the file, in bytes, if it's a local file.
+	Size int64
 }
 
 // Generate the value we're doing to filter against.
@@ -43,6 +49,18 @@ func (m markdown) relativeTime() string {
 	return relativeTime(m.Modtime)
 }
 
+// details returns when the document was modified and its size, as shown in
+// the file list.
+func (m markdown) details() string {
+	if m.Size == 0 {
+		return m.relativeTime()
+	}
+	if date := m.relativeTime(); date != "" {
+		return date + " · " + formatSize(m.Size)
+	}
+	return formatSize(m.Size)
+}
+
 // Normalize text to aid in the filtering process. In particular, we remove
 // diacritics, "ö" becomes "o". Note that Mn is the unicode key for nonspacing
 // marks.
@@ -57,13 +75,28 @@ func normalize(in string) (string, error) {
 
 // Return the time in a human-readable format relative to the current time.
 func relativeTime(then time.Time) string {
+	if then.IsZero() {
+		// Remote documents aren't dated.
+		return ""
+	}
 	now := time.Now()
 	if ago := now.Sub(then); ago < time.Minute {
-		return "just now"
+		return tr("just now")
 	} else if ago < humanize.Week {
-		return humanize.CustomRelTime(then, now, "ago", "from now", magnitudes)
+		return humanize.CustomRelTime(then, now, tr("ago"), tr("from now"), translatedMagnitudes())
+	}
+	return formatDate(then)
+}
+
+// translatedMagnitudes returns the magnitudes for relative time in the active
+// locale.
+func translatedMagnitudes() []humanize.RelTimeMagnitude {
+	out := make([]humanize.RelTimeMagnitude, len(magnitudes))
+	for i, m := range magnitudes {
+		m.Format = tr(m.Format)
+		out[i] = m
 	}
-	return then.Format("02 Jan 2006 15:04 MST")
+	return out
 }
 
 // Magnitudes for relative time.
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+const noteTimeFormat = "2006-01-02 15:04"
+
+type noteAppendedMsg struct {
+	path string
+	err  error
+}
+
+// notePath returns the file notes should be appended to: the configured inbox
+// or, if there is none, the current document.
+func (m pagerModel) notePath() string {
+	if m.common.cfg.NoteInbox != "" {
+		return utils.ExpandPath(m.common.cfg.NoteInbox)
+	}
+	return m.currentDocument.localPath
+}
+
+func (m *pagerModel) startNote() tea.Cmd {
+	if m.notePath() == "" {
+		return m.showStatusMessage(pagerStatusMessage{tr("No file to append notes to"), true})
+	}
+	return m.startPrompt(tr("Note:"), func(m *pagerModel, text string) tea.Cmd {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil
+		}
+		return appendNote(m.notePath(), m.common.cfg.NoteHeading, text, time.Now())
+	})
+}
+
+// appendNote appends a timestamped note to the file at path, under the given
+// heading.
+func appendNote(path, heading, text string, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		content, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return noteAppendedMsg{path, fmt.Errorf("unable to read file: %w", err)}
+		}
+
+		entry := fmt.Sprintf("- %s %s", now.Format(noteTimeFormat), text)
+		out := insertNote(string(content), heading, entry)
+
+		if err := os.WriteFile(path, []byte(out), 0o644); err != nil { //nolint:gosec
+			return noteAppendedMsg{path, fmt.Errorf("unable to write file: %w", err)}
+		}
+		log.Debug("appended note", "path", path)
+		return noteAppendedMsg{path: path}
+	}
+}
+
+// insertNote adds entry at the end of the section with the given heading. If
+// there is no such section it is created at the end of the document. An empty
+// heading appends the entry to the end of the document.
+func insertNote(content, heading, entry string) string {
+	content = strings.TrimRight(content, "\n")
+	if heading == "" {
+		if content == "" {
+			return entry + "\n"
+		}
+		return content + "\n\n" + entry + "\n"
+	}
+
+	lines := strings.Split(content, "\n")
+	start, level := -1, 0
+	fence := ""
+	for i, line := range lines {
+		// Lines in code blocks, like comments of shell scripts, aren't
+		// headings.
+		if fence != "" {
+			if closesCodeFence(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = codeFence(line); fence != "" {
+			continue
+		}
+		l, title := atxHeading(line)
+		if l == 0 {
+			continue
+		}
+		if start < 0 {
+			if strings.EqualFold(title, heading) {
+				start, level = i, l
+			}
+			continue
+		}
+		if l <= level {
+			// Insert before the next section, after the last non-blank line.
+			end := i
+			for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+				end--
+			}
+			out := append([]string{}, lines[:end]...)
+			if end == start+1 {
+				out = append(out, "")
+			}
+			out = append(out, entry, "")
+			out = append(out, lines[i:]...)
+			return strings.Join(out, "\n") + "\n"
+		}
+	}
+
+	if start < 0 {
+		if content != "" {
+			content += "\n\n"
+		}
+		return content + "## " + heading + "\n\n" + entry + "\n"
+	}
+	if start == len(lines)-1 {
+		content += "\n"
+	}
+	return content + "\n" + entry + "\n"
+}
+
+// atxHeading returns the level and title of an ATX heading line, or a level
+// of 0 if the line isn't a heading.
+func atxHeading(line string) (int, string) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, ""
+	}
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(trimmed) && trimmed[level] != ' ' && trimmed[level] != '\t') {
+		return 0, ""
+	}
+	title := strings.TrimSpace(trimmed[level:])
+	title = strings.TrimSpace(strings.TrimRight(title, "#"))
+	return level, title
+}
+
+// codeFence returns the fence of a line opening a fenced code block, like
+// "```" or "~~~~", or "" if the line doesn't open one.
+func codeFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return ""
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+	if n < 3 || (trimmed[0] == '`' && strings.Contains(trimmed[n:], "`")) {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// closesCodeFence reports whether a line closes the code block opened by
+// fence: with at least as many of the same characters, and nothing else.
+func closesCodeFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	rest := strings.TrimLeft(trimmed, fence[:1])
+	return len(trimmed)-len(rest) >= len(fence) && strings.TrimSpace(rest) == ""
+}
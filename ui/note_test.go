@@ -0,0 +1,62 @@
+package ui
+
+import "testing"
+
+func TestInsertNote(t *testing.T) {
+	const entry = "- 2024-01-02 10:00 new"
+	for _, tc := range []struct {
+		name, content, heading, want string
+	}{
+		{
+			name:    "no heading",
+			content: "# Doc\n\nText\n",
+			want:    "# Doc\n\nText\n\n- 2024-01-02 10:00 new\n",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			heading: "Notes",
+			want:    "## Notes\n\n- 2024-01-02 10:00 new\n",
+		},
+		{
+			name:    "heading found",
+			content: "# Doc\n\n## Notes\n\n- old\n\n## Later\n\nText\n",
+			heading: "notes",
+			want:    "# Doc\n\n## Notes\n\n- old\n- 2024-01-02 10:00 new\n\n## Later\n\nText\n",
+		},
+		{
+			name:    "heading found at the end",
+			content: "# Doc\n\n## Notes\n\n- old\n",
+			heading: "Notes",
+			want:    "# Doc\n\n## Notes\n\n- old\n- 2024-01-02 10:00 new\n",
+		},
+		{
+			name:    "subsections belong to the section",
+			content: "## Notes\n\n### Sub\n\n- old\n\n## Later\n",
+			heading: "Notes",
+			want:    "## Notes\n\n### Sub\n\n- old\n- 2024-01-02 10:00 new\n\n## Later\n",
+		},
+		{
+			name:    "heading missing",
+			content: "# Doc\n\nText\n",
+			heading: "Notes",
+			want:    "# Doc\n\nText\n\n## Notes\n\n- 2024-01-02 10:00 new\n",
+		},
+		{
+			name:    "fenced heading isn't the section",
+			content: "# Doc\n\n```sh\n# Notes\necho\n```\n",
+			heading: "Notes",
+			want:    "# Doc\n\n```sh\n# Notes\necho\n```\n\n## Notes\n\n- 2024-01-02 10:00 new\n",
+		},
+		{
+			name:    "fenced heading doesn't end the section",
+			content: "## Notes\n\n- old\n\n~~~~\n# comment\n~~~\n~~~~\n\n## Later\n",
+			heading: "Notes",
+			want:    "## Notes\n\n- old\n\n~~~~\n# comment\n~~~\n~~~~\n- 2024-01-02 10:00 new\n\n## Later\n",
+		},
+	} {
+		if got := insertNote(tc.content, tc.heading, entry); got != tc.want {
+			t.Errorf("%s: expected\n%q\ngot\n%q", tc.name, tc.want, got)
+		}
+	}
+}
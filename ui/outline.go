@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+const (
+	outlineMinWidth = 20
+	outlineMaxWidth = 40
+)
+
+// outlinePanel is the sidebar listing the headings of the current document.
+type outlinePanel struct {
+	headings []utils.Heading
+	// Rendered line of each heading, or -1 if it couldn't be found.
+	lines  []int
+	cursor int
+	offset int
+}
+
+// headingLines locates headings in the rendered lines. Headings are searched
+// in order, so that repeated titles resolve to the right occurrence.
+func headingLines(lines []string, headings []utils.Heading) []int {
+	found := make([]int, len(headings))
+	from := 0
+	for i, h := range headings {
+		found[i] = -1
+		if from >= len(lines) {
+			continue
+		}
+		if occ := textOccurrences(lines[from:], h.Text); len(occ) > 0 {
+			found[i] = from + occ[0][0]
+			from = found[i] + 1
+		}
+	}
+	return found
+}
+
+// openOutline shows the outline sidebar, with the section currently in view
+// selected.
+func (m *pagerModel) openOutline() tea.Cmd {
+	headings := utils.TableOfContents([]byte(m.currentDocument.Body), m.common.cfg.TOCDepth)
+	p := &outlinePanel{
+		headings: headings,
+		lines:    headingLines(m.lines, headings),
+	}
+	for i, line := range p.lines {
+		if line >= 0 && line <= m.viewport.YOffset {
+			p.cursor = i
+		}
+	}
+	m.outline = p
+	m.scrollOutline()
+	return m.enterOverlay()
+}
+
+func (m *pagerModel) closeOutline() tea.Cmd {
+	m.outline = nil
+	return m.leaveOverlay()
+}
+
+// handleOutlineKeys handles keystrokes while the outline is shown.
+func (m *pagerModel) handleOutlineKeys(msg tea.KeyMsg) tea.Cmd {
+	p := m.outline
+	switch msg.String() {
+	case "up", "k":
+		p.cursor = max(0, p.cursor-1)
+	case "down", "j":
+		p.cursor = max(0, min(len(p.headings)-1, p.cursor+1))
+	case "home", "g":
+		p.cursor = 0
+	case "end", "G":
+		p.cursor = max(0, len(p.headings)-1)
+	case keyEnter:
+		var line int
+		if p.cursor < len(p.lines) {
+			line = p.lines[p.cursor]
+		}
+		cmd := m.closeOutline()
+		if line >= 0 {
+			m.viewport.SetYOffset(line)
+		}
+		return tea.Batch(cmd, m.syncViewport())
+	case "q", keyEsc, "o":
+		return m.closeOutline()
+	}
+	m.scrollOutline()
+	return nil
+}
+
+// scrollOutline keeps the cursor in view.
+func (m *pagerModel) scrollOutline() {
+	p := m.outline
+	height := m.outlineHeight()
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+height {
+		p.offset = p.cursor - height + 1
+	}
+}
+
+// outlineHeight returns the number of headings visible in the sidebar.
+func (m pagerModel) outlineHeight() int {
+	return max(1, m.viewport.Height-4)
+}
+
+// outlineView renders the outline sidebar. It is placed at the right edge of
+// the viewport.
+func (m pagerModel) outlineView() string {
+	p := m.outline
+	width := max(outlineMinWidth, min(outlineMaxWidth, m.viewport.Width/3))
+
+	var b strings.Builder
+	b.WriteString(footnoteLabelStyle.Render(tr("Outline")))
+	if len(p.headings) == 0 {
+		b.WriteString("\n" + grayFg(tr("No headings found.")))
+	}
+
+	base := 0
+	for i, h := range p.headings {
+		if i == 0 || h.Level < base {
+			base = h.Level
+		}
+	}
+	end := min(len(p.headings), p.offset+m.outlineHeight())
+	for i := p.offset; i < end; i++ {
+		h := p.headings[i]
+		entry := strings.Repeat("  ", h.Level-base) + h.Text
+		entry = truncate.StringWithTail(entry, uint(max(0, width-6)), ellipsis) //nolint:gosec
+		if i == p.cursor {
+			entry = fuchsiaFg("› " + entry)
+		} else {
+			entry = "  " + entry
+		}
+		b.WriteString("\n" + entry)
+	}
+
+	// Fill the height of the viewport, like a sidebar.
+	style := annotationListStyle.Width(width).Height(max(0, m.viewport.Height-2))
+	return style.Render(b.String())
+}
+
+// outlineOverlayView draws the outline sidebar over the viewport.
+func (m pagerModel) outlineOverlayView(view string) string {
+	box := m.outlineView()
+	return overlay(view, box, 0, max(0, m.viewport.Width-lipgloss.Width(box)))
+}
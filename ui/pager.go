@@ -102,6 +102,57 @@ type pagerModel struct {
 	// it here so we can re-render it on resize.
 	currentDocument markdown
 
+	// Estimated reading time of the current document, in minutes.
+	readingTime int
+
+	// Rendered lines of the current document. We keep them around so we can
+	// locate elements like footnote references in the rendered output.
+	lines []string
+
+	// Footnote preview popup, if one is open, and the positions we jumped
+	// away from when following footnotes.
+	footnote        *footnotePopup
+	footnoteReturns []int
+
+	// Table navigation mode, if active.
+	table *tableMode
+
+	// Task list mode, if active.
+	tasks *taskMode
+
+	// Link following mode, if active, the documents we followed links away
+	// from, and where to scroll to once the next document is rendered.
+	follow     *followMode
+	history    []visitedDocument
+	jumpAnchor string
+	jumpOffset int
+
+	// Text prompt shown in place of the status bar, if one is open.
+	prompt *pagerPrompt
+
+	// Highlights and notes for the current document, where they were found
+	// in the rendered output, and the popup listing them, if open.
+	annotations    []annotation
+	resolved       []resolvedAnnotation
+	annotationList *annotationList
+
+	// Link check results, if shown.
+	linkCheck *linkCheckPanel
+
+	// Outline sidebar, if shown, and whether to open it once the next
+	// document is rendered.
+	outline     *outlinePanel
+	autoOutline bool
+
+	// Spell checking, toggled per session.
+	spellcheck     bool
+	dictionary     dictionary
+	dictionaryLang string
+	misspelled     map[string]bool
+
+	// Whether high performance rendering was suspended to draw an overlay.
+	suspendedHighPerf bool
+
 	watcher *fsnotify.Watcher
 }
 
@@ -112,9 +163,10 @@ func newPagerModel(common *commonModel) pagerModel {
 	vp.HighPerformanceRendering = config.HighPerformancePager
 
 	m := pagerModel{
-		common:   common,
-		state:    pagerStateBrowse,
-		viewport: vp,
+		common:      common,
+		state:       pagerStateBrowse,
+		viewport:    vp,
+		autoOutline: common.cfg.TOCDepth > 0,
 	}
 	m.initWatcher()
 	return m
@@ -132,8 +184,58 @@ func (m *pagerModel) setSize(w, h int) {
 	}
 }
 
+// setDocument makes md the current document. The redact patterns are
+// applied to its body once here, so that its source is redacted wherever
+// it's shown or copied, not just in the rendered output.
+func (m *pagerModel) setDocument(md markdown) {
+	md.Body = string(utils.Redact([]byte(md.Body)))
+	m.currentDocument = md
+}
+
 func (m *pagerModel) setContent(s string) {
-	m.viewport.SetContent(s)
+	m.lines = strings.Split(s, "\n")
+	if m.tasks != nil {
+		m.locateTasks()
+	}
+	m.refreshContent()
+}
+
+// isModal returns whether the pager is currently capturing all keystrokes,
+// for example while a popup is open.
+func (m pagerModel) isModal() bool {
+	return m.footnote != nil || m.table != nil || m.prompt != nil || m.annotationList != nil ||
+		m.linkCheck != nil || m.outline != nil || m.tasks != nil ||
+		m.follow != nil
+}
+
+// enterOverlay switches off high performance rendering, which bypasses View,
+// so that overlays drawn on top of the viewport are visible.
+func (m *pagerModel) enterOverlay() tea.Cmd {
+	if !m.viewport.HighPerformanceRendering {
+		return nil
+	}
+	m.viewport.HighPerformanceRendering = false
+	m.suspendedHighPerf = true
+	return tea.ClearScrollArea //nolint:staticcheck
+}
+
+// syncViewport returns a command to redraw the viewport after programmatic
+// scrolling when high performance rendering is in use.
+func (m pagerModel) syncViewport() tea.Cmd {
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// leaveOverlay restores high performance rendering if it was suspended.
+func (m *pagerModel) leaveOverlay() tea.Cmd {
+	if !m.suspendedHighPerf {
+		return nil
+	}
+	m.viewport.HighPerformanceRendering = true
+	m.suspendedHighPerf = false
+	return viewport.Sync(m.viewport)
 }
 
 func (m *pagerModel) toggleHelp() {
@@ -173,7 +275,21 @@ func (m *pagerModel) unload() {
 		m.statusMessageTimer.Stop()
 	}
 	m.state = pagerStateBrowse
-	m.viewport.SetContent("")
+	m.closeFootnote()
+	m.footnoteReturns = nil
+	m.table = nil
+	m.tasks = nil
+	m.follow = nil
+	m.history = nil
+	m.jumpAnchor, m.jumpOffset = "", 0
+	m.prompt = nil
+	m.annotations = nil
+	m.annotationList = nil
+	m.linkCheck = nil
+	m.outline = nil
+	m.autoOutline = m.common.cfg.TOCDepth > 0
+	m.misspelled = nil
+	m.setContent("")
 	m.viewport.YOffset = 0
 	m.unwatchFile()
 }
@@ -186,6 +302,25 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch {
+		case m.footnote != nil:
+			return m, m.handleFootnoteKeys(msg)
+		case m.table != nil:
+			return m, m.handleTableKeys(msg)
+		case m.prompt != nil:
+			return m, m.handlePromptKeys(msg)
+		case m.annotationList != nil:
+			return m, m.handleAnnotationListKeys(msg)
+		case m.linkCheck != nil:
+			return m, m.handleLinkCheckKeys(msg)
+		case m.outline != nil:
+			return m, m.handleOutlineKeys(msg)
+		case m.tasks != nil:
+			return m, m.handleTaskKeys(msg)
+		case m.follow != nil:
+			return m, m.handleFollowKeys(msg)
+		}
+
 		switch msg.String() {
 		case "q", keyEsc:
 			if m.state != pagerStateBrowse {
@@ -228,15 +363,49 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 			return m, openEditor(m.currentDocument.localPath, lineno)
 
 		case "c":
-			// Copy using OSC 52
-			termenv.Copy(m.currentDocument.Body)
-			// Copy using native system clipboard
-			_ = clipboard.WriteAll(m.currentDocument.Body)
-			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Copied contents", false}))
+			copyToClipboard(m.currentDocument.Body)
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{tr("Copied contents"), false}))
 
 		case "r":
 			return m, loadLocalMarkdown(&m.currentDocument)
 
+		case "[", "]":
+			return m, m.previewFootnote(msg.String() == "]")
+
+		case "t":
+			return m, m.enterTableMode()
+
+		case "x":
+			return m, m.enterTaskMode()
+
+		case "w":
+			return m, m.enterFollowMode()
+
+		case "a":
+			return m, m.startNote()
+
+		case "m":
+			return m, m.startHighlight()
+
+		case "M":
+			return m, m.showAnnotationList()
+
+		case "S":
+			return m, m.toggleSpellcheck()
+
+		case "L":
+			return m, m.startLinkCheck(false)
+
+		case "o":
+			return m, m.openOutline()
+
+		case "backspace":
+			if cmd := m.returnFromFootnote(); cmd != nil {
+				cmds = append(cmds, cmd)
+			} else if len(m.history) > 0 {
+				return m, m.returnToPreviousDocument()
+			}
+
 		case "?":
 			m.toggleHelp()
 			if m.viewport.HighPerformanceRendering {
@@ -248,11 +417,51 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 	case contentRenderedMsg:
 		log.Info("content rendered", "state", m.state)
 
+		m.checkSpelling()
+		m.readingTime = utils.Stats(utils.RemoveFrontmatter([]byte(m.currentDocument.Body))).ReadingTime()
 		m.setContent(string(msg))
 		if m.viewport.HighPerformanceRendering {
 			cmds = append(cmds, viewport.Sync(m.viewport))
 		}
-		cmds = append(cmds, m.watchFile)
+		cmds = append(cmds, m.watchFile, m.jumpToPendingPosition())
+		if m.autoOutline && !m.isModal() {
+			m.autoOutline = false
+			cmds = append(cmds, m.openOutline())
+		}
+		if m.currentDocument.localPath != "" {
+			cmds = append(cmds, loadAnnotations(m.currentDocument.localPath))
+		}
+
+	case annotationsLoadedMsg:
+		if msg.path == m.currentDocument.localPath {
+			m.annotations = msg.annotations
+			m.refreshContent()
+			cmds = append(cmds, m.syncViewport())
+		}
+
+	case spellcheckLoadedMsg:
+		if msg.err != nil {
+			return m, m.showStatusMessage(pagerStatusMessage{trf("No dictionary found for %s", msg.lang), true})
+		}
+		m.dictionary, m.dictionaryLang = msg.dict, msg.lang
+		m.spellcheck = true
+		m.checkSpelling()
+		m.refreshContent()
+		status := trf("Spell check on (%s): %d misspelled", msg.lang, len(m.misspelled))
+		return m, tea.Batch(m.syncViewport(), m.showStatusMessage(pagerStatusMessage{status, false}))
+
+	case linkCheckMsg:
+		if m.isModal() || msg.path != m.currentDocument.localPath {
+			return m, nil
+		}
+		m.linkCheck = &linkCheckPanel{results: msg.results, external: msg.external}
+		return m, m.enterOverlay()
+
+	case annotationsSavedMsg:
+		if msg.err != nil {
+			log.Error("unable to save annotations", "error", msg.err)
+			return m, m.showStatusMessage(pagerStatusMessage{tr("Couldn't save annotations"), true})
+		}
 
 	// The file was changed on disk and we're reloading it
 	case reloadMsg:
@@ -264,11 +473,34 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 	case editorFinishedMsg:
 		return m, loadLocalMarkdown(&m.currentDocument)
 
+	case linkOpenedMsg:
+		if msg.err != nil {
+			log.Error("unable to open link", "error", msg.err)
+			return m, m.showStatusMessage(pagerStatusMessage{tr("Couldn't open link"), true})
+		}
+		return m, nil
+
 	// We've received terminal dimensions, either for the first time or
 	// after a resize
 	case tea.WindowSizeMsg:
 		return m, renderWithGlamour(m, m.currentDocument.Body)
 
+	case taskToggledMsg:
+		if msg.err != nil {
+			log.Error("unable to toggle task", "path", msg.path, "error", msg.err)
+			return m, m.showStatusMessage(pagerStatusMessage{tr("Couldn't update task"), true})
+		}
+		if msg.path == m.currentDocument.localPath {
+			return m, loadLocalMarkdown(&m.currentDocument)
+		}
+
+	case noteAppendedMsg:
+		if msg.err != nil {
+			log.Error("unable to append note", "path", msg.path, "error", msg.err)
+			return m, m.showStatusMessage(pagerStatusMessage{tr("Couldn't add note"), true})
+		}
+		return m, m.showStatusMessage(pagerStatusMessage{trf("Added note to %s", filepath.Base(msg.path)), false})
+
 	case statusMessageTimeoutMsg:
 		m.state = pagerStateBrowse
 	}
@@ -281,10 +513,14 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 
 func (m pagerModel) View() string {
 	var b strings.Builder
-	fmt.Fprint(&b, m.viewport.View()+"\n")
+	fmt.Fprint(&b, m.overlayView(m.viewport.View())+"\n")
 
 	// Footer
-	m.statusBarView(&b)
+	if m.prompt != nil {
+		fmt.Fprint(&b, " "+m.prompt.input.View())
+	} else {
+		m.statusBarView(&b)
+	}
 
 	if m.showHelp {
 		fmt.Fprint(&b, "\n"+m.helpView())
@@ -307,7 +543,7 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 
 	// Scroll percent
 	percent := math.Max(minPercent, math.Min(maxPercent, m.viewport.ScrollPercent()))
-	scrollPercent := fmt.Sprintf(" %3.f%% ", percent*percentToStringMagnitude)
+	scrollPercent := fmt.Sprintf(" %s  %3.f%% ", formatReadingTime(m.readingTime), percent*percentToStringMagnitude)
 	if showStatusMessage {
 		scrollPercent = statusBarMessageScrollPosStyle(scrollPercent)
 	} else {
@@ -317,9 +553,9 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 	// "Help" note
 	var helpNote string
 	if showStatusMessage {
-		helpNote = statusBarMessageHelpStyle(" ? Help ")
+		helpNote = statusBarMessageHelpStyle(" ? " + tr("Help") + " ")
 	} else {
-		helpNote = statusBarHelpStyle(" ? Help ")
+		helpNote = statusBarHelpStyle(" ? " + tr("Help") + " ")
 	}
 
 	// Note
@@ -366,28 +602,39 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 }
 
 func (m pagerModel) helpView() (s string) {
+	col0 := []string{
+		"k/↑", "up",
+		"j/↓", "down",
+		"b/pgup", "page up",
+		"f/pgdn", "page down",
+		"u", "½ page up",
+		"d", "½ page down",
+	}
 	col1 := []string{
-		"g/home  go to top",
-		"G/end   go to bottom",
-		"c       copy contents",
-		"e       edit this document",
-		"r       reload this document",
-		"esc     back to files",
-		"q       quit",
+		"g/home", "go to top",
+		"G/end", "go to bottom",
+		"c", "copy contents",
+		"e", "edit this document",
+		"r", "reload this document",
+		"o", "outline",
+		"esc", "back to files",
+		"q", "quit",
 	}
-
-	s += "\n"
-	s += "k/↑      up                  " + col1[0] + "\n"
-	s += "j/↓      down                " + col1[1] + "\n"
-	s += "b/pgup   page up             " + col1[2] + "\n"
-	s += "f/pgdn   page down           " + col1[3] + "\n"
-	s += "u        ½ page up           " + col1[4] + "\n"
-	s += "d        ½ page down         "
-
-	if len(col1) > 5 {
-		s += col1[5]
+	col2 := []string{
+		"[/]", "preview footnote",
+		"enter", "go to footnote",
+		"⌫", "jump back",
+		"t", "navigate tables",
+		"x", "toggle tasks",
+		"w", "follow links",
+		"a", "append note",
+		"m", "highlight text",
+		"M", "list highlights",
+		"S", "toggle spell check",
+		"L", "check links",
 	}
 
+	s = "\n" + helpColumns(col0, col1, col2)
 	s = indent(s, 2)
 
 	// Fill up empty cells with spaces for background coloring
@@ -405,6 +652,49 @@ func (m pagerModel) helpView() (s string) {
 	return helpViewStyle(s)
 }
 
+// helpColumns lays out columns of key and help text pairs side by side.
+func helpColumns(pairs ...[]string) string {
+	const gap = 4
+
+	cols := make([][]string, len(pairs))
+	for i, p := range pairs {
+		var keyWidth int
+		for j := 0; j < len(p); j += 2 {
+			keyWidth = max(keyWidth, runewidth.StringWidth(p[j]))
+		}
+		for j := 0; j < len(p); j += 2 {
+			key := p[j] + strings.Repeat(" ", keyWidth-runewidth.StringWidth(p[j]))
+			cols[i] = append(cols[i], key+"  "+tr(p[j+1]))
+		}
+	}
+
+	var rows int
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		rows = max(rows, len(col))
+		for _, entry := range col {
+			widths[i] = max(widths[i], runewidth.StringWidth(entry))
+		}
+	}
+
+	lines := make([]string, rows)
+	for r := range lines {
+		var b strings.Builder
+		for i, col := range cols {
+			var entry string
+			if r < len(col) {
+				entry = col[r]
+			}
+			b.WriteString(entry)
+			if i < len(cols)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-runewidth.StringWidth(entry)+gap))
+			}
+		}
+		lines[r] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
 // COMMANDS
 
 func renderWithGlamour(m pagerModel, md string) tea.Cmd {
@@ -418,6 +708,13 @@ func renderWithGlamour(m pagerModel, md string) tea.Cmd {
 	}
 }
 
+// copyToClipboard copies s using both OSC 52 and the native system
+// clipboard.
+var copyToClipboard = func(s string) {
+	termenv.Copy(s)
+	_ = clipboard.WriteAll(s)
+}
+
 // This is where the magic happens.
 func glamourRender(m pagerModel, markdown string) (string, error) {
 	trunc := lipgloss.NewStyle().MaxWidth(m.viewport.Width - lineNumberWidth).Render
@@ -427,9 +724,9 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 	}
 
 	isCode := !utils.IsMarkdownFile(m.currentDocument.Note)
-	width := max(0, min(int(m.common.cfg.GlamourMaxWidth), m.viewport.Width)) //nolint:gosec
+	width, margin := m.contentLayout()
 	if isCode {
-		width = 0
+		width, margin = 0, 0
 	}
 
 	options := []glamour.TermRendererOption{
@@ -440,13 +737,22 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 	if m.common.cfg.PreserveNewLines {
 		options = append(options, glamour.WithPreservedNewLines())
 	}
+	if utils.CodeLineNumbers {
+		options = append(options, utils.WithCodeLineNumbers(m.common.cfg.GlamourStyle, isCode, width, termenv.TrueColor))
+	}
 	r, err := glamour.NewTermRenderer(options...)
 	if err != nil {
 		return "", fmt.Errorf("error creating glamour renderer: %w", err)
 	}
 
 	if isCode {
-		markdown = utils.WrapCodeBlock(markdown, filepath.Ext(m.currentDocument.Note))
+		markdown = utils.CodeBlock(markdown, m.currentDocument.Note)
+	} else {
+		source := m.resolveWikiLinks([]byte(markdown))
+		if utils.SmartypantsEnabled([]byte(m.currentDocument.Body)) {
+			source = utils.Smarten(source)
+		}
+		markdown = string(utils.PrepareDefinitionLists(utils.PrepareMarkdown(source)))
 	}
 
 	out, err := r.Render(markdown)
@@ -457,26 +763,55 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 	if isCode {
 		out = strings.TrimSpace(out)
 	}
+	if filter := m.common.cfg.OutputFilter; filter != nil {
+		if out, err = filter(out); err != nil {
+			return "", err
+		}
+	}
 
 	// trim lines
 	lines := strings.Split(out, "\n")
+	leftMargin := strings.Repeat(" ", margin)
 
-	var content strings.Builder
 	for i, s := range lines {
 		if isCode || m.common.cfg.ShowLineNumbers {
-			content.WriteString(lineNumberStyle(fmt.Sprintf("%"+fmt.Sprint(lineNumberWidth)+"d", i+1)))
-			content.WriteString(trunc(s))
+			lines[i] = lineNumberStyle(fmt.Sprintf("%"+fmt.Sprint(lineNumberWidth)+"d", i+1)) + trunc(leftMargin+s)
 		} else {
-			content.WriteString(s)
+			lines[i] = leftMargin + s
 		}
+	}
 
-		// don't add an artificial newline after the last split
-		if i+1 < len(lines) {
-			content.WriteRune('\n')
-		}
+	// Padding goes around the numbered lines, so it isn't numbered itself.
+	if !isCode && m.common.cfg.Padding > 0 {
+		padding := make([]string, m.common.cfg.Padding)
+		lines = append(append(padding, lines...), padding...)
 	}
 
-	return content.String(), nil
+	return strings.Join(lines, "\n"), nil
+}
+
+// contentLayout returns the width to wrap documents at and the left margin
+// to indent them by. If the terminal is wider than the configured maximum
+// width, the document is centered between the margins. Line numbers take
+// their column out of the available width.
+func (m pagerModel) contentLayout() (width, margin int) {
+	cfg := m.common.cfg
+	available := m.viewport.Width - cfg.MarginLeft - cfg.MarginRight
+	if cfg.ShowLineNumbers {
+		available -= lineNumberWidth
+	}
+	available = max(0, available)
+	maxWidth := int(cfg.MaxWidth) //nolint:gosec
+
+	width = min(int(cfg.GlamourMaxWidth), available) //nolint:gosec
+	if maxWidth > 0 && (width == 0 || maxWidth < width) {
+		width = min(maxWidth, available)
+	}
+	margin = cfg.MarginLeft
+	if maxWidth > 0 {
+		margin += (available - width) / 2
+	}
+	return width, margin
 }
 
 func (m *pagerModel) initWatcher() {
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestContentLayout(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		cfg        Config
+		width      int
+		wantWidth  int
+		wantMargin int
+	}{
+		{
+			name:       "glamour width",
+			cfg:        Config{GlamourMaxWidth: 80},
+			width:      100,
+			wantWidth:  80,
+			wantMargin: 0,
+		},
+		{
+			name:       "narrow terminal",
+			cfg:        Config{GlamourMaxWidth: 80, MarginLeft: 2, MarginRight: 2},
+			width:      60,
+			wantWidth:  56,
+			wantMargin: 2,
+		},
+		{
+			name:       "centered",
+			cfg:        Config{MaxWidth: 60, MarginLeft: 2, MarginRight: 2},
+			width:      104,
+			wantWidth:  60,
+			wantMargin: 22,
+		},
+		{
+			name:       "line numbers",
+			cfg:        Config{GlamourMaxWidth: 80, MarginLeft: 2, ShowLineNumbers: true},
+			width:      50,
+			wantWidth:  50 - lineNumberWidth - 2,
+			wantMargin: 2,
+		},
+		{
+			name:       "centered with line numbers",
+			cfg:        Config{MaxWidth: 40, ShowLineNumbers: true},
+			width:      84,
+			wantWidth:  40,
+			wantMargin: 20,
+		},
+	} {
+		m := pagerModel{common: &commonModel{cfg: tc.cfg}}
+		m.viewport.Width = tc.width
+		if width, margin := m.contentLayout(); width != tc.wantWidth || margin != tc.wantMargin {
+			t.Errorf("%s: expected width %d and margin %d, got %d and %d", tc.name, tc.wantWidth, tc.wantMargin, width, margin)
+		}
+	}
+}
+
+func TestGlamourRenderMarginWithLineNumbers(t *testing.T) {
+	enabled := config.GlamourEnabled
+	config.GlamourEnabled = true
+	defer func() { config.GlamourEnabled = enabled }()
+
+	// The column the text starts at, with and without line numbers.
+	var cols []int
+	for _, lineNumbers := range []bool{false, true} {
+		m := pagerModel{common: &commonModel{cfg: Config{
+			GlamourStyle:    "notty",
+			GlamourMaxWidth: 80,
+			MarginLeft:      3,
+			Padding:         2,
+			ShowLineNumbers: lineNumbers,
+		}}}
+		m.viewport.Width = 60
+		m.currentDocument = markdown{Note: "doc.md", Body: "Hello"}
+
+		out, err := glamourRender(m, m.currentDocument.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		col := -1
+		for _, line := range strings.Split(ansi.Strip(out), "\n") {
+			if i := strings.Index(line, "Hello"); i >= 0 {
+				col = i
+			}
+		}
+		if col < 0 {
+			t.Fatalf("lineNumbers=%v: expected the text in %q", lineNumbers, out)
+		}
+		cols = append(cols, col)
+
+		// The padding lines are blank and the last content line has the
+		// last number.
+		lines := strings.Split(ansi.Strip(out), "\n")
+		for _, i := range []int{0, 1, len(lines) - 2, len(lines) - 1} {
+			if strings.TrimSpace(lines[i]) != "" {
+				t.Errorf("lineNumbers=%v: expected padding line %d to be blank, got %q", lineNumbers, i, lines[i])
+			}
+		}
+		if lineNumbers {
+			last := strings.Fields(lines[len(lines)-3])
+			if want := fmt.Sprint(len(lines) - 4); len(last) == 0 || last[0] != want {
+				t.Errorf("expected the last content line to be numbered %s, got %q", want, lines[len(lines)-3])
+			}
+		}
+	}
+	if cols[0] < 3 {
+		t.Errorf("expected the text after the margin, got column %d", cols[0])
+	}
+	if cols[1] != cols[0]+lineNumberWidth {
+		t.Errorf("expected line numbers to keep the margin at column %d, got %d", cols[0]+lineNumberWidth, cols[1])
+	}
+}
+
+func TestCopyRedactedDocument(t *testing.T) {
+	if err := utils.SetRedactions([]string{`sk-[a-z0-9]+`}, utils.DefaultRedactReplacement); err != nil {
+		t.Fatal(err)
+	}
+	defer utils.SetRedactions(nil, utils.DefaultRedactReplacement) //nolint:errcheck
+
+	var copied string
+	orig := copyToClipboard
+	copyToClipboard = func(s string) { copied = s }
+	defer func() { copyToClipboard = orig }()
+
+	const body = "# Setup\n\n| Key | Value |\n| --- | --- |\n| token | sk-abc123 |\n"
+	const want = "# Setup\n\n| Key | Value |\n| --- | --- |\n| token | [REDACTED] |\n"
+	for _, tc := range []struct {
+		name  string
+		model func() tea.Model
+	}{
+		{
+			name:  "stdin",
+			model: func() tea.Model { return newModel(Config{GlamourStyle: "notty"}, body) },
+		},
+		{
+			name: "loaded file",
+			model: func() tea.Model {
+				m, _ := newModel(Config{GlamourStyle: "notty"}, body).Update(fetchedMarkdownMsg(&markdown{Body: body, Note: "doc.md"}))
+				return m
+			},
+		},
+	} {
+		copied = ""
+		m := tc.model()
+		if doc := m.(model).pager.currentDocument.Body; doc != want {
+			t.Errorf("%s: expected the document to be redacted, got %q", tc.name, doc)
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		if copied != want {
+			t.Errorf("%s: expected %q to be copied, got %q", tc.name, want, copied)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pagerPrompt is a single-line text input shown in place of the pager's
+// status bar.
+type pagerPrompt struct {
+	input  textinput.Model
+	submit func(m *pagerModel, value string) tea.Cmd
+}
+
+// newPagerPrompt returns a prompt with the given label which calls submit
+// with the entered value when confirmed.
+func newPagerPrompt(label string, width int, submit func(*pagerModel, string) tea.Cmd) *pagerPrompt {
+	ti := textinput.New()
+	ti.Prompt = label
+	ti.PromptStyle = stashInputPromptStyle
+	ti.Cursor.Style = stashInputCursorStyle
+	ti.Width = max(0, width-len(label)-2)
+	ti.Focus()
+	return &pagerPrompt{input: ti, submit: submit}
+}
+
+// startPrompt opens a prompt in the pager.
+func (m *pagerModel) startPrompt(label string, submit func(*pagerModel, string) tea.Cmd) tea.Cmd {
+	m.prompt = newPagerPrompt(label, m.common.width, submit)
+	return textinput.Blink
+}
+
+// handlePromptKeys handles keystrokes while a prompt is open.
+func (m *pagerModel) handlePromptKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case keyEsc:
+		m.prompt = nil
+		return nil
+	case keyEnter:
+		p := m.prompt
+		m.prompt = nil
+		return p.submit(m, p.input.Value())
+	}
+
+	var cmd tea.Cmd
+	m.prompt.input, cmd = m.prompt.input.Update(msg)
+	return cmd
+}
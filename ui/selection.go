@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var selectedItemStyle = lipgloss.NewStyle().Foreground(cream).Background(fuchsia)
+
+// selection is a cursor over items of the document, like tasks or links,
+// which are located in the rendered lines by their text.
+type selection struct {
+	// Rendered line and column of each item's text, or -1 if it couldn't be
+	// found, and the width of the text.
+	lines  []int
+	cols   []int
+	widths []int
+	index  int
+}
+
+// textNeedle returns the start of an item's text, which is what we look for
+// in the rendered lines. Long items are wrapped, so we can't search for all
+// of it.
+func textNeedle(text string) string {
+	words := strings.Fields(text)
+	return strings.Join(words[:min(3, len(words))], " ")
+}
+
+// locate finds items in the rendered lines. Items are searched in order, so
+// that repeated texts resolve to the right occurrence.
+func (s *selection) locate(lines []string, texts []string) {
+	s.lines = make([]int, len(texts))
+	s.cols = make([]int, len(texts))
+	s.widths = make([]int, len(texts))
+	s.index = max(0, min(s.index, len(texts)-1))
+
+	from := 0
+	for i, text := range texts {
+		s.lines[i] = -1
+		s.widths[i] = ansi.StringWidth(text)
+		if from >= len(lines) {
+			continue
+		}
+		if occ := textOccurrences(lines[from:], textNeedle(text)); len(occ) > 0 {
+			s.lines[i], s.cols[i] = from+occ[0][0], occ[0][1]
+			from = s.lines[i] + 1
+		}
+	}
+}
+
+// selectFrom selects the first item at or below the given line.
+func (s *selection) selectFrom(line int) {
+	for i, l := range s.lines {
+		if l >= line {
+			s.index = i
+			return
+		}
+	}
+}
+
+// move moves the cursor for navigation keys, and reports whether the key was
+// one of them.
+func (s *selection) move(key string) bool {
+	switch key {
+	case "up", "k", "shift+tab":
+		s.index = max(0, s.index-1)
+	case "down", "j", "tab":
+		s.index = max(0, min(len(s.lines)-1, s.index+1))
+	case "home", "g":
+		s.index = 0
+	case "end", "G":
+		s.index = max(0, len(s.lines)-1)
+	default:
+		return false
+	}
+	return true
+}
+
+// decorate highlights the selected item in the rendered lines.
+func (s selection) decorate(lines []string) []string {
+	if s.index >= len(s.lines) || s.lines[s.index] < 0 || s.lines[s.index] >= len(lines) {
+		return lines
+	}
+	out := append([]string(nil), lines...)
+	line, col := s.lines[s.index], s.cols[s.index]
+	// Wrapped text is highlighted up to the end of the line.
+	width := min(s.widths[s.index], ansi.StringWidth(strings.TrimRight(ansi.Strip(out[line]), " "))-col)
+	out[line] = highlightRange(out[line], col, width, selectedItemStyle)
+	return out
+}
+
+// scrollToSelection makes sure the selected item is in view.
+func (m *pagerModel) scrollToSelection(s selection) {
+	if s.index >= len(s.lines) || s.lines[s.index] < 0 {
+		return
+	}
+	line := s.lines[s.index]
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/2))
+	}
+}
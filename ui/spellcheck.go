@@ -0,0 +1,258 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+	gast "github.com/yuin/goldmark/ast"
+)
+
+const personalDictionary = "personal.txt"
+
+var (
+	wordPattern = regexp.MustCompile(`\p{L}[\p{L}'’]*`)
+
+	misspelledStyle = lipgloss.NewStyle().Foreground(red).Underline(true)
+
+	// Well-known locations of hunspell/myspell and plain word list
+	// dictionaries.
+	systemDictionaryDirs = []string{
+		"/usr/share/hunspell",
+		"/usr/share/myspell",
+		"/usr/share/myspell/dicts",
+		"/Library/Spelling",
+	}
+)
+
+// dictionary is a set of correctly spelled words.
+type dictionary map[string]struct{}
+
+type spellcheckLoadedMsg struct {
+	lang string
+	dict dictionary
+	err  error
+}
+
+// dictionaryCandidates returns the files we look for a language's dictionary
+// in, most preferred first.
+func dictionaryCandidates(lang, userDir string) []string {
+	var files []string
+	dirs := append([]string{userDir}, systemDictionaryDirs...)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		files = append(files,
+			filepath.Join(dir, lang+".dic"),
+			filepath.Join(dir, lang+".txt"),
+		)
+		if base, _, ok := strings.Cut(lang, "_"); ok {
+			files = append(files, filepath.Join(dir, base+".dic"), filepath.Join(dir, base+".txt"))
+		}
+	}
+	if strings.HasPrefix(lang, "en") {
+		files = append(files, "/usr/share/dict/words")
+	}
+	return files
+}
+
+// loadDictionary reads the dictionary for the given language, merged with the
+// user's personal word list.
+func loadDictionary(lang, userDir string) tea.Cmd {
+	return func() tea.Msg {
+		dict := dictionary{}
+		found := false
+		for _, path := range dictionaryCandidates(lang, userDir) {
+			if err := dict.readFile(path); err == nil {
+				log.Debug("loaded dictionary", "path", path)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return spellcheckLoadedMsg{lang: lang, err: fmt.Errorf("no dictionary found for %s", lang)}
+		}
+		if userDir != "" {
+			_ = dict.readFile(filepath.Join(userDir, personalDictionary))
+		}
+		return spellcheckLoadedMsg{lang: lang, dict: dict}
+	}
+}
+
+// readFile adds the words of a word list or hunspell dictionary to d. The
+// stems of hunspell dictionaries are expanded with the affixes of the .aff
+// file next to them; without one only the stems are known.
+func (d dictionary) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open dictionary: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	var aff *affixes
+	if base, ok := strings.CutSuffix(path, ".dic"); ok {
+		if aff, err = readAffixes(base + ".aff"); err != nil {
+			log.Debug("dictionary without affixes", "path", path, "error", err)
+		}
+	}
+
+	s := bufio.NewScanner(f)
+	first := true
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		// hunspell dictionaries start with a word count.
+		if first && isNumber(line) {
+			first = false
+			continue
+		}
+		first = false
+		// Stems can be followed by morphological fields.
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			line = line[:i]
+		}
+		word, flags, _ := strings.Cut(line, "/")
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if aff == nil {
+			d[strings.ToLower(word)] = struct{}{}
+			continue
+		}
+		for _, w := range aff.expand(word, flags) {
+			d[strings.ToLower(w)] = struct{}{}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("unable to read dictionary: %w", err)
+	}
+	if len(d) == 0 {
+		return errors.New("empty dictionary")
+	}
+	return nil
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// check returns whether word is spelled correctly. Acronyms and words with
+// a possessive suffix are handled leniently.
+func (d dictionary) check(word string) bool {
+	word = strings.ReplaceAll(word, "’", "'")
+	if len([]rune(word)) < 2 || strings.ToUpper(word) == word {
+		return true
+	}
+	lower := strings.ToLower(word)
+	if _, ok := d[lower]; ok {
+		return true
+	}
+	if stem, ok := strings.CutSuffix(lower, "'s"); ok {
+		_, found := d[stem]
+		return found
+	}
+	return false
+}
+
+// misspelledWords returns the misspelled words in the prose of a markdown
+// document. Code spans, code blocks, links targets and HTML are skipped.
+func misspelledWords(source []byte, d dictionary) map[string]bool {
+	words := map[string]bool{}
+	_ = gast.Walk(utils.ParseMarkdown(source), func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *gast.CodeSpan, *gast.CodeBlock, *gast.FencedCodeBlock,
+			*gast.HTMLBlock, *gast.RawHTML, *gast.AutoLink:
+			return gast.WalkSkipChildren, nil
+		case *gast.Text:
+			for _, w := range wordPattern.FindAllString(string(n.Segment.Value(source)), -1) {
+				w = strings.TrimRight(w, "'’")
+				if !d.check(w) {
+					words[w] = true
+				}
+			}
+		}
+		return gast.WalkContinue, nil
+	})
+	return words
+}
+
+// underlineMisspellings marks occurrences of the misspelled words in the
+// rendered lines.
+func underlineMisspellings(lines []string, words map[string]bool) []string {
+	if len(words) == 0 {
+		return lines
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		for _, loc := range wordPattern.FindAllStringIndex(plain, -1) {
+			w := strings.TrimRight(plain[loc[0]:loc[1]], "'’")
+			if !words[w] {
+				continue
+			}
+			col := ansi.StringWidth(plain[:loc[0]])
+			line = highlightRange(line, col, ansi.StringWidth(w), misspelledStyle)
+		}
+		out[i] = line
+	}
+	return out
+}
+
+// defaultSpellLang returns the spell checking language derived from the
+// environment's locale, e.g. en_US for LANG=en_US.UTF-8.
+func defaultSpellLang() string {
+	if lang := systemLocale(); lang != "" {
+		return lang
+	}
+	return "en_US"
+}
+
+// toggleSpellcheck turns spell checking on or off for this session.
+func (m *pagerModel) toggleSpellcheck() tea.Cmd {
+	if m.spellcheck {
+		m.spellcheck = false
+		m.misspelled = nil
+		m.refreshContent()
+		return tea.Batch(m.syncViewport(), m.showStatusMessage(pagerStatusMessage{tr("Spell check off"), false}))
+	}
+
+	lang := m.common.cfg.SpellLang
+	if lang == "" {
+		lang = defaultSpellLang()
+	}
+	if m.dictionary != nil && m.dictionaryLang == lang {
+		return func() tea.Msg { return spellcheckLoadedMsg{lang: lang, dict: m.dictionary} }
+	}
+	return loadDictionary(lang, utils.ExpandPath(m.common.cfg.DictionaryPath))
+}
+
+// checkSpelling finds the misspelled words of the current document.
+func (m *pagerModel) checkSpelling() {
+	if !m.spellcheck || m.dictionary == nil {
+		m.misspelled = nil
+		return
+	}
+	body := utils.RemoveFrontmatter([]byte(m.currentDocument.Body))
+	m.misspelled = misspelledWords(body, m.dictionary)
+}
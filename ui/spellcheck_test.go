@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Rules from the en_US dictionary.
+const testAffixes = `SET UTF-8
+TRY esianrtolcdugmphbyfvkwzESIANRTOLCDUGMPHBYFVKWZ'
+
+PFX U Y 1
+PFX U   0     un         .
+
+PFX R N 1
+PFX R   0     re         .
+
+SFX D Y 4
+SFX D   0     d          e
+SFX D   y     ied        [^aeiou]y
+SFX D   0     ed         [^ey]
+SFX D   0     ed         [aeiou]y
+
+SFX S Y 4
+SFX S   y     ies        [^aeiou]y
+SFX S   0     s          [aeiou]y
+SFX S   0     es         [sxzh]
+SFX S   0     s          [^sxzhy]
+
+SFX G Y 2
+SFX G   e     ing        e
+SFX G   0     ing        [^e]
+`
+
+func writeDictionary(t *testing.T, name, dic, aff string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(dic), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if aff != "" {
+		if err := os.WriteFile(strings.TrimSuffix(path, ".dic")+".aff", []byte(aff), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestDictionaryExpandsAffixes(t *testing.T) {
+	path := writeDictionary(t, "en_US.dic", "5\ndocument/DSG\nrender/SDG\ncarry/DSG\nlock/UDSG\nwrite/R\tpo:verb\n", testAffixes)
+	d := dictionary{}
+	if err := d.readFile(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{
+		"document", "documents", "documented", "documenting",
+		"rendered", "renders", "rendering",
+		"carried", "carries", "carrying",
+		"unlock", "unlocked", "unlocks", "unlocking",
+		"write", "rewrite",
+	} {
+		if !d.check(w) {
+			t.Errorf("expected %q to be known", w)
+		}
+	}
+	for _, w := range []string{"documentes", "carryed", "renderd", "rewrites", "unwrite", "po:verb"} {
+		if d.check(w) {
+			t.Errorf("expected %q to be unknown", w)
+		}
+	}
+}
+
+func TestAffixFlagTypes(t *testing.T) {
+	for _, tc := range []struct {
+		name, aff, dic string
+		want           []string
+	}{
+		{
+			name: "long",
+			aff:  "FLAG long\nSFX Aa Y 1\nSFX Aa 0 s .\nSFX Bb Y 1\nSFX Bb 0 ed .\n",
+			dic:  "1\nwalk/AaBb\n",
+			want: []string{"walk", "walks", "walked"},
+		},
+		{
+			name: "num",
+			aff:  "FLAG num\nSFX 10 Y 1\nSFX 10 0 s .\nSFX 200 Y 1\nSFX 200 0 ed .\n",
+			dic:  "1\nwalk/10,200\n",
+			want: []string{"walk", "walks", "walked"},
+		},
+		{
+			name: "aliases",
+			aff:  "AF 2\nAF A\nAF AB\nSFX A Y 1\nSFX A 0 s .\nSFX B Y 1\nSFX B 0 ed .\n",
+			dic:  "2\ntalk/1\nwalk/2\n",
+			want: []string{"talk", "talks", "walk", "walks", "walked"},
+		},
+		{
+			name: "utf-8",
+			aff:  "FLAG UTF-8\nSFX ä Y 1\nSFX ä 0 en [^e]\n",
+			dic:  "1\nHaus/ä\n",
+			want: []string{"haus", "hausen"},
+		},
+	} {
+		d := dictionary{}
+		if err := d.readFile(writeDictionary(t, "test.dic", tc.dic, tc.aff)); err != nil {
+			t.Fatal(err)
+		}
+		want := dictionary{}
+		for _, w := range tc.want {
+			want[w] = struct{}{}
+		}
+		if !reflect.DeepEqual(d, want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, want, d)
+		}
+	}
+}
+
+func TestDictionaryWithoutAffixes(t *testing.T) {
+	d := dictionary{}
+	if err := d.readFile(writeDictionary(t, "words.dic", "2\ndocument/DSG\nrender/SDG\n", "")); err != nil {
+		t.Fatal(err)
+	}
+	if !d.check("document") || d.check("documents") {
+		t.Errorf("expected only the stems without an affix file, got %v", d)
+	}
+
+	d = dictionary{}
+	if err := d.readFile(writeDictionary(t, "words", "Documents\n# comment\nrendered\n", "")); err != nil {
+		t.Fatal(err)
+	}
+	if !d.check("documents") || !d.check("Rendered") || d.check("comment") {
+		t.Errorf("expected the words of the list, got %v", d)
+	}
+}
+
+func TestDictionaryCheck(t *testing.T) {
+	d := dictionary{"glow": {}, "don't": {}, "markdown": {}}
+	for _, tc := range []struct {
+		word string
+		want bool
+	}{
+		{"glow", true},
+		{"Glow", true},
+		{"glows", false},
+		{"Glow's", true},
+		{"Glow’s", true},
+		{"don’t", true},
+		{"HTML", true},
+		{"x", true},
+		{"markdwn", false},
+	} {
+		if got := d.check(tc.word); got != tc.want {
+			t.Errorf("check(%q): expected %v, got %v", tc.word, tc.want, got)
+		}
+	}
+}
+
+func TestMisspelledWords(t *testing.T) {
+	d := dictionary{}
+	for _, w := range strings.Fields("a the text has and in are is not with link here see it's code") {
+		d[w] = struct{}{}
+	}
+	md := "The text has mistaks, and it's wrongg’.\n\n" +
+		"Code `in spans are skiped` and\n\n" +
+		"```go\nin fenced blocks tooo\n```\n\n" +
+		"    indented blocks neithr\n\n" +
+		"<div>html is ignord</div>\n\n" +
+		"A [link](https://exmple.com) with <https://autolnk.example> here.\n"
+	want := map[string]bool{"mistaks": true, "wrongg": true}
+	if got := misspelledWords([]byte(md), d); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestUnderlineMisspellings(t *testing.T) {
+	lines := []string{"  some mistaks here", "  not mistaksX"}
+	got := underlineMisspellings(lines, map[string]bool{"mistaks": true})
+	if got[0] == lines[0] || !strings.Contains(got[0], "mistaks") {
+		t.Errorf("expected the word to be underlined, got %q", got[0])
+	}
+	if got[1] != lines[1] {
+		t.Errorf("expected only whole words to be underlined, got %q", got[1])
+	}
+}
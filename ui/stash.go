@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/reflow/ansi"
@@ -199,6 +200,9 @@ func (m *stashModel) setCursor(i int) {
 
 // Whether or not the spinner should be spinning.
 func (m stashModel) shouldSpin() bool {
+	if m.common.cfg.Accessible {
+		return false
+	}
 	loading := !m.loadingDone()
 	openingDocument := m.viewState == stashStateLoadingDocument
 	return loading || openingDocument
@@ -379,7 +383,7 @@ func newStashModel(common *commonModel) stashModel {
 	sp.Style = stashSpinnerStyle
 
 	si := textinput.New()
-	si.Prompt = "Find:"
+	si.Prompt = tr("Find:")
 	si.PromptStyle = stashInputPromptStyle
 	si.Cursor.Style = stashInputCursorStyle
 	si.Focus()
@@ -673,7 +677,7 @@ func (m stashModel) view() string {
 	case stashStateShowingError:
 		return errorView(m.err, false)
 	case stashStateLoadingDocument:
-		s += " " + m.spinner.View() + " Loading document..."
+		s += " " + m.spinner.View() + " " + tr("Loading document...")
 	case stashStateReady:
 		loadingIndicator := " "
 		if m.shouldSpin() {
@@ -759,10 +763,10 @@ func (m stashModel) headerView() string {
 	// Filter results
 	if m.filterState == filtering {
 		if localCount == 0 {
-			return grayFg("Nothing found.")
+			return grayFg(tr("Nothing found."))
 		}
 		if localCount > 0 {
-			sections = append(sections, fmt.Sprintf("%d local", localCount))
+			sections = append(sections, trf("%d local", localCount))
 		}
 
 		for i := range sections {
@@ -778,7 +782,7 @@ func (m stashModel) headerView() string {
 
 		switch v.key {
 		case documentsSection:
-			s = fmt.Sprintf("%d documents", localCount)
+			s = trf("%d documents", localCount)
 
 		case filterSection:
 			s = fmt.Sprintf("%d “%s”", len(m.filteredMarkdowns), m.filterInput.Value())
@@ -809,9 +813,9 @@ func (m stashModel) populatedView() string {
 		switch m.sections[m.sectionIndex].key {
 		case documentsSection:
 			if m.loadingDone() {
-				f("No files found.")
+				f(tr("No files found."))
 			} else {
-				f("Looking for local files...")
+				f(tr("Looking for local files..."))
 			}
 		case filterSection:
 			return ""
@@ -851,6 +855,9 @@ func (m stashModel) populatedView() string {
 
 func loadLocalMarkdown(md *markdown) tea.Cmd {
 	return func() tea.Msg {
+		if md.fetch != nil {
+			return readRemoteMarkdown(md)
+		}
 		if md.localPath == "" {
 			return errMsg{errors.New("could not load file: missing path")}
 		}
@@ -860,6 +867,11 @@ func loadLocalMarkdown(md *markdown) tea.Cmd {
 			log.Debug("error reading local file", "error", err)
 			return errMsg{err}
 		}
+		data, err = utils.ConvertToMarkdown(data, md.localPath)
+		if err != nil {
+			log.Debug("error converting local file", "error", err)
+			return errMsg{err}
+		}
 		md.Body = string(data)
 		return fetchedMarkdownMsg(md)
 	}
@@ -164,6 +164,7 @@ const minHelpViewHeight = 5
 // renderHelp returns the rendered help view and associated line height for
 // the given groups of help items.
 func (m stashModel) renderHelp(groups ...[]string) (string, int) {
+	groups = translateHelp(groups)
 	if m.showFullHelp {
 		str := m.fullHelpView(groups...)
 		numLines := strings.Count(str, "\n") + 1
@@ -289,6 +290,22 @@ func mergeColumns(cols ...[]string) string {
 	return b.String()
 }
 
+// translateHelp translates the help text of groups of key and help text
+// pairs.
+func translateHelp(groups [][]string) [][]string {
+	out := make([][]string, len(groups))
+	for i, g := range groups {
+		out[i] = make([]string, len(g))
+		for j, s := range g {
+			if j%2 == 1 {
+				s = tr(s)
+			}
+			out[i][j] = s
+		}
+	}
+	return out
+}
+
 func concatStringSlices(s ...[]string) (agg []string) {
 	for _, v := range s {
 		agg = append(agg, v...)
@@ -10,17 +10,16 @@ import (
 	"github.com/sahilm/fuzzy"
 )
 
-const (
-	verticalLine         = "│"
-	fileListingStashIcon = "• "
-)
+const fileListingStashIcon = "• "
+
+var verticalLine = "│"
 
 func stashItemView(b *strings.Builder, m stashModel, index int, md *markdown) {
 	var (
 		truncateTo  = uint(m.common.width - stashViewHorizontalPadding*2) //nolint:gosec
 		gutter      string
 		title       = truncate.StringWithTail(md.Note, truncateTo, ellipsis)
-		date        = md.relativeTime()
+		date        = md.details()
 		editedBy    = ""
 		hasEditedBy = false
 		icon        = ""
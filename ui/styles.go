@@ -22,6 +22,20 @@ var (
 	dimGreen       = lipgloss.AdaptiveColor{Light: "#72D2B0", Dark: "#0B5137"}
 )
 
+// popupBorder is the border of popups drawn over the pager.
+var popupBorder = lipgloss.RoundedBorder()
+
+// useASCIIStyles replaces the box drawing characters used throughout the UI
+// with plain ASCII, for accessibility mode.
+func useASCIIStyles() {
+	popupBorder = lipgloss.ASCIIBorder()
+	footnotePopupStyle = footnotePopupStyle.Border(popupBorder)
+	annotationListStyle = annotationListStyle.Border(popupBorder)
+	dividerDot = darkGrayFg.SetString(" - ")
+	dividerBar = darkGrayFg.SetString(" | ")
+	verticalLine = "|"
+}
+
 // Ulimately, we'll transition to named styles.
 var (
 	dimNormalFg      = lipgloss.NewStyle().Foreground(normalDim).Render
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestUseASCIIStyles(t *testing.T) {
+	defer func(border lipgloss.Border, footnote, annotations, dot, bar lipgloss.Style, line string) {
+		popupBorder, footnotePopupStyle, annotationListStyle = border, footnote, annotations
+		dividerDot, dividerBar, verticalLine = dot, bar, line
+	}(popupBorder, footnotePopupStyle, annotationListStyle, dividerDot, dividerBar, verticalLine)
+
+	annotationListStyle = annotationListStyle.Width(30)
+	useASCIIStyles()
+
+	for name, s := range map[string]string{
+		"footnote popup":  footnotePopupStyle.Render("text"),
+		"annotation list": annotationListStyle.Render("text"),
+		"divider dot":     dividerDot.String(),
+		"divider bar":     dividerBar.String(),
+		"vertical line":   verticalLine,
+	} {
+		for _, r := range ansi.Strip(s) {
+			if r > 127 {
+				t.Errorf("%s: expected ASCII only, got %q in %q", name, r, s)
+				break
+			}
+		}
+	}
+	if !strings.Contains(annotationListStyle.Render("text"), "+") {
+		t.Error("expected the annotation list to have an ASCII border")
+	}
+	if w := annotationListStyle.GetWidth(); w != 30 {
+		t.Errorf("expected the annotation list to keep its width, got %d", w)
+	}
+}
+
+func TestShouldSpin(t *testing.T) {
+	for _, accessible := range []bool{false, true} {
+		m := newStashModel(&commonModel{cfg: Config{Accessible: accessible}})
+		if got := m.shouldSpin(); got == accessible {
+			t.Errorf("accessible=%v: expected the spinner to spin %v, got %v", accessible, !accessible, got)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	tableSeparatorPattern = regexp.MustCompile(`^\s*(─[─┼]*|-+(\|-+)+)\s*$`)
+
+	tableCellStyle     = lipgloss.NewStyle().Padding(0, 1)
+	tableHeaderStyle   = tableCellStyle.Bold(true)
+	tableSelectedStyle = tableCellStyle.Foreground(cream).Background(fuchsia)
+	tableBorderStyle   = lipgloss.NewStyle().Foreground(dullFuchsia)
+)
+
+// tableMode holds the state of the pager's table navigation mode. Rows
+// include the table header at index 0.
+type tableMode struct {
+	tables [][][]string
+	lines  []int // rendered line of each table's header separator
+	index  int
+	row    int
+	col    int
+}
+
+func (t tableMode) current() [][]string {
+	return t.tables[t.index]
+}
+
+func (t tableMode) cell() string {
+	row := t.current()[t.row]
+	if t.col < len(row) {
+		return row[t.col]
+	}
+	return ""
+}
+
+// renderedTableLines returns the rendered lines of all table header
+// separators, in document order.
+func renderedTableLines(lines []string) []int {
+	var seps []int
+	for i, line := range lines {
+		if tableSeparatorPattern.MatchString(ansi.Strip(line)) {
+			seps = append(seps, i)
+		}
+	}
+	return seps
+}
+
+// enterTableMode starts navigating the first table at or below the top of the
+// viewport.
+func (m *pagerModel) enterTableMode() tea.Cmd {
+	tables := utils.Tables(utils.RemoveFrontmatter([]byte(m.currentDocument.Body)))
+	if len(tables) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{tr("No tables in document"), false})
+	}
+
+	t := &tableMode{tables: tables, lines: renderedTableLines(m.lines)}
+	if len(t.lines) == len(tables) {
+		t.index = len(tables) - 1
+		for i, line := range t.lines {
+			if line >= m.viewport.YOffset {
+				t.index = i
+				break
+			}
+		}
+	}
+	m.table = t
+	m.scrollToTable()
+	return tea.Batch(m.enterOverlay(), m.syncViewport())
+}
+
+func (m *pagerModel) exitTableMode() tea.Cmd {
+	m.table = nil
+	return m.leaveOverlay()
+}
+
+// scrollToTable makes sure the selected table is in view.
+func (m *pagerModel) scrollToTable() {
+	if m.table.index >= len(m.table.lines) {
+		return
+	}
+	line := m.table.lines[m.table.index]
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(max(0, line-1))
+	}
+}
+
+// handleTableKeys handles keystrokes in table navigation mode.
+func (m *pagerModel) handleTableKeys(msg tea.KeyMsg) tea.Cmd {
+	t := m.table
+	rows := t.current()
+
+	switch msg.String() {
+	case "up", "k":
+		t.row = max(0, t.row-1)
+	case "down", "j":
+		t.row = min(len(rows)-1, t.row+1)
+	case "left", "h":
+		t.col = max(0, t.col-1)
+	case "right", "l":
+		t.col = min(len(rows[t.row])-1, t.col+1)
+	case "tab", "shift+tab":
+		if msg.String() == "tab" {
+			t.index = (t.index + 1) % len(t.tables)
+		} else {
+			t.index = (t.index - 1 + len(t.tables)) % len(t.tables)
+		}
+		t.row, t.col = 0, 0
+		m.scrollToTable()
+		return m.syncViewport()
+	case "y", keyEnter:
+		copyToClipboard(t.cell())
+		return m.showStatusMessage(pagerStatusMessage{tr("Copied cell"), false})
+	case "Y":
+		copyToClipboard(strings.Join(rows[t.row], "\t"))
+		return m.showStatusMessage(pagerStatusMessage{tr("Copied row"), false})
+	case "c":
+		copyToClipboard(formatDelimited(rows, ','))
+		return m.showStatusMessage(pagerStatusMessage{tr("Copied table as CSV"), false})
+	case "t":
+		copyToClipboard(formatDelimited(rows, '\t'))
+		return m.showStatusMessage(pagerStatusMessage{tr("Copied table as TSV"), false})
+	case "q", keyEsc:
+		return m.exitTableMode()
+	}
+
+	t.col = max(0, min(t.col, len(rows[t.row])-1))
+	return nil
+}
+
+// formatDelimited formats rows as CSV, using the given field delimiter.
+func formatDelimited(rows [][]string, comma rune) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma = comma
+	_ = w.WriteAll(rows)
+	return b.String()
+}
+
+// view renders the selected table with the cell cursor.
+func (t tableMode) view(maxWidth int) string {
+	rows := t.current()
+	tbl := table.New().
+		Border(popupBorder).
+		BorderStyle(tableBorderStyle).
+		Headers(rows[0]...).
+		Rows(rows[1:]...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			// The header is row -1 for lipgloss, but 0 for us.
+			if row+1 == t.row && col == t.col {
+				return tableSelectedStyle
+			}
+			if row == table.HeaderRow {
+				return tableHeaderStyle
+			}
+			return tableCellStyle
+		})
+	if w := lipgloss.Width(tbl.String()); w > maxWidth {
+		tbl.Width(maxWidth)
+	}
+	return tbl.String()
+}
+
+// tableOverlayView draws the table navigator on top of the viewport view.
+func (m pagerModel) tableOverlayView(view string) string {
+	box := m.table.view(m.viewport.Width)
+	row := 0
+	if m.table.index < len(m.table.lines) {
+		row = m.table.lines[m.table.index] - 1 - m.viewport.YOffset
+	}
+	row = max(0, min(row, m.viewport.Height-lipgloss.Height(box)))
+	return overlay(view, box, row, 0)
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatDelimited(t *testing.T) {
+	rows := [][]string{
+		{"name", "note"},
+		{"plain", "text"},
+		{"comma", "a, b"},
+		{"quote", `say "hi"`},
+		{"tab", "a\tb"},
+		{"newline", "a\nb"},
+	}
+	for _, tc := range []struct {
+		comma rune
+		want  string
+	}{
+		{',', "name,note\nplain,text\ncomma,\"a, b\"\nquote,\"say \"\"hi\"\"\"\ntab,a\tb\nnewline,\"a\nb\"\n"},
+		{'\t', "name\tnote\nplain\ttext\ncomma\ta, b\nquote\t\"say \"\"hi\"\"\"\ntab\t\"a\tb\"\nnewline\t\"a\nb\"\n"},
+	} {
+		if got := formatDelimited(rows, tc.comma); got != tc.want {
+			t.Errorf("formatDelimited with %q: expected %q, got %q", tc.comma, tc.want, got)
+		}
+	}
+}
+
+func TestRenderedTableLines(t *testing.T) {
+	lines := []string{
+		"  Some text",
+		"  name │ note",
+		"  ─────┼──────",
+		"  a    │ b",
+		"",
+		"  \x1b[1mid\x1b[0m | x",
+		"  ---|---",
+		"  -----",
+		"  ─────",
+	}
+	if got, want := renderedTableLines(lines), []int{2, 6, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected separators on lines %v, got %v", want, got)
+	}
+}
+
+func TestTableModeCell(t *testing.T) {
+	tm := tableMode{tables: [][][]string{{{"a", "b"}, {"1"}}}}
+	for _, tc := range []struct {
+		row, col int
+		want     string
+	}{
+		{0, 1, "b"},
+		{1, 0, "1"},
+		{1, 1, ""},
+	} {
+		tm.row, tm.col = tc.row, tc.col
+		if got := tm.cell(); got != tc.want {
+			t.Errorf("cell at %d,%d: expected %q, got %q", tc.row, tc.col, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// taskMode holds the state of the pager's task list mode, in which task list
+// items can be ticked and unticked.
+type taskMode struct {
+	tasks []utils.Task
+	selection
+}
+
+type taskToggledMsg struct {
+	path string
+	err  error
+}
+
+// locateTasks finds the tasks of the current document in the rendered lines.
+func (m *pagerModel) locateTasks() {
+	t := m.tasks
+	t.tasks = utils.Tasks([]byte(m.currentDocument.Body))
+	texts := make([]string, len(t.tasks))
+	for i, task := range t.tasks {
+		texts[i] = task.Text
+	}
+	t.locate(m.lines, texts)
+}
+
+// enterTaskMode selects the first task at or below the top of the viewport.
+func (m *pagerModel) enterTaskMode() tea.Cmd {
+	if m.currentDocument.localPath == "" {
+		return m.showStatusMessage(pagerStatusMessage{tr("Only local files can be edited"), true})
+	}
+	m.tasks = &taskMode{}
+	m.locateTasks()
+	if len(m.tasks.tasks) == 0 {
+		m.tasks = nil
+		return m.showStatusMessage(pagerStatusMessage{tr("No tasks in document"), false})
+	}
+
+	m.tasks.selectFrom(m.viewport.YOffset)
+	m.scrollToSelection(m.tasks.selection)
+	m.refreshContent()
+	return tea.Batch(
+		m.syncViewport(),
+		m.showStatusMessage(pagerStatusMessage{tr("Space toggles the selected task, esc exits"), false}),
+	)
+}
+
+func (m *pagerModel) exitTaskMode() tea.Cmd {
+	m.tasks = nil
+	m.refreshContent()
+	return m.syncViewport()
+}
+
+// handleTaskKeys handles keystrokes in task list mode.
+func (m *pagerModel) handleTaskKeys(msg tea.KeyMsg) tea.Cmd {
+	t := m.tasks
+	if len(t.tasks) == 0 {
+		return m.exitTaskMode()
+	}
+
+	switch key := msg.String(); {
+	case t.move(key):
+	case key == " " || key == "x" || key == keyEnter:
+		task := t.tasks[t.index]
+		return toggleTask(m.currentDocument.localPath, t.index, task.Text)
+	case key == "q" || key == keyEsc:
+		return m.exitTaskMode()
+	default:
+		return nil
+	}
+
+	m.scrollToSelection(t.selection)
+	m.refreshContent()
+	return m.syncViewport()
+}
+
+// toggleTask ticks or unticks a task in a file. The task is identified by its
+// index and text, so that we don't toggle the wrong one if the file was
+// changed since it was rendered.
+func toggleTask(path string, index int, text string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := os.Stat(path)
+		if err != nil {
+			return taskToggledMsg{path, fmt.Errorf("unable to stat file: %w", err)}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return taskToggledMsg{path, fmt.Errorf("unable to read file: %w", err)}
+		}
+
+		tasks := utils.Tasks(data)
+		if index >= len(tasks) || tasks[index].Text != text {
+			return taskToggledMsg{path, errors.New("task not found, the file has changed")}
+		}
+		data = utils.ToggleTask(data, tasks[index])
+		if err := os.WriteFile(path, data, info.Mode().Perm()); err != nil {
+			return taskToggledMsg{path, fmt.Errorf("unable to write file: %w", err)}
+		}
+		return taskToggledMsg{path: path}
+	}
+}
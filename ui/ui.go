@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,7 +14,6 @@ import (
 	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/gitcha"
-	te "github.com/muesli/termenv"
 )
 
 const (
@@ -21,13 +21,7 @@ const (
 	ellipsis             = "…"
 )
 
-var (
-	config Config
-
-	markdownExtensions = []string{
-		"*.md", "*.mdown", "*.mkdn", "*.mkd", "*.markdown",
-	}
-)
+var config Config
 
 // NewProgram returns a new Tea program.
 func NewProgram(cfg Config, content string) *tea.Program {
@@ -40,7 +34,14 @@ func NewProgram(cfg Config, content string) *tea.Program {
 	)
 
 	config = cfg
-	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	setLocale(cfg.Locale)
+	setFormatLocale(cfg.Locale, cfg.DateFormat)
+	var opts []tea.ProgramOption
+	if cfg.Accessible {
+		useASCIIStyles()
+	} else {
+		opts = append(opts, tea.WithAltScreen())
+	}
 	if cfg.EnableMouse {
 		opts = append(opts, tea.WithMouseCellMotion())
 	}
@@ -80,12 +81,14 @@ type state int
 const (
 	stateShowStash state = iota
 	stateShowDocument
+	stateFetching
 )
 
 func (s state) String() string {
 	return map[state]string{
 		stateShowStash:    "showing file listing",
 		stateShowDocument: "showing document",
+		stateFetching:     "fetching document",
 	}[s]
 }
 
@@ -95,6 +98,9 @@ type commonModel struct {
 	cwd    string
 	width  int
 	height int
+
+	// Local markdown files wiki links are resolved against.
+	wikiPages *utils.WikiPages
 }
 
 type model struct {
@@ -106,6 +112,9 @@ type model struct {
 	stash stashModel
 	pager pagerModel
 
+	// Remote document being downloaded
+	fetch remoteFetch
+
 	// Channel that receives paths to local markdown files
 	// (via the github.com/muesli/gitcha package)
 	localFileFinder chan gitcha.SearchResult
@@ -134,7 +143,7 @@ func newModel(cfg Config, content string) tea.Model {
 	initSections()
 
 	if cfg.GlamourStyle == styles.AutoStyle {
-		if te.HasDarkBackground() {
+		if utils.HasDarkBackground() {
 			cfg.GlamourStyle = styles.DarkStyle
 		} else {
 			cfg.GlamourStyle = styles.LightStyle
@@ -142,7 +151,8 @@ func newModel(cfg Config, content string) tea.Model {
 	}
 
 	common := commonModel{
-		cfg: cfg,
+		cfg:       cfg,
+		wikiPages: &utils.WikiPages{},
 	}
 
 	m := model{
@@ -152,10 +162,20 @@ func newModel(cfg Config, content string) tea.Model {
 		stash:  newStashModel(&common),
 	}
 
+	if cfg.Fetch != nil {
+		m.state = stateFetching
+		m.fetch = remoteFetch{name: cfg.Remote, read: &atomic.Int64{}}
+		return m
+	}
+
+	if cfg.RemoteFiles != nil {
+		return m
+	}
+
 	path := cfg.Path
 	if path == "" && content != "" {
 		m.state = stateShowDocument
-		m.pager.currentDocument = markdown{Body: content}
+		m.pager.setDocument(markdown{Body: content})
 		return m
 	}
 
@@ -177,6 +197,7 @@ func newModel(cfg Config, content string) tea.Model {
 			localPath: path,
 			Note:      stripAbsolutePath(path, cwd),
 			Modtime:   info.ModTime(),
+			Size:      info.Size(),
 		}
 	}
 
@@ -187,16 +208,29 @@ func (m model) Init() tea.Cmd {
 	cmds := []tea.Cmd{m.stash.spinner.Tick}
 
 	switch m.state {
+	case stateFetching:
+		cmds = append(cmds, m.startFetch())
 	case stateShowStash:
+		if m.common.cfg.RemoteFiles != nil {
+			cmds = append(cmds, listRemoteFiles(m.common.cfg.RemoteFiles))
+			break
+		}
 		cmds = append(cmds, findLocalFiles(*m.common))
 	case stateShowDocument:
-		content, err := os.ReadFile(m.common.cfg.Path)
-		if err != nil {
-			log.Error("unable to read file", "file", m.common.cfg.Path, "error", err)
-			return func() tea.Msg { return errMsg{err} }
+		if m.pager.currentDocument.localPath == "" {
+			body := string(utils.RemoveFrontmatter([]byte(m.pager.currentDocument.Body)))
+			cmds = append(cmds, renderWithGlamour(m.pager, body))
+			break
+		}
+		// Load through the regular document path so the pager keeps a copy
+		// of the source around.
+		cmds = append(cmds, loadLocalMarkdown(&m.pager.currentDocument))
+		// Wiki links are resolved against the files next to the document.
+		if m.common.cfg.WikiLinks {
+			common := *m.common
+			common.cfg.Path = filepath.Dir(m.pager.currentDocument.localPath)
+			cmds = append(cmds, findLocalFiles(common))
 		}
-		body := string(utils.RemoveFrontmatter(content))
-		cmds = append(cmds, renderWithGlamour(m.pager, body))
 	}
 
 	return tea.Batch(cmds...)
@@ -210,10 +244,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.state == stateFetching {
+		return m.updateFetch(msg)
+	}
+
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// pass through all keys if the pager is showing a popup or prompt
+		if m.state == stateShowDocument && m.pager.isModal() && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.pager, cmd = m.pager.update(msg)
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "esc":
 			if m.state == stateShowDocument || m.stash.viewState == stashStateLoadingDocument {
@@ -274,8 +319,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case fetchedMarkdownMsg:
 		// We've loaded a markdown file's contents for rendering
-		m.pager.currentDocument = *msg
-		body := string(utils.RemoveFrontmatter([]byte(msg.Body)))
+		m.pager.setDocument(*msg)
+		body := string(utils.RemoveFrontmatter([]byte(m.pager.currentDocument.Body)))
 		cmds = append(cmds, renderWithGlamour(m.pager, body))
 
 	case contentRenderedMsg:
@@ -287,8 +332,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// the stash.
 		stashModel, cmd := m.stash.update(msg)
 		m.stash = stashModel
+		// Now that all pages are known, resolve the wiki links of the
+		// document being shown.
+		if m.common.cfg.WikiLinks && m.state == stateShowDocument {
+			body := string(utils.RemoveFrontmatter([]byte(m.pager.currentDocument.Body)))
+			cmd = tea.Batch(cmd, renderWithGlamour(m.pager, body))
+		}
 		return m, cmd
 
+	case remoteFilesMsg:
+		mds := make([]*markdown, len(msg))
+		for i, f := range msg {
+			mds[i] = &markdown{Note: f.Path, fetch: f.Fetch}
+			if m.stash.filterApplied() {
+				mds[i].buildFilterValue()
+			}
+		}
+		m.stash.addMarkdowns(mds...)
+		if m.stash.shouldUpdateFilter() {
+			cmds = append(cmds, filterMarkdowns(m.stash))
+		}
+		cmds = append(cmds, func() tea.Msg { return localFileSearchFinished{} })
+
 	case foundLocalFileMsg:
 		newMd := localFileToMarkdown(m.common.cwd, gitcha.SearchResult(msg))
 		m.stash.addMarkdowns(newMd)
@@ -330,6 +395,8 @@ func (m model) View() string {
 	}
 
 	switch m.state { //nolint:exhaustive
+	case stateFetching:
+		return m.fetchView()
 	case stateShowDocument:
 		return m.pager.View()
 	default:
@@ -338,14 +405,12 @@ func (m model) View() string {
 }
 
 func errorView(err error, fatal bool) string {
-	exitMsg := "press any key to "
+	exitMsg := tr("press any key to return")
 	if fatal {
-		exitMsg += "exit"
-	} else {
-		exitMsg += "return"
+		exitMsg = tr("press any key to exit")
 	}
 	s := fmt.Sprintf("%s\n\n%v\n\n%s",
-		errorTitleStyle.Render("ERROR"),
+		errorTitleStyle.Render(tr("ERROR")),
 		err,
 		subtleStyle.Render(exitMsg),
 	)
@@ -383,9 +448,12 @@ func findLocalFiles(m commonModel) tea.Cmd {
 		// Switch between FindFiles and FindAllFiles to bypass .gitignore rules
 		var ch chan gitcha.SearchResult
 		if m.cfg.ShowAllFiles {
-			ch, err = gitcha.FindAllFilesExcept(cwd, markdownExtensions, nil)
+			log.Debug("finding all files", "patterns", utils.DocumentPatterns())
+			ch, err = gitcha.FindAllFilesExcept(cwd, utils.DocumentPatterns(), nil)
 		} else {
-			ch, err = gitcha.FindFilesExcept(cwd, markdownExtensions, ignorePatterns(m))
+			ignored := append(ignorePatterns(m), m.cfg.Exclude...)
+			log.Debug("finding files", "patterns", utils.DocumentPatterns(), "ignored", ignored, "gitignore", true)
+			ch, err = gitcha.FindFilesExcept(cwd, utils.DocumentPatterns(), ignored)
 		}
 
 		if err != nil {
@@ -402,6 +470,10 @@ func findNextLocalFile(m model) tea.Cmd {
 		res, ok := <-m.localFileFinder
 
 		if ok {
+			if m.common.cfg.WikiLinks {
+				indexWikiPage(m.common.wikiPages, res.Path)
+			}
+			log.Debug("found file", "path", res.Path)
 			// Okay now find the next one
 			return foundLocalFileMsg(res)
 		}
@@ -418,6 +490,18 @@ func waitForStatusMessageTimeout(appCtx applicationContext, t *time.Timer) tea.C
 	}
 }
 
+// indexWikiPage adds a markdown file to the pages wiki links are resolved
+// against, under its file name and title.
+func indexWikiPage(pages *utils.WikiPages, path string) {
+	var title string
+	if data, err := os.ReadFile(path); err == nil {
+		if data, err := utils.ConvertToMarkdown(data, path); err == nil {
+			title = utils.DocumentTitle(utils.RemoveFrontmatter(data))
+		}
+	}
+	pages.Add(path, title)
+}
+
 // ETC
 
 // Convert a Gitcha result to an internal representation of a markdown
@@ -428,6 +512,7 @@ func localFileToMarkdown(cwd string, res gitcha.SearchResult) *markdown {
 		localPath: res.Path,
 		Note:      stripAbsolutePath(res.Path, cwd),
 		Modtime:   res.Info.ModTime(),
+		Size:      res.Info.Size(),
 	}
 }
 
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"sync"
 )
@@ -27,6 +31,19 @@ func init() {
 }
 
 func readmeURL(path string) (*source, error) {
+	if id, ok := gistID(path); ok {
+		return findGist(id)
+	}
+	if strings.HasPrefix(path, protoPkg) {
+		return findPackageREADME(path)
+	}
+	if repo, tag, ok := releaseSpec(path); ok {
+		return findReleases(repo, tag)
+	}
+	if repo, ref, file, ok := repoFileSpec(path); ok {
+		return findRepoFile(repo, ref, file)
+	}
+
 	switch {
 	case strings.HasPrefix(path, protoGithub):
 		if u := githubReadmeURL(path); u != nil {
@@ -40,7 +57,7 @@ func readmeURL(path string) (*source, error) {
 		return nil, nil
 	}
 
-	if !strings.HasPrefix(path, protoHTTPS) {
+	if !strings.Contains(path, "://") {
 		path = protoHTTPS + path
 	}
 	u, err := url.Parse(path)
@@ -50,9 +67,28 @@ func readmeURL(path string) (*source, error) {
 
 	switch {
 	case u.Hostname() == githubURL.Hostname():
+		if repo, number, ok := githubIssue(u); ok {
+			return findGitHubIssue(repo, number)
+		}
+		if repo, page, ok := githubWikiPage(u); ok {
+			return findGitHubWikiPage(repo, page)
+		}
+		if repo, tag, ok := githubReleasesPath(u); ok {
+			return findReleases(repo, tag)
+		}
 		return findGitHubREADME(u)
-	case u.Hostname() == gitlabURL.Hostname():
-		return findGitLabREADME(u)
+	case isGitLabHost(u.Hostname()):
+		if _, ok := gitlabProject(u); ok {
+			return findGitLabREADME(u)
+		}
+	case u.Hostname() == bitbucketHost:
+		if _, _, ok := bitbucketRepo(u); ok {
+			return findBitbucketREADME(u)
+		}
+	case isGiteaHost(u.Hostname()):
+		if _, _, ok := giteaRepo(u); ok {
+			return findGiteaREADME(u)
+		}
 	}
 
 	return nil, nil
@@ -69,18 +105,85 @@ func githubReadmeURL(path string) *url.URL {
 	return u.JoinPath(path)
 }
 
+// gitlabReadmeURL returns the URL of a project given like
+// gitlab://group/subgroup/project, or gitlab://gitlab.example.com/group/project
+// for self-hosted instances.
 func gitlabReadmeURL(path string) *url.URL {
 	path = strings.TrimPrefix(path, protoGitlab)
-	parts := strings.Split(path, "/")
-	if len(parts) != 2 {
-		// custom hostnames are not supported yet
+	u, _ := url.Parse(gitlabURL.String())
+	if host, project, ok := strings.Cut(path, "/"); ok && strings.Contains(host, ".") {
+		u.Host, path = host, project
+	}
+	if len(strings.Split(path, "/")) < 2 {
 		return nil
 	}
-	u, _ := url.Parse(gitlabURL.String())
 	return u.JoinPath(path)
 }
 
+// isRemoteArg returns whether a source argument refers to a remote document,
+// e.g. a URL or a GitHub repository, rather than a local file.
+func isRemoteArg(arg string) bool {
+	if arg == "-" {
+		return false
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return false
+	}
+	return isURL(arg) ||
+		strings.HasPrefix(arg, protoGithub) ||
+		strings.HasPrefix(arg, protoGitlab) ||
+		strings.HasPrefix(arg, protoGist) ||
+		strings.HasPrefix(arg, protoPkg) ||
+		strings.HasPrefix(arg, githubURL.Hostname()+"/") ||
+		strings.Contains(arg, "/") && isRepoHost(strings.Split(arg, "/")[0]) ||
+		isSCPArg(arg)
+}
+
+// isRemoteSpec returns whether a source argument names a package, or
+// releases or a file of a repository, which can't be anything else.
+func isRemoteSpec(arg string) bool {
+	_, _, isReleases := releaseSpec(arg)
+	_, _, _, isFile := repoFileSpec(arg)
+	return strings.HasPrefix(arg, protoPkg) || isRemoteArg(arg) && (isReleases || isFile)
+}
+
+// isRepoHost returns whether a host serves repositories whose READMEs are
+// found by their URL.
+func isRepoHost(host string) bool {
+	return isGitLabHost(host) || isGiteaHost(host) || host == bitbucketHost || host == gistHost
+}
+
 func isURL(path string) bool {
 	_, err := url.ParseRequestURI(path)
 	return err == nil && strings.Contains(path, "://")
 }
+
+// getJSON decodes the response of an API request.
+func getJSON(apiURL string, v any) error {
+	body, err := getBody(apiURL)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unable to parse json: %w", err)
+	}
+	return nil
+}
+
+// getBody returns the body of a successful response to a GET request.
+func getBody(u string) ([]byte, error) {
+	res, err := httpGet(u)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get url: %w", err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get %s: HTTP status %d", u, res.StatusCode)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read http response body: %w", err)
+	}
+	return body, nil
+}
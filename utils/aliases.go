@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+var (
+	// aliases are the shortcodes and abbreviations replaced in the text of
+	// documents, like ":company:" with "🏢 Acme" or "WIP" with "Work in
+	// progress", and aliasPattern matches them
+	aliases      map[string]string
+	aliasPattern *regexp.Regexp
+)
+
+// SetAliases sets the aliases replaced by ExpandAliases.
+func SetAliases(m map[string]string) {
+	aliases, aliasPattern = m, nil
+	if len(m) == 0 {
+		return
+	}
+	froms := make([]string, 0, len(m))
+	for from := range m {
+		froms = append(froms, from)
+	}
+	// Prefer the longest alias where they overlap, like :co: and :company:.
+	sort.Slice(froms, func(i, j int) bool {
+		if len(froms[i]) != len(froms[j]) {
+			return len(froms[i]) > len(froms[j])
+		}
+		return froms[i] < froms[j]
+	})
+	for i, from := range froms {
+		froms[i] = regexp.QuoteMeta(from)
+	}
+	aliasPattern = regexp.MustCompile(strings.Join(froms, "|"))
+}
+
+// ExpandAliases replaces the aliases in the text of a document. Aliases
+// starting or ending with a letter or digit only match whole words, so WIP
+// isn't replaced in WIPE. Code, HTML and links' destinations are left alone.
+func ExpandAliases(source []byte) []byte {
+	if aliasPattern == nil {
+		return source
+	}
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan, *ast.RawHTML, *ast.AutoLink:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			edits = append(edits, expandText(source, n.Segment.Start, n.Segment.Stop)...)
+		}
+		return ast.WalkContinue, nil
+	})
+	return applyEdits(source, edits)
+}
+
+func expandText(source []byte, start, stop int) []textEdit {
+	var edits []textEdit
+	text := source[start:stop]
+	for _, m := range aliasPattern.FindAllIndex(text, -1) {
+		from := string(text[m[0]:m[1]])
+		if !aliasBoundary(source, start+m[0], start+m[1], from) {
+			continue
+		}
+		edits = append(edits, textEdit{start + m[0], start + m[1], aliases[from]})
+	}
+	return edits
+}
+
+// aliasBoundary reports whether an alias found at source[start:stop] isn't
+// part of a longer word.
+func aliasBoundary(source []byte, start, stop int, from string) bool {
+	first, _ := utf8.DecodeRuneInString(from)
+	last, _ := utf8.DecodeLastRuneInString(from)
+	if isWordRune(first) && start > 0 {
+		if r, _ := utf8.DecodeLastRune(source[:start]); isWordRune(r) {
+			return false
+		}
+	}
+	if isWordRune(last) && stop < len(source) {
+		if r, _ := utf8.DecodeRune(source[stop:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_'
+}
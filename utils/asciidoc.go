@@ -0,0 +1,306 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	adocSection    = regexp.MustCompile(`^(={1,6})\s+(.+?)(?:\s+=+)?\s*$`)
+	adocAttribute  = regexp.MustCompile(`^:([\w-]+)(!?):\s*(.*)$`)
+	adocAttrRef    = regexp.MustCompile(`\{([\w-]+)\}`)
+	adocBlockAttrs = regexp.MustCompile(`^\[(.*)\]\s*$`)
+	adocBlockTitle = regexp.MustCompile(`^\.([^\s.].*)$`)
+	adocDelimiter  = regexp.MustCompile(`^(-{4,}|\.{4,}|={4,}|_{4,}|\*{4,}|/{4,}|\+{4,}|--)\s*$`)
+	adocAdmonition = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	adocBullet     = regexp.MustCompile(`^\s*(\*{1,5}|-)\s+(.*)$`)
+	adocNumbered   = regexp.MustCompile(`^\s*(\.{1,5})\s+(.*)$`)
+	adocTerm       = regexp.MustCompile(`^(\S.*?)(:{2,4}|;;)(?:\s+(.*))?$`)
+	adocImage      = regexp.MustCompile(`^image::([^\[]+)\[([^\]]*)\]\s*$`)
+	adocCols       = regexp.MustCompile(`cols\s*=\s*"?([^",\]]*(?:,[^",\]]*)*)"?`)
+	adocCode       = regexp.MustCompile("`\\+?([^`]+?)\\+?`")
+
+	adocInlineRules = []inlineRule{
+		{re: regexp.MustCompile(`image:([^\s\[:][^\s\[]*)\[([^\]]*)\]`), fn: func(m []string) string {
+			return "![" + adocPositional(m[2]) + "](" + m[1] + ")"
+		}},
+		{re: regexp.MustCompile(`(?:link:([^\s\[]+)|((?:https?|ftp|irc|mailto):[^\s\[]+))\[([^\]]*)\]`), fn: func(m []string) string {
+			target, text := m[1]+m[2], adocPositional(m[3])
+			if text == "" {
+				return "<" + target + ">"
+			}
+			return "[" + text + "](" + target + ")"
+		}},
+		{re: regexp.MustCompile(`<<([^,>]+)(?:,\s*([^>]+))?>>`), fn: func(m []string) string {
+			text := m[2]
+			if text == "" {
+				text = m[1]
+			}
+			return "[" + text + "](#" + m[1] + ")"
+		}},
+		{re: regexp.MustCompile(`xref:([^\s\[]+)\[([^\]]*)\]`), fn: func(m []string) string {
+			text := m[2]
+			if text == "" {
+				text = m[1]
+			}
+			return "[" + text + "](" + m[1] + ")"
+		}},
+		{re: regexp.MustCompile(`(^|[\s(\[{'"])\*([^\s*](?:[^*]*[^\s*])?)\*($|[\s)\]}.,;:!?'"-])`), repl: "$1**$2**$3"},
+		{re: regexp.MustCompile(`__([^_]+)__`), repl: "*$1*"},
+		{re: regexp.MustCompile(`(^|[\s(\[{'"])_([^\s_](?:[^_]*[^\s_])?)_($|[\s)\]}.,;:!?'"-])`), repl: "$1*$2*$3"},
+	}
+)
+
+// adocPositional returns the first positional attribute of an attribute
+// list, like the text of a link.
+func adocPositional(attrs string) string {
+	first, _, _ := strings.Cut(attrs, ",")
+	if strings.Contains(first, "=") {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(first), `"`)
+}
+
+// AsciiDocToMarkdown converts an AsciiDoc document to markdown. Sections,
+// inline markup, links, cross references, lists, tables, images, admonitions
+// and delimited blocks are converted, and attribute references substituted.
+func AsciiDocToMarkdown(source []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+	c := adocConverter{attributes: map[string]string{}}
+	return joinLines(c.convert(lines))
+}
+
+type adocConverter struct {
+	attributes map[string]string
+	// term is set after a description list term whose description is on
+	// the next line.
+	term bool
+}
+
+func (c *adocConverter) convert(lines []string) []string {
+	var (
+		out []string
+		// attrs are the attributes of the next block, like [source,go].
+		attrs string
+	)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if m := adocDelimiter.FindStringSubmatch(trimmed); m != nil {
+			var body []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != m[1]; i++ {
+				body = append(body, lines[i])
+			}
+			out = appendBlock(out, c.block(m[1], attrs, body))
+			attrs = ""
+			continue
+		}
+		if trimmed == "|===" {
+			var table []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "|==="; i++ {
+				table = append(table, lines[i])
+			}
+			out = appendBlock(out, c.table(attrs, table))
+			attrs = ""
+			continue
+		}
+		if strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if m := adocAttribute.FindStringSubmatch(line); m != nil {
+			if m[2] == "!" {
+				delete(c.attributes, m[1])
+			} else {
+				c.attributes[m[1]] = m[3]
+			}
+			continue
+		}
+		if m := adocBlockAttrs.FindStringSubmatch(trimmed); m != nil && !strings.HasPrefix(trimmed, "[[") {
+			attrs = m[1]
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[[") && strings.HasSuffix(trimmed, "]]") {
+			// block anchors
+			continue
+		}
+		if m := adocBlockTitle.FindStringSubmatch(line); m != nil {
+			out = append(out, "**"+c.inline(m[1])+"**", "")
+			continue
+		}
+		if m := adocSection.FindStringSubmatch(line); m != nil {
+			out = append(out, "", strings.Repeat("#", len(m[1]))+" "+c.inline(m[2]), "")
+			attrs = ""
+			continue
+		}
+		if m := adocImage.FindStringSubmatch(trimmed); m != nil {
+			out = appendBlock(out, []string{"![" + adocPositional(m[2]) + "](" + m[1] + ")"})
+			continue
+		}
+		if strings.HasPrefix(trimmed, "include::") || trimmed == "<<<" {
+			continue
+		}
+		if trimmed == "'''" {
+			out = append(out, "", "---", "")
+			continue
+		}
+		if trimmed == "+" {
+			// list continuations
+			out = append(out, "")
+			continue
+		}
+
+		// paragraphs
+		if title, ok := admonitionTitles[strings.ToLower(adocPositional(attrs))]; ok && trimmed != "" {
+			var para []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				para = append(para, c.inline(lines[i]))
+			}
+			out = appendBlock(out, admonition(title, para))
+			attrs = ""
+			continue
+		}
+		if m := adocAdmonition.FindStringSubmatch(line); m != nil {
+			para := []string{c.inline(m[2])}
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				para = append(para, c.inline(lines[i]))
+			}
+			out = appendBlock(out, admonition(admonitionTitles[strings.ToLower(m[1])], para))
+			continue
+		}
+		if trimmed == "" {
+			attrs = ""
+		} else if c.term {
+			out = append(out, ": "+c.inline(trimmed))
+			c.term = false
+			continue
+		}
+		out = append(out, c.line(line)...)
+	}
+	return out
+}
+
+// line converts a line of a paragraph or list.
+func (c *adocConverter) line(line string) []string {
+	if m := adocBullet.FindStringSubmatch(line); m != nil {
+		level := len(m[1])
+		if m[1] == "-" {
+			level = 1
+		}
+		return []string{strings.Repeat("  ", level-1) + "- " + c.inline(m[2])}
+	}
+	if m := adocNumbered.FindStringSubmatch(line); m != nil {
+		return []string{strings.Repeat("   ", len(m[1])-1) + "1. " + c.inline(m[2])}
+	}
+	if m := adocTerm.FindStringSubmatch(line); m != nil && !strings.Contains(m[1], "://") {
+		out := []string{"", c.inline(m[1])}
+		if m[3] != "" {
+			out = append(out, ": "+c.inline(m[3]))
+		} else {
+			c.term = true
+		}
+		return out
+	}
+	if strings.HasSuffix(line, " +") {
+		// hard line breaks
+		return []string{c.inline(strings.TrimSuffix(line, " +")) + `\`}
+	}
+	return []string{c.inline(line)}
+}
+
+// block converts a delimited block.
+func (c *adocConverter) block(delimiter, attrs string, body []string) []string {
+	style := strings.ToLower(adocPositional(attrs))
+	switch delimiter[0] {
+	case '-':
+		if delimiter == "--" {
+			break
+		}
+		var lang string
+		if style == "source" {
+			_, rest, _ := strings.Cut(attrs, ",")
+			lang, _, _ = strings.Cut(strings.TrimSpace(rest), ",")
+		}
+		return fencedCode(lang, body)
+	case '.':
+		return fencedCode("", body)
+	case '/':
+		return nil
+	case '+':
+		return body
+	case '_':
+		return blockquote(c.convert(body))
+	}
+	if title, ok := admonitionTitles[style]; ok {
+		return admonition(title, c.convert(body))
+	}
+	if style == "source" || style == "listing" || style == "literal" {
+		return c.block("----", attrs, body)
+	}
+	return c.convert(body)
+}
+
+// table converts the cells of a table, the first row of which is used as
+// its header.
+func (c *adocConverter) table(attrs string, lines []string) []string {
+	var (
+		cells []string
+		cols  int
+	)
+	if m := adocCols.FindStringSubmatch(attrs); m != nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(m[1])); err == nil {
+			cols = n
+		} else {
+			cols = strings.Count(m[1], ",") + 1
+		}
+	}
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if !strings.HasPrefix(l, "|") {
+			// continued cells
+			if l != "" && len(cells) > 0 {
+				cells[len(cells)-1] += " " + l
+			}
+			continue
+		}
+		row := strings.Split(l[1:], "|")
+		if cols == 0 {
+			cols = len(row)
+		}
+		for _, cell := range row {
+			cells = append(cells, strings.TrimSpace(cell))
+		}
+	}
+	if cols == 0 {
+		return nil
+	}
+
+	var out []string
+	for i := 0; i < len(cells); i += cols {
+		row := cells[i:min(i+cols, len(cells))]
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		for j, cell := range row {
+			row[j] = strings.ReplaceAll(c.inline(cell), "|", `\|`)
+		}
+		out = append(out, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			out = append(out, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return out
+}
+
+// inline substitutes attribute references and converts the inline markup of
+// a line.
+func (c *adocConverter) inline(line string) string {
+	line = adocAttrRef.ReplaceAllStringFunc(line, func(ref string) string {
+		if v, ok := c.attributes[ref[1:len(ref)-1]]; ok {
+			return v
+		}
+		return ref
+	})
+	return convertInline(line, adocCode, "`$1`", adocInlineRules)
+}
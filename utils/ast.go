@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// ASTNode is a node of a parsed markdown document, as exposed to other tools.
+// Positions are byte offsets into the source, and lines are one-based.
+type ASTNode struct {
+	Type       string         `json:"type"`
+	Block      bool           `json:"block"`
+	Start      *int           `json:"start,omitempty"`
+	Stop       *int           `json:"stop,omitempty"`
+	Line       int            `json:"line,omitempty"`
+	Text       string         `json:"text,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Children   []*ASTNode     `json:"children,omitempty"`
+}
+
+// MarkdownAST parses a markdown document, like glow does for rendering, and
+// returns its syntax tree. Front matter is skipped, but positions are
+// relative to the whole document.
+func MarkdownAST(content []byte) *ASTNode {
+	source := RemoveFrontmatter(content)
+	offset := len(content) - len(source)
+	return astNode(ParseMarkdown(source), source, content, offset)
+}
+
+func astNode(n ast.Node, source, content []byte, offset int) *ASTNode {
+	node := &ASTNode{
+		Type:       n.Kind().String(),
+		Block:      n.Type() != ast.TypeInline,
+		Text:       nodeRawText(n, source),
+		Attributes: nodeAttributes(n, source),
+	}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		node.Children = append(node.Children, astNode(c, source, content, offset))
+	}
+
+	if start, stop, ok := nodeRange(n, node.Children, offset); ok {
+		node.Start, node.Stop = &start, &stop
+		node.Line = bytes.Count(content[:start], []byte("\n")) + 1
+	}
+	return node
+}
+
+// nodeRange returns the range of a node in the document: the lines of a
+// block, the segment of text, or else the range its children span.
+func nodeRange(n ast.Node, children []*ASTNode, offset int) (start, stop int, ok bool) {
+	switch {
+	case n.Kind() == ast.KindText:
+		t := n.(*ast.Text)
+		return t.Segment.Start + offset, t.Segment.Stop + offset, true
+	case n.Type() != ast.TypeInline && n.Lines().Len() > 0:
+		lines := n.Lines()
+		return lines.At(0).Start + offset, lines.At(lines.Len()-1).Stop + offset, true
+	case n.Kind() == ast.KindRawHTML:
+		segs := n.(*ast.RawHTML).Segments
+		if segs.Len() > 0 {
+			return segs.At(0).Start + offset, segs.At(segs.Len()-1).Stop + offset, true
+		}
+	}
+
+	for _, c := range children {
+		if c.Start == nil {
+			continue
+		}
+		if !ok || *c.Start < start {
+			start = *c.Start
+		}
+		if !ok || *c.Stop > stop {
+			stop = *c.Stop
+		}
+		ok = true
+	}
+	return start, stop, ok
+}
+
+// nodeRawText returns the source text of leaf nodes: text, code and HTML.
+func nodeRawText(n ast.Node, source []byte) string {
+	switch n := n.(type) {
+	case *ast.Text:
+		return string(n.Segment.Value(source))
+	case *ast.String:
+		return string(n.Value)
+	case *ast.RawHTML:
+		var b bytes.Buffer
+		for i := range n.Segments.Len() {
+			seg := n.Segments.At(i)
+			b.Write(seg.Value(source))
+		}
+		return b.String()
+	case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock:
+		return codeBlockText(n, source)
+	}
+	return ""
+}
+
+// nodeAttributes returns the properties of a node that aren't children, like
+// the level of a heading or the destination of a link.
+func nodeAttributes(n ast.Node, source []byte) map[string]any {
+	attrs := map[string]any{}
+	switch n := n.(type) {
+	case *ast.Heading:
+		attrs["level"] = n.Level
+		if id, ok := n.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				attrs["id"] = string(b)
+			}
+		}
+	case *ast.Link:
+		attrs["destination"] = string(n.Destination)
+		if len(n.Title) > 0 {
+			attrs["title"] = string(n.Title)
+		}
+	case *ast.Image:
+		attrs["destination"] = string(n.Destination)
+		if len(n.Title) > 0 {
+			attrs["title"] = string(n.Title)
+		}
+	case *ast.AutoLink:
+		attrs["url"] = string(n.URL(source))
+		attrs["email"] = n.AutoLinkType == ast.AutoLinkEmail
+	case *ast.FencedCodeBlock:
+		if lang := n.Language(source); len(lang) > 0 {
+			attrs["language"] = string(lang)
+		}
+	case *ast.List:
+		attrs["ordered"] = n.IsOrdered()
+		attrs["tight"] = n.IsTight
+		if n.IsOrdered() {
+			attrs["start"] = n.Start
+		}
+		attrs["marker"] = string(n.Marker)
+	case *ast.Emphasis:
+		attrs["level"] = n.Level
+	case *ast.Text:
+		if n.SoftLineBreak() {
+			attrs["softLineBreak"] = true
+		}
+		if n.HardLineBreak() {
+			attrs["hardLineBreak"] = true
+		}
+	case *east.TaskCheckBox:
+		attrs["checked"] = n.IsChecked
+	case *east.Table:
+		aligns := make([]string, len(n.Alignments))
+		for i, a := range n.Alignments {
+			aligns[i] = a.String()
+		}
+		attrs["alignments"] = aligns
+	case *east.TableCell:
+		attrs["alignment"] = n.Alignment.String()
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
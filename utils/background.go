@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// backgroundTimeout is how long the terminal has to answer the query of its
+// background color, before falling back to COLORFGBG.
+const backgroundTimeout = 500 * time.Millisecond
+
+var darkBackground = sync.OnceValue(detectDarkBackground)
+
+// HasDarkBackground reports whether the terminal has a dark background, for
+// the auto style. It asks the terminal for its background color (OSC 11),
+// even when stdout is piped, falling back to COLORFGBG and then to dark. The
+// answer is shared with lipgloss, so its adaptive colors agree.
+func HasDarkBackground() bool {
+	return darkBackground()
+}
+
+func detectDarkBackground() bool {
+	var dark bool
+	if answer, err := queryBackground(backgroundTimeout); err == nil && answer != "" {
+		dark = oscColorDark(answer)
+	} else {
+		dark = colorFGBGDark(os.Getenv("COLORFGBG"))
+	}
+	lipgloss.SetHasDarkBackground(dark)
+	return dark
+}
+
+// oscColorDark reports whether a color answered to an OSC 10 or 11 query,
+// like "rgb:ffff/ffff/dddd", is dark.
+func oscColorDark(answer string) bool {
+	rgb, ok := strings.CutPrefix(answer, "rgb:")
+	if !ok {
+		return true
+	}
+	channels := strings.Split(rgb, "/")
+	if len(channels) != 3 { //nolint:mnd
+		return true
+	}
+	// Channels have 1 to 4 hex digits, scaled to 0-1.
+	var c [3]float64
+	for i, ch := range channels {
+		n, err := strconv.ParseUint(ch, 16, 16)
+		if err != nil || ch == "" || len(ch) > 4 {
+			return true
+		}
+		c[i] = float64(n) / float64(uint64(1)<<(4*len(ch))-1)
+	}
+	// Relative luminance, as colors like pure blue are dark, but not black.
+	return 0.2126*c[0]+0.7152*c[1]+0.0722*c[2] < 0.5 //nolint:mnd
+}
+
+// colorFGBGDark reports whether COLORFGBG, like "15;0", has a dark
+// background, which is any of the ANSI colors but white and the bright ones
+// but bright black. Without it backgrounds are assumed to be dark.
+func colorFGBGDark(colorFGBG string) bool {
+	i := strings.LastIndex(colorFGBG, ";")
+	if i < 0 {
+		return true
+	}
+	// rxvt adds a default color between them, like "0;default;15".
+	n, err := strconv.Atoi(colorFGBG[i+1:])
+	if err != nil {
+		return true
+	}
+	return n < 7 || n == 8 //nolint:mnd
+}
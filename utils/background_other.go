@@ -0,0 +1,14 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// queryBackground isn't supported outside of Unix, where the background is
+// detected from COLORFGBG.
+func queryBackground(time.Duration) (string, error) {
+	return "", errors.New("unsupported")
+}
@@ -0,0 +1,49 @@
+package utils
+
+import "testing"
+
+func TestOSCColorDark(t *testing.T) {
+	for _, tc := range []struct {
+		answer string
+		want   bool
+	}{
+		{"rgb:ffff/ffff/ffff", false},
+		{"rgb:0000/0000/0000", true},
+		{"rgb:fdfd/f6f6/e3e3", false},
+		{"rgb:1e1e/1e1e/2e2e", true},
+		{"rgb:ff/ff/ff", false},
+		{"rgb:28/2c/34", true},
+		{"rgb:f/f/f", false},
+		{"rgb:0000/0000/ffff", true},
+		{"rgb:0000/ffff/0000", false},
+		{"rgba:ffff/ffff/ffff/ffff", true},
+		{"rgb:ffff/ffff", true},
+		{"rgb:fffff/ffff/ffff", true},
+		{"rgb:zz/ff/ff", true},
+		{"", true},
+	} {
+		if got := oscColorDark(tc.answer); got != tc.want {
+			t.Errorf("oscColorDark(%q): expected %v, got %v", tc.answer, tc.want, got)
+		}
+	}
+}
+
+func TestColorFGBGDark(t *testing.T) {
+	for _, tc := range []struct {
+		colorFGBG string
+		want      bool
+	}{
+		{"15;0", true},
+		{"0;15", false},
+		{"0;default;15", false},
+		{"15;default;0", true},
+		{"0;7", false},
+		{"15;8", true},
+		{"0;default", true},
+		{"", true},
+	} {
+		if got := colorFGBGDark(tc.colorFGBG); got != tc.want {
+			t.Errorf("colorFGBGDark(%q): expected %v, got %v", tc.colorFGBG, tc.want, got)
+		}
+	}
+}
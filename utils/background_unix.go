@@ -0,0 +1,105 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// queryBackground asks the terminal for its background color, returning its
+// answer, like "rgb:ffff/ffff/dddd", or nothing if it doesn't support the
+// query. The query is followed by one for the terminal's attributes (DA1),
+// which every terminal answers, so those not answering the first one aren't
+// waited for until the timeout.
+func queryBackground(timeout time.Duration) (string, error) {
+	if strings.HasPrefix(os.Getenv("TERM"), "dumb") {
+		return "", errors.New("dumb terminal")
+	}
+	// The terminal, rather than stdout, which may be piped.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	defer tty.Close()   //nolint:errcheck
+	fd := int(tty.Fd()) //nolint:gosec
+
+	// Reading from the terminal in the background would stop glow.
+	pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil || pgrp != unix.Getpgrp() {
+		return "", errors.New("not in the foreground")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	defer term.Restore(fd, state) //nolint:errcheck
+
+	if _, err := tty.WriteString("\x1b]11;?\x1b\\\x1b[c"); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	var answer []byte
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 64) //nolint:mnd
+	for !daAnswered(answer) {
+		if err := waitForInput(fd, time.Until(deadline)); err != nil {
+			return "", err
+		}
+		n, err := tty.Read(buf)
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		answer = append(answer, buf[:n]...)
+	}
+	return oscAnswer(string(answer), "11"), nil
+}
+
+// daAnswered reports whether the terminal's answers end with the one to the
+// attributes query, like "\x1b[?62;22c".
+func daAnswered(answer []byte) bool {
+	i := strings.LastIndex(string(answer), "\x1b[?")
+	return i >= 0 && strings.Contains(string(answer[i:]), "c")
+}
+
+// oscAnswer returns the value of an OSC answer, like "\x1b]11;rgb:0/0/0\a",
+// among the terminal's answers.
+func oscAnswer(answers, code string) string {
+	_, value, ok := strings.Cut(answers, "\x1b]"+code+";")
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexAny(value, "\a\x1b"); i >= 0 {
+		return value[:i]
+	}
+	return ""
+}
+
+// waitForInput waits until the terminal has input to read.
+func waitForInput(fd int, timeout time.Duration) error {
+	for {
+		if timeout <= 0 {
+			return errors.New("timeout")
+		}
+		var fds unix.FdSet
+		fds.Set(fd)
+		tv := unix.NsecToTimeval(int64(timeout))
+		start := time.Now()
+		n, err := unix.Select(fd+1, &fds, nil, nil, &tv)
+		if errors.Is(err, unix.EINTR) {
+			timeout -= time.Since(start)
+			continue
+		}
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		if n == 0 {
+			return errors.New("timeout")
+		}
+		return nil
+	}
+}
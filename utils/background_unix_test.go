@@ -0,0 +1,40 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package utils
+
+import "testing"
+
+func TestOSCAnswer(t *testing.T) {
+	for _, tc := range []struct {
+		name, answers, want string
+	}{
+		{"BEL", "\x1b]11;rgb:ffff/ffff/ffff\a\x1b[?62;22c", "rgb:ffff/ffff/ffff"},
+		{"ST", "\x1b]11;rgb:1e1e/1e1e/2e2e\x1b\\\x1b[?64;1;9c", "rgb:1e1e/1e1e/2e2e"},
+		{"two digits", "\x1b]11;rgb:28/2c/34\a\x1b[?1;2c", "rgb:28/2c/34"},
+		{"foreground first", "\x1b]10;rgb:0/0/0\a\x1b]11;rgb:f/f/f\a", "rgb:f/f/f"},
+		{"DA1 only", "\x1b[?62;22c", ""},
+		{"unterminated", "\x1b]11;rgb:ffff/ffff", ""},
+	} {
+		if got := oscAnswer(tc.answers, "11"); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestDAAnswered(t *testing.T) {
+	for _, tc := range []struct {
+		name, answers string
+		want          bool
+	}{
+		{"DA1 only", "\x1b[?62;22c", true},
+		{"after OSC 11", "\x1b]11;rgb:ffff/ffff/ffff\a\x1b[?64;1;9c", true},
+		{"after OSC 11 with ST", "\x1b]11;rgb:ffff/ffff/ffff\x1b\\\x1b[?1;2c", true},
+		{"OSC 11 only", "\x1b]11;rgb:ffff/ffff/ffff\a", false},
+		{"partial DA1", "\x1b]11;rgb:0/0/0\a\x1b[?62;2", false},
+		{"nothing", "", false},
+	} {
+		if got := daAnswered([]byte(tc.answers)); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
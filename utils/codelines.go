@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	xansi "github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// CodeLineNumbers enables a line-number gutter in rendered code blocks.
+var CodeLineNumbers bool
+
+// lineNumbersTheme is the chroma style used for code blocks of styles that
+// don't highlight code, so that glamour still renders them through our
+// formatter.
+const lineNumbersTheme = "bw"
+
+var lineNumbersMu sync.Mutex
+
+// WithCodeLineNumbers returns a glamour option that renders code blocks with a
+// dim line-number gutter. Code lines are wrapped to fit the word wrap width
+// before numbering, so continuation lines get an empty gutter and stay
+// aligned with the code above them.
+func WithCodeLineNumbers(style string, isCode bool, wordWrap int, profile termenv.Profile) glamour.TermRendererOption {
+	width := 0
+	if wordWrap > 0 {
+		cfg, err := GlamourStyleConfig(style)
+		if err != nil {
+			return func(*glamour.TermRenderer) error { return err }
+		}
+		width = wordWrap - codeBlockIndent(cfg, isCode)
+	}
+
+	color := profile != termenv.Ascii
+	name := fmt.Sprintf("glow-line-numbers-%d-%t", max(0, width), color)
+
+	lineNumbersMu.Lock()
+	defer lineNumbersMu.Unlock()
+	if _, ok := formatters.Registry[name]; !ok {
+		formatters.Register(name, &lineNumbersFormatter{
+			width:   width,
+			profile: profile,
+			color:   color,
+		})
+	}
+	return glamour.WithChromaFormatter(name)
+}
+
+// codeBlockIndent returns the number of columns in front of code in a code
+// block, at the top level of a document.
+func codeBlockIndent(cfg ansi.StyleConfig, isCode bool) int {
+	var n uint
+	if cfg.Document.Margin != nil {
+		n += 2 * *cfg.Document.Margin
+	}
+	if cfg.Document.Indent != nil {
+		n += *cfg.Document.Indent
+	}
+	if cfg.CodeBlock.Indent != nil {
+		n += *cfg.CodeBlock.Indent
+	}
+	if cfg.CodeBlock.Margin != nil && !isCode {
+		n += *cfg.CodeBlock.Margin
+	}
+	return int(n) //nolint:gosec
+}
+
+// lineNumbersFormatter is a chroma formatter prefixing every line of code
+// with its number.
+type lineNumbersFormatter struct {
+	// Width of the code including the gutter, or 0 to not wrap lines.
+	width   int
+	profile termenv.Profile
+	color   bool
+}
+
+func (f *lineNumbersFormatter) Format(w io.Writer, style *chroma.Style, it chroma.Iterator) error {
+	base := formatters.NoOp
+	if f.color {
+		base = formatters.TTY256
+	}
+
+	lines := chroma.SplitTokensIntoLines(it.Tokens())
+	digits := len(strconv.Itoa(len(lines)))
+	blank := strings.Repeat(" ", digits+2)
+
+	for i, tokens := range lines {
+		// Format the line without its newline, so it can be wrapped.
+		var newline bool
+		if n := len(tokens); n > 0 && strings.HasSuffix(tokens[n-1].Value, "\n") {
+			tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "\n")
+			newline = true
+		}
+		var b bytes.Buffer
+		if err := base.Format(&b, style, chroma.Literator(tokens...)); err != nil {
+			return fmt.Errorf("unable to format code: %w", err)
+		}
+
+		line := b.String()
+		if f.width > len(blank) {
+			line = xansi.Hardwrap(line, f.width-len(blank), true)
+		}
+		gutter := f.profile.String(fmt.Sprintf("%*d  ", digits, i+1)).Faint().String()
+		line = gutter + strings.ReplaceAll(line, "\n", "\n"+blank)
+		if newline {
+			line += "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return fmt.Errorf("unable to write code: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Converters are commands converting documents to markdown by file
+// extension, like "rst": "pandoc -f rst -t gfm". The document is written to
+// their stdin and the markdown read from their stdout. They take precedence
+// over the built-in converters.
+var Converters map[string]string
+
+// builtinConverters convert other markup formats to markdown, by file
+// extension.
+var builtinConverters = map[string]func(source []byte, filename string) []byte{
+	"rst":      ignoringName(RSTToMarkdown),
+	"rest":     ignoringName(RSTToMarkdown),
+	"org":      ignoringName(OrgToMarkdown),
+	"adoc":     ignoringName(AsciiDocToMarkdown),
+	"asciidoc": ignoringName(AsciiDocToMarkdown),
+	"html":     HTMLToMarkdown,
+	"htm":      HTMLToMarkdown,
+	"xhtml":    HTMLToMarkdown,
+	"eml":      ignoringName(EmailToMarkdown),
+	"gmi":      GemtextToMarkdown,
+	"gemini":   GemtextToMarkdown,
+	"rss":      FeedToMarkdown,
+	"atom":     FeedToMarkdown,
+}
+
+// unlistedFormats are converted when opened, but not listed along with the
+// documents in directories, where they're mostly generated.
+var unlistedFormats = map[string]bool{"html": true, "htm": true, "xhtml": true, "rss": true, "atom": true}
+
+func ignoringName(convert func([]byte) []byte) func([]byte, string) []byte {
+	return func(source []byte, _ string) []byte { return convert(source) }
+}
+
+// converterExt returns the extension of a file name, as converters are keyed
+// by.
+func converterExt(filename string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// IsConvertibleFile returns whether a file can be converted to markdown,
+// with a built-in or a configured converter.
+func IsConvertibleFile(filename string) bool {
+	ext := converterExt(filename)
+	if ext == "" {
+		return false
+	}
+	_, builtin := builtinConverters[ext]
+	_, configured := Converters[ext]
+	return builtin || configured
+}
+
+// DocumentPatterns returns the file patterns of markdown documents and of
+// the documents that can be converted to markdown.
+func DocumentPatterns() []string {
+	patterns := make([]string, 0, len(markdownExtensions)+len(builtinConverters)+len(Converters))
+	for _, ext := range markdownExtensions {
+		patterns = append(patterns, "*"+ext)
+	}
+	var exts []string
+	for ext := range builtinConverters {
+		if !unlistedFormats[ext] {
+			exts = append(exts, ext)
+		}
+	}
+	for ext := range Converters {
+		if _, ok := builtinConverters[ext]; !ok {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		patterns = append(patterns, "*."+ext)
+	}
+	return patterns
+}
+
+// ConvertToMarkdown converts a document to markdown based on its file
+// extension. Documents that need no conversion are returned as-is.
+func ConvertToMarkdown(source []byte, filename string) ([]byte, error) {
+	return ConvertFormat(source, converterExt(filename), filename)
+}
+
+// ConvertFormat converts a document of the format with the given file
+// extension to markdown. The links of web pages are resolved against their
+// URL, given as filename. Documents that need no conversion are returned
+// as-is.
+func ConvertFormat(source []byte, ext, filename string) ([]byte, error) {
+	if ext == "" {
+		return source, nil
+	}
+	if command, ok := Converters[ext]; ok {
+		return runConverter(command, source)
+	}
+	if convert, ok := builtinConverters[ext]; ok {
+		return convert(source, filename), nil
+	}
+	return source, nil
+}
+
+// runConverter converts a document with an external command.
+func runConverter(command string, source []byte) ([]byte, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty converter command")
+	}
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(args[0], args[1:]...) //nolint:gosec
+	c.Stdin = bytes.NewReader(source)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("unable to convert document with %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("unable to convert document with %s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// inlineRule replaces inline markup with its markdown equivalent, with
+// either a template or a function of the submatches.
+type inlineRule struct {
+	re   *regexp.Regexp
+	repl string
+	fn   func(m []string) string
+}
+
+// convertInline applies inline rules to a line of text. The code spans
+// matched by code are replaced with the codeRepl template, and left alone by
+// the other rules.
+func convertInline(line string, code *regexp.Regexp, codeRepl string, rules []inlineRule) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, loc := range code.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(applyInlineRules(line[last:loc[0]], rules))
+		b.Write(code.ExpandString(nil, codeRepl, line, loc))
+		last = loc[1]
+	}
+	b.WriteString(applyInlineRules(line[last:], rules))
+	return b.String()
+}
+
+func applyInlineRules(s string, rules []inlineRule) string {
+	for _, r := range rules {
+		if r.fn == nil {
+			s = r.re.ReplaceAllString(s, r.repl)
+			continue
+		}
+		s = r.re.ReplaceAllStringFunc(s, func(m string) string {
+			return r.fn(r.re.FindStringSubmatch(m))
+		})
+	}
+	return s
+}
+
+// fencedCode returns lines as a fenced code block. The fence is made longer
+// than any backtick run in the code.
+func fencedCode(lang string, lines []string) []string {
+	fence := "```"
+	for _, l := range lines {
+		for strings.Contains(l, fence) {
+			fence += "`"
+		}
+	}
+	out := append([]string{fence + lang}, lines...)
+	return append(out, fence)
+}
+
+// appendBlock appends the lines of a block, separated from the surrounding
+// ones by blank lines.
+func appendBlock(out, block []string) []string {
+	out = append(out, "")
+	out = append(out, block...)
+	return append(out, "")
+}
+
+// joinLines joins converted lines into a document, without the runs of
+// blank lines the conversion leaves outside of code blocks.
+func joinLines(lines []string) []byte {
+	var (
+		out   []string
+		fence string
+	)
+	for _, l := range trimBlank(lines) {
+		if t := strings.TrimSpace(l); strings.HasPrefix(t, "```") {
+			switch {
+			case fence == "":
+				fence = t[:len(t)-len(strings.TrimLeft(t, "`"))]
+			case strings.TrimRight(t, "`") == "" && len(t) >= len(fence):
+				fence = ""
+			}
+		}
+		if fence == "" && strings.TrimSpace(l) == "" && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+			continue
+		}
+		out = append(out, l)
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+// dedentLines removes the indentation all non-blank lines share.
+func dedentLines(lines []string) []string {
+	return strings.Split(strings.TrimSuffix(dedent(strings.Join(lines, "\n")+"\n"), "\n"), "\n")
+}
+
+// trimBlank removes leading and trailing blank lines.
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// indentWidth returns the width of the indentation of a line, with tabs
+// counted as 8 columns as reStructuredText and AsciiDoc do.
+func indentWidth(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 8 - n%8
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// blockquote prefixes lines with a blockquote marker.
+func blockquote(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimRight("> "+l, " ")
+	}
+	return out
+}
+
+// admonitionTitles are the titles of the admonitions converted to
+// blockquotes, by lower case name.
+var admonitionTitles = map[string]string{
+	"note":      "Note",
+	"tip":       "Tip",
+	"hint":      "Hint",
+	"important": "Important",
+	"warning":   "Warning",
+	"caution":   "Caution",
+	"danger":    "Danger",
+	"attention": "Attention",
+	"error":     "Error",
+	"seealso":   "See also",
+}
+
+// admonition returns markdown lines as a blockquote starting with a title.
+func admonition(title string, body []string) []string {
+	body = trimBlank(body)
+	if len(body) == 0 {
+		return blockquote([]string{"**" + title + "**"})
+	}
+	body = append([]string{}, body...)
+	body[0] = "**" + title + ":** " + body[0]
+	return blockquote(body)
+}
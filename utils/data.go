@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// PrettyData is whether JSON, YAML and TOML files are pretty-printed before
+// they're rendered.
+var PrettyData bool
+
+// dataLanguages are the languages of structured data files, by extension.
+var dataLanguages = map[string]string{
+	"json":    "json",
+	"geojson": "json",
+	"yaml":    "yaml",
+	"yml":     "yaml",
+	"toml":    "toml",
+}
+
+// DataLanguage returns the language of a structured data file, like "json",
+// or an empty string if it's not one.
+func DataLanguage(filename string) string {
+	return dataLanguages[converterExt(filename)]
+}
+
+// IsJSON returns whether content is a JSON object or array, like documents
+// piped from web APIs.
+func IsJSON(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	return (bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("["))) && json.Valid(trimmed)
+}
+
+// CodeBlock wraps a source file in a code block highlighted as the language
+// of its file extension. Structured data files are pretty-printed if
+// PrettyData is set, and left as they are if they can't be parsed.
+func CodeBlock(s, filename string) string {
+	if !strings.HasSuffix(s, "\n") {
+		// The closing fence needs a line of its own.
+		s += "\n"
+	}
+	lang := DataLanguage(filename)
+	if lang == "" {
+		return WrapCodeBlock(s, filepath.Ext(filename))
+	}
+	if PrettyData {
+		if b, err := PrettyPrint([]byte(s), lang); err == nil {
+			s = string(b)
+		}
+	}
+	return WrapCodeBlock(s, lang)
+}
+
+// PrettyPrint reformats JSON, YAML or TOML with consistent indentation. YAML
+// keeps its comments and key order; TOML is re-encoded with sorted keys and
+// without its comments.
+func PrettyPrint(source []byte, language string) ([]byte, error) {
+	var b bytes.Buffer
+	switch language {
+	case "json":
+		if err := json.Indent(&b, bytes.TrimSpace(source), "", "  "); err != nil {
+			return nil, fmt.Errorf("unable to parse JSON: %w", err)
+		}
+		b.WriteByte('\n')
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(source))
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		for {
+			var doc yaml.Node
+			if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("unable to parse YAML: %w", err)
+			}
+			if err := enc.Encode(&doc); err != nil {
+				return nil, fmt.Errorf("unable to encode YAML: %w", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			return nil, fmt.Errorf("unable to encode YAML: %w", err)
+		}
+	case "toml":
+		var doc map[string]any
+		if err := toml.Unmarshal(source, &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse TOML: %w", err)
+		}
+		enc := toml.NewEncoder(&b)
+		enc.SetIndentTables(true)
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("unable to encode TOML: %w", err)
+		}
+	default:
+		return source, nil
+	}
+	return b.Bytes(), nil
+}
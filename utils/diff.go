@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// DiffOp is the kind of change of a block.
+type DiffOp int
+
+// Kinds of changes.
+const (
+	DiffEqual DiffOp = iota
+	DiffDelete
+	DiffInsert
+)
+
+// BlockDiff is a block of markdown and how it changed.
+type BlockDiff struct {
+	Op   DiffOp
+	Text string
+}
+
+// MarkdownBlocks splits a markdown document into its blocks: paragraphs,
+// headings, code blocks, tables and the items of top-level lists. Blocks are
+// separated by blank lines, except within code blocks and list items.
+func MarkdownBlocks(source []byte) []string {
+	lines := strings.SplitAfter(string(source), "\n")
+	// Blank lines in code blocks and list items don't end a block.
+	joined := make([]bool, len(lines))
+	starts := map[int]bool{}
+
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			if first, last, ok := blockLines(n, source); ok {
+				if _, fenced := n.(*ast.FencedCodeBlock); fenced {
+					first, last = max(0, first-1), min(len(lines)-1, last+1)
+				}
+				for i := first; i <= last; i++ {
+					joined[i] = true
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.List:
+			if n.Parent().Kind() != ast.KindDocument {
+				return ast.WalkSkipChildren, nil
+			}
+			for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+				if first, last, ok := blockLines(item, source); ok {
+					starts[first] = true
+					for i := first; i <= last; i++ {
+						joined[i] = true
+					}
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	var (
+		blocks []string
+		block  strings.Builder
+	)
+	flush := func() {
+		if block.Len() > 0 {
+			blocks = append(blocks, block.String())
+			block.Reset()
+		}
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" && !joined[i] {
+			flush()
+			continue
+		}
+		if starts[i] {
+			flush()
+		}
+		block.WriteString(line)
+	}
+	flush()
+	return blocks
+}
+
+// DiffBlocks returns the changes between two lists of blocks, based on their
+// longest common subsequence. Blocks are compared ignoring leading and
+// trailing whitespace.
+func DiffBlocks(a, b []string) []BlockDiff {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if sameBlock(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []BlockDiff
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case sameBlock(a[i], b[j]):
+			diff = append(diff, BlockDiff{DiffEqual, b[j]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, BlockDiff{DiffDelete, a[i]})
+			i++
+		default:
+			diff = append(diff, BlockDiff{DiffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, BlockDiff{DiffDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, BlockDiff{DiffInsert, b[j]})
+	}
+	return diff
+}
+
+func sameBlock(a, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
@@ -0,0 +1,570 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/yuin/goldmark/ast"
+)
+
+// Graphs beyond these limits are shown as source, since their layout would
+// hardly be legible in a terminal.
+const (
+	maxGraphNodes = 20
+	maxGraphWidth = 72
+)
+
+var errUnsupportedDOT = errors.New("unsupported DOT statement")
+
+// dotGraph is a graph parsed from the DOT language.
+type dotGraph struct {
+	directed bool
+	// Node IDs in order of appearance.
+	nodes  []string
+	labels map[string]string
+	edges  [][2]string
+}
+
+type dotToken struct {
+	text   string
+	quoted bool
+}
+
+var dotIDRe = regexp.MustCompile(`^[\w.]+$`)
+
+var dotTokenRe = regexp.MustCompile(`(?s)^(\s+|//[^\n]*|#[^\n]*|/\*.*?\*/|"(?:[^"\\]|\\.)*"|->|--|[{}\[\]=;,:<>]|[\w.]+)`)
+
+// lexDOT splits DOT source into tokens, dropping whitespace and comments.
+func lexDOT(src string) ([]dotToken, error) {
+	var tokens []dotToken
+	for len(src) > 0 {
+		m := dotTokenRe.FindString(src)
+		if m == "" {
+			return nil, fmt.Errorf("unexpected character %q", src[0])
+		}
+		src = src[len(m):]
+
+		switch {
+		case strings.TrimSpace(m) == "", strings.HasPrefix(m, "//"),
+			strings.HasPrefix(m, "#"), strings.HasPrefix(m, "/*"):
+			continue
+		case strings.HasPrefix(m, `"`):
+			s := strings.NewReplacer(`\"`, `"`, `\n`, " ", `\l`, " ", `\r`, " ", `\\`, `\`).Replace(m[1 : len(m)-1])
+			tokens = append(tokens, dotToken{text: s, quoted: true})
+		default:
+			tokens = append(tokens, dotToken{text: m})
+		}
+	}
+	return tokens, nil
+}
+
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  *dotGraph
+}
+
+func (p *dotParser) peek() dotToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return dotToken{}
+}
+
+func (p *dotParser) next() dotToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// isKeyword reports whether the next token is the given unquoted keyword or
+// punctuation.
+func (p *dotParser) isKeyword(s string) bool {
+	t := p.peek()
+	return !t.quoted && strings.EqualFold(t.text, s)
+}
+
+func (p *dotParser) id() (string, error) {
+	t := p.next()
+	if t.quoted || dotIDRe.MatchString(t.text) {
+		return t.text, nil
+	}
+	if t.text == "{" || t.text == "<" {
+		return "", errUnsupportedDOT
+	}
+	return "", fmt.Errorf("expected an ID, got %q", t.text)
+}
+
+// parseDOT parses the subset of DOT made of node and edge statements.
+// Subgraphs, ports and HTML labels aren't supported.
+func parseDOT(src string) (*dotGraph, error) {
+	tokens, err := lexDOT(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{tokens: tokens, graph: &dotGraph{labels: map[string]string{}}}
+
+	if p.isKeyword("strict") {
+		p.next()
+	}
+	switch kind := strings.ToLower(p.next().text); kind {
+	case "digraph":
+		p.graph.directed = true
+	case "graph":
+	default:
+		return nil, fmt.Errorf("expected graph or digraph, got %q", kind)
+	}
+	if !p.isKeyword("{") {
+		p.next()
+	}
+	if !p.isKeyword("{") {
+		return nil, errors.New("expected {")
+	}
+	p.next()
+
+	for !p.isKeyword("}") {
+		if p.pos >= len(p.tokens) {
+			return nil, errors.New("unexpected end of graph")
+		}
+		if err := p.statement(); err != nil {
+			return nil, err
+		}
+	}
+	return p.graph, nil
+}
+
+func (p *dotParser) statement() error {
+	switch {
+	case p.isKeyword(";"), p.isKeyword(","):
+		p.next()
+		return nil
+	case p.isKeyword("subgraph"), p.isKeyword("{"):
+		return errUnsupportedDOT
+	case p.isKeyword("graph"), p.isKeyword("node"), p.isKeyword("edge"):
+		p.next()
+		_, err := p.attributes()
+		return err
+	}
+
+	id, err := p.id()
+	if err != nil {
+		return err
+	}
+	if p.isKeyword("=") {
+		// A graph attribute, like rankdir=LR.
+		p.next()
+		_, err := p.id()
+		return err
+	}
+	if p.isKeyword(":") {
+		return errUnsupportedDOT
+	}
+
+	ids := []string{id}
+	for p.isKeyword("->") || p.isKeyword("--") {
+		p.next()
+		id, err := p.id()
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	attrs, err := p.attributes()
+	if err != nil {
+		return err
+	}
+
+	g := p.graph
+	for _, id := range ids {
+		if _, ok := g.labels[id]; !ok {
+			g.nodes = append(g.nodes, id)
+			g.labels[id] = id
+		}
+	}
+	if len(ids) == 1 {
+		if label, ok := attrs["label"]; ok {
+			g.labels[id] = label
+		}
+	}
+	for i := 1; i < len(ids); i++ {
+		g.edges = append(g.edges, [2]string{ids[i-1], ids[i]})
+	}
+	return nil
+}
+
+// attributes parses optional attribute lists like [label="A", shape=box].
+func (p *dotParser) attributes() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.isKeyword("[") {
+		p.next()
+		for !p.isKeyword("]") {
+			if p.isKeyword(";") || p.isKeyword(",") {
+				p.next()
+				continue
+			}
+			key, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			if p.isKeyword("=") {
+				p.next()
+				value, err := p.id()
+				if err != nil {
+					return nil, err
+				}
+				attrs[key] = value
+			}
+		}
+		p.next()
+	}
+	return attrs, nil
+}
+
+// graphNode is a node placed in a layer. Edges spanning several layers pass
+// through dummy nodes, drawn as plain lines.
+type graphNode struct {
+	label string
+	dummy bool
+	width int
+	// Column of the node's center.
+	center int
+	x      int
+}
+
+type graphSegment struct {
+	from, to *graphNode
+}
+
+// Line directions of a canvas cell.
+const (
+	lineUp = 1 << iota
+	lineDown
+	lineLeft
+	lineRight
+)
+
+var lineGlyphs = map[int]rune{
+	lineUp: '│', lineDown: '│', lineUp | lineDown: '│',
+	lineLeft: '─', lineRight: '─', lineLeft | lineRight: '─',
+	lineDown | lineRight: '┌', lineDown | lineLeft: '┐',
+	lineUp | lineRight: '└', lineUp | lineLeft: '┘',
+	lineUp | lineDown | lineRight: '├', lineUp | lineDown | lineLeft: '┤',
+	lineLeft | lineRight | lineDown: '┬', lineLeft | lineRight | lineUp: '┴',
+	lineUp | lineDown | lineLeft | lineRight: '┼',
+}
+
+// graphCanvas is a grid of characters on which lines are joined.
+type graphCanvas struct {
+	chars [][]rune
+	lines [][]int
+}
+
+func newGraphCanvas(width, height int) *graphCanvas {
+	c := &graphCanvas{chars: make([][]rune, height), lines: make([][]int, height)}
+	for y := range height {
+		c.chars[y] = make([]rune, width)
+		c.lines[y] = make([]int, width)
+	}
+	return c
+}
+
+func (c *graphCanvas) text(x, y int, s string) {
+	for _, r := range s {
+		c.chars[y][x] = r
+		x++
+	}
+}
+
+// connect draws a straight line between two cells.
+func (c *graphCanvas) connect(x0, y0, x1, y1 int) {
+	for x0 != x1 || y0 != y1 {
+		switch {
+		case x1 > x0:
+			c.lines[y0][x0] |= lineRight
+			x0++
+			c.lines[y0][x0] |= lineLeft
+		case x1 < x0:
+			c.lines[y0][x0] |= lineLeft
+			x0--
+			c.lines[y0][x0] |= lineRight
+		case y1 > y0:
+			c.lines[y0][x0] |= lineDown
+			y0++
+			c.lines[y0][x0] |= lineUp
+		default:
+			c.lines[y0][x0] |= lineUp
+			y0--
+			c.lines[y0][x0] |= lineDown
+		}
+	}
+}
+
+func (c *graphCanvas) String() string {
+	var b strings.Builder
+	for y, row := range c.chars {
+		var line strings.Builder
+		for x, r := range row {
+			switch {
+			case r != 0:
+				line.WriteRune(r)
+			case c.lines[y][x] != 0:
+				line.WriteRune(lineGlyphs[c.lines[y][x]])
+			default:
+				line.WriteByte(' ')
+			}
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	return b.String()
+}
+
+// RenderDOT lays out a graph in the DOT language as boxes and arrows, from
+// top to bottom. Only small, acyclic graphs without subgraphs are supported;
+// ok is false for anything else.
+func RenderDOT(src string) (string, bool) {
+	g, err := parseDOT(src)
+	if err != nil || len(g.nodes) == 0 || len(g.nodes) > maxGraphNodes {
+		return "", false
+	}
+
+	layers, segments, ok := layerGraph(g)
+	if !ok {
+		return "", false
+	}
+
+	// Place nodes left to right, centering each layer.
+	width := 0
+	for _, layer := range layers {
+		x := 0
+		for _, n := range layer {
+			n.x = x
+			x += n.width + 3
+		}
+		width = max(width, x-3)
+	}
+	if width > maxGraphWidth {
+		return "", false
+	}
+	for _, layer := range layers {
+		last := layer[len(layer)-1]
+		offset := (width - (last.x + last.width)) / 2
+		for _, n := range layer {
+			n.x += offset
+			n.center = n.x + n.width/2
+		}
+	}
+
+	// Each gap between layers has a row for every bent edge to run
+	// horizontally in, and one for the arrow heads.
+	tops := make([]int, len(layers))
+	bends := make([]int, len(layers))
+	for _, s := range segments {
+		if s.from.center != s.to.center {
+			bends[s.layer(layers)]++
+		}
+	}
+	y := 0
+	for i := range layers {
+		tops[i] = y
+		y += 3 + max(2, bends[i]+1)
+	}
+	c := newGraphCanvas(width, tops[len(tops)-1]+3)
+
+	for i, layer := range layers {
+		for _, n := range layer {
+			if n.dummy {
+				c.connect(n.center, tops[i], n.center, tops[i]+2)
+				continue
+			}
+			inner := strings.Repeat("─", n.width-2)
+			c.text(n.x, tops[i], "┌"+inner+"┐")
+			c.text(n.x, tops[i]+1, "│ "+n.label+" │")
+			c.text(n.x, tops[i]+2, "└"+inner+"┘")
+		}
+	}
+
+	row := make([]int, len(layers))
+	for _, s := range segments {
+		i := s.layer(layers)
+		x0, x1 := s.from.center, s.to.center
+		y0, y1 := tops[i]+3, tops[i+1]-1
+		if s.from.dummy {
+			c.connect(x0, y0-1, x0, y0)
+		} else {
+			c.chars[y0-1][x0] = '┬'
+			c.lines[y0][x0] |= lineUp
+		}
+
+		if x0 == x1 {
+			c.connect(x0, y0, x1, y1)
+		} else {
+			bend := y0 + row[i]
+			row[i]++
+			c.connect(x0, y0, x0, bend)
+			c.connect(x0, bend, x1, bend)
+			c.connect(x1, bend, x1, y1)
+		}
+
+		switch {
+		case s.to.dummy:
+			c.connect(x1, y1, x1, y1+1)
+		case g.directed:
+			c.chars[y1][x1] = '▼'
+		default:
+			c.lines[y1][x1] |= lineDown
+			c.chars[y1+1][x1] = '┴'
+		}
+	}
+	return c.String(), true
+}
+
+// layer returns the index of the layer a segment starts in.
+func (s graphSegment) layer(layers [][]*graphNode) int {
+	for i, layer := range layers {
+		for _, n := range layer {
+			if n == s.from {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// layerGraph assigns nodes to layers by their longest path from a source,
+// inserting dummy nodes into edges that span several layers. ok is false if
+// the graph has cycles.
+func layerGraph(g *dotGraph) ([][]*graphNode, []graphSegment, bool) {
+	indegree := map[string]int{}
+	children := map[string][]string{}
+	var edges [][2]string
+	for _, e := range g.edges {
+		if e[0] == e[1] {
+			continue
+		}
+		edges = append(edges, e)
+		indegree[e[1]]++
+		children[e[0]] = append(children[e[0]], e[1])
+	}
+
+	rank := map[string]int{}
+	var queue []string
+	for _, id := range g.nodes {
+		if indegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	for done := 0; done < len(g.nodes); done++ {
+		if len(queue) == 0 {
+			return nil, nil, false
+		}
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			rank[child] = max(rank[child], rank[id]+1)
+			if indegree[child]--; indegree[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	depth := 0
+	for _, r := range rank {
+		depth = max(depth, r)
+	}
+	layers := make([][]*graphNode, depth+1)
+	nodes := map[string]*graphNode{}
+	for _, id := range g.nodes {
+		label := g.labels[id]
+		n := &graphNode{label: label, width: ansi.StringWidth(label) + 4}
+		nodes[id] = n
+		layers[rank[id]] = append(layers[rank[id]], n)
+	}
+
+	var segments []graphSegment
+	for _, e := range edges {
+		from := nodes[e[0]]
+		for r := rank[e[0]] + 1; r < rank[e[1]]; r++ {
+			d := &graphNode{dummy: true, width: 1}
+			layers[r] = append(layers[r], d)
+			segments = append(segments, graphSegment{from, d})
+			from = d
+		}
+		segments = append(segments, graphSegment{from, nodes[e[1]]})
+	}
+
+	orderLayers(layers, segments)
+	return layers, segments, true
+}
+
+// orderLayers reduces edge crossings by sorting every layer by the average
+// position of the nodes pointing to it.
+func orderLayers(layers [][]*graphNode, segments []graphSegment) {
+	for i := 1; i < len(layers); i++ {
+		pos := map[*graphNode]int{}
+		for j, n := range layers[i-1] {
+			pos[n] = j
+		}
+		weight := map[*graphNode]float64{}
+		for j, n := range layers[i] {
+			var sum, count float64
+			for _, s := range segments {
+				if p, ok := pos[s.from]; ok && s.to == n {
+					sum += float64(p)
+					count++
+				}
+			}
+			if count == 0 {
+				weight[n] = float64(j)
+			} else {
+				weight[n] = sum / count
+			}
+		}
+		sort.SliceStable(layers[i], func(a, b int) bool {
+			return weight[layers[i][a]] < weight[layers[i][b]]
+		})
+	}
+}
+
+// diagramLanguages are the info strings of code blocks drawn by
+// RenderDiagrams.
+var diagramLanguages = []string{"dot", "graphviz"}
+
+// RenderDiagrams replaces the source of DOT graphs in fenced code blocks with
+// their drawing. Graphs that can't be drawn are left as they are.
+func RenderDiagrams(source []byte) []byte {
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if block.Info == nil || block.Lines().Len() == 0 ||
+			!slices.Contains(diagramLanguages, strings.ToLower(string(block.Language(source)))) {
+			return ast.WalkSkipChildren, nil
+		}
+
+		drawing, ok := RenderDOT(codeBlockText(block, source))
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+
+		// Keep the prefix of nested blocks, like "> " in block quotes.
+		first, last := block.Lines().At(0), block.Lines().At(block.Lines().Len()-1)
+		start := lineStart(source, first.Start)
+		prefix := string(source[start:first.Start])
+		lines := strings.SplitAfter(drawing, "\n")
+		lines = lines[:len(lines)-1]
+		info := block.Info.Segment
+		edits = append(edits,
+			textEdit{info.Start, info.Stop, "text"},
+			textEdit{start, last.Stop, prefix + strings.Join(lines, prefix)},
+		)
+		return ast.WalkSkipChildren, nil
+	})
+	return applyEdits(source, edits)
+}
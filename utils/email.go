@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// setextUnderline matches lines markdown takes for the underline of a
+// heading.
+var setextUnderline = regexp.MustCompile(`^ {0,3}(?:-+|=+)[ \t]*$`)
+
+// emailHeaders are the headers of an email shown above its body.
+var emailHeaders = []string{"From", "To", "Cc", "Date"}
+
+// emailBodyTypes are the media types of the parts an email's body is taken
+// from, by preference.
+var emailBodyTypes = []string{"text/markdown", "text/x-markdown", "text/plain", "text/html"}
+
+// emailPart is a leaf part of an email.
+type emailPart struct {
+	mediaType string
+	filename  string
+	body      []byte
+}
+
+// EmailToMarkdown converts an email, like an .eml file, to markdown: its
+// subject as title, a table of its headers, and its body from the markdown,
+// plain text or HTML part. Patches in plain text are shown as diffs.
+func EmailToMarkdown(source []byte) []byte {
+	msg, err := mail.ReadMessage(bytes.NewReader(source))
+	if err != nil {
+		return source
+	}
+	dec := mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+	header := func(key string) string {
+		v := msg.Header.Get(key)
+		if d, err := dec.DecodeHeader(v); err == nil {
+			v = d
+		}
+		return strings.Join(strings.Fields(v), " ")
+	}
+
+	var b strings.Builder
+	subject := header("Subject")
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", EscapeMarkdown(subject))
+
+	escape := strings.NewReplacer("|", `\|`)
+	b.WriteString("| Header | Value |\n|--------|-------|\n")
+	for _, key := range emailHeaders {
+		v := header(key)
+		if key != "Date" {
+			v = emailAddresses(v)
+		}
+		if v != "" {
+			fmt.Fprintf(&b, "| %s | %s |\n", key, escape.Replace(EscapeMarkdown(v)))
+		}
+	}
+	b.WriteString("\n")
+
+	parts := emailParts(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body)
+	var attachments []string
+	for _, p := range parts {
+		if p.filename != "" {
+			attachments = append(attachments, EscapeMarkdown(p.filename))
+		}
+	}
+	if body := emailBody(parts); body != "" {
+		b.WriteString(body + "\n\n")
+	}
+	if len(attachments) > 0 {
+		fmt.Fprintf(&b, "**Attachments:** %s\n", strings.Join(attachments, ", "))
+	}
+	return []byte(b.String())
+}
+
+// emailAddresses returns a list of addresses with each address in angle
+// brackets, so bare ones aren't turned into links.
+func emailAddresses(v string) string {
+	list, err := mail.ParseAddressList(v)
+	if err != nil {
+		return v
+	}
+	addrs := make([]string, 0, len(list))
+	for _, a := range list {
+		if a.Name == "" {
+			addrs = append(addrs, "<"+a.Address+">")
+			continue
+		}
+		addrs = append(addrs, a.Name+" <"+a.Address+">")
+	}
+	return strings.Join(addrs, ", ")
+}
+
+// emailParts returns the leaf parts of an email, or of one of its parts,
+// decoded.
+func emailParts(contentType, encoding, disposition string, r io.Reader) []emailPart {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var parts []emailPart
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err != nil {
+				return parts
+			}
+			parts = append(parts, emailParts(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p.Header.Get("Content-Disposition"), p)...)
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	}
+	part := emailPart{mediaType: mediaType}
+	if d, dparams, err := mime.ParseMediaType(disposition); err == nil && d == "attachment" {
+		part.filename = dparams["filename"]
+		if part.filename == "" {
+			part.filename = params["name"]
+		}
+		if part.filename == "" {
+			part.filename = "(unnamed)"
+		}
+		return []emailPart{part}
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		if cr, err := charset.NewReaderLabel(params["charset"], r); err == nil && params["charset"] != "" {
+			r = cr
+		}
+	}
+	part.body, _ = io.ReadAll(r)
+	return []emailPart{part}
+}
+
+// emailBody returns the body of an email as markdown, from its preferred
+// part.
+func emailBody(parts []emailPart) string {
+	for _, mediaType := range emailBodyTypes {
+		for _, p := range parts {
+			if p.filename != "" || p.mediaType != mediaType {
+				continue
+			}
+			body := strings.ReplaceAll(string(p.body), "\r\n", "\n")
+			switch mediaType {
+			case "text/html":
+				return strings.TrimSpace(string(HTMLToMarkdown([]byte(body), "")))
+			case "text/plain":
+				return strings.TrimSpace(plainEmailBody(body))
+			default:
+				return strings.TrimSpace(body)
+			}
+		}
+	}
+	return ""
+}
+
+// plainEmailBody returns a plain text body as markdown, with the diff of a
+// patch, and its diffstat, in a code block.
+func plainEmailBody(body string) string {
+	lines := strings.Split(body, "\n")
+	text, diff := lines, []string(nil)
+	for i, l := range lines {
+		if strings.HasPrefix(l, "diff --git ") || strings.HasPrefix(l, "Index: ") {
+			start := i
+			for j := i - 1; j >= 0; j-- {
+				if lines[j] == "---" {
+					start = j + 1
+					break
+				}
+			}
+			text, diff = lines[:start], lines[start:]
+			break
+		}
+	}
+
+	out := make([]string, 0, len(text))
+	for _, l := range text {
+		// Separators, like of signatures, would underline the text before
+		// them as a heading.
+		if setextUnderline.MatchString(l) {
+			l = "\\" + strings.TrimSpace(l)
+		}
+		out = append(out, l)
+	}
+	if len(diff) > 0 {
+		out = append(out, "")
+		out = append(out, fencedCode("diff", trimBlank(diff))...)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// feedDateLayout is how the dates of feed entries are shown.
+const feedDateLayout = "Jan 2, 2006"
+
+// feedDateLayouts are the layouts of the dates of RSS and Atom feeds,
+// including some common deviations from RFC 822.
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	time.DateOnly,
+}
+
+// Feed is an RSS or Atom feed.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Entries     []FeedEntry
+}
+
+// FeedEntry is an entry of a feed, with its summary and content converted to
+// markdown.
+type FeedEntry struct {
+	Title   string
+	Link    string
+	Author  string
+	Date    time.Time
+	Summary string
+	Content string
+}
+
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+type feedText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// feedAuthor is the author of an entry, named in Atom and given as text,
+// often an email address, in RSS.
+type feedAuthor struct {
+	Name string `xml:"name"`
+	Text string `xml:",chardata"`
+}
+
+type feedItem struct {
+	Title       feedText   `xml:"title"`
+	Links       []feedLink `xml:"link"`
+	GUID        string     `xml:"guid"`
+	ID          string     `xml:"id"`
+	Author      feedAuthor `xml:"author"`
+	Creator     string     `xml:"creator"`
+	PubDate     string     `xml:"pubDate"`
+	Date        string     `xml:"date"`
+	Published   string     `xml:"published"`
+	Updated     string     `xml:"updated"`
+	Description feedText   `xml:"description"`
+	Summary     feedText   `xml:"summary"`
+	Encoded     string     `xml:"encoded"`
+	Content     feedText   `xml:"content"`
+}
+
+type feedChannel struct {
+	Title       feedText   `xml:"title"`
+	Links       []feedLink `xml:"link"`
+	Description feedText   `xml:"description"`
+	Subtitle    feedText   `xml:"subtitle"`
+	Items       []feedItem `xml:"item"`
+}
+
+// feedDocument holds RSS 2.0 feeds in its channel, RSS 1.0 feeds in its
+// channel and items, and Atom feeds in itself.
+type feedDocument struct {
+	XMLName xml.Name
+	Channel feedChannel `xml:"channel"`
+	Items   []feedItem  `xml:"item"`
+
+	Title    feedText   `xml:"title"`
+	Links    []feedLink `xml:"link"`
+	Subtitle feedText   `xml:"subtitle"`
+	Entries  []feedItem `xml:"entry"`
+}
+
+// IsFeed returns whether a document is an RSS or Atom feed, by its root
+// element.
+func IsFeed(source []byte) bool {
+	d := newFeedDecoder(source)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return false
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			return isFeedElement(el.Name.Local)
+		}
+	}
+}
+
+func isFeedElement(name string) bool {
+	return name == "rss" || name == "feed" || name == "RDF"
+}
+
+func newFeedDecoder(source []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(source))
+	d.Strict = false
+	d.CharsetReader = charset.NewReaderLabel
+	return d
+}
+
+// ParseFeed parses an RSS or Atom feed. Links of entries are resolved
+// against the feed's URL.
+func ParseFeed(source []byte, feedURL string) (*Feed, error) {
+	var doc feedDocument
+	if err := newFeedDecoder(source).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unable to parse feed: %w", err)
+	}
+	if !isFeedElement(doc.XMLName.Local) {
+		return nil, errors.New("not an RSS or Atom feed")
+	}
+
+	f := &Feed{}
+	items := doc.Entries
+	if doc.XMLName.Local == "feed" {
+		f.Title = doc.Title.text()
+		f.Link = feedLinkHref(doc.Links)
+		f.Description = doc.Subtitle.html()
+	} else {
+		f.Title = doc.Channel.Title.text()
+		f.Link = feedLinkHref(doc.Channel.Links)
+		f.Description = firstNonEmpty(doc.Channel.Description.html(), doc.Channel.Subtitle.html())
+		items = append(doc.Channel.Items, doc.Items...)
+	}
+	base := firstNonEmpty(f.Link, feedURL)
+	f.Description = HTMLFragmentToMarkdown(f.Description, base)
+
+	for _, it := range items {
+		e := FeedEntry{
+			Title:  it.Title.text(),
+			Link:   feedLinkHref(it.Links),
+			Author: strings.TrimSpace(firstNonEmpty(it.Author.Name, it.Creator, it.Author.Text)),
+			Date:   parseFeedDate(firstNonEmpty(it.Published, it.PubDate, it.Date, it.Updated)),
+		}
+		if e.Link == "" && strings.HasPrefix(it.GUID, "http") {
+			e.Link = strings.TrimSpace(it.GUID)
+		}
+		if e.Link != "" {
+			e.Link = resolveLink(e.Link, base)
+		}
+		entryBase := firstNonEmpty(e.Link, base)
+		summary := firstNonEmpty(it.Summary.html(), it.Description.html())
+		content := firstNonEmpty(it.Encoded, it.Content.html())
+		e.Summary = HTMLFragmentToMarkdown(firstNonEmpty(summary, content), entryBase)
+		e.Content = HTMLFragmentToMarkdown(firstNonEmpty(content, summary), entryBase)
+		if e.Title == "" {
+			e.Title = firstNonEmpty(e.Link, it.ID, it.GUID)
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f, nil
+}
+
+// text returns the plain text of a title.
+func (t feedText) text() string {
+	if t.Type == "html" || t.Type == "xhtml" {
+		return strings.TrimSpace(htmlSpace.ReplaceAllString(htmlText(t.html()), " "))
+	}
+	return strings.TrimSpace(htmlSpace.ReplaceAllString(t.Text, " "))
+}
+
+// html returns the HTML of a summary or content. Atom's XHTML content is
+// inline, the HTML of RSS and other Atom content is escaped.
+func (t feedText) html() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	if t.Type == "text" {
+		return strings.TrimSpace(htmlEscape(t.Text))
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func htmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// feedLinkHref returns the link of a feed or an entry to its web page.
+func feedLinkHref(links []feedLink) string {
+	for _, l := range links {
+		if href := strings.TrimSpace(l.Href); href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			return href
+		}
+		if text := strings.TrimSpace(l.Text); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// resolveLink resolves a link against the URL of a feed.
+func resolveLink(ref, base string) string {
+	c := htmlConverter{}
+	if u, err := url.Parse(base); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		c.base = u
+	}
+	return c.link(ref)
+}
+
+func parseFeedDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Markdown returns an entry as a document, titled with the entry's title and
+// ending with a link to its web page.
+func (e FeedEntry) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", EscapeMarkdown(e.Title))
+	if meta := e.meta(); meta != "" {
+		fmt.Fprintf(&b, "*%s*\n\n", meta)
+	}
+	if e.Content != "" {
+		b.WriteString(e.Content + "\n\n")
+	}
+	if e.Link != "" {
+		fmt.Fprintf(&b, "[Read on the web](%s)\n", e.Link)
+	}
+	return b.String()
+}
+
+// meta returns the date and author of an entry.
+func (e FeedEntry) meta() string {
+	var meta []string
+	if !e.Date.IsZero() {
+		meta = append(meta, e.Date.Format(feedDateLayout))
+	}
+	if e.Author != "" {
+		meta = append(meta, EscapeMarkdown(e.Author))
+	}
+	return strings.Join(meta, " · ")
+}
+
+// Markdown returns a digest of a feed: its entries' titles, linked to their
+// web pages, with their dates and summaries.
+func (f *Feed) Markdown() string {
+	var b strings.Builder
+	if f.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", EscapeMarkdown(f.Title))
+	}
+	if f.Description != "" {
+		b.WriteString(f.Description + "\n\n")
+	}
+	for _, e := range f.Entries {
+		if e.Link != "" {
+			fmt.Fprintf(&b, "## [%s](%s)\n\n", EscapeMarkdown(e.Title), e.Link)
+		} else {
+			fmt.Fprintf(&b, "## %s\n\n", EscapeMarkdown(e.Title))
+		}
+		if meta := e.meta(); meta != "" {
+			fmt.Fprintf(&b, "*%s*\n\n", meta)
+		}
+		if e.Summary != "" {
+			b.WriteString(e.Summary + "\n\n")
+		}
+	}
+	return b.String()
+}
+
+// FeedToMarkdown converts an RSS or Atom feed to a digest of its entries.
+// Documents that aren't feeds are returned as-is.
+func FeedToMarkdown(source []byte, feedURL string) []byte {
+	f, err := ParseFeed(source, feedURL)
+	if err != nil {
+		return source
+	}
+	return []byte(f.Markdown())
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/yuin/goldmark/ast"
+)
+
+var (
+	// FenceHandlers are commands drawing code blocks of a language, like
+	// "mermaid": "mmdc --ascii". The code is written to their stdin, and
+	// their stdout replaces the block.
+	FenceHandlers map[string]string
+
+	// FenceTimeout limits how long a fence handler may run, 0 for no limit.
+	FenceTimeout time.Duration
+
+	// FenceCacheDir is where the output of fence handlers is cached, by
+	// command and code, so they don't run again for unchanged blocks. Their
+	// output isn't cached if it's empty.
+	FenceCacheDir string
+
+	// FenceSandbox runs fence handlers in an empty directory, with only
+	// PATH and the locale of the environment, so they can't read the
+	// documents around or the credentials in the environment.
+	FenceSandbox bool
+)
+
+// DrawsFence reports whether code blocks of a language are replaced by what
+// a fence handler or RenderDiagrams draws of their code.
+func DrawsFence(lang string) bool {
+	lang = strings.ToLower(lang)
+	_, ok := FenceHandlers[lang]
+	return ok || slices.Contains(diagramLanguages, lang)
+}
+
+// RenderFences replaces the code of fenced code blocks that have a fence
+// handler with its output. Blocks whose handler fails, or has no output,
+// are left as they are.
+func RenderFences(source []byte) []byte {
+	if len(FenceHandlers) == 0 {
+		return source
+	}
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if block.Info == nil || block.Lines().Len() == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		command, ok := FenceHandlers[strings.ToLower(string(block.Language(source)))]
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		out, err := runFenceHandler(command, codeBlockText(block, source))
+		if err != nil {
+			log.Debug("unable to run fence handler", "command", command, "error", err)
+		}
+		if strings.TrimSpace(out) == "" {
+			return ast.WalkSkipChildren, nil
+		}
+
+		// Keep the prefix of nested blocks, like "> " in block quotes.
+		first, last := block.Lines().At(0), block.Lines().At(block.Lines().Len()-1)
+		start := lineStart(source, first.Start)
+		prefix := string(source[start:first.Start])
+		lines := strings.SplitAfter(strings.TrimRight(out, "\n")+"\n", "\n")
+		lines = lines[:len(lines)-1]
+		info := block.Info.Segment
+		edits = append(edits,
+			textEdit{info.Start, info.Stop, "text"},
+			textEdit{start, last.Stop, prefix + strings.Join(lines, prefix)},
+		)
+		return ast.WalkSkipChildren, nil
+	})
+	return applyEdits(source, edits)
+}
+
+// runFenceHandler runs a fence handler on the code of a block, or reads its
+// output from the cache.
+func runFenceHandler(command, code string) (string, error) {
+	var cachePath string
+	if FenceCacheDir != "" {
+		sum := sha256.Sum256([]byte(command + "\x00" + code))
+		cachePath = filepath.Join(FenceCacheDir, hex.EncodeToString(sum[:]))
+		if b, err := os.ReadFile(cachePath); err == nil {
+			return string(b), nil
+		}
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", errors.New("empty fence handler command")
+	}
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if FenceTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, FenceTimeout)
+	}
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+	c.Stdin = strings.NewReader(code)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	// Processes the handler started may keep its output open.
+	c.WaitDelay = time.Second
+	if FenceSandbox {
+		dir, err := os.MkdirTemp("", "glow-fence-")
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		defer os.RemoveAll(dir) //nolint:errcheck
+		c.Dir = dir
+		c.Env = sandboxEnv(dir)
+	}
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err //nolint:wrapcheck
+	}
+
+	out := stdout.String()
+	if cachePath != "" && strings.TrimSpace(out) != "" {
+		if err := os.MkdirAll(FenceCacheDir, 0o700); err == nil {
+			_ = os.WriteFile(cachePath, []byte(out), 0o600)
+		}
+	}
+	return out, nil
+}
+
+// sandboxEnv returns the environment of sandboxed fence handlers: PATH, the
+// locale, and the sandbox directory as home and temporary directory.
+func sandboxEnv(dir string) []string {
+	env := []string{"HOME=" + dir, "TMPDIR=" + dir}
+	keep := []string{"PATH", "LANG", "LC_ALL", "LC_CTYPE"}
+	if runtime.GOOS == "windows" {
+		env = []string{"USERPROFILE=" + dir, "TEMP=" + dir, "TMP=" + dir}
+		keep = append(keep, "SYSTEMROOT", "PATHEXT")
+	}
+	for _, k := range keep {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
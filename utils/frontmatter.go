@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// Front matter formats.
+const (
+	FrontmatterYAML = "yaml"
+	FrontmatterTOML = "toml"
+)
+
+// Frontmatter returns the front matter of a markdown file, without its fences,
+// and whether it's YAML or TOML. The format is empty if there's none.
+func Frontmatter(content []byte) ([]byte, string) {
+	fences := frontmatterFences(content)
+	if fences == nil {
+		return nil, ""
+	}
+	format := FrontmatterYAML
+	if bytes.HasPrefix(content, []byte("+++")) {
+		format = FrontmatterTOML
+	}
+	return content[fences[0][1]:fences[1][0]], format
+}
+
+// MetadataField is a key and its value from front matter. Values are
+// formatted for display: lists are comma-separated and nested keys are
+// flattened into dotted keys.
+type MetadataField struct {
+	Key   string
+	Value string
+}
+
+// ParseFrontmatter parses the front matter of a markdown file into its
+// fields, in the order they appear in.
+func ParseFrontmatter(content []byte) ([]MetadataField, error) {
+	meta, format := Frontmatter(content)
+	switch format {
+	case FrontmatterYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(meta, &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse front matter: %w", err)
+		}
+		if len(doc.Content) == 0 {
+			return nil, nil
+		}
+		return yamlFields("", doc.Content[0])
+	case FrontmatterTOML:
+		var doc map[string]any
+		if err := toml.Unmarshal(meta, &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse front matter: %w", err)
+		}
+		return mapFields("", doc, meta), nil
+	}
+	return nil, nil
+}
+
+// FrontmatterData parses the front matter of a markdown file into a map of
+// its keys and values. It's nil if there's no front matter.
+func FrontmatterData(content []byte) (map[string]any, error) {
+	meta, format := Frontmatter(content)
+	var data map[string]any
+	switch format {
+	case FrontmatterYAML:
+		if err := yaml.Unmarshal(meta, &data); err != nil {
+			return nil, fmt.Errorf("unable to parse front matter: %w", err)
+		}
+	case FrontmatterTOML:
+		if err := toml.Unmarshal(meta, &data); err != nil {
+			return nil, fmt.Errorf("unable to parse front matter: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// LookupField returns the value of a front matter field, given by its key or
+// a dotted path to a nested key like author.name.
+func LookupField(data map[string]any, key string) (any, bool) {
+	if v, ok := data[key]; ok {
+		return v, true
+	}
+	name, rest, nested := strings.Cut(key, ".")
+	if !nested {
+		return nil, false
+	}
+	sub, ok := data[name].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return LookupField(sub, rest)
+}
+
+func yamlFields(prefix string, n *yaml.Node) ([]MetadataField, error) {
+	if n.Kind != yaml.MappingNode {
+		if prefix == "" {
+			return nil, errors.New("unable to parse front matter: expected key/value pairs")
+		}
+		return []MetadataField{{prefix, yamlValue(n)}}, nil
+	}
+
+	var fields []MetadataField
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key := n.Content[i].Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		f, err := yamlFields(key, n.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f...)
+	}
+	return fields, nil
+}
+
+// yamlValue formats a YAML value, keeping scalars as they were written.
+func yamlValue(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+	case yaml.SequenceNode:
+		items := make([]string, len(n.Content))
+		for i, item := range n.Content {
+			items[i] = yamlValue(item)
+		}
+		return strings.Join(items, ", ")
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return ""
+	}
+	return FormatMetadataValue(v)
+}
+
+// mapFields flattens a decoded TOML table. Maps are unordered, so keys are
+// sorted by where they first appear in the source.
+func mapFields(prefix string, m map[string]any, source []byte) []MetadataField {
+	keys := make([]string, 0, len(m))
+	pos := make(map[string]int, len(m))
+	for k := range m {
+		keys = append(keys, k)
+		pos[k] = len(source)
+		if loc := regexp.MustCompile(`(?m)^\s*(\[\s*)?"?` + regexp.QuoteMeta(k)).FindIndex(source); loc != nil {
+			pos[k] = loc[0]
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if pos[keys[i]] != pos[keys[j]] {
+			return pos[keys[i]] < pos[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	var fields []MetadataField
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := m[k].(map[string]any); ok {
+			fields = append(fields, mapFields(key, sub, source)...)
+			continue
+		}
+		fields = append(fields, MetadataField{key, FormatMetadataValue(m[k])})
+	}
+	return fields
+}
+
+// FormatMetadataValue formats a front matter value for display: lists are
+// comma-separated and dates are printed without their time at midnight.
+func FormatMetadataValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = FormatMetadataValue(item)
+		}
+		return strings.Join(items, ", ")
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format(time.RFC3339)
+	case map[string]any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// orderedListStart matches the start of a line markdown would take for an
+// item of an ordered list.
+var orderedListStart = regexp.MustCompile(`^(\d+)([.)])`)
+
+// GemtextToMarkdown converts gemtext, the format of Gemini documents, to
+// markdown. Links, which gemtext has one per line, are listed, resolved
+// against the document's URL.
+func GemtextToMarkdown(source []byte, docURL string) []byte {
+	base, _ := url.Parse(docURL)
+	if base != nil && !base.IsAbs() {
+		base = nil
+	}
+
+	var (
+		out  []string
+		pre  []string
+		lang string
+		in   bool
+		kind string
+	)
+	// block separates lines of different kinds, like a list from the text
+	// following it.
+	block := func(k string) {
+		if k != kind || k == "text" {
+			out = append(out, "")
+		}
+		kind = k
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "```") {
+			if in {
+				block("pre")
+				out = append(out, fencedCode(lang, pre)...)
+				kind = ""
+				pre, in = nil, false
+				continue
+			}
+			// The alt text describes the preformatted text, and is only
+			// kept as its language if it's a single word.
+			lang = strings.TrimSpace(line[3:])
+			if strings.ContainsAny(lang, " \t") {
+				lang = ""
+			}
+			in = true
+			continue
+		}
+		if in {
+			pre = append(pre, line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "=>"):
+			fields := strings.Fields(line[2:])
+			if len(fields) == 0 {
+				continue
+			}
+			target := fields[0]
+			if u, err := url.Parse(target); err == nil && base != nil {
+				target = base.ResolveReference(u).String()
+			}
+			label := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[2:]), fields[0]))
+			if label == "" {
+				label = fields[0]
+			}
+			if strings.ContainsAny(target, " ()") {
+				target = "<" + target + ">"
+			}
+			block("links")
+			out = append(out, "- ["+EscapeMarkdown(label)+"]("+target+")")
+		case strings.HasPrefix(line, "#"):
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			level = min(level, 3)
+			block("text")
+			out = append(out, strings.Repeat("#", level)+" "+EscapeMarkdown(strings.TrimSpace(strings.TrimLeft(line, "#"))))
+		case strings.HasPrefix(line, "* "):
+			block("list")
+			out = append(out, "- "+EscapeMarkdown(strings.TrimSpace(line[2:])))
+		case strings.HasPrefix(line, ">"):
+			block("quote")
+			out = append(out, strings.TrimRight("> "+EscapeMarkdown(strings.TrimSpace(line[1:])), " "))
+		case strings.TrimSpace(line) == "":
+			kind = ""
+		default:
+			block("text")
+			out = append(out, gemtextLine(line))
+		}
+	}
+	if in {
+		block("pre")
+		out = append(out, fencedCode(lang, pre)...)
+	}
+	return joinLines(out)
+}
+
+// gemtextLine returns a line of text, escaped so it isn't taken for markdown
+// blocks.
+func gemtextLine(line string) string {
+	text := EscapeMarkdown(strings.TrimLeft(line, " \t"))
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "=") {
+		// list items and setext heading underlines
+		return "\\" + text
+	}
+	return orderedListStart.ReplaceAllString(text, `$1\$2`)
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// BlockMatch is a block of a markdown document that matches a search.
+type BlockMatch struct {
+	// Line is the zero-based source line of the first match.
+	Line int
+	// Headings are the titles of the sections the block is in, outermost
+	// first.
+	Headings []string
+	// Source is the markdown of the block. Of code blocks, only the matching
+	// lines are kept.
+	Source string
+}
+
+// GrepBlocks returns the blocks of a markdown document, like paragraphs,
+// list items and code blocks, that match a regular expression.
+func GrepBlocks(source []byte, re *regexp.Regexp) []BlockMatch {
+	lines := strings.SplitAfter(string(source), "\n")
+	headings := Headings(source)
+
+	var matches []BlockMatch
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *ast.Paragraph, *ast.TextBlock, *ast.Heading, *ast.HTMLBlock, *east.Table:
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			if m, ok := grepCode(n, source, re); ok {
+				m.Headings = enclosingHeadings(headings, m.Line, -1)
+				matches = append(matches, m)
+			}
+			return ast.WalkSkipChildren, nil
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		first, last, ok := blockLines(n, source)
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		block := strings.Join(lines[first:last+1], "")
+		loc := re.FindStringIndex(block)
+		if loc == nil {
+			return ast.WalkSkipChildren, nil
+		}
+
+		line := first + strings.Count(block[:loc[0]], "\n")
+		self := -1
+		if _, ok := n.(*ast.Heading); ok {
+			self = first
+		}
+		matches = append(matches, BlockMatch{
+			Line:     line,
+			Headings: enclosingHeadings(headings, first, self),
+			Source:   dedent(block),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return matches
+}
+
+// grepCode returns the lines of a code block matching a search, as a code
+// block of their own.
+func grepCode(n ast.Node, source []byte, re *regexp.Regexp) (BlockMatch, bool) {
+	var (
+		m     BlockMatch
+		found []string
+	)
+	for i := range n.Lines().Len() {
+		seg := n.Lines().At(i)
+		text := string(seg.Value(source))
+		if !re.MatchString(text) {
+			continue
+		}
+		if found == nil {
+			m.Line = strings.Count(string(source[:seg.Start]), "\n")
+		}
+		found = append(found, strings.TrimRight(text, "\r\n"))
+	}
+	if found == nil {
+		return m, false
+	}
+
+	var lang string
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok {
+		lang = string(fenced.Language(source))
+	}
+	m.Source = WrapCodeBlock(strings.Join(found, "\n")+"\n", lang)
+	return m, true
+}
+
+// blockLines returns the first and last zero-based source lines of a block.
+func blockLines(n ast.Node, source []byte) (first, last int, ok bool) {
+	start, stop := -1, -1
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if t, isText := c.(*ast.Text); isText {
+			if start < 0 || t.Segment.Start < start {
+				start = t.Segment.Start
+			}
+			stop = max(stop, t.Segment.Stop)
+		}
+		if c.Type() != ast.TypeInline {
+			for i := range c.Lines().Len() {
+				seg := c.Lines().At(i)
+				if start < 0 || seg.Start < start {
+					start = seg.Start
+				}
+				stop = max(stop, seg.Stop)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	if start < 0 {
+		return 0, 0, false
+	}
+	first = strings.Count(string(source[:start]), "\n")
+	last = first + strings.Count(strings.TrimRight(string(source[start:stop]), "\n"), "\n")
+	return first, last, true
+}
+
+// enclosingHeadings returns the titles of the sections a line is in. The
+// heading on line self, if any, is left out.
+func enclosingHeadings(headings []Heading, line, self int) []string {
+	var stack []Heading
+	for _, h := range headings {
+		if h.Line > line {
+			break
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if h.Line == self {
+			break
+		}
+		stack = append(stack, h)
+	}
+	titles := make([]string, len(stack))
+	for i, h := range stack {
+		titles[i] = h.Text
+	}
+	return titles
+}
+
+// dedent removes the indentation all lines of a block share, so that nested
+// list items can be shown on their own.
+func dedent(block string) string {
+	lines := strings.SplitAfter(block, "\n")
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent <= 0 {
+		return block
+	}
+	for i, l := range lines {
+		if len(l) >= indent && strings.TrimSpace(l[:indent]) == "" {
+			lines[i] = l[indent:]
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,653 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	// unlikelyContent matches the classes and ids of page elements that
+	// aren't part of an article, like navigation and comments.
+	unlikelyContent = regexp.MustCompile(`(?i)\b(comments?|sidebar|footer|nav|navbar|menu|share|sharing|social|promo|related|cookies?|banner|newsletter|subscribe|advert|ads?|sponsor|popup|modal|breadcrumbs?|pagination|skip)\b`)
+	// likelyContent matches the classes and ids of elements holding an
+	// article, which are kept even if they look unlikely.
+	likelyContent = regexp.MustCompile(`(?i)(article|content|main|post|entry|story|body|text|blog)`)
+
+	htmlSpace = regexp.MustCompile(`[ \t\r\n\f]+`)
+	htmlLang  = regexp.MustCompile(`(?:^|\s)(?:language|lang|highlight-source)-([\w+#.-]+)`)
+)
+
+// Readability makes converted web pages read like a browser's reader view:
+// blocks of the article that are mostly links, like lists of related posts,
+// are left out, and its byline is shown below its title.
+var Readability bool
+
+// linkDenseBlocks are the elements left out of articles in readability mode
+// when most of their text is links.
+var linkDenseBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Ul: true, atom.Ol: true,
+	atom.Table: true, atom.Header: true, atom.Figure: true, atom.Dl: true,
+}
+
+// skippedElements are the elements left out of converted pages.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Form: true,
+	atom.Iframe: true, atom.Svg: true, atom.Button: true, atom.Canvas: true,
+	atom.Select: true, atom.Input: true, atom.Textarea: true, atom.Dialog: true,
+	atom.Object: true, atom.Embed: true, atom.Head: true, atom.Link: true, atom.Meta: true,
+}
+
+// htmlBlocks are the elements converted to blocks of their own.
+var htmlBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Pre: true, atom.Blockquote: true, atom.Ul: true,
+	atom.Ol: true, atom.Li: true, atom.Table: true, atom.Hr: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Dl: true, atom.Dt: true, atom.Dd: true, atom.Details: true,
+	atom.Summary: true, atom.Address: true, atom.Center: true, atom.Fieldset: true,
+	atom.Body: true, atom.Html: true, atom.Picture: true,
+}
+
+// HTMLToMarkdown converts an HTML page to markdown. Only the main content of
+// the page is kept, without navigation, sidebars and the like, titled with
+// the page's title. Links and images of web pages are resolved against their
+// URL.
+func HTMLToMarkdown(source []byte, pageURL string) []byte {
+	if enc, name, _ := charset.DetermineEncoding(source, ""); name != "utf-8" {
+		if b, err := io.ReadAll(enc.NewDecoder().Reader(bytes.NewReader(source))); err == nil {
+			source = b
+		}
+	}
+	doc, err := html.Parse(bytes.NewReader(source))
+	if err != nil {
+		return source
+	}
+
+	c := htmlConverter{}
+	if u, err := url.Parse(pageURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		c.base = u
+	}
+	if base := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Base }); base != nil {
+		if u, err := c.resolve(attr(base, "href")); err == nil {
+			c.base = u
+		}
+	}
+
+	title := pageTitle(doc)
+	byline := ""
+	if Readability {
+		byline = pageByline(doc)
+	}
+	content := mainContent(doc)
+	if Readability {
+		removeLinkDense(content)
+	}
+	blocks := c.blocks(content)
+	if title != "" && (len(blocks) == 0 || !strings.HasPrefix(blocks[0], "# ")) &&
+		findElement(content, func(n *html.Node) bool { return n.DataAtom == atom.H1 }) == nil {
+		blocks = append([]string{"# " + EscapeMarkdown(title)}, blocks...)
+	}
+	if byline != "" {
+		i := 0
+		if len(blocks) > 0 && strings.HasPrefix(blocks[0], "# ") {
+			i = 1
+		}
+		blocks = append(blocks[:i], append([]string{"*" + EscapeMarkdown(byline) + "*"}, blocks[i:]...)...)
+	}
+	return []byte(strings.Join(blocks, "\n\n") + "\n")
+}
+
+// HTMLFragmentToMarkdown converts a fragment of HTML, like the content of a
+// feed entry, to markdown. All of it is kept, with links resolved against
+// the base URL.
+func HTMLFragmentToMarkdown(source string, baseURL string) string {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return source
+	}
+	c := htmlConverter{}
+	if u, err := url.Parse(baseURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		c.base = u
+	}
+	removeSkipped(doc)
+	body := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+	if body == nil {
+		body = doc
+	}
+	return strings.Join(c.blocks(body), "\n\n")
+}
+
+// htmlText returns the text of a fragment of HTML.
+func htmlText(source string) string {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return source
+	}
+	return textContent(doc)
+}
+
+// removeSkipped removes the elements of a fragment that aren't content, like
+// scripts.
+func removeSkipped(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || c.Type == html.ElementNode && skippedElements[c.DataAtom] {
+			n.RemoveChild(c)
+		} else {
+			removeSkipped(c)
+		}
+		c = next
+	}
+}
+
+// pageTitle returns the title of a page.
+func pageTitle(doc *html.Node) string {
+	if meta := findElement(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Meta && attr(n, "property") == "og:title"
+	}); meta != nil {
+		if t := strings.TrimSpace(attr(meta, "content")); t != "" {
+			return t
+		}
+	}
+	if t := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Title }); t != nil {
+		return strings.TrimSpace(htmlSpace.ReplaceAllString(textContent(t), " "))
+	}
+	return ""
+}
+
+// pageByline returns the byline of a page's article from its metadata: its
+// author, site and publication date.
+func pageByline(doc *html.Node) string {
+	meta := func(keys ...string) string {
+		for _, key := range keys {
+			if n := findElement(doc, func(n *html.Node) bool {
+				return n.DataAtom == atom.Meta && (attr(n, "name") == key || attr(n, "property") == key)
+			}); n != nil {
+				if v := strings.TrimSpace(attr(n, "content")); v != "" {
+					return v
+				}
+			}
+		}
+		return ""
+	}
+
+	var parts []string
+	author := meta("author", "article:author", "twitter:creator")
+	if author == "" || strings.Contains(author, "://") {
+		author = ""
+		if n := findElement(doc, func(n *html.Node) bool {
+			return attr(n, "rel") == "author" || attr(n, "itemprop") == "author"
+		}); n != nil {
+			author = strings.TrimSpace(htmlSpace.ReplaceAllString(textContent(n), " "))
+		}
+	}
+	if author != "" && len(author) < 100 {
+		parts = append(parts, "By "+author)
+	}
+	if site := meta("og:site_name"); site != "" {
+		parts = append(parts, site)
+	}
+	published := meta("article:published_time", "date", "dc.date")
+	if published == "" {
+		if n := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Time }); n != nil {
+			published = attr(n, "datetime")
+		}
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, published); err == nil {
+			parts = append(parts, t.Format("Jan 2, 2006"))
+			break
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// removeLinkDense removes the blocks of an article that are mostly links,
+// like lists of related posts or tags.
+func removeLinkDense(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && linkDenseBlocks[c.DataAtom] && linkDensity(c) > 0.5 {
+			n.RemoveChild(c)
+		} else {
+			removeLinkDense(c)
+		}
+		c = next
+	}
+}
+
+// linkDensity returns how much of the text of an element is links.
+func linkDensity(n *html.Node) float64 {
+	text := len(strings.TrimSpace(textContent(n)))
+	if text == 0 {
+		return 0
+	}
+	links := 0
+	for d := range n.Descendants() {
+		if d.DataAtom == atom.A {
+			links += len(strings.TrimSpace(textContent(d)))
+		}
+	}
+	return float64(links) / float64(text)
+}
+
+// mainContent returns the element holding the article of a page: its
+// largest <article> or <main> element, or else the element with the most
+// paragraph text. Unlikely elements like comments are removed.
+func mainContent(doc *html.Node) *html.Node {
+	removeUnlikely(doc)
+
+	for _, a := range []atom.Atom{atom.Article, atom.Main} {
+		var best *html.Node
+		for n := range doc.Descendants() {
+			if n.DataAtom == a && (best == nil || len(textContent(n)) > len(textContent(best))) {
+				best = n
+			}
+		}
+		if best != nil && len(strings.TrimSpace(textContent(best))) > 0 {
+			return best
+		}
+	}
+	if n := findElement(doc, func(n *html.Node) bool { return attr(n, "role") == "main" }); n != nil {
+		return n
+	}
+
+	// Score the parents of paragraphs by their text, like readability does.
+	var (
+		scores     = map[*html.Node]int{}
+		candidates []*html.Node
+	)
+	add := func(n *html.Node, score int) {
+		if _, ok := scores[n]; !ok {
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	for n := range doc.Descendants() {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre {
+			continue
+		}
+		score := len(strings.TrimSpace(textContent(n)))
+		if score < 25 {
+			continue
+		}
+		if p := n.Parent; p != nil {
+			add(p, score)
+			if gp := p.Parent; gp != nil {
+				add(gp, score/2)
+			}
+		}
+	}
+	var best *html.Node
+	for _, n := range candidates {
+		if best == nil || scores[n] > scores[best] {
+			best = n
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if body := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body }); body != nil {
+		return body
+	}
+	return doc
+}
+
+// removeUnlikely removes the elements of a page that aren't content.
+func removeUnlikely(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else if c.Type == html.ElementNode {
+			if skippedElements[c.DataAtom] || unlikelyElement(c) {
+				n.RemoveChild(c)
+			} else {
+				removeUnlikely(c)
+			}
+		}
+		c = next
+	}
+}
+
+func unlikelyElement(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Article, atom.Main:
+		return false
+	}
+	if attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyContent.MatchString(names) && !likelyContent.MatchString(names)
+}
+
+type htmlConverter struct {
+	base *url.URL
+}
+
+func (c *htmlConverter) resolve(ref string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if c.base != nil {
+		u = c.base.ResolveReference(u)
+	}
+	return u, nil
+}
+
+// link returns the URL of a link or image, resolved against the page.
+func (c *htmlConverter) link(ref string) string {
+	u, err := c.resolve(ref)
+	if err != nil {
+		return ref
+	}
+	s := u.String()
+	if strings.ContainsAny(s, " ()") {
+		return "<" + s + ">"
+	}
+	return s
+}
+
+// blocks converts the children of an element to markdown blocks.
+func (c *htmlConverter) blocks(n *html.Node) []string {
+	var (
+		out  []string
+		para strings.Builder
+	)
+	flush := func() {
+		if s := trimInline(para.String()); s != "" {
+			out = append(out, s)
+		}
+		para.Reset()
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if ch.Type == html.ElementNode && htmlBlocks[ch.DataAtom] {
+			flush()
+			out = append(out, c.block(ch)...)
+			continue
+		}
+		para.WriteString(c.inline(ch))
+	}
+	flush()
+	return out
+}
+
+// block converts a block element.
+func (c *htmlConverter) block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.ReplaceAll(trimInline(c.inlineChildren(n)), "\n", " ")
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", int(n.Data[1]-'0')) + " " + strings.TrimSuffix(text, `\`)}
+	case atom.Pre:
+		return []string{strings.Join(fencedCode(codeLanguage(n), strings.Split(strings.TrimRight(textContent(n), "\n"), "\n")), "\n")}
+	case atom.Blockquote:
+		blocks := c.blocks(n)
+		if len(blocks) == 0 {
+			return nil
+		}
+		return []string{strings.Join(blockquote(strings.Split(strings.Join(blocks, "\n\n"), "\n")), "\n")}
+	case atom.Ul, atom.Ol:
+		if list := c.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case atom.Table:
+		if table := c.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Dt:
+		return []string{trimInline(c.inlineChildren(n))}
+	case atom.Dd:
+		return []string{": " + strings.Join(c.blocks(n), " ")}
+	case atom.Dl:
+		blocks := c.blocks(n)
+		// Terms and their definitions are not separated by blank lines.
+		var b strings.Builder
+		for i, s := range blocks {
+			if i > 0 {
+				if strings.HasPrefix(s, ": ") {
+					b.WriteString("\n")
+				} else {
+					b.WriteString("\n\n")
+				}
+			}
+			b.WriteString(s)
+		}
+		return []string{b.String()}
+	case atom.Figcaption:
+		if text := trimInline(c.inlineChildren(n)); text != "" {
+			return []string{"*" + text + "*"}
+		}
+		return nil
+	}
+	return c.blocks(n)
+}
+
+// list converts a list, with nested lists indented.
+func (c *htmlConverter) list(n *html.Node) string {
+	var items []string
+	i := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(i) + ". "
+			i++
+		}
+		blocks := c.blocks(li)
+		if len(blocks) == 0 {
+			continue
+		}
+		lines := strings.Split(strings.Join(blocks, "\n"), "\n")
+		for j := range lines {
+			if j == 0 {
+				lines[j] = marker + lines[j]
+			} else if lines[j] != "" {
+				lines[j] = strings.Repeat(" ", len(marker)) + lines[j]
+			}
+		}
+		items = append(items, strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table converts a table, the first row of which is used as its header.
+func (c *htmlConverter) table(n *html.Node) string {
+	var rows [][]string
+	cols := 0
+	for tr := range n.Descendants() {
+		if tr.DataAtom != atom.Tr {
+			continue
+		}
+		var row []string
+		for td := tr.FirstChild; td != nil; td = td.NextSibling {
+			if td.DataAtom != atom.Td && td.DataAtom != atom.Th {
+				continue
+			}
+			cell := strings.Join(c.blocks(td), " ")
+			cell = strings.ReplaceAll(strings.ReplaceAll(cell, "\n", " "), "|", `\|`)
+			row = append(row, cell)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+			cols = max(cols, len(row))
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var out []string
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		out = append(out, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			out = append(out, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// inline converts an inline node.
+func (c *htmlConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return EscapeMarkdown(htmlSpace.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skippedElements[n.DataAtom] {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\\\n"
+	case atom.Img:
+		src := attr(n, "src")
+		if src == "" {
+			src = attr(n, "data-src")
+		}
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return ""
+		}
+		return "![" + EscapeMarkdown(attr(n, "alt")) + "](" + c.link(src) + ")"
+	case atom.A:
+		text := trimInline(c.inlineChildren(n))
+		href := attr(n, "href")
+		if href == "" || strings.HasPrefix(href, "javascript:") || text == "" {
+			return text
+		}
+		return "[" + strings.ReplaceAll(text, "\n", " ") + "](" + c.link(href) + ")"
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return codeSpan(htmlSpace.ReplaceAllString(textContent(n), " "))
+	case atom.Strong, atom.B:
+		return wrapInline(c.inlineChildren(n), "**")
+	case atom.Em, atom.I, atom.Cite:
+		return wrapInline(c.inlineChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(c.inlineChildren(n), "~~")
+	}
+	return c.inlineChildren(n)
+}
+
+func (c *htmlConverter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if ch.Type == html.ElementNode && htmlBlocks[ch.DataAtom] {
+			// blocks within inline elements, like a <div> in a link
+			b.WriteString(" " + strings.Join(c.blocks(ch), " ") + " ")
+			continue
+		}
+		b.WriteString(c.inline(ch))
+	}
+	return b.String()
+}
+
+// wrapInline wraps text in emphasis markers, keeping the surrounding spaces
+// outside of them.
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := text[:strings.Index(text, trimmed)]
+	end := text[len(start)+len(trimmed):]
+	return start + marker + trimmed + marker + end
+}
+
+// codeSpan returns text as a code span, delimited by more backticks than
+// it contains.
+func codeSpan(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if len(fence) > 1 {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// trimInline trims the spaces around the lines of converted inline content.
+func trimInline(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = strings.TrimSpace(strings.Join(lines, "\n"))
+	return strings.TrimSuffix(s, `\`)
+}
+
+// codeLanguage returns the language of a <pre> element, from the classes of
+// it or its <code> element.
+func codeLanguage(pre *html.Node) string {
+	classes := attr(pre, "class")
+	if code := findElement(pre, func(n *html.Node) bool { return n.DataAtom == atom.Code }); code != nil {
+		classes += " " + attr(code, "class")
+	}
+	if m := htmlLang.FindStringSubmatch(classes); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// textContent returns the text of a node and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			b.WriteString(d.Data)
+		}
+	}
+	return b.String()
+}
+
+// findElement returns the first element within a node matching a condition.
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && match(d) {
+			return d
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/yuin/goldmark/ast"
+)
+
+// LanguageDetection enables guessing the language of code blocks without an
+// info string, so they still get highlighted.
+var LanguageDetection bool
+
+// shebangLanguages maps interpreters to the languages of their scripts.
+var shebangLanguages = map[string]string{
+	"sh":      "bash",
+	"bash":    "bash",
+	"zsh":     "zsh",
+	"python":  "python",
+	"python3": "python",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"lua":     "lua",
+	"pwsh":    "powershell",
+}
+
+// languageRules are keyword patterns of common languages, in order of
+// precedence.
+var languageRules = []struct {
+	language string
+	pattern  *regexp.Regexp
+}{
+	{"php", regexp.MustCompile(`^<\?php`)},
+	{"xml", regexp.MustCompile(`^<\?xml`)},
+	{"html", regexp.MustCompile(`(?i)^<(!doctype html|html|head|body|div|p|span|a|ul|table)[\s>]`)},
+	{"go", regexp.MustCompile(`(?m)^package \w+$|^func (\(\w+ \*?\w+\) )?\w+\(`)},
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+|\blet mut \w+|^use \w+::`)},
+	{"c", regexp.MustCompile(`(?m)^#include\s*[<"]`)},
+	{"java", regexp.MustCompile(`(?m)^\s*public (static |final |abstract )*(class|interface|enum|void) `)},
+	{"python", regexp.MustCompile(`(?m)^\s*(def \w+\(.*\)( -> .+)?:$|from [\w.]+ import |elif .*:$)`)},
+	{"docker", regexp.MustCompile(`(?m)^FROM \S+`)},
+	{"console", regexp.MustCompile(`(?m)^\$ \S`)},
+	{"javascript", regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = |^\s*function\s*\w*\(|console\.log\(|^import .* from ['"]`)},
+	{"sql", regexp.MustCompile(`(?i)^\s*(select .+ from |insert into |create table |update \w+ set |delete from )`)},
+}
+
+// yamlLine matches the lines of a YAML document: keys, list items and
+// comments.
+var yamlLine = regexp.MustCompile(`^\s*(#.*|- .*|-|[a-z_][\w.-]*:( .*)?)$`)
+
+// DetectLanguage guesses the language of a piece of code from its shebang,
+// keywords and shape, falling back to chroma's analysers. It returns an empty
+// string if the language couldn't be determined.
+func DetectLanguage(code string) string {
+	code = strings.TrimLeft(code, "\n")
+	if lang := shebangLanguage(code); lang != "" {
+		return lang
+	}
+	for _, rule := range languageRules {
+		if rule.pattern.MatchString(code) {
+			return rule.language
+		}
+	}
+
+	trimmed := strings.TrimSpace(code)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if json.Valid([]byte(trimmed)) {
+			return "json"
+		}
+	}
+	if isYAML(trimmed) {
+		return "yaml"
+	}
+
+	if l := lexers.Analyse(code); l != nil {
+		return strings.ToLower(l.Config().Name)
+	}
+	return ""
+}
+
+func shebangLanguage(code string) string {
+	line, _, _ := strings.Cut(code, "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// #!/usr/bin/env -S python3 -u
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interpreter = f
+				break
+			}
+		}
+	}
+	return shebangLanguages[interpreter]
+}
+
+// isYAML reports whether code looks like a YAML document with at least two
+// keys.
+func isYAML(code string) bool {
+	var keys int
+	for _, line := range strings.Split(code, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !yamlLine.MatchString(line) {
+			return false
+		}
+		if strings.Contains(line, ":") {
+			keys++
+		}
+	}
+	return keys >= 2
+}
+
+// fenceMarker matches the backticks or tildes opening a fenced code block.
+var fenceMarker = regexp.MustCompile("```+|~~~+")
+
+// LabelCodeBlocks adds the detected language to fenced code blocks without an
+// info string, so they are highlighted as if they had been labeled.
+func LabelCodeBlocks(source []byte) []byte {
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if block.Info != nil || block.Lines().Len() == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+
+		lang := DetectLanguage(codeBlockText(block, source))
+		if lang == "" {
+			return ast.WalkSkipChildren, nil
+		}
+
+		// The opening fence is on the line before the code.
+		start := lineStart(source, block.Lines().At(0).Start)
+		fenceStart := lineStart(source, max(0, start-1))
+		if loc := fenceMarker.FindIndex(source[fenceStart:start]); loc != nil {
+			offset := fenceStart + loc[1]
+			edits = append(edits, textEdit{offset, offset, lang})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return applyEdits(source, edits)
+}
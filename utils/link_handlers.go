@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// LinkHandlers are commands opening links, by the scheme of the link, like
+// "zoommtg", or by a pattern of it, like "*.pdf" or "https://github.com/*".
+// The link is given as their last argument. "glow" opens links in glow
+// itself. Links without a handler are opened with the system's opener.
+var LinkHandlers map[string]string
+
+// GlowHandler is the handler opening links in glow itself.
+const GlowHandler = "glow"
+
+// LinkHandler returns the command opening a link: the handler of the most
+// specific, that is longest, pattern it matches, or else of its scheme.
+// Local files match by their path, and by the "file" scheme.
+func LinkHandler(link string) string {
+	lower := strings.ToLower(link)
+	patterns := make([]string, 0, len(LinkHandlers))
+	for p := range LinkHandlers {
+		if strings.ContainsAny(p, "*?[") {
+			patterns = append(patterns, p)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, p := range patterns {
+		if matchLink(strings.ToLower(p), lower) {
+			return LinkHandlers[p]
+		}
+	}
+
+	scheme := "file"
+	if u, err := url.Parse(link); err == nil && len(u.Scheme) > 1 {
+		// Windows paths, like C:\docs, parse with a single letter scheme.
+		scheme = strings.ToLower(u.Scheme)
+	}
+	for s, command := range LinkHandlers {
+		if strings.EqualFold(s, scheme) {
+			return command
+		}
+	}
+	return ""
+}
+
+// matchLink reports whether a link matches a pattern. Patterns without a
+// slash, like *.pdf, match the last element of the link's path.
+func matchLink(pattern, link string) bool {
+	if ok, _ := path.Match(pattern, link); ok {
+		return true
+	}
+	if strings.Contains(pattern, "/") {
+		return false
+	}
+	p := link
+	if u, err := url.Parse(link); err == nil && u.Path != "" {
+		p = u.Path
+	}
+	ok, _ := path.Match(pattern, path.Base(strings.ReplaceAll(p, `\`, "/")))
+	return ok
+}
+
+// LinkCommand returns the command opening a link with its handler, or the
+// system's opener if it has none. Links handled by glow are opened with the
+// running glow, given glowArgs, like --tui.
+func LinkCommand(link string, glowArgs ...string) (*exec.Cmd, error) {
+	args := strings.Fields(LinkHandler(link))
+	if len(args) == 0 {
+		args = systemOpener()
+	}
+	if len(args) == 0 {
+		return nil, errors.New("no command to open links with, set one in linkHandlers")
+	}
+	if args[0] == GlowHandler {
+		if exe, err := os.Executable(); err == nil {
+			args[0] = exe
+		}
+		args = append(args, glowArgs...)
+	}
+	args = append(args, link)
+	return exec.Command(args[0], args[1:]...), nil //nolint:gosec
+}
+
+// systemOpener returns the command opening files and URLs with the
+// application the system associates with them.
+func systemOpener() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler"}
+	default:
+		if _, err := exec.LookPath("xdg-open"); err == nil {
+			return []string{"xdg-open"}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,463 @@
+package utils
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Markdown is the goldmark configuration glow uses for parsing. It mirrors the
+// extensions enabled by glamour so that positions and node types match what
+// ends up being rendered.
+var Markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		extension.DefinitionList,
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+	),
+)
+
+// ParseMarkdown parses markdown source into a goldmark AST.
+func ParseMarkdown(source []byte) ast.Node {
+	return Markdown.Parser().Parse(text.NewReader(source))
+}
+
+// NodeText returns the plain text content of a node and its descendants.
+func NodeText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *ast.AutoLink:
+			b.Write(n.URL(source))
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// Tables returns the cell values of all tables in the markdown source. The
+// first row of every table is its header.
+func Tables(source []byte) [][][]string {
+	var tables [][][]string
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		table, ok := n.(*east.Table)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var rows [][]string
+		for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+			var cells []string
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				cells = append(cells, strings.TrimSpace(NodeText(cell, source)))
+			}
+			rows = append(rows, cells)
+		}
+		tables = append(tables, rows)
+		return ast.WalkSkipChildren, nil
+	})
+	return tables
+}
+
+// Heading is a heading found in a markdown document.
+type Heading struct {
+	Level int
+	Text  string
+	// Slug is the GitHub-compatible anchor of the heading.
+	Slug string
+	// Line is the zero-based source line the heading starts on.
+	Line int
+}
+
+// Headings returns the headings of a markdown document in order of
+// appearance.
+func Headings(source []byte) []Heading {
+	var headings []Heading
+	slugs := NewSlugger()
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		title := strings.TrimSpace(NodeText(h, source))
+		headings = append(headings, Heading{
+			Level: h.Level,
+			Text:  title,
+			Slug:  slugs.Slug(title),
+			Line:  nodeLine(h, source),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return headings
+}
+
+// Slugger generates unique GitHub-compatible heading anchors.
+type Slugger struct {
+	seen map[string]int
+}
+
+// NewSlugger returns a new Slugger.
+func NewSlugger() *Slugger {
+	return &Slugger{seen: map[string]int{}}
+}
+
+// Slug returns the anchor for a heading title. Repeated titles get a numeric
+// suffix, like on GitHub.
+func (s *Slugger) Slug(title string) string {
+	slug := Slug(title)
+	n, ok := s.seen[slug]
+	s.seen[slug] = n + 1
+	if ok {
+		return slug + "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// Generate implements goldmark's parser.IDs, so rendered headings get the
+// same anchors as those returned by Headings.
+func (s *Slugger) Generate(value []byte, _ ast.NodeKind) []byte {
+	return []byte(s.Slug(string(value)))
+}
+
+// Put implements goldmark's parser.IDs.
+func (s *Slugger) Put(value []byte) {
+	if _, ok := s.seen[string(value)]; !ok {
+		s.seen[string(value)] = 1
+	}
+}
+
+// Slug returns the GitHub-compatible anchor for a heading title: lowercased,
+// punctuation removed and spaces replaced by hyphens.
+func Slug(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// Link is a link or image found in a markdown document.
+type Link struct {
+	Text        string
+	Destination string
+	Image       bool
+	// Line is the zero-based source line the link is on.
+	Line int
+}
+
+// Links returns all links, autolinks and images of a markdown document.
+func Links(source []byte) []Link {
+	var links []Link
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Link:
+			links = append(links, Link{
+				Text:        NodeText(n, source),
+				Destination: string(n.Destination),
+				Line:        nodeLine(n, source),
+			})
+		case *ast.Image:
+			links = append(links, Link{
+				Text:        NodeText(n, source),
+				Destination: string(n.Destination),
+				Image:       true,
+				Line:        nodeLine(n, source),
+			})
+		case *ast.AutoLink:
+			url := string(n.URL(source))
+			if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(url, "mailto:") {
+				url = "mailto:" + url
+			}
+			links = append(links, Link{
+				Text:        string(n.Label(source)),
+				Destination: url,
+				Line:        nodeLine(n, source),
+			})
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// nodeLine returns the zero-based source line a node starts on. Inline nodes
+// don't carry positions, so we use their first text segment or, failing that,
+// the enclosing block.
+func nodeLine(n ast.Node, source []byte) int {
+	offset := -1
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if t, ok := c.(*ast.Text); ok {
+			offset = t.Segment.Start
+			return ast.WalkStop, nil
+		}
+		if c.Type() != ast.TypeInline && c.Lines().Len() > 0 {
+			offset = c.Lines().At(0).Start
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	for p := n; offset < 0 && p != nil; p = p.Parent() {
+		if p.Type() != ast.TypeInline && p.Lines().Len() > 0 {
+			offset = p.Lines().At(0).Start
+		}
+	}
+	if offset < 0 {
+		return 0
+	}
+	return bytes.Count(source[:min(offset, len(source))], []byte("\n"))
+}
+
+// Section returns the part of a markdown document under the heading matching
+// the query, including the heading itself and its subsections. Headings match
+// by title or anchor, case-insensitively. Nested headings can be given as a
+// path like "Usage/Docker".
+func Section(source []byte, query string) ([]byte, bool) {
+	headings := Headings(source)
+	i := findSection(headings, []string{query})
+	if i < 0 {
+		i = findSection(headings, strings.Split(query, "/"))
+	}
+	if i < 0 {
+		return nil, false
+	}
+
+	lines := strings.SplitAfter(string(source), "\n")
+	end := len(lines)
+	for _, h := range headings[i+1:] {
+		if h.Level <= headings[i].Level {
+			end = h.Line
+			break
+		}
+	}
+	return []byte(strings.Join(lines[headings[i].Line:end], "")), true
+}
+
+// findSection returns the index of the heading matching the last element of
+// path, nested under headings matching the elements before it.
+func findSection(headings []Heading, path []string) int {
+	start, end := 0, len(headings)
+	found := -1
+	for _, name := range path {
+		name = strings.TrimSpace(name)
+		found = -1
+		for i := start; i < end; i++ {
+			if strings.EqualFold(headings[i].Text, name) || headings[i].Slug == Slug(name) {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return -1
+		}
+
+		// Look for the next element among the subsections.
+		start, end = found+1, len(headings)
+		for i := start; i < len(headings); i++ {
+			if headings[i].Level <= headings[found].Level {
+				end = i
+				break
+			}
+		}
+	}
+	return found
+}
+
+// TableOfContents returns the headings to list in a table of contents, down
+// to depth levels below the top-most heading level. A depth of 0 includes all
+// headings.
+func TableOfContents(source []byte, depth int) []Heading {
+	headings := Headings(source)
+	if depth <= 0 || len(headings) == 0 {
+		return headings
+	}
+
+	base := headings[0].Level
+	for _, h := range headings {
+		base = min(base, h.Level)
+	}
+	toc := headings[:0]
+	for _, h := range headings {
+		if h.Level-base < depth {
+			toc = append(toc, h)
+		}
+	}
+	return toc
+}
+
+// HardBreaks turns every line break within a paragraph into a hard break, so
+// that text isn't reflowed.
+var HardBreaks bool
+
+// PrepareMarkdown applies glow's extensions to markdown before rendering:
+// code blocks with fence handlers and diagrams are drawn, aliases are
+// expanded, redacted text is replaced and, if enabled, the language of code
+// blocks is detected and line breaks are kept.
+func PrepareMarkdown(source []byte) []byte {
+	source = RenderFences(source)
+	source = RenderDiagrams(source)
+	source = ExpandAliases(source)
+	source = Redact(source)
+	if LanguageDetection {
+		source = LabelCodeBlocks(source)
+	}
+	if HardBreaks {
+		source = HardLineBreaks(source)
+	}
+	return source
+}
+
+// HardLineBreaks turns soft line breaks into hard ones, like GitHub does for
+// comments. Headings and code are left alone.
+func HardLineBreaks(source []byte) []byte {
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading, *ast.CodeSpan, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if !n.SoftLineBreak() {
+				break
+			}
+			// The line may end with spaces, which aren't part of the text.
+			if end := bytes.IndexByte(source[n.Segment.Stop:], '\n'); end >= 0 {
+				at := n.Segment.Stop + end
+				if at > 0 && source[at-1] == '\r' {
+					at--
+				}
+				edits = append(edits, textEdit{at, at, "\\"})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return applyEdits(source, edits)
+}
+
+// PrepareDefinitionLists makes the terms of definition lists bold and keeps
+// the lines of multi-line definitions indented, which glamour doesn't do on
+// its own. It is meant to be applied right before rendering with glamour.
+func PrepareDefinitionLists(source []byte) []byte {
+	indent := strings.Repeat("\u00a0", len(DefinitionIndent))
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *east.DefinitionTerm:
+			if n.Lines().Len() > 0 {
+				start := n.Lines().At(0).Start
+				stop := n.Lines().At(n.Lines().Len() - 1).Stop
+				for stop > start && unicode.IsSpace(rune(source[stop-1])) {
+					stop--
+				}
+				edits = append(edits, textEdit{start, start, "**"}, textEdit{stop, stop, "**"})
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *east.DefinitionDescription:
+			// Line breaks become hard breaks followed by the indentation,
+			// as do the starts of further paragraphs.
+			var indentNext bool
+			_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+				if !entering {
+					return ast.WalkContinue, nil
+				}
+				if c.Type() == ast.TypeBlock && c.Parent() == n && c != n.FirstChild() {
+					indentNext = true
+				}
+				t, ok := c.(*ast.Text)
+				if !ok {
+					return ast.WalkContinue, nil
+				}
+				if indentNext {
+					edits = append(edits, textEdit{t.Segment.Start, t.Segment.Start, indent})
+				}
+				indentNext = t.SoftLineBreak() || t.HardLineBreak()
+				if indentNext {
+					end := bytes.IndexByte(source[t.Segment.Stop:], '\n')
+					if end >= 0 {
+						edits = append(edits, textEdit{t.Segment.Stop, t.Segment.Stop + end, "\\"})
+					}
+				}
+				return ast.WalkContinue, nil
+			})
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return applyEdits(source, edits)
+}
+
+// codeBlockText returns the content of a code block.
+func codeBlockText(block ast.Node, source []byte) string {
+	var b strings.Builder
+	for i := range block.Lines().Len() {
+		line := block.Lines().At(i)
+		b.Write(line.Value(source))
+	}
+	return b.String()
+}
+
+// lineStart returns the offset of the start of the line containing offset.
+func lineStart(source []byte, offset int) int {
+	return bytes.LastIndexByte(source[:offset], '\n') + 1
+}
+
+// textEdit replaces source[start:stop] with text.
+type textEdit struct {
+	start, stop int
+	text        string
+}
+
+// applyEdits applies non-overlapping edits, given in order of their position,
+// to the source.
+func applyEdits(source []byte, edits []textEdit) []byte {
+	if len(edits) == 0 {
+		return source
+	}
+	var b bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		b.Write(source[last:e.start])
+		b.WriteString(e.text)
+		last = e.stop
+	}
+	b.Write(source[last:])
+	return b.Bytes()
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	orgHeadline = regexp.MustCompile(`^(\*+)\s+(.*?)(\s+:[\w@#%:]+:)?\s*$`)
+	orgKeyword  = regexp.MustCompile(`^\s*#\+(\w+):\s*(.*)$`)
+	orgBegin    = regexp.MustCompile(`(?i)^\s*#\+begin_(\w+)\s*(\S*)`)
+	orgDrawer   = regexp.MustCompile(`^\s*:[\w-]+:\s*$`)
+	orgFixed    = regexp.MustCompile(`^(\s*):( |$)`)
+	orgOrdered  = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+`)
+	orgCheckbox = regexp.MustCompile(`^(\s*[-+*]\s+|\s*\d+\.\s+)\[([ X-])\]`)
+	orgTableSep = regexp.MustCompile(`^\s*\|-`)
+	orgCode     = regexp.MustCompile(`(^|[\s(\[{'"])[=~]([^\s=~](?:.*?[^\s])?)[=~]($|[\s)\]}.,;:!?'"-])`)
+
+	orgInlineRules = []inlineRule{
+		// [[target][description]] and [[target]]
+		{re: regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`), fn: func(m []string) string {
+			target := strings.TrimPrefix(m[1], "file:")
+			if m[2] == "" {
+				if isImagePath(target) {
+					return "![](" + target + ")"
+				}
+				return "[" + m[1] + "](" + target + ")"
+			}
+			return "[" + m[2] + "](" + target + ")"
+		}},
+		{re: regexp.MustCompile(`(^|[\s(\[{'"])\*([^\s*](?:[^*]*[^\s*])?)\*($|[\s)\]}.,;:!?'"-])`), repl: "$1**$2**$3"},
+		{re: regexp.MustCompile(`(^|[\s(\[{'"])/([^\s/](?:[^/]*[^\s/])?)/($|[\s)\]}.,;:!?'"-])`), repl: "$1*$2*$3"},
+		{re: regexp.MustCompile(`(^|[\s(\[{'"])\+([^\s+](?:[^+]*[^\s+])?)\+($|[\s)\]}.,;:!?'"-])`), repl: "$1~~$2~~$3"},
+	}
+)
+
+// isImagePath returns whether a link target is an image.
+func isImagePath(target string) bool {
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		target = target[:i]
+	}
+	switch strings.ToLower(path.Ext(target)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp":
+		return true
+	}
+	return false
+}
+
+// OrgToMarkdown converts an Org mode document to markdown. Headlines, the
+// title, inline markup, links, lists, tables and blocks are converted;
+// other keywords, drawers and comments are left out.
+func OrgToMarkdown(source []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+
+	// Headlines are one level below the title, if there is one.
+	shift := 0
+	for _, l := range lines {
+		if m := orgKeyword.FindStringSubmatch(l); m != nil && strings.EqualFold(m[1], "title") {
+			shift = 1
+			break
+		}
+	}
+
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := orgBegin.FindStringSubmatch(line); m != nil {
+			kind := strings.ToLower(m[1])
+			var body []string
+			for i++; i < len(lines); i++ {
+				if strings.EqualFold(strings.TrimSpace(lines[i]), "#+end_"+kind) {
+					break
+				}
+				body = append(body, lines[i])
+			}
+			out = appendBlock(out, orgBlock(kind, m[2], dedentLines(body)))
+			continue
+		}
+		if m := orgKeyword.FindStringSubmatch(line); m != nil {
+			if strings.EqualFold(m[1], "title") {
+				out = append(out, "# "+orgInline(m[2]), "")
+			}
+			continue
+		}
+		if m := orgHeadline.FindStringSubmatch(line); m != nil {
+			level := min(len(m[1])+shift, 6)
+			out = append(out, "", strings.Repeat("#", level)+" "+orgInline(m[2]), "")
+			continue
+		}
+		if orgDrawer.MatchString(line) {
+			// drawers like :PROPERTIES: ... :END:
+			for ; i < len(lines); i++ {
+				if strings.EqualFold(strings.TrimSpace(lines[i]), ":end:") {
+					break
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "# ") || strings.TrimSpace(line) == "#" {
+			continue
+		}
+		if orgFixed.MatchString(line) {
+			var body []string
+			for ; i < len(lines) && orgFixed.MatchString(lines[i]); i++ {
+				body = append(body, orgFixed.ReplaceAllString(lines[i], "$1"))
+			}
+			i--
+			out = appendBlock(out, fencedCode("", dedentLines(body)))
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "|") {
+			var table []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				table = append(table, strings.TrimSpace(lines[i]))
+			}
+			i--
+			out = appendBlock(out, orgTable(table))
+			continue
+		}
+		if strings.Trim(strings.TrimSpace(line), "-") == "" && len(strings.TrimSpace(line)) >= 5 {
+			out = append(out, "---")
+			continue
+		}
+
+		line = orgOrdered.ReplaceAllString(line, "${1}${2}. ")
+		line = orgCheckbox.ReplaceAllStringFunc(line, func(s string) string {
+			return strings.Replace(strings.Replace(s, "[X]", "[x]", 1), "[-]", "[ ]", 1)
+		})
+		out = append(out, orgInline(line))
+	}
+	return joinLines(out)
+}
+
+// orgBlock converts a #+BEGIN_ block.
+func orgBlock(kind, arg string, body []string) []string {
+	switch kind {
+	case "src":
+		return fencedCode(arg, body)
+	case "example", "verse":
+		return fencedCode("", body)
+	case "quote":
+		var quote []string
+		for _, l := range body {
+			quote = append(quote, orgInline(l))
+		}
+		return blockquote(quote)
+	case "comment", "export":
+		return nil
+	}
+	var out []string
+	for _, l := range body {
+		out = append(out, orgInline(l))
+	}
+	if title, ok := admonitionTitles[kind]; ok {
+		return admonition(title, out)
+	}
+	return out
+}
+
+// orgTable converts the rows of a table. Horizontal rules become the
+// separator of the header row, which is added if missing.
+func orgTable(rows []string) []string {
+	var (
+		out    []string
+		header bool
+	)
+	for _, r := range rows {
+		if orgTableSep.MatchString(r) {
+			if !header && len(out) == 1 {
+				out = append(out, tableSeparator(out[0]))
+				header = true
+			}
+			continue
+		}
+		out = append(out, orgInline(r))
+	}
+	if !header && len(out) > 0 {
+		out = append(out[:1], append([]string{tableSeparator(out[0])}, out[1:]...)...)
+	}
+	return out
+}
+
+// tableSeparator returns the separator of a markdown table header row.
+func tableSeparator(row string) string {
+	cells := strings.Count(strings.Trim(strings.TrimSpace(row), "|"), "|") + 1
+	return "|" + strings.Repeat(" --- |", cells)
+}
+
+func orgInline(line string) string {
+	return convertInline(line, orgCode, "$1`$2`$3", orgInlineRules)
+}
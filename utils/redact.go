@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedactReplacement is what redacted text is replaced with, unless
+// configured otherwise.
+const DefaultRedactReplacement = "[REDACTED]"
+
+var (
+	// redactPattern matches the text redacted from documents and output,
+	// like secrets or personal data, and redactReplacement replaces it
+	redactPattern     *regexp.Regexp
+	redactReplacement = DefaultRedactReplacement
+)
+
+// ansiSequence matches the escape sequences of terminal output: CSI ones,
+// like colors, and OSC ones, like hyperlinks.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// SetRedactions sets the regular expressions of the text Redact replaces,
+// and what it's replaced with.
+func SetRedactions(patterns []string, replacement string) error {
+	redactPattern, redactReplacement = nil, replacement
+	if len(patterns) == 0 {
+		return nil
+	}
+	alts := make([]string, len(patterns))
+	for i, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		alts[i] = "(?:" + p + ")"
+	}
+	redactPattern = regexp.MustCompile(strings.Join(alts, "|"))
+	return nil
+}
+
+// Redact replaces the text matching the redact patterns. Terminal output is
+// matched as it's shown, without the escape sequences styling it, which
+// may split the text; those within a match are kept, so styles carry on.
+func Redact(b []byte) []byte {
+	if redactPattern == nil {
+		return b
+	}
+	seqs := ansiSequence.FindAllIndex(b, -1)
+	plain := make([]byte, 0, len(b))
+	// at maps the bytes of plain to their index in b.
+	at := make([]int, 0, len(b))
+	prev := 0
+	for _, s := range append(seqs, []int{len(b), len(b)}) {
+		for i := prev; i < s[0]; i++ {
+			plain = append(plain, b[i])
+			at = append(at, i)
+		}
+		prev = s[1]
+	}
+
+	matches := redactPattern.FindAllIndex(plain, -1)
+	if len(matches) == 0 {
+		return b
+	}
+	var out bytes.Buffer
+	prev = 0
+	for _, m := range matches {
+		if m[0] == m[1] {
+			continue
+		}
+		start, end := at[m[0]], at[m[1]-1]+1
+		out.Write(b[prev:start])
+		out.WriteString(redactReplacement)
+		for _, s := range seqs {
+			if s[0] >= start && s[1] <= end {
+				out.Write(b[s[0]:s[1]])
+			}
+		}
+		prev = end
+	}
+	out.Write(b[prev:])
+	return out.Bytes()
+}
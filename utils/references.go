@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// NumberLinks replaces the destinations of links with numbers, like
+// text[3], and appends a references section listing the numbered URLs.
+// Relative destinations are resolved against baseURL, if given. Links to
+// anchors and links wrapping images are left alone.
+func NumberLinks(source []byte, baseURL string) []byte {
+	edits, urls := numberLinks(source, baseURL)
+	if len(urls) == 0 {
+		return source
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n## References\n\n")
+	for i, u := range urls {
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			// Other URLs aren't autolinked, so they're read as markdown.
+			u = EscapeMarkdown(u)
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, u)
+	}
+	out := bytes.TrimRight(applyEdits(source, edits), "\n")
+	return append(out, b.String()...)
+}
+
+// LinkReferences returns the URLs NumberLinks numbers, in order.
+func LinkReferences(source []byte, baseURL string) []string {
+	_, urls := numberLinks(source, baseURL)
+	return urls
+}
+
+func numberLinks(source []byte, baseURL string) ([]textEdit, []string) {
+	var (
+		edits   []textEdit
+		urls    []string
+		numbers = map[string]int{}
+	)
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan, *ast.RawHTML, *ast.AutoLink, *ast.Image:
+			return ast.WalkSkipChildren, nil
+		case *ast.Link:
+			dest := string(n.Destination)
+			if dest == "" || strings.HasPrefix(dest, "#") {
+				return ast.WalkSkipChildren, nil
+			}
+			start, closing, stop, ok := linkRange(n, source)
+			if !ok {
+				return ast.WalkSkipChildren, nil
+			}
+
+			dest = ResolveRelativeURL(baseURL, dest)
+			num, seen := numbers[dest]
+			if !seen {
+				urls = append(urls, dest)
+				num = len(urls)
+				numbers[dest] = num
+			}
+			edits = append(edits, textEdit{start, stop, fmt.Sprintf(`%s\[%d\]`, source[start+1:closing], num)})
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return edits, urls
+}
+
+// linkRange returns the range of a link in the source, from its opening
+// bracket to the end of its destination or label, and where its text ends.
+func linkRange(link *ast.Link, source []byte) (start, closing, stop int, ok bool) {
+	first, last := -1, -1
+	_ = ast.Walk(link, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, isImage := n.(*ast.Image); isImage {
+			first = -1
+			return ast.WalkStop, nil
+		}
+		if t, isText := n.(*ast.Text); entering && isText {
+			if first < 0 {
+				first = t.Segment.Start
+			}
+			last = t.Segment.Stop
+		}
+		return ast.WalkContinue, nil
+	})
+	if first < 0 {
+		return 0, 0, 0, false
+	}
+
+	start = bytes.LastIndexByte(source[:first], '[')
+	end := bytes.IndexByte(source[last:], ']')
+	if start < 0 || end < 0 {
+		return 0, 0, 0, false
+	}
+	closing = last + end
+	return start, closing, linkEnd(source, closing+1), true
+}
+
+// linkEnd returns the end of a link's destination or reference label, given
+// the offset right after the closing bracket of its text.
+func linkEnd(source []byte, i int) int {
+	if i >= len(source) {
+		return i
+	}
+	switch source[i] {
+	case '(':
+		depth := 0
+		for j := i; j < len(source); j++ {
+			switch source[j] {
+			case '\\':
+				j++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+	case '[':
+		if j := bytes.IndexByte(source[i:], ']'); j >= 0 {
+			return i + j + 1
+		}
+	}
+	return i
+}
+
+// ResolveRelativeURL resolves a link destination against a base URL the way
+// glamour does before printing it.
+func ResolveRelativeURL(baseURL, rel string) string {
+	u, err := url.Parse(rel)
+	if err != nil || u.IsAbs() {
+		return rel
+	}
+	u.Path = strings.TrimPrefix(u.Path, "/")
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return rel
+	}
+	return base.ResolveReference(u).String()
+}
+
+// EscapeMarkdown escapes characters that would otherwise be taken as markup.
+func EscapeMarkdown(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_{}[]<>()#+!|~", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
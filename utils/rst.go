@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	rstDirective  = regexp.MustCompile(`^(\s*)\.\.\s+([\w-]+(?::[\w-]+)?)::\s*(.*)$`)
+	rstTarget     = regexp.MustCompile(`^\s*\.\.\s+_([^:]+):\s*(\S*)\s*$`)
+	rstFootnote   = regexp.MustCompile(`^\s*\.\.\s+\[([^\]\s]+)\]\s+(.*)$`)
+	rstComment    = regexp.MustCompile(`^\s*\.\.(\s|$)`)
+	rstOption     = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+	rstEnumerated = regexp.MustCompile(`^(\s*)\(?(#|\d+|[a-zA-Z])[.)]\s+`)
+	rstCode       = regexp.MustCompile("``(.+?)``")
+	rstReference  = regexp.MustCompile("`([^`]+)`__?|\\b([\\w-]+)__?\\b")
+	rstTable      = regexp.MustCompile(`^\s*(\+[-=+]+\+|=+( +=+)+)\s*$`)
+
+	rstInlineRules = []inlineRule{
+		// `text <url>`_ and `text <url>`__
+		{re: regexp.MustCompile("`([^`<]*?)\\s*<([^`>]+)>`__?"), fn: func(m []string) string {
+			if m[1] == "" {
+				return "<" + m[2] + ">"
+			}
+			return "[" + m[1] + "](" + m[2] + ")"
+		}},
+		// :role:`text` and :role:`text <target>`
+		{re: regexp.MustCompile(":([\\w-]+(?::[\\w-]+)?):`([^`]+)`"), fn: func(m []string) string {
+			text := m[2]
+			if i := strings.LastIndex(text, " <"); i > 0 && strings.HasSuffix(text, ">") {
+				text = text[:i]
+			}
+			switch m[1] {
+			case "code", "literal", "command", "file", "samp", "kbd", "envvar", "program", "option":
+				return "`" + text + "`"
+			case "emphasis":
+				return "*" + text + "*"
+			case "strong":
+				return "**" + text + "**"
+			}
+			return text
+		}},
+		// footnote references: [1]_
+		{re: regexp.MustCompile(`\[([^\]\s]+)\]_`), repl: "[^$1]"},
+	}
+)
+
+// rstAdornment returns the punctuation character a section title is
+// underlined or overlined with.
+func rstAdornment(line string) (byte, bool) {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 || !strings.ContainsRune("=-~^\"'`#*+:.,_!$%&/;<>?@\\|", rune(line[0])) {
+		return 0, false
+	}
+	if strings.Trim(line, line[:1]) != "" {
+		return 0, false
+	}
+	return line[0], true
+}
+
+// RSTToMarkdown converts a reStructuredText document to markdown. Sections,
+// inline markup, links, lists, literal and code blocks, images, footnotes
+// and admonitions are converted; other directives are reduced to their
+// content.
+func RSTToMarkdown(source []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+
+	targets := map[string]string{}
+	for _, l := range lines {
+		if m := rstTarget.FindStringSubmatch(l); m != nil && m[2] != "" {
+			targets[strings.ToLower(strings.TrimSpace(m[1]))] = m[2]
+		}
+	}
+	c := rstConverter{targets: targets}
+	return joinLines(c.convert(lines))
+}
+
+type rstConverter struct {
+	targets map[string]string
+	// styles are the adornment styles of section titles, in the order they
+	// appear, which determines their levels.
+	styles []string
+}
+
+func (c *rstConverter) level(style string) int {
+	for i, s := range c.styles {
+		if s == style {
+			return min(i+1, 6)
+		}
+	}
+	c.styles = append(c.styles, style)
+	return min(len(c.styles), 6)
+}
+
+func (c *rstConverter) convert(lines []string) []string {
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// a section title with an overline and an underline
+		if a, ok := rstAdornment(line); ok && i+2 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			if b, ok := rstAdornment(lines[i+2]); ok && a == b {
+				title := c.inline(strings.TrimSpace(lines[i+1]))
+				out = append(out, strings.Repeat("#", c.level("over"+string(a)))+" "+title, "")
+				i += 2
+				continue
+			}
+		}
+		// a section title with an underline
+		if trimmed != "" && indentWidth(line) == 0 && i+1 < len(lines) && (i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			if a, ok := rstAdornment(lines[i+1]); ok && utf8.RuneCountInString(strings.TrimSpace(lines[i+1])) >= utf8.RuneCountInString(trimmed) {
+				out = append(out, strings.Repeat("#", c.level(string(a)))+" "+c.inline(trimmed), "")
+				i++
+				continue
+			}
+		}
+		// a transition
+		if _, ok := rstAdornment(line); ok && len(trimmed) >= 4 && (i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			out = append(out, "---")
+			continue
+		}
+
+		// tables keep their layout in a code block
+		if rstTable.MatchString(line) {
+			var table []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				table = append(table, lines[i])
+			}
+			out = appendBlock(out, fencedCode("", dedentLines(table)))
+			continue
+		}
+
+		if m := rstDirective.FindStringSubmatch(line); m != nil {
+			body, next := indentedBlock(lines, i+1, indentWidth(line))
+			out = append(out, c.directive(strings.ToLower(m[2]), strings.TrimSpace(m[3]), body)...)
+			i = next - 1
+			continue
+		}
+		if m := rstFootnote.FindStringSubmatch(line); m != nil {
+			body, next := indentedBlock(lines, i+1, indentWidth(line))
+			note := "[^" + m[1] + "]: " + c.inline(m[2])
+			for _, l := range trimBlank(body) {
+				note += " " + c.inline(strings.TrimSpace(l))
+			}
+			out = append(out, note)
+			i = next - 1
+			continue
+		}
+		if rstComment.MatchString(line) {
+			// targets and comments
+			_, next := indentedBlock(lines, i+1, indentWidth(line))
+			i = next - 1
+			continue
+		}
+
+		// a paragraph introducing a literal block
+		if strings.HasSuffix(trimmed, "::") {
+			switch {
+			case trimmed == "::":
+				line = ""
+			case strings.HasSuffix(trimmed, " ::"):
+				line = strings.TrimSuffix(strings.TrimRight(line, " \t"), " ::")
+			default:
+				line = strings.TrimSuffix(strings.TrimRight(line, " \t"), ":")
+			}
+			if line != "" {
+				out = append(out, c.inline(c.listItem(line)))
+			}
+			body, next := indentedBlock(lines, i+1, indentWidth(lines[i]))
+			if body = trimBlank(body); len(body) > 0 {
+				out = appendBlock(out, fencedCode("", dedentLines(body)))
+				i = next - 1
+			}
+			continue
+		}
+
+		out = append(out, c.inline(c.listItem(line)))
+	}
+	return out
+}
+
+// directive converts a directive with its argument and indented body.
+func (c *rstConverter) directive(name, arg string, body []string) []string {
+	body = dedentLines(trimBlank(body))
+	options := map[string]string{}
+	for len(body) > 0 {
+		m := rstOption.FindStringSubmatch(strings.TrimSpace(body[0]))
+		if m == nil {
+			break
+		}
+		options[m[1]] = m[2]
+		body = body[1:]
+	}
+	body = trimBlank(body)
+
+	switch name {
+	case "code", "code-block", "sourcecode":
+		return appendBlock(nil, fencedCode(arg, body))
+	case "image", "figure":
+		out := []string{"", "![" + options["alt"] + "](" + arg + ")", ""}
+		if len(body) > 0 {
+			out = append(out, c.convert(body)...)
+			out = append(out, "")
+		}
+		return out
+	case "admonition":
+		return appendBlock(nil, admonition(c.inline(arg), c.convert(body)))
+	case "include", "toctree", "contents", "sectnum", "raw", "meta", "index", "highlight", "role", "default-role", "title":
+		return nil
+	}
+	if title, ok := admonitionTitles[name]; ok {
+		if arg != "" {
+			body = append([]string{arg}, body...)
+		}
+		return appendBlock(nil, admonition(title, c.convert(body)))
+	}
+	// unknown directives are reduced to their content
+	if arg != "" {
+		body = append([]string{arg, ""}, body...)
+	}
+	return c.convert(body)
+}
+
+// listItem converts the marker of an enumerated list item.
+func (c *rstConverter) listItem(line string) string {
+	m := rstEnumerated.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line
+	}
+	marker := line[m[4]:m[5]]
+	if marker == "#" || (len(marker) == 1 && !strings.ContainsAny(marker, "0123456789")) {
+		marker = "1"
+	}
+	return line[:m[3]] + marker + ". " + line[m[1]:]
+}
+
+// inline converts the inline markup of a line. Emphasis and strong emphasis
+// are the same in markdown.
+func (c *rstConverter) inline(line string) string {
+	rules := append([]inlineRule{}, rstInlineRules...)
+	rules = append(rules,
+		// named references: `name`_ and name_
+		inlineRule{re: rstReference, fn: func(m []string) string {
+			name := m[1] + m[2]
+			if url, ok := c.targets[strings.ToLower(name)]; ok {
+				return "[" + name + "](" + url + ")"
+			}
+			if m[1] != "" {
+				return "*" + name + "*"
+			}
+			return m[0]
+		}},
+	)
+	return convertInline(line, rstCode, "`$1`", rules)
+}
+
+// indentedBlock returns the lines from start that are blank or indented more
+// than indent, and the index of the first line after them. Trailing blank
+// lines are left out of the block.
+func indentedBlock(lines []string, start, indent int) ([]string, int) {
+	end := start
+	for i := start; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if indentWidth(lines[i]) <= indent {
+			break
+		}
+		end = i + 1
+	}
+	return lines[start:end], end
+}
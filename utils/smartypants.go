@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Smartypants enables typographic quotes, dashes and ellipses.
+var Smartypants bool
+
+// SmartypantsEnabled reports whether to use typographic punctuation in a
+// document. A smartypants key in its front matter overrides the Smartypants
+// option.
+func SmartypantsEnabled(content []byte) bool {
+	fields, _ := ParseFrontmatter(content)
+	for _, f := range fields {
+		if strings.EqualFold(f.Key, "smartypants") {
+			if v, err := strconv.ParseBool(f.Value); err == nil {
+				return v
+			}
+		}
+	}
+	return Smartypants
+}
+
+var smartDashes = []struct{ from, to string }{
+	{"---", "—"},
+	{"--", "–"},
+	{"...", "…"},
+}
+
+// Smarten replaces straight quotes with curly ones, -- and --- with en and em
+// dashes and ... with an ellipsis. Code, HTML and links' destinations are
+// left alone.
+func Smarten(source []byte) []byte {
+	var edits []textEdit
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan, *ast.RawHTML, *ast.AutoLink:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			edits = append(edits, smartenText(source, n.Segment.Start, n.Segment.Stop)...)
+		}
+		return ast.WalkContinue, nil
+	})
+	return applyEdits(source, edits)
+}
+
+func smartenText(source []byte, start, stop int) []textEdit {
+	var edits []textEdit
+	for i := start; i < stop; {
+		if i > 0 && source[i-1] == '\\' {
+			i++
+			continue
+		}
+
+		var replaced bool
+		for _, r := range smartDashes {
+			if bytes.HasPrefix(source[i:stop], []byte(r.from)) {
+				edits = append(edits, textEdit{i, i + len(r.from), r.to})
+				i += len(r.from)
+				replaced = true
+				break
+			}
+		}
+		if replaced {
+			continue
+		}
+
+		switch c := source[i]; c {
+		case '"', '\'':
+			quotes := [2]string{"“", "”"}
+			if c == '\'' {
+				quotes = [2]string{"‘", "’"}
+			}
+			q := quotes[1]
+			if opensQuote(source, i) {
+				q = quotes[0]
+			}
+			edits = append(edits, textEdit{i, i + 1, q})
+		}
+		i++
+	}
+	return edits
+}
+
+// opensQuote reports whether the quote at offset i opens a quotation: it
+// follows whitespace, an opening bracket or markup, and precedes text.
+func opensQuote(source []byte, i int) bool {
+	next, _ := utf8.DecodeRune(source[i+1:])
+	if i+1 >= len(source) || unicode.IsSpace(next) || strings.ContainsRune(".,;:!?)]}", next) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRune(source[:i])
+	return unicode.IsSpace(prev) || strings.ContainsRune("([{*_~—–-\"'", prev)
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"regexp"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// wordPattern matches a word of prose.
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}'’_-]*`)
+
+// WordsPerMinute is the reading speed reading times are estimated with.
+const WordsPerMinute = 200
+
+// DocumentStats are counts of the contents of a markdown document.
+type DocumentStats struct {
+	Words      int `json:"words"`
+	Headings   int `json:"headings"`
+	CodeBlocks int `json:"codeBlocks"`
+	Links      int `json:"links"`
+	Images     int `json:"images"`
+}
+
+// ReadingTime returns the estimated reading time in minutes.
+func (s DocumentStats) ReadingTime() int {
+	return (s.Words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+// Add adds the counts of another document.
+func (s *DocumentStats) Add(o DocumentStats) {
+	s.Words += o.Words
+	s.Headings += o.Headings
+	s.CodeBlocks += o.CodeBlocks
+	s.Links += o.Links
+	s.Images += o.Images
+}
+
+// Stats counts the words, headings, code blocks, links and images of a
+// markdown document. Words in code, HTML and image descriptions aren't
+// counted.
+func Stats(source []byte) DocumentStats {
+	var s DocumentStats
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			s.Headings++
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			s.CodeBlocks++
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Link:
+			s.Links++
+		case *ast.AutoLink:
+			s.Links++
+			return ast.WalkSkipChildren, nil
+		case *ast.Image:
+			s.Images++
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			s.Words += len(wordPattern.FindAllIndex(n.Segment.Value(source), -1))
+		}
+		return ast.WalkContinue, nil
+	})
+	return s
+}
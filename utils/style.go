@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+)
+
+// HighContrastStyle is the name of the style used in accessibility mode.
+const HighContrastStyle = "high-contrast"
+
+// HighContrastStyleConfig is a colorless, ASCII-only style that relies on the
+// terminal's own foreground and background colors, using bold and underline
+// for emphasis.
+var HighContrastStyleConfig = highContrastStyleConfig()
+
+func init() {
+	styles.DefaultStyles[HighContrastStyle] = &HighContrastStyleConfig
+}
+
+func highContrastStyleConfig() ansi.StyleConfig {
+	bold, underline := true, true
+	s := styles.ASCIIStyleConfig
+	s.Heading.Bold = &bold
+	s.Strong.Bold = &bold
+	s.Link.Underline = &underline
+	s.LinkText.Bold = &bold
+	s.Item.BlockPrefix = "* "
+	s.ImageText.Format = "Image: {{.text}} ->"
+	return s
+}
+
+// StyleOverrides patch individual elements of the glamour style, on top of
+// the base style. They mirror the structure of glamour's style JSON, e.g.
+// {"h1": {"color": "212"}}.
+var StyleOverrides map[string]any
+
+// CodeTheme is the chroma theme code blocks are highlighted with, instead of
+// the colors of the glamour style.
+var CodeTheme string
+
+// GlamourStyleConfig returns the style config of a built-in style or a JSON
+// style file, with StyleOverrides and CodeTheme applied. The auto style
+// resolves to the dark or light style depending on the terminal's background.
+func GlamourStyleConfig(style string) (ansi.StyleConfig, error) {
+	cfg, err := baseStyleConfig(style)
+	if err != nil {
+		return cfg, err
+	}
+	cfg, err = applyStyleOverrides(cfg, StyleOverrides)
+	if err != nil {
+		return cfg, err
+	}
+	if CodeTheme != "" {
+		// glamour only uses the theme if the style has no chroma colors.
+		cfg.CodeBlock.Theme = CodeTheme
+		cfg.CodeBlock.Chroma = nil
+	}
+	// Without a theme glamour doesn't pass code through the chroma formatter
+	// that adds line numbers.
+	if CodeLineNumbers && cfg.CodeBlock.Theme == "" {
+		cfg.CodeBlock.Theme = lineNumbersTheme
+	}
+	return cfg, nil
+}
+
+func baseStyleConfig(style string) (ansi.StyleConfig, error) {
+	if style == styles.AutoStyle {
+		style = styles.DarkStyle
+		if !HasDarkBackground() {
+			style = styles.LightStyle
+		}
+	}
+	if s, ok := styles.DefaultStyles[style]; ok {
+		cfg := *s
+		definitionListStyle(&cfg)
+		taskListStyle(&cfg)
+		return cfg, nil
+	}
+
+	var cfg ansi.StyleConfig
+	b, err := os.ReadFile(ExpandPath(style))
+	if err != nil {
+		return cfg, fmt.Errorf("unable to read style: %w", err)
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("unable to parse style: %w", err)
+	}
+	return cfg, nil
+}
+
+// DefinitionIndent is the indentation of definitions under their terms.
+const DefinitionIndent = "    "
+
+// definitionListStyle indents the definitions of definition lists, rather
+// than putting an arrow in front of them. See also PrepareDefinitionLists.
+func definitionListStyle(cfg *ansi.StyleConfig) {
+	cfg.DefinitionDescription.BlockPrefix = "\n" + DefinitionIndent
+}
+
+// taskListStyle draws task list checkboxes as ballot boxes. ASCII styles keep
+// their bracketed checkboxes.
+func taskListStyle(cfg *ansi.StyleConfig) {
+	if cfg.Task.Ticked == "[✓] " {
+		cfg.Task.Ticked = "☑ "
+		cfg.Task.Unticked = "☐ "
+	}
+}
+
+// applyStyleOverrides merges overrides into a style config by way of its JSON
+// representation.
+func applyStyleOverrides(cfg ansi.StyleConfig, overrides map[string]any) (ansi.StyleConfig, error) {
+	if len(overrides) == 0 {
+		return cfg, nil
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("unable to encode style: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return cfg, fmt.Errorf("unable to encode style: %w", err)
+	}
+	mergeStyle(m, overrides)
+
+	b, err = json.Marshal(m)
+	if err != nil {
+		return cfg, fmt.Errorf("unable to apply style overrides: %w", err)
+	}
+	var patched ansi.StyleConfig
+	if err := json.Unmarshal(b, &patched); err != nil {
+		return cfg, fmt.Errorf("unable to apply style overrides: %w", err)
+	}
+	return patched, nil
+}
+
+// StyleOverrideError is a mistake in a style override, about a field of it
+// like h1.color, or the name of a field glamour doesn't know.
+type StyleOverrideError struct {
+	Field   string
+	Unknown bool
+	Msg     string
+}
+
+func (e *StyleOverrideError) Error() string {
+	return e.Msg
+}
+
+// CheckStyleOverride checks the override of an element of the style, like
+// h1, for elements and properties glamour doesn't know, or values of the
+// wrong type, which applying overrides leaves out.
+func CheckStyleOverride(element string, override any) error {
+	m := map[string]any{}
+	mergeStyle(m, map[string]any{element: override})
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("unable to encode style override: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var cfg ansi.StyleConfig
+	err = dec.Decode(&cfg)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr):
+		return &StyleOverrideError{Field: typeErr.Field, Msg: fmt.Sprintf("%s can't be a %s", typeErr.Field, typeErr.Value)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// The decoder doesn't tell where the field is.
+		name, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		if name == element {
+			return &StyleOverrideError{Field: name, Unknown: true, Msg: fmt.Sprintf("unknown style element %q", name)}
+		}
+		return &StyleOverrideError{Field: name, Unknown: true, Msg: fmt.Sprintf("%s has no property %q", element, name)}
+	}
+	return fmt.Errorf("unable to apply style override: %w", err)
+}
+
+func mergeStyle(dst, src map[string]any) {
+	for k, v := range src {
+		if sub, ok := styleMap(v); ok {
+			d, ok := dst[k].(map[string]any)
+			if !ok {
+				d = map[string]any{}
+				dst[k] = d
+			}
+			mergeStyle(d, sub)
+			continue
+		}
+
+		// Colors are strings, but ANSI colors are commonly written as
+		// numbers in config files.
+		if strings.HasSuffix(k, "color") {
+			switch v.(type) {
+			case int, int64, uint, uint64, float64:
+				v = fmt.Sprint(v)
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// styleMap returns v as a map, as decoded from YAML or JSON.
+func styleMap(v any) (map[string]any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		return v, true
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = e
+		}
+		return m, true
+	}
+	return nil, false
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHighContrastStyle(t *testing.T) {
+	if _, ok := styles.DefaultStyles[HighContrastStyle]; !ok {
+		t.Fatalf("expected the %s style to be registered", HighContrastStyle)
+	}
+
+	r, err := glamour.NewTermRenderer(GlamourStyle(HighContrastStyle, false), glamour.WithWordWrap(60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := "# Title\n\n## Section\n\nSome **bold**, *emphasis* and a [link](https://example.com).\n\n" +
+		"- one\n- two\n  - nested\n\n1. first\n\n- [x] done\n- [ ] todo\n\n> quoted\n\n---\n\n" +
+		"| a | b |\n| - | - |\n| 1 | 2 |\n\n```go\nfmt.Println(\"hi\")\n```\n"
+	out, err := r.Render(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range ansi.Strip(out) {
+		if r > 127 {
+			t.Fatalf("expected ASCII-only output, got %q at %d in\n%s", r, i, out)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// Task is an item of a task list, like "- [x] Done".
+type Task struct {
+	Text    string
+	Checked bool
+	// Offset is the byte offset of the mark between the checkbox's brackets.
+	Offset int
+	// Line is the zero-based source line the task is on.
+	Line int
+}
+
+// Tasks returns the task list items of a markdown document, in document order.
+func Tasks(source []byte) []Task {
+	var tasks []Task
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		box, ok := n.(*east.TaskCheckBox)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		// The checkbox is the start of its paragraph, so the paragraph's
+		// first line starts with it.
+		block := box.Parent()
+		if block == nil || block.Lines().Len() == 0 {
+			return ast.WalkContinue, nil
+		}
+		start := block.Lines().At(0).Start
+		if start+2 >= len(source) || source[start] != '[' || source[start+2] != ']' {
+			return ast.WalkContinue, nil
+		}
+		tasks = append(tasks, Task{
+			Text:    strings.TrimSpace(NodeText(block, source)),
+			Checked: box.IsChecked,
+			Offset:  start + 1,
+			Line:    nodeLine(block, source),
+		})
+		return ast.WalkContinue, nil
+	})
+	return tasks
+}
+
+// ToggleTask returns a copy of source with the checkbox of a task ticked or
+// unticked.
+func ToggleTask(source []byte, t Task) []byte {
+	out := append([]byte(nil), source...)
+	if t.Checked {
+		out[t.Offset] = ' '
+	} else {
+		out[t.Offset] = 'x'
+	}
+	return out
+}
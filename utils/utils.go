@@ -8,9 +8,6 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/glamour"
-	"github.com/charmbracelet/glamour/ansi"
-	"github.com/charmbracelet/glamour/styles"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/mitchellh/go-homedir"
 )
 
@@ -22,15 +19,29 @@ func RemoveFrontmatter(content []byte) []byte {
 	return content
 }
 
-var yamlPattern = regexp.MustCompile(`(?m)^---\r?\n(\s*\r?\n)?`)
+var (
+	yamlPattern = regexp.MustCompile(`(?m)^---\r?\n(\s*\r?\n)?`)
+	tomlPattern = regexp.MustCompile(`(?m)^\+\+\+\r?\n(\s*\r?\n)?`)
+)
 
 func detectFrontmatter(c []byte) []int {
-	if matches := yamlPattern.FindAllIndex(c, 2); len(matches) > 1 {
+	if matches := frontmatterFences(c); matches != nil {
 		return []int{matches[0][0], matches[1][1]}
 	}
 	return []int{-1, -1}
 }
 
+// frontmatterFences returns the positions of the opening and closing fence of
+// YAML (---) or TOML (+++) front matter, if the content starts with it.
+func frontmatterFences(c []byte) [][]int {
+	for _, pattern := range []*regexp.Regexp{yamlPattern, tomlPattern} {
+		if matches := pattern.FindAllIndex(c, 2); len(matches) > 1 && matches[0][0] == 0 {
+			return matches
+		}
+	}
+	return nil
+}
+
 // ExpandPath expands tilde and all environment variables from the given path.
 func ExpandPath(path string) string {
 	s, err := homedir.Expand(path)
@@ -49,7 +60,8 @@ var markdownExtensions = []string{
 	".md", ".mdown", ".mkdn", ".mkd", ".markdown",
 }
 
-// IsMarkdownFile returns whether the filename has a markdown extension.
+// IsMarkdownFile returns whether the filename has a markdown extension, or
+// is a document that is converted to markdown.
 func IsMarkdownFile(filename string) bool {
 	ext := filepath.Ext(filename)
 
@@ -57,6 +69,9 @@ func IsMarkdownFile(filename string) bool {
 		// By default, assume it's a markdown file.
 		return true
 	}
+	if IsConvertibleFile(filename) {
+		return true
+	}
 
 	for _, v := range markdownExtensions {
 		if strings.EqualFold(ext, v) {
@@ -71,43 +86,16 @@ func IsMarkdownFile(filename string) bool {
 
 // GlamourStyle returns a glamour.TermRendererOption based on the given style.
 func GlamourStyle(style string, isCode bool) glamour.TermRendererOption {
-	if !isCode {
-		if style == styles.AutoStyle {
-			return glamour.WithAutoStyle()
-		}
-		return glamour.WithStylePath(style)
+	styleConfig, err := GlamourStyleConfig(style)
+	if err != nil {
+		return func(*glamour.TermRenderer) error { return err }
 	}
 
 	// If we are rendering a pure code block, we need to modify the style to
 	// remove the indentation.
-
-	var styleConfig ansi.StyleConfig
-
-	switch style {
-	case styles.AutoStyle:
-		if lipgloss.HasDarkBackground() {
-			styleConfig = styles.DarkStyleConfig
-		} else {
-			styleConfig = styles.LightStyleConfig
-		}
-	case styles.DarkStyle:
-		styleConfig = styles.DarkStyleConfig
-	case styles.LightStyle:
-		styleConfig = styles.LightStyleConfig
-	case styles.PinkStyle:
-		styleConfig = styles.PinkStyleConfig
-	case styles.NoTTYStyle:
-		styleConfig = styles.NoTTYStyleConfig
-	case styles.DraculaStyle:
-		styleConfig = styles.DraculaStyleConfig
-	case styles.TokyoNightStyle:
-		styleConfig = styles.DraculaStyleConfig
-	default:
-		return glamour.WithStylesFromJSONFile(style)
+	if isCode {
+		var margin uint
+		styleConfig.CodeBlock.Margin = &margin
 	}
-
-	var margin uint
-	styleConfig.CodeBlock.Margin = &margin
-
 	return glamour.WithStyles(styleConfig)
 }
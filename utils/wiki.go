@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// wikiLinkPattern matches [[Page]], [[Page#Heading]] and [[Page|Label]], or
+// [[Label|Page]] as written in GitHub wikis.
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|#\n]+)(#[^\[\]|\n]*)?(?:\|([^\[\]\n]+))?\]\]`)
+
+// WikiPages indexes markdown files by file name and title, so that wiki links
+// like [[Page Name]] can be resolved. It's safe for concurrent use.
+type WikiPages struct {
+	mu     sync.RWMutex
+	byName map[string][]string
+}
+
+// wikiName normalizes a page name, so that "Page Name" matches files named
+// page-name.md or page_name.md.
+func wikiName(name string) string {
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Add indexes a markdown file under its file name and, if it has one, its
+// title.
+func (p *WikiPages) Add(path, title string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.byName == nil {
+		p.byName = make(map[string][]string)
+	}
+	base := filepath.Base(path)
+	names := []string{wikiName(strings.TrimSuffix(base, filepath.Ext(base)))}
+	if title != "" {
+		names = append(names, wikiName(title))
+	}
+	for _, name := range names {
+		p.byName[name] = append(p.byName[name], path)
+		// Prefer files closer to the root.
+		sort.SliceStable(p.byName[name], func(i, j int) bool {
+			return strings.Count(p.byName[name][i], string(filepath.Separator)) <
+				strings.Count(p.byName[name][j], string(filepath.Separator))
+		})
+	}
+}
+
+// Resolve returns the path of the page a wiki link points to. Names may
+// include directories, like [[notes/Page]], to pick between pages of the same
+// name.
+func (p *WikiPages) Resolve(name string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	name = filepath.FromSlash(strings.TrimSpace(name))
+	dir, base := filepath.Split(name)
+	for _, path := range p.byName[wikiName(base)] {
+		if dir == "" || strings.Contains(path, string(filepath.Separator)+dir) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// DocumentTitle returns the title of a markdown document, which is the text of
+// its first level 1 heading.
+func DocumentTitle(source []byte) string {
+	for _, h := range Headings(source) {
+		if h.Level == 1 {
+			return h.Text
+		}
+	}
+	return ""
+}
+
+// ResolveWikiLinks turns wiki links into regular markdown links to the pages
+// they point to, relative to dir. Links to unknown pages are left alone, as
+// is anything in code.
+func ResolveWikiLinks(source []byte, dir string, pages *WikiPages) []byte {
+	code := codeRanges(source)
+	var edits []textEdit
+	for _, m := range wikiLinkPattern.FindAllSubmatchIndex(source, -1) {
+		start, stop := m[0], m[1]
+		// ![[Page]] embeds a page, which we don't support.
+		if start > 0 && source[start-1] == '!' || inRanges(code, start) {
+			continue
+		}
+
+		name := string(source[m[2]:m[3]])
+		path, ok := pages.Resolve(name)
+		if !ok && m[4] < 0 && m[6] >= 0 {
+			// GitHub wikis put the label first, like [[Label|Page]].
+			label := string(source[m[6]:m[7]])
+			if path, ok = pages.Resolve(label); ok {
+				name, m[6], m[7] = label, m[2], m[3]
+			}
+		}
+		if !ok {
+			continue
+		}
+		dest := path
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			dest = filepath.ToSlash(rel)
+		}
+
+		label := strings.TrimSpace(name)
+		if m[4] >= 0 {
+			heading := strings.TrimSpace(string(source[m[4]+1 : m[5]]))
+			label += " > " + heading
+			dest += "#" + Slug(heading)
+		}
+		if m[6] >= 0 {
+			label = strings.TrimSpace(string(source[m[6]:m[7]]))
+		}
+		edits = append(edits, textEdit{start, stop, "[" + label + "](<" + dest + ">)"})
+	}
+	return applyEdits(source, edits)
+}
+
+// codeRanges returns the byte ranges of code blocks and code spans.
+func codeRanges(source []byte) [][2]int {
+	var ranges [][2]int
+	_ = ast.Walk(ParseMarkdown(source), func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			if lines := n.Lines(); lines.Len() > 0 {
+				ranges = append(ranges, [2]int{lines.At(0).Start, lines.At(lines.Len() - 1).Stop})
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					ranges = append(ranges, [2]int{t.Segment.Start, t.Segment.Stop})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+func inRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
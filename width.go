@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Word-wrap widths used when it's not given, or can't be told from the
+// terminal.
+const (
+	defaultMaxWidth = 120
+	fallbackWidth   = 80
+)
+
+const (
+	widthAuto = "auto"
+	// widthCap separates a relative width from its maximum.
+	widthCap = ",max="
+)
+
+// widthSpec is a word-wrap width as given with --width or in the config: a
+// number of columns, "auto" for the terminal's width or a percentage of it,
+// optionally capped like "90%,max=100".
+type widthSpec struct {
+	columns uint
+	// percent of the terminal's width, if the width is relative.
+	percent uint
+	max     uint
+}
+
+func parseWidth(s string) (widthSpec, error) {
+	var spec widthSpec
+	s = strings.TrimSpace(s)
+	if value, limit, ok := strings.Cut(s, widthCap); ok {
+		n, err := strconv.ParseUint(limit, 10, 0)
+		if err != nil || n == 0 {
+			return spec, fmt.Errorf("invalid maximum width %q", limit)
+		}
+		spec.max, s = uint(n), value
+	}
+
+	switch {
+	case s == widthAuto:
+		spec.percent = 100
+	case strings.HasSuffix(s, "%"):
+		n, err := strconv.ParseUint(strings.TrimSuffix(s, "%"), 10, 0)
+		if err != nil || n == 0 || n > 100 {
+			return spec, fmt.Errorf("invalid width %q, percentages range from 1%% to 100%%", s)
+		}
+		spec.percent = uint(n)
+	default:
+		n, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return spec, fmt.Errorf("invalid width %q, use columns, a percentage like 80%% or auto", s)
+		}
+		spec.columns = uint(n)
+	}
+	return spec, nil
+}
+
+// resolve returns the width in columns, given the terminal's width. Relative
+// widths fall back to 80 columns if that's unknown.
+func (w widthSpec) resolve(termWidth int) uint {
+	n := w.columns
+	if w.percent > 0 {
+		n = fallbackWidth
+		if termWidth > 0 {
+			n = max(1, uint(termWidth)*w.percent/100) //nolint:gosec
+		}
+	}
+	if w.max > 0 && n > w.max {
+		n = w.max
+	}
+	return n
+}
+
+// terminalWidth returns the width of the terminal stdout is, or 0 if it's not
+// one.
+func terminalWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	w, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// widthValue is the --width flag. Absolute widths are set right away, and
+// relative ones once the options are validated.
+type widthValue struct {
+	width *uint
+	spec  string
+}
+
+func newWidthValue(width *uint) *widthValue {
+	return &widthValue{width: width, spec: "0"}
+}
+
+func (v *widthValue) Set(s string) error {
+	spec, err := parseWidth(s)
+	if err != nil {
+		return err
+	}
+	v.spec = s
+	*v.width = spec.columns
+	return nil
+}
+
+func (v *widthValue) String() string { return v.spec }
+
+func (v *widthValue) Type() string { return "width" }